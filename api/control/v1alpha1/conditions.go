@@ -28,15 +28,40 @@ const (
 	TypeSynced ConditionType = "Synced"
 	// TypeStatusCollectorsAvailable indicates whether all required statuscollectors of the bindingpolicy are available.
 	TypeStatusCollectorsAvailable ConditionType = "StatusCollectorsAvailable"
+	// TypeConflicting indicates whether the downsync modulation of some of the bindingpolicy's
+	// workload objects is overridden by another bindingpolicy that takes precedence.
+	TypeConflicting ConditionType = "Conflicting"
+	// TypePendingWindow indicates whether changes for some of the destinations of a binding
+	// are being held back because those destinations are outside their propagation windows.
+	TypePendingWindow ConditionType = "PendingWindow"
+	// TypeDrifted indicates whether some of the binding's workload objects that are checked
+	// for drift differ, in some destinations, from their desired state.
+	TypeDrifted ConditionType = "Drifted"
+	// TypeFrozen indicates whether the wrapped objects for some of the destinations of a binding
+	// are being left as they are because errors in the binding keep the desired state from being known.
+	TypeFrozen ConditionType = "Frozen"
+	// TypeCreatorRecorded indicates whether a bindingpolicy records its creator
+	// (see CreatorAnnotationKey); it is maintained only while the admission webhooks are in use.
+	TypeCreatorRecorded ConditionType = "CreatorRecorded"
 )
 
 type ConditionReason string
 
 const (
-	ReasonAvailable   ConditionReason = "Available"
-	ReasonUnavailable ConditionReason = "Unavailable"
-	ReasonCreating    ConditionReason = "Creating"
-	ReasonDeleting    ConditionReason = "Deleting"
+	ReasonAvailable     ConditionReason = "Available"
+	ReasonUnavailable   ConditionReason = "Unavailable"
+	ReasonCreating      ConditionReason = "Creating"
+	ReasonDeleting      ConditionReason = "Deleting"
+	ReasonOverridden    ConditionReason = "Overridden"
+	ReasonNoConflict    ConditionReason = "NoConflict"
+	ReasonOutsideWindow ConditionReason = "OutsideWindow"
+	ReasonNothingHeld   ConditionReason = "NothingHeld"
+	ReasonDrifted       ConditionReason = "Drifted"
+	ReasonNoDrift       ConditionReason = "NoDrift"
+	ReasonUserErrors    ConditionReason = "UserErrors"
+	ReasonNothingFrozen ConditionReason = "NothingFrozen"
+	ReasonRecorded      ConditionReason = "Recorded"
+	ReasonLegacyPolicy  ConditionReason = "LegacyPolicy"
 )
 
 const (
@@ -41,6 +41,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&BindingPolicy{},
 		&BindingPolicyList{},
+		&NamespacedBindingPolicy{},
+		&NamespacedBindingPolicyList{},
+		&ClusterGrant{},
+		&ClusterGrantList{},
 		&Binding{},
 		&BindingList{},
 		&CustomTransform{},
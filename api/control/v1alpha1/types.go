@@ -54,6 +54,97 @@ import (
 
 const TemplateExpansionAnnotationKey string = "control.kubestellar.io/expand-templates"
 
+// PropagationWindowsAnnotationKey is the key of an annotation of a WEC's inventory object
+// that restricts when changes may be propagated to that WEC.
+// The value of the annotation is a JSON array of PropagationWindow.
+// Changes are propagated to the WEC only while at least one of these windows is open,
+// in addition to the restriction from the `propagationWindows` of the BindingPolicy.
+// A value that does not parse makes the WEC never open for changes.
+const PropagationWindowsAnnotationKey = "control.kubestellar.io/propagation-windows"
+
+// UpsyncClausesAnnotationKey is the key of an annotation that the transport controller,
+// when upsync is enabled, puts on every wrapped object of a Binding that has `upsync` clauses.
+// The value is the JSON encoding of those clauses, and tells the WEC's status agent
+// (from the separate ocm-status-addon project) which objects to report for upsync
+// (see UpsyncPolicyClause). The agent decodes and applies the clauses with
+// `UpsyncClausesOf` and `UpsyncClauseMatches` from `pkg/util`, the same code that
+// the status controller uses.
+const UpsyncClausesAnnotationKey = "control.kubestellar.io/upsync-clauses"
+
+// UpsyncWorkStatusLabelKey is the key of a label, with value "true", on a WorkStatus
+// that reports an object for upsync rather than the status of a downsynced object.
+// The `.status` of such a WorkStatus holds the whole of the reported object.
+const UpsyncWorkStatusLabelKey = "control.kubestellar.io/upsync"
+
+// UpsyncedFromLabelKey is the key of a label that KubeStellar puts on each upsynced copy
+// in the WDS. The value is the name of the WEC that the object was copied from.
+const UpsyncedFromLabelKey = "control.kubestellar.io/upsynced-from"
+
+// UpsyncSourceAnnotationKey is the key of an annotation that KubeStellar puts on each
+// upsynced copy in the WDS. The value is the "namespace/name" (or just "name",
+// for a cluster-scoped object) of the object in the WEC.
+const UpsyncSourceAnnotationKey = "control.kubestellar.io/upsync-source"
+
+// DesiredStateAnnotationKey is the key of an annotation that the transport controller, when
+// drift detection is enabled, puts on each wrapped copy of a workload object whose `driftPolicy` is set.
+// The value is the hex encoding of the SHA-256 hash of the JSON encoding of the object
+// as desired in that destination (after transformation and customization), omitting its
+// status and all of its metadata other than labels and annotations. It tells the WEC's
+// status agent to report the whole object (see DriftWorkStatusLabelKey), and identifies
+// the desired state, which is kept in a desired-state ConfigMap (see
+// DesiredStateConfigMapLabelKey), for the status controller to compare with.
+const DesiredStateAnnotationKey = "control.kubestellar.io/desired-state"
+
+// DesiredStateConfigMapLabelKey is the key of a label, with value "true", on a ConfigMap
+// that holds desired states of workload objects whose drift is checked.
+// The transport controller maintains one such ConfigMap in the ITS for each wrapped object
+// that holds such workload objects, with the same namespace and name as the wrapped object,
+// which owns it. Each data key is the value of a DesiredStateAnnotationKey annotation,
+// and its value is the desired state that was hashed.
+const DesiredStateConfigMapLabelKey = "control.kubestellar.io/desired-states"
+
+// DriftWorkStatusLabelKey is the key of a label, with value "true", on a WorkStatus
+// that reports a downsynced object for drift detection.
+// The WEC's status agent (from the separate ocm-status-addon project) makes one for each
+// object that has a DesiredStateAnnotationKey annotation.
+// The `.status` of such a WorkStatus holds the whole of the object as it is in the WEC.
+const DriftWorkStatusLabelKey = "control.kubestellar.io/drift"
+
+// ReapplyAnnotationKey is the key of an annotation that the transport controller puts
+// on the wrapped copies of a workload object whose `driftPolicy` is `Reapply` and that
+// has drifted. The value identifies the drifted destinations and the desired states that
+// they drifted from, so that it changes, and thereby forces the object to be applied again,
+// when the object drifts from a new desired state. It does not change when reapplying
+// does not cure the drift, so an object is reapplied once per desired state and destination.
+const ReapplyAnnotationKey = "control.kubestellar.io/reapply"
+
+// PolicyNamespaceLabelKey is the key of a label that the binding controller puts on
+// the Binding of a NamespacedBindingPolicy. The value is the namespace of that policy.
+// Such a Binding has no owner reference, because a cluster-scoped object can not be
+// owned by a namespaced one; the binding controller deletes it when the policy goes away.
+const PolicyNamespaceLabelKey = "control.kubestellar.io/policy-namespace"
+
+// CreatorAnnotationKey is the key of an annotation that the mutating admission webhook
+// puts on every BindingPolicy and NamespacedBindingPolicy when it is created, and
+// preserves on update. The value is the JSON encoding of the `authentication.k8s.io/v1`
+// UserInfo of the user that created the policy. A policy created without this annotation
+// (e.g., before the webhook was in use) is a legacy policy and never gets it.
+// The binding controller leaves out of the policy's resolution every workload object
+// that the recorded creator or last modifier (see ModifierAnnotationKey) is not
+// authorized to `get` in the WDS, and reports those denials in the policy's
+// `.status.errors`. A legacy policy that no one has changed is not checked at all.
+// When the webhooks are in use, the binding controller reports in a condition of type
+// `CreatorRecorded` whether a policy has this annotation.
+const CreatorAnnotationKey = "control.kubestellar.io/creator"
+
+// ModifierAnnotationKey is the key of an annotation that the mutating admission webhook
+// maintains on every BindingPolicy and NamespacedBindingPolicy. The value is the JSON
+// encoding of the `authentication.k8s.io/v1` UserInfo of the user that last changed the
+// policy's spec (the creator, until someone else does; absent from a legacy policy
+// until someone does). Thus a user can not get a policy
+// to select objects that they may not read by editing a policy that someone else created.
+const ModifierAnnotationKey = "control.kubestellar.io/modifier"
+
 // PropertyConfigMapNamespace is the namespace in the ITS that holds ConfigMap objects that provide
 // WEC properties to be used in customization.
 const PropertyConfigMapNamespace = "customization-properties"
@@ -82,30 +173,254 @@ type BindingPolicySpec struct {
 	// A Cluster is relevant if and only if it passes any of the LabelSelectors in this field.
 	ClusterSelectors []metav1.LabelSelector `json:"clusterSelectors,omitempty"`
 
-	// We agreed not to expose NumberOfClusters in release 0.20, to avoid confusions.
-	// We may or may not support it in later releases per future discussions.
-	// NumberOfClusters represents the desired number of ManagedClusters to be selected which meet the
-	// BindingPolicy's requirements.
-	// 1) If not specified, all Clusters which meet the BindingPolicy's requirements will be selected;
-	// 2) Otherwise if the number of Clusters meet the BindingPolicy's requirements is larger than
-	//    NumberOfClusters, a random subset with desired number of ManagedClusters will be selected;
-	// 3) If the number of Clusters meet the BindingPolicy's requirements is equal to NumberOfClusters,
-	//    all of them will be selected;
-	// 4) If the number of Clusters meet the BindingPolicy's requirements is less than NumberOfClusters,
-	//    all of them will be selected, and the status of condition `BindingPolicyConditionSatisfied` will be
-	//    set to false;
-	// +optional
-	// NumberOfClusters *int32 `json:"numberOfClusters,omitempty"`
+	// `clusterSelectorExpressions` identifies more relevant Cluster objects by means of
+	// CEL expressions that evaluate to a boolean.
+	// A Cluster is relevant if it passes any of the `clusterSelectors`
+	// or any of these expressions.
+	// Each expression can reference the following variables.
+	// - `obj`: the whole inventory object (ManagedCluster), including its status.
+	// - `labels`: the labels of the inventory object.
+	// - `annotations`: the annotations of the inventory object.
+	// - `conditions`: a map from condition type to condition status (e.g., "True"),
+	//   for the conditions in the status of the inventory object.
+	// For example: `int(labels["gpu-count"]) >= 2`.
+	// An expression that fails to evaluate, or evaluates to something other than `true`,
+	// for a given Cluster does not select that Cluster.
+	// Expressions that fail to parse or type-check are reported in `.status.errors`.
+	// +optional
+	ClusterSelectorExpressions []Expression `json:"clusterSelectorExpressions,omitempty"`
+
+	// `excludeClusterSelectors` identifies Cluster objects to exclude, in terms of their labels.
+	// A Cluster that passes any of these LabelSelectors is not relevant,
+	// regardless of `clusterSelectors` and `clusterSelectorExpressions`.
+	// Note that the empty LabelSelector excludes every Cluster.
+	// +optional
+	ExcludeClusterSelectors []metav1.LabelSelector `json:"excludeClusterSelectors,omitempty"`
+
+	// `numberOfClusters`, if set, is the maximum number of clusters to select.
+	// When more clusters than this pass the cluster selection criteria above,
+	// the candidates are ranked according to `prioritizers` and the
+	// top `numberOfClusters` of them are selected.
+	// The ranking is deterministic. Ties are broken first in favor of clusters
+	// that are already selected, so that the selection is stable, and then by cluster name.
+	// If fewer clusters pass the criteria then all of them are selected.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	NumberOfClusters *int32 `json:"numberOfClusters,omitempty"`
+
+	// `prioritizers` defines the ranking of candidate clusters used when
+	// `numberOfClusters` limits the selection.
+	// An earlier entry takes precedence over a later one;
+	// a later entry only matters among clusters that are tied by all the earlier ones.
+	// +optional
+	Prioritizers []ClusterPrioritizer `json:"prioritizers,omitempty"`
 
 	// `downsync` selects the objects to bind with the selected WECs for downsync,
 	// and modulates their downsync.
 	// An object is selected if it matches at least one member of this list.
 	// When multiple DownsyncPolicyClause match the same workload object:
-	// the `createOnly` bits are ORed together, and the StatusCollector reference
-	// sets are combined by union.
+	// the `createOnly` bits are ORed together, `Orphan` wins over `Delete` in `deletionPolicy`,
+	// `Reapply` wins over `Report` in `driftPolicy`, the StatusCollector reference
+	// sets are combined by union, and the first `replicaSplit` in this list applies.
 	Downsync []DownsyncPolicyClause `json:"downsync,omitempty"`
+
+	// `priority` determines which BindingPolicy prevails when several
+	// BindingPolicies select the same workload object.
+	// A higher value takes precedence over a lower value; ties are broken in favor of
+	// the BindingPolicy whose name sorts first.
+	// For a given workload object, the modulation fields combine across BindingPolicies as follows.
+	// - `createOnly`, `deletionPolicy`, and `replicaSplit` come from the prevailing BindingPolicy.
+	// - The StatusCollector reference sets are combined by union.
+	// - `wantSingletonReportedState` and `wantMultiWECReportedState` are ORed together.
+	// A BindingPolicy whose `createOnly`, `deletionPolicy`, or `replicaSplit` is overridden in this way
+	// gets a `Conflicting` condition that names the object and the prevailing BindingPolicy.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// `suspend`, when true, stages this BindingPolicy without putting it into effect.
+	// The binding controller keeps computing what this BindingPolicy selects
+	// and reports a summary in `.status.resolutionPreview`, but does not create
+	// or update the corresponding Binding. Thus a suspended new BindingPolicy
+	// ships nothing, and the Binding of a suspended existing BindingPolicy stays as it was.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+
+	// `overrides` lists patches to apply to workload objects on their way
+	// to particular destinations.
+	// For a given workload object and destination, every entry that matches both
+	// is applied, in the order of this list, after template expansion.
+	// When applying them fails for a destination, the failure is reported in the Binding's
+	// `.status.errors`, what that destination has been sent is left as it is,
+	// and the Binding's `Frozen` condition is true.
+	// +optional
+	Overrides []Override `json:"overrides,omitempty"`
+
+	// `rollout`, if set, makes changes to the workload reach the destinations
+	// progressively, in ordered waves, rather than all at once.
+	// While the rollout is invalid, what the destinations have been sent is left as it is
+	// and the Binding's `Frozen` condition is true.
+	// +optional
+	Rollout *RolloutStrategy `json:"rollout,omitempty"`
+
+	// `propagationWindows`, if not empty, restricts when changes are propagated.
+	// Changes to what a destination has been sent from this BindingPolicy are made only while
+	// at least one of these windows is open and the destination is within its own windows
+	// (see PropagationWindowsAnnotationKey).
+	// Changes for a destination outside its windows are held, and made when the windows open;
+	// this includes removal of what a destination that is no longer selected has been sent.
+	// The Binding's `PendingWindow` condition lists the destinations being held back.
+	// While these windows are invalid, what the destinations have been sent is left as it is
+	// and the Binding's `Frozen` condition is true.
+	// +optional
+	PropagationWindows []PropagationWindow `json:"propagationWindows,omitempty"`
+
+	// `upsync` identifies objects that are created in the destination clusters
+	// and are to be copied from there into the WDS.
+	// +optional
+	Upsync []UpsyncPolicyClause `json:"upsync,omitempty"`
+}
+
+// PropagationWindow is a recurring period of time during which changes may be propagated.
+type PropagationWindow struct {
+	// `schedule` is a cron expression, in the standard five-field format
+	// (minute, hour, day of month, month, day of week), that gives the times
+	// when the window opens. For example, "0 22 * * 1-5" opens at 22:00 on weekdays.
+	Schedule string `json:"schedule"`
+
+	// `duration` is how long the window stays open each time it opens.
+	Duration metav1.Duration `json:"duration"`
+
+	// `timeZone` is the name of the IANA time zone in which `schedule` is interpreted.
+	// The default is UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// RolloutStrategy splits the destinations into ordered waves.
+// The first wave always gets the current workload.
+// Each later wave gets it only once the previous wave is fully up to date
+// and the `gate` passes for the previous wave.
+// Until then, the destinations in the later waves keep what they have already been sent,
+// which is typically the previous version of the workload objects.
+type RolloutStrategy struct {
+	// `waves` defines the waves, in order.
+	// Each wave takes its destinations from those not taken by an earlier wave,
+	// considering them in order of cluster name.
+	// The destinations left over after the last wave form an implicit final wave.
+	// Waves that get no destinations are skipped.
+	// +kubebuilder:validation:MinItems=1
+	Waves []RolloutWave `json:"waves"`
+
+	// `gate` decides when a wave has done well enough for the next one to proceed.
+	Gate RolloutGate `json:"gate"`
+}
+
+// RolloutWave identifies the destinations in one wave of a rollout.
+// At least one of `clusterSelector` and `count` must be set.
+// +kubebuilder:validation:XValidation:rule="has(self.clusterSelector) || has(self.count)",message="at least one of clusterSelector and count must be set"
+type RolloutWave struct {
+	// `clusterSelector` selects the destinations of this wave by the labels
+	// of their inventory objects (e.g., `ring: canary`).
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+
+	// `count` limits the number of destinations in this wave.
+	// If `clusterSelector` is not set then this wave takes the first `count`
+	// of the remaining destinations.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Count *int32 `json:"count,omitempty"`
+}
+
+// RolloutGate is a test on the CombinedStatus of the workload objects.
+// The gate passes for a wave if, for every workload object to which the named
+// StatusCollector applies, the CombinedStatus has a result from that StatusCollector
+// and the expression evaluates to `true` on it.
+// The gate does not pass while no workload object has that StatusCollector.
+type RolloutGate struct {
+	// `statusCollector` is the name of the StatusCollector whose results are tested.
+	StatusCollector string `json:"statusCollector"`
+
+	// `expression` is a CEL expression that evaluates to a boolean.
+	// It can reference the following variables.
+	// - `rows`: the rows of the StatusCollector's result, each a map from column name to value.
+	// - `clusters`: the names of the destinations in the wave being judged.
+	// Note that the rows do not identify their WEC unless the StatusCollector selects it
+	// (e.g., `inventory.name`), and that a row may reflect an earlier version of the
+	// workload object unless the StatusCollector tests for that.
+	// For example, with a StatusCollector that selects `wec: inventory.name` and
+	// `ready: returned.status.observedGeneration == obj.metadata.generation && returned.status.availableReplicas == obj.spec.replicas`,
+	// the gate `clusters.all(c, rows.exists(r, r.wec == c && r.ready))`
+	// waits for every WEC of the wave to have the new version available.
+	Expression Expression `json:"expression"`
+}
+
+// Override is a patch to apply to some of the workload objects
+// on their way to some of the destinations.
+// Exactly one of `mergePatch` and `jsonPatch` must be set.
+// +kubebuilder:validation:XValidation:rule="has(self.mergePatch) != has(self.jsonPatch)",message="exactly one of mergePatch and jsonPatch must be set"
+type Override struct {
+	// `clusterSelector` identifies the destinations, by the labels of their inventory objects.
+	// The empty selector matches every destination.
+	// +optional
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector,omitempty"`
+
+	// `apiGroup` is the API group of the objects to patch.
+	// Empty string for the "core" API group.
+	// nil matches every API group.
+	// +optional
+	APIGroup *string `json:"apiGroup,omitempty"`
+
+	// `resources` is a list of lowercase plural names for the sorts of objects to patch.
+	// An entry of "*" matches all.
+	// Empty list matches all.
+	// +optional
+	Resources []string `json:"resources,omitempty"`
+
+	// `namespaces` is a list of acceptable names for the namespace of an object.
+	// An entry of "*" matches all.
+	// Empty list matches all, including cluster-scoped objects.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// `objectNames` is a list of object names that match.
+	// An entry of "*" matches all.
+	// Empty list matches all.
+	// +optional
+	ObjectNames []string `json:"objectNames,omitempty"`
+
+	// `mergePatch` is a JSON merge patch (RFC 7386) to apply to the object.
+	// +optional
+	MergePatch *v1.JSON `json:"mergePatch,omitempty"`
+
+	// `jsonPatch` is a JSON patch (RFC 6902), an array of operations to apply to the object.
+	// +optional
+	JSONPatch *v1.JSON `json:"jsonPatch,omitempty"`
+}
+
+// ClusterPrioritizer ranks clusters according to the value of one of their labels.
+// If the values of that label on two clusters both parse as numbers
+// then they are compared numerically, otherwise they are compared as strings.
+// A cluster that lacks the label ranks after all the clusters that have it.
+type ClusterPrioritizer struct {
+	// `label` is the key of the label on the inventory object.
+	Label string `json:"label"`
+
+	// `order` says whether lower values rank first (`Ascending`, the default)
+	// or higher values rank first (`Descending`).
+	// +optional
+	Order PrioritizerOrder `json:"order,omitempty"`
 }
 
+// PrioritizerOrder says which direction a ClusterPrioritizer ranks in.
+// +kubebuilder:validation:Enum=Ascending;Descending
+type PrioritizerOrder string
+
+const (
+	PrioritizerOrderAscending  PrioritizerOrder = "Ascending"
+	PrioritizerOrderDescending PrioritizerOrder = "Descending"
+)
+
 const (
 	ValidationErrorKeyPrefix string = "validation-error.kubestellar.io/"
 
@@ -124,6 +439,94 @@ type DownsyncPolicyClause struct {
 	DownsyncModulation `json:",inline"`
 }
 
+// UpsyncPolicyClause identifies objects in the destination clusters to copy into the WDS,
+// and says where the copies go.
+// The clauses are conveyed to each destination's status agent on the wrapped objects
+// (see UpsyncClausesAnnotationKey), so they take effect at a destination only while the
+// Binding sends it some workload object. The agent reports each matching object in a
+// WorkStatus (see UpsyncWorkStatusLabelKey), and the status controller maintains the copy
+// for as long as that WorkStatus exists and the object still matches.
+// A copy never overwrites an object in the WDS that is not an upsynced copy from the same WEC,
+// and a copy is never downsynced.
+// Upsync is off unless the controller-manager and the transport controller are run with
+// `--enable-upsync` (chart value `features.upsync`), because the OCM status add-on agent
+// that KubeStellar deploys (version 0.2.0-rc17) does not report objects for upsync;
+// it must be enabled only with a status agent that does.
+// The full content of each upsynced object travels through the ITS, in the WorkStatus that
+// reports it, and lands in the WDS; anyone who can read WorkStatuses in the WEC's namespace of
+// the ITS, or the copies in the WDS, can read it. That is why Secrets are upsynced only when
+// a clause explicitly accepts this exposure (see `acknowledgeSecretExposure`).
+type UpsyncPolicyClause struct {
+	// `apiGroup` is the API group of the objects to upsync.
+	// The empty string means the core API group.
+	// +optional
+	APIGroup string `json:"apiGroup,omitempty"`
+
+	// `resources` holds the lowercase plural names of the resources to upsync.
+	// They must be named explicitly ("*" is not allowed), and only resources
+	// that hold reports are allowed: `configmaps` in the core API group, and
+	// `policyreports` and `clusterpolicyreports` in the `wgpolicyk8s.io` API group.
+	// Additionally, `secrets` in the core API group is allowed when
+	// `acknowledgeSecretExposure` is true.
+	// +kubebuilder:validation:MinItems=1
+	Resources []string `json:"resources"`
+
+	// `acknowledgeSecretExposure`, when true, allows this clause to upsync Secrets
+	// (e.g., certificates issued by cert-manager in the WECs), acknowledging that their
+	// data is then readable in the ITS and the WDS as explained above. Restricting such
+	// a clause with `namespaces` and `objectNames` or `objectSelectors` is advised.
+	// +optional
+	AcknowledgeSecretExposure bool `json:"acknowledgeSecretExposure,omitempty"`
+
+	// `namespaces`, if not empty, restricts upsync to objects in these namespaces of the WEC.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// `objectNames`, if not empty, restricts upsync to objects with these names.
+	// +optional
+	ObjectNames []string `json:"objectNames,omitempty"`
+
+	// `objectSelectors`, if not empty, restricts upsync to objects whose labels
+	// match at least one of these selectors.
+	// +optional
+	ObjectSelectors []metav1.LabelSelector `json:"objectSelectors,omitempty"`
+
+	// `placement` says where the copy of an object goes in the WDS.
+	// With `ClusterNamespace`, a namespaced object is copied into the namespace whose
+	// name is the WEC's name, which is created if necessary.
+	// With `ClusterNamePrefix`, a namespaced object is copied into its own namespace,
+	// with the WEC's name and a dash prefixed to its name.
+	// A cluster-scoped object always gets the prefixed name.
+	// +kubebuilder:validation:Enum=ClusterNamespace;ClusterNamePrefix
+	// +kubebuilder:default=ClusterNamespace
+	// +optional
+	Placement UpsyncPlacement `json:"placement,omitempty"`
+}
+
+// UpsyncPlacement says where the copy of an upsynced object goes in the WDS.
+type UpsyncPlacement string
+
+const (
+	UpsyncPlacementClusterNamespace  UpsyncPlacement = "ClusterNamespace"
+	UpsyncPlacementClusterNamePrefix UpsyncPlacement = "ClusterNamePrefix"
+)
+
+// DriftPolicy says what to do when a downsynced object in a WEC differs from its desired state.
+type DriftPolicy string
+
+const (
+	DriftPolicyReport  DriftPolicy = "Report"
+	DriftPolicyReapply DriftPolicy = "Reapply"
+)
+
+// DeletionPolicy says what to do in a WEC with an object that stops being downsynced there.
+type DeletionPolicy string
+
+const (
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+	DeletionPolicyOrphan DeletionPolicy = "Orphan"
+)
+
 // DownsyncModulation is about variations on downsync behavior.
 type DownsyncModulation struct {
 	// `createOnly` indicates that in a given WEC, the object is not to be updated
@@ -131,6 +534,33 @@ type DownsyncModulation struct {
 	// +optional
 	CreateOnly bool `json:"createOnly,omitempty"`
 
+	// `deletionPolicy` says what happens in a WEC to the object when it stops being
+	// downsynced there, either because it stops matching or because the WEC stops
+	// being a destination. With `Delete` (the default) the object is deleted from the WEC.
+	// With `Orphan` the object is left in place, no longer maintained by KubeStellar;
+	// this is meant for objects that carry data, such as PersistentVolumeClaims and
+	// CustomResourceDefinitions.
+	// +kubebuilder:validation:Enum=Delete;Orphan
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// `driftPolicy`, when set, requests detection of drift: differences between
+	// the object in a WEC and its desired state there (after CustomTransforms and
+	// customization). Only the fields that are in the desired state are compared,
+	// so fields that the WEC adds (e.g., defaults) are not drift.
+	// With `Report`, drift is reported in the Binding's `.status.drift` and
+	// its `Drifted` condition. With `Reapply`, drift is also reported and the object
+	// is applied again at the destination where it drifted, once for each desired state
+	// that it drifts from (so reapplying does not fight another writer forever).
+	// Drift detection relies on the WEC's status agent reporting the object (see DesiredStateAnnotationKey).
+	// It is off unless the controller-manager and the transport controller are run with
+	// `--enable-drift-detection` (chart value `features.drift_detection`), because the
+	// OCM status add-on agent that KubeStellar deploys (version 0.2.0-rc17) does not report
+	// objects for drift detection; it must be enabled only with a status agent that does.
+	// +kubebuilder:validation:Enum=Report;Reapply
+	// +optional
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
+
 	// `statusCollectors` is a list of references of StatusCollectors to apply.
 	// +optional
 	StatusCollectors []string `json:"statusCollectors,omitempty"`
@@ -201,6 +631,46 @@ type DownsyncModulation struct {
 	// NOTE: This API isn't yet implemented.
 	// +optional
 	WantMultiWECReportedState bool `json:"wantMultiWECReportedState,omitempty"`
+
+	// `replicaSplit`, if set, requests that the `spec.replicas` of each matching
+	// workload object be divided among the destinations rather than given in full
+	// to every destination. This has no effect on objects that do not have an
+	// integer `spec.replicas`.
+	// +optional
+	ReplicaSplit *ReplicaSplit `json:"replicaSplit,omitempty"`
+
+	// `includeDependencies` requests that, for each matching workload object
+	// that is a Pod or has a pod template (Deployment, StatefulSet, DaemonSet,
+	// ReplicaSet, ReplicationController, Job, CronJob), the ConfigMaps, Secrets,
+	// ServiceAccount, and PersistentVolumeClaims referenced by the pod spec
+	// are also downsynced to the same destinations. Referenced objects that do
+	// not exist in the WDS are skipped until they appear. The default
+	// ServiceAccount is never included because every namespace has its own.
+	// In the Binding, such objects are marked as `implicit`.
+	// +optional
+	IncludeDependencies bool `json:"includeDependencies,omitempty"`
+}
+
+// ReplicaSplit says how to divide the `spec.replicas` of a workload object among its destinations.
+// Each destination's share is proportional to its weight, rounded down;
+// the replicas left over go one each to the destinations with the largest remainders,
+// with ties broken in the order of the Binding's destinations.
+// Thus the shares always add up to the original number of replicas.
+type ReplicaSplit struct {
+	// `weightProperty`, if not empty, is the name of the cluster property that holds
+	// the destination's weight, as a non-negative decimal integer.
+	// The cluster properties are the same as for template expansion
+	// (see TemplateExpansionAnnotationKey); in particular, they come from the
+	// destination's ConfigMap in the "customization-properties" namespace and from the
+	// labels and annotations of the inventory object, and their names are Go identifiers.
+	// A destination that lacks this property gets a weight of zero.
+	// If this field is empty then every destination gets a weight of one,
+	// which means an even split.
+	// A weight that does not parse, or weights that are all zero,
+	// are reported in the Binding's `.status.errors`; meanwhile, what the destinations
+	// have been sent is left as it is and the Binding's `Frozen` condition is true.
+	// +optional
+	WeightProperty string `json:"weightProperty,omitempty"`
 }
 
 // DownsyncObjectTest is a set of criteria that characterize matching objects.
@@ -250,12 +720,55 @@ type DownsyncObjectTest struct {
 	// +optional
 	ObjectSelectors []metav1.LabelSelector `json:"objectSelectors,omitempty"`
 
+	// `annotationSelectors` is a list of label selectors that are applied to
+	// the annotations of the object being tested.
+	// At least one of them must match the annotations of that object.
+	// For example, `{matchLabels: {"meta.helm.sh/release-name": "X"}}` selects
+	// the objects of Helm release X.
+	// Only annotation values that are valid as label values can be tested this way.
+	// Empty list is a special case, it matches every object.
+	// +optional
+	AnnotationSelectors []metav1.LabelSelector `json:"annotationSelectors,omitempty"`
+
+	// `objectFilter` is a CEL expression that must evaluate to `true`
+	// for the object being tested, as it appears in the WDS.
+	// The expression can reference the following variables.
+	// - `obj`: the whole object.
+	// - `labels`: the labels of the object.
+	// - `annotations`: the annotations of the object.
+	// For example: `obj.kind == "Deployment" && obj.spec.replicas > 2`.
+	// An expression that fails to evaluate, or evaluates to something other than `true`,
+	// does not match.
+	// An expression that fails to parse or type-check is reported in the BindingPolicy's `.status.errors`.
+	// nil matches every object.
+	// +optional
+	ObjectFilter *Expression `json:"objectFilter,omitempty"`
+
 	// `objectNames` is a list of object names that match.
 	// An entry of `"*"` means that all match.
 	// If this list contains `"*"` then it should contain nothing else.
 	// Empty list is a special case, it matches every object.
 	// +optional
 	ObjectNames []string `json:"objectNames,omitempty"`
+
+	// `excludeNamespaces` is a list of namespace names.
+	// An object whose namespace is in this list does not match,
+	// regardless of the fields above.
+	// +optional
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"`
+
+	// `excludeObjectNames` is a list of object names.
+	// An object whose name is in this list does not match,
+	// regardless of the fields above.
+	// +optional
+	ExcludeObjectNames []string `json:"excludeObjectNames,omitempty"`
+
+	// `excludeObjectSelectors` is a list of label selectors.
+	// An object whose labels match any of them does not match,
+	// regardless of the fields above.
+	// Note that the empty LabelSelector excludes every object.
+	// +optional
+	ExcludeObjectSelectors []metav1.LabelSelector `json:"excludeObjectSelectors,omitempty"`
 }
 
 // BindingPolicyStatus defines the observed state of BindingPolicy
@@ -267,6 +780,29 @@ type BindingPolicyStatus struct {
 
 	// +optional
 	Errors []string `json:"errors,omitempty"`
+
+	// `resolutionPreview` summarizes what this BindingPolicy currently selects.
+	// It is maintained only while `spec.suspend` is true.
+	// +optional
+	ResolutionPreview *ResolutionPreview `json:"resolutionPreview,omitempty"`
+}
+
+// ResolutionPreview summarizes the resolution of a BindingPolicy.
+type ResolutionPreview struct {
+	// `objectCounts` gives the number of selected workload objects of each GroupResource.
+	// +optional
+	ObjectCounts []GroupResourceCount `json:"objectCounts,omitempty"`
+
+	// `clusters` lists the names of the selected clusters, in sorted order.
+	// +optional
+	Clusters []string `json:"clusters,omitempty"`
+}
+
+// GroupResourceCount is the number of objects of a particular GroupResource.
+type GroupResourceCount struct {
+	metav1.GroupResource `json:",inline"`
+
+	Count int32 `json:"count"`
 }
 
 // +kubebuilder:object:root=true
@@ -278,6 +814,77 @@ type BindingPolicyList struct {
 	Items           []BindingPolicy `json:"items"`
 }
 
+// NamespacedBindingPolicy is a BindingPolicy that lives in a namespace, so that
+// the owners of that namespace can manage it without cluster-wide authority.
+// It can only select workload objects in its own namespace,
+// and only clusters that a ClusterGrant grants to that namespace.
+// Its Binding is named `<namespace>.<name>`; if there is a BindingPolicy with
+// that name then the NamespacedBindingPolicy is not implemented.
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="SYNCED",type="string",JSONPath=".status.conditions[?(@.type=='Synced')].status"
+// +kubebuilder:printcolumn:name="READY",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="AGE",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:scope=Namespaced,shortName={nbp}
+type NamespacedBindingPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// `spec` has the same meaning as in a BindingPolicy, with these restrictions.
+	// A downsync clause selects only objects in the policy's namespace;
+	// a clause whose `namespaces` does not include that namespace (or "*") selects nothing.
+	// An upsync clause copies only objects from the policy's namespace and
+	// only applies if its `placement` is `ClusterNamePrefix`.
+	Spec   BindingPolicySpec   `json:"spec,omitempty"`
+	Status BindingPolicyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// NamespacedBindingPolicyList contains a list of NamespacedBindingPolicies
+type NamespacedBindingPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NamespacedBindingPolicy `json:"items"`
+}
+
+// ClusterGrant is owned by the platform administrators and allows the
+// NamespacedBindingPolicy objects in some namespaces to select some clusters.
+// A NamespacedBindingPolicy can select a cluster only if some ClusterGrant
+// lists the policy's namespace and has a cluster selector that the cluster passes.
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName={cg}
+type ClusterGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterGrantSpec `json:"spec,omitempty"`
+}
+
+// ClusterGrantSpec says which clusters are granted to which namespaces.
+type ClusterGrantSpec struct {
+	// `namespaces` lists the namespaces that are granted the clusters.
+	// +kubebuilder:validation:MinItems=1
+	Namespaces []string `json:"namespaces"`
+
+	// `clusterSelectors` identifies the granted clusters in terms of their labels.
+	// A cluster is granted if and only if it passes any of these selectors.
+	// +kubebuilder:validation:MinItems=1
+	ClusterSelectors []metav1.LabelSelector `json:"clusterSelectors"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterGrantList contains a list of ClusterGrants
+type ClusterGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterGrant `json:"items"`
+}
+
 // Binding is mapped 1:1 to a single BindingPolicy object.
 // Binding reflects the resolution of the BindingPolicy's selectors,
 // and explicitly reflects which objects should go to what destinations.
@@ -315,6 +922,22 @@ type BindingSpec struct {
 	// +listType=map
 	// +listMapKey=clusterId
 	Destinations []Destination `json:"destinations,omitempty"`
+
+	// `overrides` is copied from the BindingPolicy.
+	// +optional
+	Overrides []Override `json:"overrides,omitempty"`
+
+	// `rollout` is copied from the BindingPolicy.
+	// +optional
+	Rollout *RolloutStrategy `json:"rollout,omitempty"`
+
+	// `propagationWindows` is copied from the BindingPolicy.
+	// +optional
+	PropagationWindows []PropagationWindow `json:"propagationWindows,omitempty"`
+
+	// `upsync` is copied from the BindingPolicy.
+	// +optional
+	Upsync []UpsyncPolicyClause `json:"upsync,omitempty"`
 }
 
 // DownsyncObjectClauses defines the objects to be down-synced, grouping them by scope.
@@ -346,6 +969,12 @@ type DownsyncObjectClauses struct {
 type NamespaceScopeDownsyncClause struct {
 	NamespaceScopeDownsyncObject `json:",inline"`
 	DownsyncModulation           `json:",inline"`
+
+	// `implicit` is true when the object is not selected by the BindingPolicy
+	// itself but is included because a selected object depends on it
+	// (see `includeDependencies`).
+	// +optional
+	Implicit bool `json:"implicit,omitempty"`
 }
 
 // NamespaceScopeDownsyncObject references a specific namespace-scoped object to downsync,
@@ -366,6 +995,12 @@ type NamespaceScopeDownsyncObject struct {
 type ClusterScopeDownsyncClause struct {
 	ClusterScopeDownsyncObject `json:",inline"`
 	DownsyncModulation         `json:",inline"`
+
+	// `implicit` is true when the object is not selected by the BindingPolicy
+	// itself but is included because a selected object depends on it
+	// (see `includeDependencies`).
+	// +optional
+	Implicit bool `json:"implicit,omitempty"`
 }
 
 // ClusterScopeDownsyncObject references a specific cluster-scoped object to downsync,
@@ -391,6 +1026,66 @@ type BindingStatus struct {
 
 	ObservedGeneration int64    `json:"observedGeneration"`
 	Errors             []string `json:"errors,omitempty"`
+
+	// `destinations` reports, for each destination in the spec, on the delivery
+	// of the workload to that destination.
+	// +optional
+	// +listType=map
+	// +listMapKey=clusterId
+	Destinations []DestinationStatus `json:"destinations,omitempty"`
+
+	// `drift` lists the workload objects, among those whose `driftPolicy` is set,
+	// that differ from their desired state in some of the destinations.
+	// +optional
+	Drift []ObjectDrift `json:"drift,omitempty"`
+}
+
+// ObjectDrift reports how one workload object differs from its desired state in some destinations.
+type ObjectDrift struct {
+	metav1.GroupVersionResource `json:",inline"`
+	// `namespace` of the object, empty if the object is cluster-scoped.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	// `name` of the object.
+	Name string `json:"name"`
+	// `destinations` has an entry for each destination where the object has drifted.
+	Destinations []DestinationDrift `json:"destinations"`
+}
+
+// DestinationDrift reports how a workload object in one destination differs from its desired state.
+type DestinationDrift struct {
+	// `clusterId` identifies the destination.
+	ClusterId string `json:"clusterId"`
+	// `paths` are the JSON paths (e.g., `.spec.replicas`) where the object in the destination
+	// differs from its desired state. A path is listed if the desired value is missing from
+	// the destination or different there.
+	Paths []string `json:"paths"`
+	// `observedResourceVersion` is the resourceVersion, in the destination, of the drifted object.
+	ObservedResourceVersion string `json:"observedResourceVersion"`
+	// `desiredStateHash` identifies the desired state that the object drifted from
+	// (see DesiredStateAnnotationKey).
+	// +optional
+	DesiredStateHash string `json:"desiredStateHash,omitempty"`
+}
+
+// DestinationStatus summarizes the delivery of a Binding's workload to one destination,
+// as reported by the wrapped objects in the ITS.
+type DestinationStatus struct {
+	// `clusterId` identifies the destination.
+	ClusterId string `json:"clusterId"`
+
+	// `wrappedObjects` is the number of wrapped objects (e.g., ManifestWorks)
+	// currently in the ITS for this destination.
+	WrappedObjects int32 `json:"wrappedObjects"`
+
+	// `conditions` summarizes the conditions that the transport reports on those wrapped
+	// objects (e.g., `Applied` and `Available` for ManifestWorks), one per condition type.
+	// A condition is `True` if it is `True` on all of the wrapped objects,
+	// `False` if it is `False` on any of them, and `Unknown` otherwise.
+	// The reason and message come from a wrapped object that is not `True`, if there is one.
+	// Empty if the transport does not report on delivery.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // BindingList is the API type for a list of Binding
@@ -674,9 +1369,14 @@ type CombinedStatusList struct {
 
 // CustomTransform describes how to select and transform some objects
 // on their way from WDS to WEC, without regard to the WEC (i.e.,
-// not changes that are specific to the individual WEC).
+// not changes that are specific to the individual WEC) unless
+// the transform is limited to WECs with certain labels.
 // The transformation specified here is in addition to, and follows,
 // whatever is built into KubeStellar for that object.
+// When several CustomTransforms apply to the same object, they are applied
+// in order of their names. A CustomTransform that depends on the WEC
+// (one with a `clusterSelector` or `compute`) is applied separately for each WEC,
+// and so are all the ones after it in that order.
 //
 // +genclient
 // +genclient:nonNamespaced
@@ -694,7 +1394,8 @@ type CustomTransform struct {
 }
 
 // CustomTransformSpec selects some objects and describes how to transform them.
-// The selected objects are those that match the `apiGroup` and `resource` fields.
+// The selected objects are those that match the `apiGroup` and `resource` fields
+// and the selectors that are present.
 type CustomTransformSpec struct {
 	// `apiGroup` holds just the group, not also the version
 	APIGroup string `json:"apiGroup"`
@@ -703,15 +1404,105 @@ type CustomTransformSpec struct {
 	// "subresources" can not be directly bound to, only whole (top-level) objects.
 	Resource string `json:"resource"`
 
-	// `remove` is a list of JSONPath expressions (https://goessner.net/articles/JsonPath/)
-	// that identify part of the object to remove if present.
-	// Only a subset of JSONPath is supported.
-	// The expression used in a filter must be a conjunction of field == literal tests.
+	// `objectSelector`, if present, limits the transform to objects whose labels match it.
+	// +optional
+	ObjectSelector *metav1.LabelSelector `json:"objectSelector,omitempty"`
+
+	// `namespaceSelector`, if present, limits the transform to objects in namespaces
+	// whose labels match it. For a Namespace object, its own labels are tested.
+	// Other cluster-scoped objects are not limited by this selector.
+	// A change to the labels of a namespace causes the Bindings whose objects
+	// were tested against them to be processed again.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// `clusterSelector`, if present, limits the transform to the copies of an object
+	// that go to the destinations whose inventory objects have labels that match it.
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+
+	// `remove` is a list of JSONPath expressions (RFC 9535)
+	// that identify parts of the object to remove if present.
+	// Function extensions (e.g., `length()`) are not supported.
 	// Examples:
 	// - "$.spec.resources.GenericItems[*].generictemplate.metadata.resourceVersion"
-	// - "$.store.book[?(@.author == 'Kilgore Trout' && @.category == 'fiction')].price"
+	// - "$.spec.template.spec.containers[*].resources"
+	// - "$.spec.template.spec.containers[?@.name == 'istio-proxy']"
 	// +optional
 	Remove []string `json:"remove,omitempty"`
+
+	// `rename` is a list of moves of part of the object, each from one place to another.
+	// Each `from` and `to` is a singular JSONPath query: one that uses only
+	// member names and array indices, and thus identifies at most one place.
+	// When the `from` part is present, it is removed and its value is put at `to`,
+	// replacing whatever was there.
+	// The renames are done after the removals, in the order given.
+	// Example: move an annotation with from `$.metadata.annotations["example.com/old"]`
+	// and to `$.metadata.annotations["example.com/new"]`.
+	// +optional
+	Rename []RenameOperation `json:"rename,omitempty"`
+
+	// `set` is a list of assignments, each putting a given value at the places in the object
+	// identified by a JSONPath expression, replacing whatever was there.
+	// Missing enclosing JSON objects are created where the path has just member names.
+	// The assignments are done after the renames, in the order given.
+	// Examples:
+	// - path `$.spec.template.spec.priorityClassName` and value `"edge-critical"`
+	// - path `$.spec.template.spec.containers[*].imagePullPolicy` and value `"IfNotPresent"`
+	// +optional
+	Set []SetOperation `json:"set,omitempty"`
+
+	// `setIfAbsent` is like `set` except that each assignment is only done
+	// if the place is not already present in the object.
+	// These assignments are done after the `set` assignments.
+	// +optional
+	SetIfAbsent []SetOperation `json:"setIfAbsent,omitempty"`
+
+	// `compute` is a list of assignments, each putting the value of a CEL expression
+	// at the places in the object identified by a JSONPath expression.
+	// These assignments are done last, in the order given, separately for each destination.
+	// The expression can reference `obj`, the object as transformed so far,
+	// and `props`, the map from name to value of the destination's properties
+	// (these are the same properties as for template expansion; see TemplateExpansionAnnotationKey).
+	// An expression that fails to evaluate for a destination, or whose value
+	// can not be represented in JSON, is reported in `.status.errors` of both the Binding
+	// and this CustomTransform, and the wrapped objects for that destination are left as they are
+	// (the Binding's `Frozen` condition says so); other destinations are not affected.
+	// Examples:
+	// - path `$.spec.replicas` and expression `obj.spec.replicas * int(props["scale"])`
+	// - path `$.spec.rules[0].host` and expression `props["region"] + ".example.com"`
+	// +optional
+	Compute []ComputeOperation `json:"compute,omitempty"`
+}
+
+// ComputeOperation puts a computed value at a place in an object.
+type ComputeOperation struct {
+	// `path` is a JSONPath expression of the form allowed in `remove`
+	// that identifies where to put the value.
+	Path string `json:"path"`
+
+	// `expression` is the CEL expression that computes the value.
+	// Its result must be representable in JSON.
+	Expression Expression `json:"expression"`
+}
+
+// SetOperation puts a literal value at a place in an object.
+type SetOperation struct {
+	// `path` is a JSONPath expression of the form allowed in `remove`
+	// that identifies where to put the value.
+	Path string `json:"path"`
+
+	// `value` is the JSON value to put there.
+	Value v1.JSON `json:"value"`
+}
+
+// RenameOperation moves part of an object from one place to another.
+type RenameOperation struct {
+	// `from` is a JSONPath expression that identifies the part to move.
+	From string `json:"from"`
+
+	// `to` is a JSONPath expression that identifies where to put that part.
+	To string `json:"to"`
 }
 
 type CustomTransformStatus struct {
@@ -32,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/component-base/metrics/legacyregistry"
@@ -39,6 +40,7 @@ import (
 	_ "k8s.io/component-base/metrics/prometheus/version"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	v1alpha1 "github.com/kubestellar/kubestellar/api/control/v1alpha1"
 	clientopts "github.com/kubestellar/kubestellar/options"
@@ -48,6 +50,7 @@ import (
 	ksmetrics "github.com/kubestellar/kubestellar/pkg/metrics"
 	"github.com/kubestellar/kubestellar/pkg/status"
 	"github.com/kubestellar/kubestellar/pkg/util"
+	kswebhook "github.com/kubestellar/kubestellar/pkg/webhook"
 )
 
 var (
@@ -77,10 +80,20 @@ func main() {
 	var wdsName string
 	var allowedGroupsString string
 	var controllers []string
+	var webhookPort int
+	var webhookCertDir string
+	var webhookHost string
+	var enableUpsync bool
+	var enableDriftDetection bool
 	pflag.StringVar(&itsName, "its-name", "", "name of the Inventory and Transport Space to connect to (empty string means to use the only one)")
 	pflag.StringVar(&wdsName, "wds-name", "", "name of the workload description space to connect to")
 	pflag.StringVar(&allowedGroupsString, "api-groups", "", "list of allowed api groups, comma separated. Empty string means all API groups are allowed")
 	pflag.StringSliceVar(&controllers, "controllers", []string{}, "list of controllers to be started by the controller manager, lower case and comma separated, e.g. 'binding,status'. If not specified (or empty list specified), all controllers are started. Currently available controllers are 'binding' and 'status'.")
+	pflag.IntVar(&webhookPort, "webhook-port", 0, "port on which to serve the validating admission webhooks for KubeStellar control objects (0 means to not serve them)")
+	pflag.StringVar(&webhookCertDir, "webhook-cert-dir", "", "directory holding tls.crt and tls.key for the webhook server (empty string means the controller-runtime default)")
+	pflag.StringVar(&webhookHost, "webhook-host", "", "DNS name at which the WDS reaches the webhook server on port 443; when set, a self-signed serving certificate for that name is written into the webhook cert dir and the webhook configurations are maintained in the WDS")
+	pflag.BoolVar(&enableUpsync, "enable-upsync", false, "maintain in the WDS the copies of objects that the status agents in the WECs report for upsync; requires status agents that do so, which the currently deployed OCM status add-on agent does not")
+	pflag.BoolVar(&enableDriftDetection, "enable-drift-detection", false, "report in Bindings the drift that the status agents in the WECs report; requires status agents that do so, which the currently deployed OCM status add-on agent does not")
 	pflag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
@@ -117,6 +130,37 @@ func main() {
 
 	ksctlr.Start(ctx, processOpts)
 
+	var webhookCABundle []byte
+	if webhookPort != 0 {
+		if webhookHost != "" {
+			var err error
+			if webhookCertDir == "" {
+				webhookCertDir, err = os.MkdirTemp("", "kubestellar-webhook-certs")
+				if err != nil {
+					setupLog.Error(err, "unable to create the webhook cert dir")
+					os.Exit(1)
+				}
+			}
+			webhookCABundle, err = kswebhook.GenerateServingCert(webhookCertDir, webhookHost)
+			if err != nil {
+				setupLog.Error(err, "unable to make the webhook serving certificate")
+				os.Exit(1)
+			}
+		}
+		webhookServer := ctrlwebhook.NewServer(ctrlwebhook.Options{Port: webhookPort, CertDir: webhookCertDir})
+		if err := kswebhook.Register(webhookServer, scheme); err != nil {
+			setupLog.Error(err, "unable to register the validating webhooks")
+			os.Exit(1)
+		}
+		go func() {
+			setupLog.Info("Starting webhook server", "port", webhookPort)
+			if err := webhookServer.Start(ctx); err != nil {
+				setupLog.Error(err, "error running the webhook server")
+				os.Exit(1)
+			}
+		}()
+	}
+
 	spacesClientMetrics := ksmetrics.NewMultiSpaceClientMetrics()
 	ksmetrics.MustRegister(legacyregistry.Register, spacesClientMetrics)
 	wdsClientMetrics := spacesClientMetrics.MetricsForSpace("wds")
@@ -153,11 +197,27 @@ func main() {
 		os.Exit(1)
 	}
 
+	if webhookPort != 0 {
+		bindingController.ReportLegacyPolicies()
+	}
+
 	if err := bindingController.EnsureCRDs(ctx); err != nil {
 		setupLog.Error(err, "error installing the CRDs")
 		os.Exit(1)
 	}
 
+	if webhookCABundle != nil {
+		wdsClient, err := kubernetes.NewForConfig(wdsRestConfig)
+		if err != nil {
+			setupLog.Error(err, "unable to create the client for installing the webhook configurations")
+			os.Exit(1)
+		}
+		if err := kswebhook.InstallConfigurations(ctx, wdsClient, "https://"+webhookHost, webhookCABundle, setupLog); err != nil {
+			setupLog.Error(err, "error installing the webhook configurations")
+			os.Exit(1)
+		}
+	}
+
 	if err := bindingController.AppendKSResources(ctx); err != nil {
 		setupLog.Error(err, "error appending KubeStellar resources to discovered lists")
 		os.Exit(1)
@@ -189,6 +249,12 @@ func main() {
 			setupLog.Error(err, "unable to create status controller")
 			os.Exit(1)
 		}
+		if enableUpsync {
+			statusController.EnableUpsync()
+		}
+		if enableDriftDetection {
+			statusController.EnableDriftDetection()
+		}
 		workloadEventRelay.statusController = statusController
 	} else {
 		setupLog.Info("Not creating status controller")
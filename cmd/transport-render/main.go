@@ -0,0 +1,224 @@
+/*
+Copyright 2025 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/yaml"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	transportgeneric "github.com/kubestellar/kubestellar/pkg/transport/generic"
+	"github.com/kubestellar/kubestellar/pkg/transport/generic/filtering"
+)
+
+func main() {
+	klog.InitFlags(flag.CommandLine)
+	fs := pflag.NewFlagSet("transport-render", pflag.ExitOnError)
+	fs.AddGoFlagSet(flag.CommandLine)
+	var objectFiles, ctFiles, namespaceFiles, inventoryFiles, propertyFiles []string
+	var destination, cleanupRulesFile string
+	var resourceMap []string
+	fs.StringArrayVarP(&objectFiles, "filename", "f", nil, "file holding workload objects as in the WDS (Namespace objects here also count for --namespaces)")
+	fs.StringArrayVar(&ctFiles, "custom-transforms", nil, "file holding CustomTransform objects")
+	fs.StringArrayVar(&namespaceFiles, "namespaces", nil, "file holding Namespace objects of the WDS, consulted for namespaceSelectors")
+	fs.StringArrayVar(&inventoryFiles, "inventory", nil, "file holding inventory (ManagedCluster) objects")
+	fs.StringArrayVar(&propertyFiles, "properties", nil, "file holding property ConfigMap objects, of which those in namespace "+v1alpha1.PropertyConfigMapNamespace+" are used")
+	fs.StringVar(&destination, "destination", "", "name of the destination's inventory object")
+	fs.StringVar(&cleanupRulesFile, "cleanup-rules-file", "", "pathname of a YAML file holding a list of cleanup rules to use in addition to the built-in ones")
+	fs.StringArrayVar(&resourceMap, "resource-map", nil, "KIND[.GROUP]=RESOURCE, giving the resource of a kind whose resource is not the guessed plural of the kind (e.g., Endpoints=endpoints)")
+	fs.Parse(os.Args[1:])
+
+	ctx := context.Background()
+	logger := klog.FromContext(ctx)
+	if destination == "" {
+		logger.Error(nil, "The --destination flag is required")
+		os.Exit(2)
+	}
+
+	input := transportgeneric.RenderInput{Destination: destination}
+	var err error
+	input.Resources, err = parseResourceMap(resourceMap)
+	if err != nil {
+		logger.Error(err, "Invalid --resource-map")
+		os.Exit(2)
+	}
+	input.Objects, err = readFiles(objectFiles, func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) { return obj, nil })
+	if err == nil {
+		input.CustomTransforms, err = readFiles(ctFiles, convertTo[v1alpha1.CustomTransform])
+	}
+	if err == nil {
+		input.Namespaces, err = readFiles(namespaceFiles, convertTo[corev1.Namespace])
+	}
+	if err == nil {
+		input.Inventory, err = readFiles(inventoryFiles, convertTo[clusterv1.ManagedCluster])
+	}
+	if err == nil {
+		input.PropertyConfigMaps, err = readFiles(propertyFiles, convertTo[corev1.ConfigMap])
+	}
+	if err != nil {
+		logger.Error(err, "Failed to read input")
+		os.Exit(1)
+	}
+	for _, obj := range input.Objects {
+		if obj.GetAPIVersion() == "v1" && obj.GetKind() == "Namespace" {
+			ns, err := convertTo[corev1.Namespace](obj)
+			if err != nil {
+				logger.Error(err, "Failed to read input")
+				os.Exit(1)
+			}
+			input.Namespaces = append(input.Namespaces, ns)
+		}
+	}
+
+	cleanupRuleList := filtering.DefaultCleanupRules()
+	if cleanupRulesFile != "" {
+		moreRules, err := filtering.LoadCleanupRules(cleanupRulesFile)
+		if err != nil {
+			logger.Error(err, "Failed to load cleanup rules", "file", cleanupRulesFile)
+			os.Exit(1)
+		}
+		cleanupRuleList = append(cleanupRuleList, moreRules...)
+	}
+	input.CleanupRules, err = filtering.NewCleanupRules(cleanupRuleList)
+	if err != nil {
+		logger.Error(err, "Invalid cleanup rules", "file", cleanupRulesFile)
+		os.Exit(1)
+	}
+
+	output, err := transportgeneric.RenderForDestination(ctx, input)
+	if err != nil {
+		logger.Error(err, "Failed to render")
+		os.Exit(1)
+	}
+	for _, problem := range output.CustomTransformProblems {
+		fmt.Fprintln(os.Stderr, problem)
+	}
+	for _, problem := range output.BindingErrors {
+		fmt.Fprintln(os.Stderr, "Error in Binding: "+problem)
+	}
+	for _, problem := range output.FrozenErrors {
+		fmt.Fprintln(os.Stderr, "Error in Binding, leaving what the destination has as it is: "+problem)
+	}
+	if len(output.BindingErrors) > 0 || len(output.FrozenErrors) > 0 {
+		// The transport controller would not deliver these objects, so they are not printed
+		os.Exit(1)
+	}
+	for idx, obj := range output.Objects {
+		objYAML, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			logger.Error(err, "Failed to format object", "index", idx)
+			os.Exit(1)
+		}
+		if idx > 0 {
+			fmt.Println("---")
+		}
+		os.Stdout.Write(objYAML)
+	}
+}
+
+// parseResourceMap parses the values of the --resource-map flag.
+func parseResourceMap(entries []string) (map[schema.GroupKind]string, error) {
+	ans := make(map[schema.GroupKind]string, len(entries))
+	for _, entry := range entries {
+		kindGroup, resource, found := strings.Cut(entry, "=")
+		if !found || kindGroup == "" || resource == "" {
+			return nil, fmt.Errorf("%q is not of the form KIND[.GROUP]=RESOURCE", entry)
+		}
+		ans[schema.ParseGroupKind(kindGroup)] = resource
+	}
+	return ans, nil
+}
+
+// readFiles reads the objects in the given YAML or JSON files, in order.
+// A file may hold multiple objects, as a YAML stream or in `List` objects.
+// Each object is converted by the given function.
+func readFiles[Obj any](filenames []string, convert func(*unstructured.Unstructured) (Obj, error)) ([]Obj, error) {
+	var ans []Obj
+	for _, filename := range filenames {
+		objs, err := readFile(filename, convert)
+		if err != nil {
+			return nil, err
+		}
+		ans = append(ans, objs...)
+	}
+	return ans, nil
+}
+
+// readFile reads the objects in one file for readFiles.
+func readFile[Obj any](filename string, convert func(*unstructured.Unstructured) (Obj, error)) ([]Obj, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var ans []Obj
+	decoder := utilyaml.NewYAMLOrJSONDecoder(file, 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		err := decoder.Decode(&obj.Object)
+		if errors.Is(err, io.EOF) {
+			return ans, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %w", filename, err)
+		}
+		if len(obj.Object) == 0 { // empty document
+			continue
+		}
+		var items []*unstructured.Unstructured
+		if obj.IsList() {
+			err = obj.EachListItem(func(item runtime.Object) error {
+				items = append(items, item.(*unstructured.Unstructured))
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse list in %q: %w", filename, err)
+			}
+		} else {
+			items = []*unstructured.Unstructured{obj}
+		}
+		for _, item := range items {
+			converted, err := convert(item)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert %s %q in %q: %w", item.GetKind(), item.GetName(), filename, err)
+			}
+			ans = append(ans, converted)
+		}
+	}
+}
+
+// convertTo converts the given object to the given typed form.
+func convertTo[Obj any](obj *unstructured.Unstructured) (*Obj, error) {
+	var ans Obj
+	err := runtime.DefaultUnstructuredConverter.FromUnstructuredWithValidation(obj.Object, &ans, true)
+	return &ans, err
+}
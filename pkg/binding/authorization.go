@@ -0,0 +1,263 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+// accessCacheTTL is how long the answer to a SubjectAccessReview is remembered.
+// Each policy that used an answer is requeued when the answer expires, so
+// a change to the RBAC rules in the WDS takes up to this long to affect
+// the resolutions of BindingPolicies. The controller does not watch RBAC objects.
+const accessCacheTTL = 5 * time.Minute
+
+// accessCacheSize bounds the number of remembered SubjectAccessReview answers.
+const accessCacheSize = 4096
+
+// accessKey identifies a question asked through a SubjectAccessReview:
+// may the given user get objects of the given resource in the given namespace.
+type accessKey struct {
+	user      string // the value of the creator or modifier annotation
+	group     string
+	resource  string
+	namespace string
+}
+
+// accessAnswer is a remembered answer to a SubjectAccessReview.
+type accessAnswer struct {
+	allowed bool
+	expires time.Time
+}
+
+// recordedUser is a user recorded in the creator or modifier annotation of a policy.
+type recordedUser struct {
+	role     string // "creator" or "last modifier"
+	userJSON string // the value of the annotation
+	authenticationv1.UserInfo
+}
+
+// ReportLegacyPolicies makes the controller maintain, in the status of each policy, a
+// condition of type CreatorRecorded that tells whether the policy records its creator.
+// This is for when the admission webhooks that record creators and modifiers are in use,
+// so that a policy without that record is one that predates them (a "legacy" policy).
+// It must be called before Start.
+func (c *Controller) ReportLegacyPolicies() {
+	c.reportLegacyPolicies = true
+}
+
+// policyUsers returns the users recorded in the creator and modifier annotations
+// of the given policy, omitting a modifier that is the creator.
+// A legacy policy, which predates the recording of creators, may record only
+// a modifier or no user at all.
+// An error is returned if an annotation is malformed.
+func (c *Controller) policyUsers(policy *v1alpha1.BindingPolicy) ([]recordedUser, error) {
+	var users []recordedUser
+	creatorJSON, hasCreator := policy.Annotations[v1alpha1.CreatorAnnotationKey]
+	if hasCreator {
+		users = append(users, recordedUser{role: "creator", userJSON: creatorJSON})
+	}
+	if modifierJSON, has := policy.Annotations[v1alpha1.ModifierAnnotationKey]; has && !(hasCreator && modifierJSON == creatorJSON) {
+		users = append(users, recordedUser{role: "last modifier", userJSON: modifierJSON})
+	}
+	for idx := range users {
+		if err := json.Unmarshal([]byte(users[idx].userJSON), &users[idx].UserInfo); err != nil {
+			return nil, fmt.Errorf("malformed annotation recording the %s: %w", users[idx].role, err)
+		}
+	}
+	return users, nil
+}
+
+// creatorRecordedCondition returns the condition that ReportLegacyPolicies asks for.
+func creatorRecordedCondition(policy *v1alpha1.BindingPolicy) v1alpha1.BindingPolicyCondition {
+	if _, has := policy.Annotations[v1alpha1.CreatorAnnotationKey]; has {
+		return v1alpha1.BindingPolicyCondition{
+			Type:    v1alpha1.TypeCreatorRecorded,
+			Status:  corev1.ConditionTrue,
+			Reason:  v1alpha1.ReasonRecorded,
+			Message: "The creator of this policy is recorded, and its access to workload objects is checked",
+		}
+	}
+	return v1alpha1.BindingPolicyCondition{
+		Type:   v1alpha1.TypeCreatorRecorded,
+		Status: corev1.ConditionFalse,
+		Reason: v1alpha1.ReasonLegacyPolicy,
+		Message: "This policy predates the recording of creators, so access to workload objects is checked " +
+			"only for a user who changes its spec from now on",
+	}
+}
+
+// usersMayGet tells whether every user recorded as the creator or last modifier of the given
+// policy is authorized to get the given workload object in the WDS. For a legacy policy that
+// records no user, the answer is true. When a record is malformed, the answer is false.
+// An error is returned if a SubjectAccessReview could not be done.
+func (c *Controller) usersMayGet(ctx context.Context, policy *v1alpha1.BindingPolicy, objIdentifier util.ObjectIdentifier) (bool, error) {
+	users, err := c.policyUsers(policy)
+	if err != nil {
+		return false, nil
+	}
+	for _, user := range users {
+		allowed, err := c.userMayGet(ctx, policy, user, objIdentifier)
+		if err != nil || !allowed {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// userMayGet tells whether the given user of the given policy is authorized
+// to get the given workload object in the WDS.
+func (c *Controller) userMayGet(ctx context.Context, policy *v1alpha1.BindingPolicy, user recordedUser, objIdentifier util.ObjectIdentifier) (bool, error) {
+	key := accessKey{
+		user:      user.userJSON,
+		group:     objIdentifier.GVK.Group,
+		resource:  objIdentifier.Resource,
+		namespace: objIdentifier.ObjectName.Namespace,
+	}
+	if answer, has := c.accessCache.Get(key); has {
+		c.recheckAccessAt(policy.Name, answer.(accessAnswer).expires)
+		return answer.(accessAnswer).allowed, nil
+	}
+	extra := make(map[string]authorizationv1.ExtraValue, len(user.Extra))
+	for extraKey, extraValue := range user.Extra {
+		extra[extraKey] = authorizationv1.ExtraValue(extraValue)
+	}
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			Groups: user.Groups,
+			UID:    user.UID,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: key.namespace,
+				Verb:      "get",
+				Group:     key.group,
+				Resource:  key.resource,
+			},
+		},
+	}
+	result, err := c.sarClient.Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to review access of %q to %s in namespace %q: %w",
+			user.Username, schema.GroupResource{Group: key.group, Resource: key.resource}, key.namespace, err)
+	}
+	allowed := result.Status.Allowed && !result.Status.Denied
+	klog.FromContext(ctx).V(4).Info("Reviewed access of BindingPolicy user", "bindingPolicy", policy.Name,
+		"role", user.role, "user", user.Username, "group", key.group, "resource", key.resource,
+		"namespace", key.namespace, "allowed", allowed, "reason", result.Status.Reason)
+	answer := accessAnswer{allowed: allowed, expires: time.Now().Add(accessCacheTTL)}
+	c.accessCache.Add(key, answer, accessCacheTTL)
+	c.recheckAccessAt(policy.Name, answer.expires)
+	return allowed, nil
+}
+
+// recheckAccessAt requeues the given policy for when an answer that its resolution
+// depends on expires, so that a revoked authorization does not linger in the resolution.
+// The workqueue keeps only the earliest of the requeues of the same policy.
+func (c *Controller) recheckAccessAt(bindingPolicyKey string, expires time.Time) {
+	c.workqueue.AddAfter(bindingPolicyRef(bindingPolicyKey), time.Until(expires))
+}
+
+// noteAccess records whether the given object is left out of the resolution of the
+// given policy because the policy's creator or last modifier may not get it.
+// The returned bool indicates whether the record changed.
+func (c *Controller) noteAccess(bindingPolicyKey string, objIdentifier util.ObjectIdentifier, allowed bool) bool {
+	c.accessDenialsMutex.Lock()
+	defer c.accessDenialsMutex.Unlock()
+	denied := c.accessDenials[bindingPolicyKey]
+	if allowed {
+		if !denied.Has(objIdentifier) {
+			return false
+		}
+		denied.Delete(objIdentifier)
+		if denied.Len() == 0 {
+			delete(c.accessDenials, bindingPolicyKey)
+		}
+		return true
+	}
+	if denied.Has(objIdentifier) {
+		return false
+	}
+	if denied == nil {
+		denied = sets.New[util.ObjectIdentifier]()
+		c.accessDenials[bindingPolicyKey] = denied
+	}
+	denied.Insert(objIdentifier)
+	return true
+}
+
+// forgetAccessDenials discards the record of denials for the given policy.
+func (c *Controller) forgetAccessDenials(bindingPolicyKey string) {
+	c.accessDenialsMutex.Lock()
+	defer c.accessDenialsMutex.Unlock()
+	delete(c.accessDenials, bindingPolicyKey)
+}
+
+// accessDenialErrors returns the errors to report in the status of the given policy
+// about the workload objects left out because its creator or last modifier may not get them.
+// There is one error per combination of GroupResource and namespace.
+func (c *Controller) accessDenialErrors(policy *v1alpha1.BindingPolicy) []string {
+	users, err := c.policyUsers(policy)
+	if err != nil {
+		return []string{err.Error() + "; all workload objects are left out"}
+	} else if len(users) == 0 {
+		return nil
+	}
+	whos := make([]string, len(users))
+	for idx, user := range users {
+		whos[idx] = fmt.Sprintf("%s %q", user.role, user.Username)
+	}
+	who := strings.Join(whos, " or ")
+	who = strings.ToUpper(who[:1]) + who[1:]
+	type groupResourceNamespace struct {
+		groupResource schema.GroupResource
+		namespace     string
+	}
+	counts := map[groupResourceNamespace]int{}
+	c.accessDenialsMutex.Lock()
+	for objIdentifier := range c.accessDenials[policy.Name] {
+		counts[groupResourceNamespace{objIdentifier.GVR().GroupResource(), objIdentifier.ObjectName.Namespace}]++
+	}
+	c.accessDenialsMutex.Unlock()
+	ans := make([]string, 0, len(counts))
+	for grn, count := range counts {
+		where := "at cluster scope"
+		if grn.namespace != "" {
+			where = fmt.Sprintf("in namespace %q", grn.namespace)
+		}
+		ans = append(ans, fmt.Sprintf("%s is not authorized to get %s %s; left out %d object(s)",
+			who, grn.groupResource, where, count))
+	}
+	sort.Strings(ans)
+	return ans
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	testingclock "k8s.io/utils/clock/testing"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+func TestUsersMayGet(t *testing.T) {
+	kubeClient := fake.NewSimpleClientset()
+	numReviews := 0
+	kubeClient.PrependReactor("create", "subjectaccessreviews", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		numReviews++
+		review := action.(clienttesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		review.Status.Allowed = review.Spec.User == "alice" && attrs.Verb == "get" && attrs.Namespace == "team-a"
+		return true, review, nil
+	})
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	c := &Controller{
+		sarClient:     kubeClient.AuthorizationV1().SubjectAccessReviews(),
+		accessCache:   utilcache.NewLRUExpireCache(accessCacheSize),
+		accessDenials: map[string]sets.Set[util.ObjectIdentifier]{},
+		workqueue: workqueue.NewRateLimitingQueueWithConfig(workqueue.DefaultControllerRateLimiter(),
+			workqueue.RateLimitingQueueConfig{Clock: fakeClock}),
+	}
+	defer c.workqueue.ShutDown()
+	cmId := func(namespace, name string) util.ObjectIdentifier {
+		return util.ObjectIdentifier{
+			GVK:        schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+			Resource:   "configmaps",
+			ObjectName: cache.NewObjectName(namespace, name),
+		}
+	}
+	policyWithModifier := func(creator, modifier string) *v1alpha1.BindingPolicy {
+		ans := &v1alpha1.BindingPolicy{ObjectMeta: metav1.ObjectMeta{Name: "p", Annotations: map[string]string{}}}
+		if creator != "" {
+			ans.Annotations[v1alpha1.CreatorAnnotationKey] = creator
+		}
+		if modifier != "" {
+			ans.Annotations[v1alpha1.ModifierAnnotationKey] = modifier
+		}
+		return ans
+	}
+	policy := func(creator string) *v1alpha1.BindingPolicy {
+		return policyWithModifier(creator, creator)
+	}
+	ctx := context.Background()
+	for _, testCase := range []struct {
+		name     string
+		creator  string
+		modifier string
+		objId    util.ObjectIdentifier
+		expected bool
+	}{
+		{name: "no-creator", objId: cmId("team-b", "x"), expected: true},
+		{name: "malformed-creator", creator: "alice", objId: cmId("team-a", "x")},
+		{name: "allowed", creator: `{"username":"alice"}`, objId: cmId("team-a", "x"), expected: true},
+		{name: "allowed-cached", creator: `{"username":"alice"}`, objId: cmId("team-a", "y"), expected: true},
+		{name: "denied", creator: `{"username":"alice"}`, objId: cmId("team-b", "x")},
+		{name: "other-user", creator: `{"username":"bob"}`, objId: cmId("team-a", "x")},
+		{name: "modifier-denied", creator: `{"username":"alice"}`, modifier: `{"username":"bob"}`, objId: cmId("team-a", "x")},
+		{name: "creator-denied", creator: `{"username":"bob"}`, modifier: `{"username":"alice"}`, objId: cmId("team-a", "x")},
+		{name: "malformed-modifier", creator: `{"username":"alice"}`, modifier: "bob", objId: cmId("team-a", "x")},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			modifier := testCase.modifier
+			if modifier == "" {
+				modifier = testCase.creator
+			}
+			allowed, err := c.usersMayGet(ctx, policyWithModifier(testCase.creator, modifier), testCase.objId)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if allowed != testCase.expected {
+				t.Errorf("Expected %v, got %v", testCase.expected, allowed)
+			}
+		})
+	}
+	if numReviews != 3 {
+		t.Errorf("Expected 3 SubjectAccessReviews, got %d", numReviews)
+	}
+	// the policy is resolved again when the answers expire
+	if c.workqueue.Len() != 0 {
+		t.Errorf("Expected no policy to be requeued before the answers expire, got %d items", c.workqueue.Len())
+	}
+	fakeClock.Step(accessCacheTTL)
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, wait.ForeverTestTimeout, true, func(context.Context) (bool, error) {
+		return c.workqueue.Len() > 0, nil
+	}); err != nil {
+		t.Fatalf("Expected the policy to be requeued when the answers expire: %s", err)
+	}
+	if item, _ := c.workqueue.Get(); item != bindingPolicyRef("p") || c.workqueue.Len() != 0 {
+		t.Errorf("Expected the policy to be requeued once, got %v and %d more", item, c.workqueue.Len())
+	} else {
+		c.workqueue.Done(item)
+	}
+
+	c.ReportLegacyPolicies()
+	if allowed, err := c.usersMayGet(ctx, policy(""), cmId("team-b", "x")); err != nil || !allowed {
+		t.Errorf("Expected a legacy policy to be allowed, got %v and %v", allowed, err)
+	}
+	if errs := c.accessDenialErrors(policy("")); len(errs) != 0 {
+		t.Errorf("Expected no errors for a legacy policy, got %q", errs)
+	}
+	if numReviews != 3 {
+		t.Errorf("Expected no SubjectAccessReview for a legacy policy, got %d in all", numReviews)
+	}
+	if cond := creatorRecordedCondition(policy("")); cond.Status != corev1.ConditionFalse || cond.Reason != v1alpha1.ReasonLegacyPolicy {
+		t.Errorf("Expected a legacy policy to be reported, got %+v", cond)
+	}
+	if cond := creatorRecordedCondition(policy(`{"username":"alice"}`)); cond.Status != corev1.ConditionTrue || cond.Reason != v1alpha1.ReasonRecorded {
+		t.Errorf("Expected a policy with a recorded creator to be reported as such, got %+v", cond)
+	}
+	if allowed, err := c.usersMayGet(ctx, policyWithModifier("", `{"username":"bob"}`), cmId("team-a", "x")); err != nil || allowed {
+		t.Errorf("Expected a legacy policy to be checked against its last modifier, got %v and %v", allowed, err)
+	}
+	c.reportLegacyPolicies = false
+
+	if !c.noteAccess("p", cmId("team-b", "x"), false) || !c.noteAccess("p", cmId("team-b", "y"), false) {
+		t.Errorf("Expected new denials to change the record")
+	}
+	if c.noteAccess("p", cmId("team-b", "x"), false) {
+		t.Errorf("Expected a repeated denial to not change the record")
+	}
+	errs := c.accessDenialErrors(policy(`{"username":"alice"}`))
+	expected := `Creator "alice" is not authorized to get configmaps in namespace "team-b"; left out 2 object(s)`
+	if len(errs) != 1 || errs[0] != expected {
+		t.Errorf("Expected errors [%s], got %q", expected, errs)
+	}
+	errs = c.accessDenialErrors(policyWithModifier(`{"username":"alice"}`, `{"username":"bob"}`))
+	expected = `Creator "alice" or last modifier "bob" is not authorized to get configmaps in namespace "team-b"; left out 2 object(s)`
+	if len(errs) != 1 || errs[0] != expected {
+		t.Errorf("Expected errors [%s], got %q", expected, errs)
+	}
+	if !c.noteAccess("p", cmId("team-b", "x"), true) || !c.noteAccess("p", cmId("team-b", "y"), true) {
+		t.Errorf("Expected removal of denials to change the record")
+	}
+	if len(c.accessDenials) != 0 {
+		t.Errorf("Expected no denials left, got %v", c.accessDenials)
+	}
+}
@@ -19,8 +19,8 @@ package binding
 import (
 	"context"
 	"fmt"
-	"reflect"
 	"slices"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -34,10 +34,13 @@ import (
 	k8scoreapi "k8s.io/api/core/v1"
 	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilcache "k8s.io/apimachinery/pkg/util/cache"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
@@ -45,12 +48,14 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/kubernetes"
+	authorizationclient "k8s.io/client-go/kubernetes/typed/authorization/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog/v2"
 
 	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/celeval"
 	"github.com/kubestellar/kubestellar/pkg/crd"
 	ksclient "github.com/kubestellar/kubestellar/pkg/generated/clientset/versioned"
 	controlclient "github.com/kubestellar/kubestellar/pkg/generated/clientset/versioned/typed/control/v1alpha1"
@@ -58,6 +63,8 @@ import (
 	controlinformers "github.com/kubestellar/kubestellar/pkg/generated/informers/externalversions/control/v1alpha1"
 	controllisters "github.com/kubestellar/kubestellar/pkg/generated/listers/control/v1alpha1"
 	ksmetrics "github.com/kubestellar/kubestellar/pkg/metrics"
+	"github.com/kubestellar/kubestellar/pkg/ocm"
+	transportgeneric "github.com/kubestellar/kubestellar/pkg/transport/generic"
 	"github.com/kubestellar/kubestellar/pkg/util"
 )
 
@@ -122,26 +129,44 @@ const (
 // Controller watches all objects, finds associated bindingpolicies, when matched a bindingpolicy wraps and
 // places objects into mailboxes
 type Controller struct {
-	logger                      logr.Logger
-	bindingPolicyClient         ksmetrics.ClientModNamespace[*v1alpha1.BindingPolicy, *v1alpha1.BindingPolicyList]
-	bindingClient               ksmetrics.ClientModNamespace[*v1alpha1.Binding, *v1alpha1.BindingList]
-	ksInformerFactoryStart      func(stopCh <-chan struct{})
-	bindingInformer             cache.SharedIndexInformer
-	bindingLister               controllisters.BindingLister
-	bindingPolicyInformer       cache.SharedIndexInformer
-	bindingPolicyLister         controllisters.BindingPolicyLister
-	managedClusterClient        ksmetrics.ClientModNamespace[*managedclusterapi.ManagedCluster, *managedclusterapi.ManagedClusterList]
-	clusterInformerFactoryStart func(stopCh <-chan struct{})
-	clusterInformer             cache.SharedIndexInformer // used for ManagedCluster in ITS
-	clusterLister               clusterlisters.ManagedClusterLister
-	dynamicClient               dynamic.Interface // used for workload
-	workloadObserver            WorkloadEventHandler
+	logger                          logr.Logger
+	bindingPolicyClient             ksmetrics.ClientModNamespace[*v1alpha1.BindingPolicy, *v1alpha1.BindingPolicyList]
+	bindingClient                   ksmetrics.ClientModNamespace[*v1alpha1.Binding, *v1alpha1.BindingList]
+	ksInformerFactoryStart          func(stopCh <-chan struct{})
+	bindingInformer                 cache.SharedIndexInformer
+	bindingLister                   controllisters.BindingLister
+	bindingPolicyInformer           cache.SharedIndexInformer
+	bindingPolicyLister             controllisters.BindingPolicyLister
+	namespacedBindingPolicyClient   ksmetrics.NamespacedClient[*v1alpha1.NamespacedBindingPolicy, *v1alpha1.NamespacedBindingPolicyList]
+	namespacedBindingPolicyInformer cache.SharedIndexInformer
+	namespacedBindingPolicyLister   controllisters.NamespacedBindingPolicyLister
+	clusterGrantInformer            cache.SharedIndexInformer
+	clusterGrantLister              controllisters.ClusterGrantLister
+	managedClusterClient            ksmetrics.ClientModNamespace[*managedclusterapi.ManagedCluster, *managedclusterapi.ManagedClusterList]
+	clusterInformerFactoryStart     func(stopCh <-chan struct{})
+	clusterInformer                 cache.SharedIndexInformer // used for ManagedCluster in ITS
+	clusterLister                   clusterlisters.ManagedClusterLister
+	dynamicClient                   dynamic.Interface // used for workload
+	workloadObserver                WorkloadEventHandler
 
 	discoveryClient discovery.DiscoveryInterface                                                   // for WDS
 	namespaceClient ksmetrics.ClientModNamespace[*k8scoreapi.Namespace, *k8scoreapi.NamespaceList] // for WDS
 
 	extClient ksmetrics.ClientModNamespace[*apiextensionsv1.CustomResourceDefinition, *apiextensionsv1.CustomResourceDefinitionList] // for CRDs in WDS
 
+	// sarClient is used to check whether the creator and last modifier of a BindingPolicy
+	// may get the workload objects that it selects; see CreatorAnnotationKey.
+	sarClient   authorizationclient.SubjectAccessReviewInterface // for WDS
+	accessCache *utilcache.LRUExpireCache                        // accessKey -> accessAnswer
+
+	// reportLegacyPolicies is set by ReportLegacyPolicies.
+	reportLegacyPolicies bool
+
+	// accessDenials maps the key of a BindingPolicy to the identifiers of the workload
+	// objects left out of its resolution because its creator or last modifier may not get them.
+	accessDenials      map[string]sets.Set[util.ObjectIdentifier]
+	accessDenialsMutex sync.Mutex
+
 	apiResourceLists []*metav1.APIResourceList
 	listers          util.ConcurrentMap[schema.GroupVersionResource, cache.GenericLister]
 	informers        util.ConcurrentMap[schema.GroupVersionResource, cache.SharedIndexInformer]
@@ -149,6 +174,15 @@ type Controller struct {
 
 	bindingPolicyResolver BindingPolicyResolver
 
+	// clusterSelectionEvaluator evaluates the `clusterSelectorExpressions` of BindingPolicies
+	clusterSelectionEvaluator *celeval.Evaluator
+
+	// objectFilterEvaluator evaluates the `objectFilter` of downsync clauses
+	objectFilterEvaluator *celeval.Evaluator
+
+	// rolloutGateEvaluator checks the gate `expression` of BindingPolicy rollouts
+	rolloutGateEvaluator *celeval.Evaluator
+
 	// Contains bindingPolicyRef, bindingRef, util.ObjectIdentifier
 	workqueue        workqueue.RateLimitingInterface
 	initializedTs    time.Time
@@ -283,7 +317,7 @@ func makeController(logger logr.Logger,
 	ksInformerFactoryStart func(stopCh <-chan struct{}),
 	controlInformers controlinformers.Interface,
 	dynamicClient dynamic.Interface, // used for CRD, Binding[Policy], workload
-	kubernetesClient kubernetes.Interface, // used for Namespaces, and Discovery
+	kubernetesClient kubernetes.Interface, // used for Namespaces, Discovery, and SubjectAccessReviews
 	extClient apiextensionsclientset.Interface, // used for CRD
 	clusterClient clusterclientset.Interface, // used for ManagedCluster in ITS
 	clusterInformerFactoryStart func(<-chan struct{}),
@@ -297,33 +331,59 @@ func makeController(logger logr.Logger,
 		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(50), 300)},
 	)
 
+	clusterSelectionEvaluator, err := ocm.NewClusterSelectionEvaluator()
+	if err != nil {
+		return nil, err
+	}
+	objectFilterEvaluator, err := newObjectFilterEvaluator()
+	if err != nil {
+		return nil, err
+	}
+	rolloutGateEvaluator, err := transportgeneric.NewRolloutGateEvaluator()
+	if err != nil {
+		return nil, err
+	}
+
 	clusterInformer := clusterPreInformer.Informer()
 	controller := &Controller{
-		wdsName:                     wdsName,
-		logger:                      logger,
-		bindingPolicyClient:         ksmetrics.NewWrappedClusterScopedClient(wdsClientMetrics, util.GetBindingPolicyGVR(), controlClient.BindingPolicies()),
-		bindingClient:               ksmetrics.NewWrappedClusterScopedClient(wdsClientMetrics, util.GetBindingGVR(), controlClient.Bindings()),
-		ksInformerFactoryStart:      ksInformerFactoryStart,
-		bindingInformer:             controlInformers.Bindings().Informer(),
-		bindingLister:               controlInformers.Bindings().Lister(),
-		bindingPolicyInformer:       controlInformers.BindingPolicies().Informer(),
-		bindingPolicyLister:         controlInformers.BindingPolicies().Lister(),
-		managedClusterClient:        ksmetrics.NewWrappedClusterScopedClient[*managedclusterapi.ManagedCluster, *managedclusterapi.ManagedClusterList](itsClientMetrics, managedclusterapi.SchemeGroupVersion.WithResource("managedclusters"), clusterClient.ClusterV1().ManagedClusters()),
-		clusterInformerFactoryStart: clusterInformerFactoryStart,
-		clusterInformer:             clusterInformer,
-		clusterLister:               clusterPreInformer.Lister(),
-		dynamicClient:               dynamicClient,
-		workloadObserver:            workloadObserver,
-		discoveryClient:             kubernetesClient.Discovery(),
-		namespaceClient:             ksmetrics.NewWrappedClusterScopedClient(wdsClientMetrics, k8scoreapi.SchemeGroupVersion.WithResource("namespaces"), kubernetesClient.CoreV1().Namespaces()),
-		extClient:                   ksmetrics.NewWrappedClusterScopedClient(wdsClientMetrics, apiextensionsv1.SchemeGroupVersion.WithResource("customresourcedefinitions"), extClient.ApiextensionsV1().CustomResourceDefinitions()),
-		apiResourceLists:            apiResourceLists,
-		listers:                     util.NewConcurrentMap[schema.GroupVersionResource, cache.GenericLister](),
-		informers:                   util.NewConcurrentMap[schema.GroupVersionResource, cache.SharedIndexInformer](),
-		stoppers:                    util.NewConcurrentMap[schema.GroupVersionResource, chan struct{}](),
-		bindingPolicyResolver:       NewBindingPolicyResolver(),
-		workqueue:                   workqueue.NewRateLimitingQueueWithConfig(ratelimiter, workqueue.RateLimitingQueueConfig{Name: ControllerName + "-" + wdsName}),
-		allowedGroupsSet:            allowedGroupsSet,
+		wdsName:                wdsName,
+		logger:                 logger,
+		bindingPolicyClient:    ksmetrics.NewWrappedClusterScopedClient(wdsClientMetrics, util.GetBindingPolicyGVR(), controlClient.BindingPolicies()),
+		bindingClient:          ksmetrics.NewWrappedClusterScopedClient(wdsClientMetrics, util.GetBindingGVR(), controlClient.Bindings()),
+		ksInformerFactoryStart: ksInformerFactoryStart,
+		bindingInformer:        controlInformers.Bindings().Informer(),
+		bindingLister:          controlInformers.Bindings().Lister(),
+		bindingPolicyInformer:  controlInformers.BindingPolicies().Informer(),
+		bindingPolicyLister:    controlInformers.BindingPolicies().Lister(),
+		namespacedBindingPolicyClient: ksmetrics.NewWrappedNamespacedClient(wdsClientMetrics, v1alpha1.GroupVersion.WithResource(util.NamespacedBindingPolicyResource), func(ns string) ksmetrics.ClientModNamespace[*v1alpha1.NamespacedBindingPolicy, *v1alpha1.NamespacedBindingPolicyList] {
+			return controlClient.NamespacedBindingPolicies(ns)
+		}),
+		namespacedBindingPolicyInformer: controlInformers.NamespacedBindingPolicies().Informer(),
+		namespacedBindingPolicyLister:   controlInformers.NamespacedBindingPolicies().Lister(),
+		clusterGrantInformer:            controlInformers.ClusterGrants().Informer(),
+		clusterGrantLister:              controlInformers.ClusterGrants().Lister(),
+		managedClusterClient:            ksmetrics.NewWrappedClusterScopedClient[*managedclusterapi.ManagedCluster, *managedclusterapi.ManagedClusterList](itsClientMetrics, managedclusterapi.SchemeGroupVersion.WithResource("managedclusters"), clusterClient.ClusterV1().ManagedClusters()),
+		clusterInformerFactoryStart:     clusterInformerFactoryStart,
+		clusterInformer:                 clusterInformer,
+		clusterLister:                   clusterPreInformer.Lister(),
+		dynamicClient:                   dynamicClient,
+		workloadObserver:                workloadObserver,
+		discoveryClient:                 kubernetesClient.Discovery(),
+		namespaceClient:                 ksmetrics.NewWrappedClusterScopedClient(wdsClientMetrics, k8scoreapi.SchemeGroupVersion.WithResource("namespaces"), kubernetesClient.CoreV1().Namespaces()),
+		sarClient:                       kubernetesClient.AuthorizationV1().SubjectAccessReviews(),
+		accessCache:                     utilcache.NewLRUExpireCache(accessCacheSize),
+		accessDenials:                   map[string]sets.Set[util.ObjectIdentifier]{},
+		extClient:                       ksmetrics.NewWrappedClusterScopedClient(wdsClientMetrics, apiextensionsv1.SchemeGroupVersion.WithResource("customresourcedefinitions"), extClient.ApiextensionsV1().CustomResourceDefinitions()),
+		apiResourceLists:                apiResourceLists,
+		listers:                         util.NewConcurrentMap[schema.GroupVersionResource, cache.GenericLister](),
+		informers:                       util.NewConcurrentMap[schema.GroupVersionResource, cache.SharedIndexInformer](),
+		stoppers:                        util.NewConcurrentMap[schema.GroupVersionResource, chan struct{}](),
+		bindingPolicyResolver:           NewBindingPolicyResolver(),
+		workqueue:                       workqueue.NewRateLimitingQueueWithConfig(ratelimiter, workqueue.RateLimitingQueueConfig{Name: ControllerName + "-" + wdsName}),
+		allowedGroupsSet:                allowedGroupsSet,
+		clusterSelectionEvaluator:       clusterSelectionEvaluator,
+		objectFilterEvaluator:           objectFilterEvaluator,
+		rolloutGateEvaluator:            rolloutGateEvaluator,
 	}
 
 	return controller, nil
@@ -361,11 +421,18 @@ func (c *Controller) Start(parentCtx context.Context, workers int, cListers chan
 	if err := c.setupBindingPolicyInformer(ctx); err != nil {
 		return err
 	}
+	if err := c.setupNamespacedBindingPolicyInformer(ctx); err != nil {
+		return err
+	}
+	if err := c.setupClusterGrantInformer(ctx); err != nil {
+		return err
+	}
 	if err := c.setupBindingInformer(ctx); err != nil {
 		return err
 	}
 	c.ksInformerFactoryStart(ctx.Done())
-	if ok := cache.WaitForCacheSync(ctx.Done(), c.bindingPolicyInformer.HasSynced, c.bindingInformer.HasSynced); !ok {
+	if ok := cache.WaitForCacheSync(ctx.Done(), c.bindingPolicyInformer.HasSynced, c.namespacedBindingPolicyInformer.HasSynced,
+		c.clusterGrantInformer.HasSynced, c.bindingInformer.HasSynced); !ok {
 		return fmt.Errorf("failed to wait for KubeStellar informers to sync")
 	}
 
@@ -513,26 +580,25 @@ func (c *Controller) run(ctx context.Context, workers int, cListers chan interfa
 func (c *Controller) setupManagedClustersInformer(ctx context.Context) error {
 	_, err := c.clusterInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
-			objM := obj.(metav1.Object)
-			c.evaluateBindingPolicies(ctx, objM.GetName(), objM.GetLabels())
+			cluster := obj.(*managedclusterapi.ManagedCluster)
+			c.evaluateBindingPolicies(ctx, cluster)
 		},
 		UpdateFunc: func(old, new interface{}) {
-			oldM := old.(metav1.Object)
-			newM := new.(metav1.Object)
-			// Re-evaluateBindingPolicies iff labels have changed.
-			oldLabels := oldM.GetLabels()
-			newLabels := newM.GetLabels()
-			if !reflect.DeepEqual(oldLabels, newLabels) {
-				c.logger.V(5).Info("Handling labels change", "old", old, "new", new)
-				c.evaluateBindingPoliciesForUpdate(ctx, newM.GetName(), oldLabels, newLabels)
+			oldCluster := old.(*managedclusterapi.ManagedCluster)
+			newCluster := new.(*managedclusterapi.ManagedCluster)
+			// Re-evaluate BindingPolicies iff the cluster has changed;
+			// label changes matter for all, other changes only for expressions.
+			if oldCluster.ResourceVersion != newCluster.ResourceVersion {
+				c.logger.V(5).Info("Handling cluster change", "old", old, "new", new)
+				c.evaluateBindingPoliciesForUpdate(ctx, oldCluster, newCluster)
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
 			if typed, is := obj.(cache.DeletedFinalStateUnknown); is {
 				obj = typed.Obj
 			}
-			objM := obj.(metav1.Object)
-			c.evaluateBindingPolicies(ctx, objM.GetName(), objM.GetLabels())
+			cluster := obj.(*managedclusterapi.ManagedCluster)
+			c.evaluateBindingPolicies(ctx, cluster)
 		},
 	})
 	if err != nil {
@@ -794,6 +860,8 @@ func (c *Controller) GetInformers() util.ConcurrentMap[schema.GroupVersionResour
 // populateBindingPolicyResolverWithExistingBindingPolicies fills the BindingPolicyResolver
 // with entries for existing BindingPolicy objects. Any bindingpolicy name that is not
 // associated with a resolution gets associated to an empty resolution.
+// For a bindingpolicy that limits its number of clusters, the destinations of the existing
+// Binding (if any) are noted so that the selection of clusters is stable across restarts.
 // No concurrent calls allowed.
 // May not be called concurrently with Controller::reconcile.
 func (c *Controller) populateBindingPolicyResolverWithExistingBindingPolicies() error {
@@ -804,6 +872,20 @@ func (c *Controller) populateBindingPolicyResolverWithExistingBindingPolicies()
 
 	for _, bindingpolicy := range bindingpolicies {
 		c.bindingPolicyResolver.NoteBindingPolicy(bindingpolicy)
+		if bindingpolicy.Spec.NumberOfClusters == nil {
+			continue
+		}
+		binding, err := c.bindingLister.Get(bindingpolicy.Name)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to get Binding from informer cache (name=%v): %w", bindingpolicy.Name, err)
+		}
+		previous := make(map[string]labels.Set, len(binding.Spec.Destinations))
+		for _, dest := range binding.Spec.Destinations {
+			previous[dest.ClusterId] = nil
+		}
+		_ = c.bindingPolicyResolver.SetDestinations(bindingpolicy.Name, previous)
 	}
 
 	return nil
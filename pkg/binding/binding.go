@@ -17,10 +17,15 @@ limitations under the License.
 package binding
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2"
@@ -60,7 +65,7 @@ func (c *Controller) syncBinding(ctx context.Context, bindingName string) error
 	// binding name matches that of the bindingpolicy 1:1, therefore its NamespacedName is the same.
 	bindingPolicyIdentifier := binding.GetName()
 
-	policy, policyErr := c.bindingPolicyLister.Get(bindingPolicyIdentifier)
+	policy, policyErr := c.getBindingPolicy(bindingPolicyIdentifier)
 	// `*policy` is immutable
 	if errors.IsNotFound(policyErr) {
 		logger.V(2).Info("Aborting sync of Binding because the corresponding Policy is gone", "name", bindingName)
@@ -76,11 +81,13 @@ func (c *Controller) syncBinding(ctx context.Context, bindingName string) error
 	}
 
 	// calculate if the resolved decision is different from the current one
-	if c.bindingPolicyResolver.CompareBinding(bindingPolicyIdentifier, &binding.Spec) {
+	if policy.Spec.Suspend {
+		logger.V(4).Info("Not updating Binding because its BindingPolicy is suspended", "name", binding.GetName())
+	} else if c.bindingPolicyResolver.CompareBinding(bindingPolicyIdentifier, &binding.Spec) {
 		logger.V(4).Info("Binding is up to date", "name", binding.GetName())
 	} else {
 		// update the binding object in the cluster by updating spec
-		if err := c.updateOrCreateBinding(ctx, binding, generatedBindingSpec); err != nil {
+		if err := c.updateOrCreateBinding(ctx, binding, policy, generatedBindingSpec); err != nil {
 			return fmt.Errorf("failed to update or create binding: %w", err)
 		}
 
@@ -106,15 +113,31 @@ func (c *Controller) syncBinding(ctx context.Context, bindingName string) error
 			policyErrors = append(policyErrors, fmt.Sprintf("Singleton reported status return is requested but some objects have the wrong number of associated WECs, for example: %s", string(badSRBytes)))
 		}
 	}
+	policyErrors = append(policyErrors, c.accessDenialErrors(policy)...)
+	for _, err := range validateBindingPolicySpec(c.clusterSelectionEvaluator, c.objectFilterEvaluator, c.rolloutGateEvaluator, &policy.Spec) {
+		policyErrors = append(policyErrors, err.Error())
+	}
+	ownConditions := []v1alpha1.BindingPolicyCondition{conflictingCondition(c.bindingPolicyResolver.GetModulationConflicts(bindingPolicyIdentifier))}
+	if c.reportLegacyPolicies {
+		ownConditions = append(ownConditions, creatorRecordedCondition(policy))
+	}
+	conditions := policyConditions(binding.Status.Conditions, policy.Status.Conditions, ownConditions...)
 	policyWithStatus := policy.DeepCopy()
 	policyWithStatus.Status = v1alpha1.BindingPolicyStatus{
 		ObservedGeneration: policy.Generation,
-		Conditions:         binding.Status.Conditions,
+		Conditions:         conditions,
 		Errors:             append(policyErrors, binding.Status.Errors...),
 	}
-	policyEcho, updateErr := c.bindingPolicyClient.UpdateStatus(ctx, policyWithStatus, metav1.UpdateOptions{FieldManager: ControllerName})
+	if policy.Spec.Suspend {
+		policyWithStatus.Status.ResolutionPreview = resolutionPreview(generatedBindingSpec)
+	}
+	if apiequality.Semantic.DeepEqual(policy.Status, policyWithStatus.Status) {
+		logger.V(4).Info("Status of BindingPolicy is up to date", "name", bindingPolicyIdentifier, "generation", policy.Generation)
+		return nil
+	}
+	resourceVersion, updateErr := c.updateBindingPolicyStatus(ctx, policyWithStatus)
 	if updateErr == nil {
-		logger.V(4).Info("Updated Status of BindingPolicy", "name", bindingPolicyIdentifier, "generation", policy.Generation, "numErrors", len(policyErrors), "resourceVersion", policyEcho.ResourceVersion)
+		logger.V(4).Info("Updated Status of BindingPolicy", "name", bindingPolicyIdentifier, "generation", policy.Generation, "numErrors", len(policyErrors), "resourceVersion", resourceVersion)
 	} else if errors.IsNotFound(updateErr) {
 		logger.V(2).Info("Did not update Status of absent BindingPolicy", "name", bindingPolicyIdentifier)
 	} else {
@@ -124,6 +147,87 @@ func (c *Controller) syncBinding(ctx context.Context, bindingName string) error
 	return nil
 }
 
+// resolutionPreview summarizes the given BindingSpec.
+func resolutionPreview(spec *v1alpha1.BindingSpec) *v1alpha1.ResolutionPreview {
+	counts := map[metav1.GroupResource]int32{}
+	for _, clause := range spec.Workload.ClusterScope {
+		counts[metav1.GroupResource{Group: clause.Group, Resource: clause.Resource}]++
+	}
+	for _, clause := range spec.Workload.NamespaceScope {
+		counts[metav1.GroupResource{Group: clause.Group, Resource: clause.Resource}]++
+	}
+	preview := &v1alpha1.ResolutionPreview{
+		ObjectCounts: make([]v1alpha1.GroupResourceCount, 0, len(counts)),
+		Clusters:     make([]string, 0, len(spec.Destinations)),
+	}
+	for gr, count := range counts {
+		preview.ObjectCounts = append(preview.ObjectCounts, v1alpha1.GroupResourceCount{GroupResource: gr, Count: count})
+	}
+	slices.SortFunc(preview.ObjectCounts, func(a, b v1alpha1.GroupResourceCount) int {
+		return cmp.Or(strings.Compare(a.Group, b.Group), strings.Compare(a.Resource, b.Resource))
+	})
+	for _, dest := range spec.Destinations {
+		preview.Clusters = append(preview.Clusters, dest.ClusterId)
+	}
+	slices.Sort(preview.Clusters)
+	return preview
+}
+
+// maxConflictsInCondition limits the number of conflicts described in a Conflicting condition.
+const maxConflictsInCondition = 4
+
+// conflictingCondition returns the Conflicting condition that reports the given conflicts.
+func conflictingCondition(conflicts []ModulationConflict) v1alpha1.BindingPolicyCondition {
+	if len(conflicts) == 0 {
+		return v1alpha1.BindingPolicyCondition{
+			Type:    v1alpha1.TypeConflicting,
+			Status:  corev1.ConditionFalse,
+			Reason:  v1alpha1.ReasonNoConflict,
+			Message: "No downsync modulation is overridden by another BindingPolicy",
+		}
+	}
+	descriptions := make([]string, 0, maxConflictsInCondition)
+	for _, conflict := range conflicts[:min(len(conflicts), maxConflictsInCondition)] {
+		descriptions = append(descriptions, conflict.String())
+	}
+	message := strings.Join(descriptions, "; ")
+	if len(conflicts) > maxConflictsInCondition {
+		message += fmt.Sprintf("; and %d more", len(conflicts)-maxConflictsInCondition)
+	}
+	return v1alpha1.BindingPolicyCondition{
+		Type:    v1alpha1.TypeConflicting,
+		Status:  corev1.ConditionTrue,
+		Reason:  v1alpha1.ReasonOverridden,
+		Message: message,
+	}
+}
+
+// policyConditions returns the conditions for a BindingPolicy's status:
+// those of its Binding plus the given conditions that the binding controller maintains itself.
+// The policy's existing versions of those conditions are used as the starting point,
+// so that their LastTransitionTimes are preserved when nothing changes.
+// None of the given slices is mutated.
+func policyConditions(bindingConditions, oldPolicyConditions []v1alpha1.BindingPolicyCondition, ownConditions ...v1alpha1.BindingPolicyCondition) []v1alpha1.BindingPolicyCondition {
+	isOwn := func(condition v1alpha1.BindingPolicyCondition) bool {
+		return slices.ContainsFunc(ownConditions, func(own v1alpha1.BindingPolicyCondition) bool { return own.Type == condition.Type })
+	}
+	conditions := make([]v1alpha1.BindingPolicyCondition, 0, len(bindingConditions)+len(ownConditions))
+	for _, condition := range bindingConditions {
+		if !isOwn(condition) {
+			conditions = append(conditions, condition)
+		}
+	}
+	for _, condition := range oldPolicyConditions {
+		if isOwn(condition) {
+			conditions = append(conditions, condition)
+		}
+	}
+	for _, ownCondition := range ownConditions {
+		conditions, _ = v1alpha1.SetCondition(conditions, ownCondition)
+	}
+	return conditions
+}
+
 type objectWithNumWECs struct {
 	ObjectID util.ObjectIdentifier
 	NumWECs  int
@@ -132,18 +236,28 @@ type objectWithNumWECs struct {
 // updateOrCreateBinding updates or creates a binding object in the cluster.
 // If the object already exists, it is updated. Otherwise, it is created.
 // The given `bdg *v1alpha1.Binding` points to immutable storage.
-func (c *Controller) updateOrCreateBinding(ctx context.Context, bdg *v1alpha1.Binding,
+// The given policy is the Binding's BindingPolicy, or the effective BindingPolicy
+// of its NamespacedBindingPolicy.
+func (c *Controller) updateOrCreateBinding(ctx context.Context, bdg *v1alpha1.Binding, policy *v1alpha1.BindingPolicy,
 	generatedBindingSpec *v1alpha1.BindingSpec) error {
 	bdg = bdg.DeepCopy()
 	// use the passed binding and set its spec
 	bdg.Spec = *generatedBindingSpec
 
-	// set owner reference
-	ownerReference, err := c.bindingPolicyResolver.GetOwnerReference(bdg.GetName())
-	if err != nil {
-		return fmt.Errorf("failed to get OwnerReference: %w", err)
+	if policy.Namespace != "" {
+		// a cluster-scoped object can not be owned by a namespaced one
+		if bdg.Labels == nil {
+			bdg.Labels = map[string]string{}
+		}
+		bdg.Labels[v1alpha1.PolicyNamespaceLabelKey] = policy.Namespace
+	} else {
+		// set owner reference
+		ownerReference, err := c.bindingPolicyResolver.GetOwnerReference(bdg.GetName())
+		if err != nil {
+			return fmt.Errorf("failed to get OwnerReference: %w", err)
+		}
+		bdg.SetOwnerReferences([]metav1.OwnerReference{ownerReference})
 	}
-	bdg.SetOwnerReferences([]metav1.OwnerReference{ownerReference})
 
 	logger := klog.FromContext(ctx)
 	bdgEcho, err := c.bindingClient.Update(ctx, bdg, metav1.UpdateOptions{FieldManager: ControllerName})
@@ -0,0 +1,56 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"testing"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestResolutionPreview(t *testing.T) {
+	deployments := metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	configMaps := metav1.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	namespaces := metav1.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	spec := &v1alpha1.BindingSpec{
+		Workload: v1alpha1.DownsyncObjectClauses{
+			ClusterScope: []v1alpha1.ClusterScopeDownsyncClause{
+				{ClusterScopeDownsyncObject: v1alpha1.ClusterScopeDownsyncObject{GroupVersionResource: namespaces, Name: "demo"}},
+			},
+			NamespaceScope: []v1alpha1.NamespaceScopeDownsyncClause{
+				{NamespaceScopeDownsyncObject: v1alpha1.NamespaceScopeDownsyncObject{GroupVersionResource: deployments, Namespace: "demo", Name: "web"}},
+				{NamespaceScopeDownsyncObject: v1alpha1.NamespaceScopeDownsyncObject{GroupVersionResource: configMaps, Namespace: "demo", Name: "a"}},
+				{NamespaceScopeDownsyncObject: v1alpha1.NamespaceScopeDownsyncObject{GroupVersionResource: configMaps, Namespace: "demo", Name: "b"}},
+			},
+		},
+		Destinations: []v1alpha1.Destination{{ClusterId: "wec2"}, {ClusterId: "wec1"}},
+	}
+	expected := &v1alpha1.ResolutionPreview{
+		ObjectCounts: []v1alpha1.GroupResourceCount{
+			{GroupResource: metav1.GroupResource{Resource: "configmaps"}, Count: 2},
+			{GroupResource: metav1.GroupResource{Resource: "namespaces"}, Count: 1},
+			{GroupResource: metav1.GroupResource{Group: "apps", Resource: "deployments"}, Count: 1},
+		},
+		Clusters: []string{"wec1", "wec2"},
+	}
+	if actual := resolutionPreview(spec); !apiequality.Semantic.DeepEqual(actual, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, actual)
+	}
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/go-logr/logr"
 
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -43,15 +44,46 @@ type bindingPolicyResolution struct {
 	// in the requiresSingletonReportedState or requiresMultiWECReportedState setting for an object.
 	reportedStateRequestChangeConsumer func(util.ObjectIdentifier)
 
+	// One immutable function that gets called synchronously whenever an object identifier
+	// is added to (true) or removed from (false) objectIdentifierToData.
+	membershipChangeConsumer func(util.ObjectIdentifier, bool)
+
 	sync.RWMutex
 
 	// This map is mutable, but every `ObjectData` stored in it is immutable.
 	// No `*ObjectData` in this map is nil.
 	objectIdentifierToData map[util.ObjectIdentifier]*ObjectData
 
+	// dependencies maps each selected object that has `includeDependencies`
+	// to the identifiers of the objects that its pod spec references,
+	// whether or not those exist. Every Set stored here is immutable.
+	dependencies map[util.ObjectIdentifier]sets.Set[util.ObjectIdentifier]
+
 	// Every Set ever stored here is immutable from the time it is stored here.
 	destinations sets.Set[string]
 
+	// placement limits and ranks the clusters that become destinations.
+	placement clusterPlacement
+
+	// overrides is copied from the BindingPolicy spec and is immutable.
+	overrides []v1alpha1.Override
+
+	// rollout is copied from the BindingPolicy spec and is immutable.
+	rollout *v1alpha1.RolloutStrategy
+
+	// propagationWindows is copied from the BindingPolicy spec and is immutable.
+	propagationWindows []v1alpha1.PropagationWindow
+
+	// upsync is copied from the BindingPolicy spec and is immutable.
+	upsync []v1alpha1.UpsyncPolicyClause
+
+	// priority is copied from the BindingPolicy spec.
+	priority int32
+
+	// suspended is copied from the BindingPolicy spec.
+	// The modulations of a suspended BindingPolicy do not prevail over others.
+	suspended bool
+
 	// ownerReference identifies the bindingpolicy that this resolution is
 	// associated with as an owning object.
 	// This pointer is never nil (why is it a pointer?).
@@ -68,6 +100,10 @@ type ObjectData struct {
 	ResourceVersion string
 
 	Modulation DownsyncModulation
+
+	// Implicit is true when the object is in the resolution only because
+	// another object in it depends on it. The Modulation of such an object is zero.
+	Implicit bool
 }
 
 // Assert that `*bindingPolicyResolution` implements Resolution
@@ -116,13 +152,16 @@ func (resolution *bindingPolicyResolution) ensureObjectData(objIdentifier util.O
 	defer resolution.Unlock()
 
 	objData := resolution.objectIdentifierToData[objIdentifier]
-	if objData == nil || objData.UID != objUID || objData.ResourceVersion != resourceVersion ||
+	if objData == nil || objData.Implicit || objData.UID != objUID || objData.ResourceVersion != resourceVersion ||
 		!objData.Modulation.Equal(modulation) {
 		resolution.objectIdentifierToData[objIdentifier] = &ObjectData{
 			UID:             objUID,
 			ResourceVersion: resourceVersion,
 			Modulation:      modulation,
 		}
+		if objData == nil {
+			resolution.membershipChangeConsumer(objIdentifier, true)
+		}
 		// Notify when singleton or multi-WEC status flags change
 		singletonChanged := objData == nil && modulation.WantSingletonReportedState ||
 			objData != nil && objData.Modulation.WantSingletonReportedState != modulation.WantSingletonReportedState
@@ -140,18 +179,29 @@ func (resolution *bindingPolicyResolution) ensureObjectData(objIdentifier util.O
 }
 
 // removeObjectIdentifier removes an object identifier from the resolution if it
-// exists. The return bool indicates whether the resolution was changed.
+// exists, along with the implicitly selected objects that only it depended on.
+// The object stays required if other objects depend on it, so that it
+// returns implicitly if it reappears.
+// The return bool indicates whether the resolution was changed.
 // This function is thread-safe.
 func (resolution *bindingPolicyResolution) removeObjectIdentifier(objIdentifier util.ObjectIdentifier) bool {
 	resolution.Lock()
 	defer resolution.Unlock()
 
+	changed := resolution.setDependenciesLocked(objIdentifier, nil, nil)
+	return resolution.deleteObjectLocked(objIdentifier) || changed
+}
+
+// deleteObjectLocked removes an object identifier from objectIdentifierToData.
+// The caller must hold the write lock.
+func (resolution *bindingPolicyResolution) deleteObjectLocked(objIdentifier util.ObjectIdentifier) bool {
 	objData, exists := resolution.objectIdentifierToData[objIdentifier]
 	if !exists {
 		return false
 	}
 
 	delete(resolution.objectIdentifierToData, objIdentifier)
+	resolution.membershipChangeConsumer(objIdentifier, false)
 	if objData.Modulation.WantSingletonReportedState || objData.Modulation.WantMultiWECReportedState {
 		klog.InfoS("Noting removal of object from resolution", "resolution", fmt.Sprintf("%p", resolution), "objId", objIdentifier)
 		resolution.reportedStateRequestChangeConsumer(objIdentifier)
@@ -159,9 +209,115 @@ func (resolution *bindingPolicyResolution) removeObjectIdentifier(objIdentifier
 	return true
 }
 
+// setDependencies records the objects that the given selected object depends on.
+// `existing` holds the data of those dependencies that currently exist;
+// those not already selected explicitly are added as implicitly selected.
+// Implicitly selected objects that are no longer depended upon are removed.
+// Neither argument is mutated or retained.
+// The return bool indicates whether the resolution was changed.
+// This function is thread-safe.
+func (resolution *bindingPolicyResolution) setDependencies(objIdentifier util.ObjectIdentifier,
+	dependencies sets.Set[util.ObjectIdentifier], existing map[util.ObjectIdentifier]ObjectData) bool {
+	resolution.Lock()
+	defer resolution.Unlock()
+
+	return resolution.setDependenciesLocked(objIdentifier, dependencies, existing)
+}
+
+// setDependenciesLocked is setDependencies for a caller that holds the write lock.
+func (resolution *bindingPolicyResolution) setDependenciesLocked(objIdentifier util.ObjectIdentifier,
+	dependencies sets.Set[util.ObjectIdentifier], existing map[util.ObjectIdentifier]ObjectData) bool {
+	previous := resolution.dependencies[objIdentifier]
+	if len(dependencies) == 0 {
+		delete(resolution.dependencies, objIdentifier)
+	} else {
+		resolution.dependencies[objIdentifier] = dependencies.Clone()
+	}
+	changed := false
+	for depId, depData := range existing {
+		if !dependencies.Has(depId) {
+			continue
+		}
+		if current := resolution.objectIdentifierToData[depId]; current == nil ||
+			current.Implicit && (current.UID != depData.UID || current.ResourceVersion != depData.ResourceVersion) {
+			resolution.objectIdentifierToData[depId] = &ObjectData{
+				UID:             depData.UID,
+				ResourceVersion: depData.ResourceVersion,
+				Modulation:      ZeroDownsyncModulation(),
+				Implicit:        true,
+			}
+			if current == nil {
+				resolution.membershipChangeConsumer(depId, true)
+			}
+			changed = true
+		}
+	}
+	for depId := range previous {
+		if dependencies.Has(depId) || resolution.isDependencyLocked(depId) {
+			continue
+		}
+		if current := resolution.objectIdentifierToData[depId]; current != nil && current.Implicit {
+			changed = resolution.deleteObjectLocked(depId) || changed
+		}
+	}
+	return changed
+}
+
+// noteImplicitObject is for an object that the BindingPolicy does not select.
+// If some selected object depends on the given one then this ensures that the
+// given one is in the resolution as implicitly selected, with the given UID and
+// resource version, and the first returned bool is true.
+// The second returned bool indicates whether the resolution was changed.
+// This function is thread-safe.
+func (resolution *bindingPolicyResolution) noteImplicitObject(objIdentifier util.ObjectIdentifier,
+	objUID, resourceVersion string) (bool, bool) {
+	resolution.Lock()
+	defer resolution.Unlock()
+
+	if !resolution.isDependencyLocked(objIdentifier) {
+		return false, false
+	}
+	// an object that is no longer selected explicitly no longer contributes dependencies
+	changed := resolution.setDependenciesLocked(objIdentifier, nil, nil)
+	if objData := resolution.objectIdentifierToData[objIdentifier]; objData != nil && objData.Implicit &&
+		objData.UID == objUID && objData.ResourceVersion == resourceVersion {
+		return true, changed
+	}
+	resolution.deleteObjectLocked(objIdentifier)
+	resolution.objectIdentifierToData[objIdentifier] = &ObjectData{
+		UID:             objUID,
+		ResourceVersion: resourceVersion,
+		Modulation:      ZeroDownsyncModulation(),
+		Implicit:        true,
+	}
+	resolution.membershipChangeConsumer(objIdentifier, true)
+	return true, true
+}
+
+// isDependency tells whether any selected object depends on the given one.
+// This function is thread-safe.
+func (resolution *bindingPolicyResolution) isDependency(objIdentifier util.ObjectIdentifier) bool {
+	resolution.RLock()
+	defer resolution.RUnlock()
+
+	return resolution.isDependencyLocked(objIdentifier)
+}
+
+// isDependencyLocked tells whether any selected object depends on the given one.
+// The caller must hold the lock.
+func (resolution *bindingPolicyResolution) isDependencyLocked(objIdentifier util.ObjectIdentifier) bool {
+	for _, dependencies := range resolution.dependencies {
+		if dependencies.Has(objIdentifier) {
+			return true
+		}
+	}
+	return false
+}
+
 // toBindingSpec converts the resolution to a binding
-// spec. This function is thread-safe.
-func (resolution *bindingPolicyResolution) toBindingSpec() *v1alpha1.BindingSpec {
+// spec, taking into account the modulations that prevail from other BindingPolicies.
+// This function is thread-safe.
+func (resolution *bindingPolicyResolution) toBindingSpec(prevailing map[util.ObjectIdentifier]prevailingModulation) *v1alpha1.BindingSpec {
 	resolution.RLock()
 	defer resolution.RUnlock()
 
@@ -172,6 +328,7 @@ func (resolution *bindingPolicyResolution) toBindingSpec() *v1alpha1.BindingSpec
 	// Therefore, whenever an object is about to be appended, we simply append.
 	for objIdentifier, objData := range resolution.objectIdentifierToData {
 		// check if object is cluster-scoped or namespaced by checking namespace
+		modulation := effectiveModulation(objIdentifier, objData.Modulation, prevailing)
 		if objIdentifier.ObjectName.Namespace == metav1.NamespaceNone {
 			clause := v1alpha1.ClusterScopeDownsyncClause{
 				ClusterScopeDownsyncObject: v1alpha1.ClusterScopeDownsyncObject{
@@ -179,7 +336,8 @@ func (resolution *bindingPolicyResolution) toBindingSpec() *v1alpha1.BindingSpec
 					Name:                 objIdentifier.ObjectName.Name,
 					ResourceVersion:      objData.ResourceVersion,
 				},
-				DownsyncModulation: objData.Modulation.ToExternal(),
+				DownsyncModulation: modulation.ToExternal(),
+				Implicit:           objData.Implicit,
 			}
 			workload.ClusterScope = append(workload.ClusterScope, clause)
 			continue
@@ -192,7 +350,8 @@ func (resolution *bindingPolicyResolution) toBindingSpec() *v1alpha1.BindingSpec
 				Namespace:            objIdentifier.ObjectName.Namespace,
 				ResourceVersion:      objData.ResourceVersion,
 			},
-			DownsyncModulation: objData.Modulation.ToExternal(),
+			DownsyncModulation: modulation.ToExternal(),
+			Implicit:           objData.Implicit,
 		}
 
 		workload.NamespaceScope = append(workload.NamespaceScope, clause)
@@ -202,12 +361,16 @@ func (resolution *bindingPolicyResolution) toBindingSpec() *v1alpha1.BindingSpec
 	sortBindingWorkloadObjects(&workload)
 
 	return &v1alpha1.BindingSpec{
-		Workload:     workload,
-		Destinations: destinationsStringSetToSortedDestinations(resolution.destinations),
+		Workload:           workload,
+		Destinations:       destinationsStringSetToSortedDestinations(resolution.destinations),
+		Overrides:          resolution.overrides,
+		Rollout:            resolution.rollout,
+		PropagationWindows: resolution.propagationWindows,
+		Upsync:             resolution.upsync,
 	}
 }
 
-func (resolution *bindingPolicyResolution) matchesBindingSpec(bindingSpec *v1alpha1.BindingSpec) bool {
+func (resolution *bindingPolicyResolution) matchesBindingSpec(bindingSpec *v1alpha1.BindingSpec, prevailing map[util.ObjectIdentifier]prevailingModulation) bool {
 	resolution.RLock()
 	defer resolution.RUnlock()
 
@@ -216,6 +379,26 @@ func (resolution *bindingPolicyResolution) matchesBindingSpec(bindingSpec *v1alp
 		return false
 	}
 
+	// check overrides
+	if !apiequality.Semantic.DeepEqual(resolution.overrides, bindingSpec.Overrides) {
+		return false
+	}
+
+	// check rollout
+	if !apiequality.Semantic.DeepEqual(resolution.rollout, bindingSpec.Rollout) {
+		return false
+	}
+
+	// check propagation windows
+	if !apiequality.Semantic.DeepEqual(resolution.propagationWindows, bindingSpec.PropagationWindows) {
+		return false
+	}
+
+	// check upsync
+	if !apiequality.Semantic.DeepEqual(resolution.upsync, bindingSpec.Upsync) {
+		return false
+	}
+
 	// check workload
 	if len(resolution.objectIdentifierToData) != len(bindingSpec.Workload.ClusterScope)+
 		len(bindingSpec.Workload.NamespaceScope) {
@@ -226,12 +409,14 @@ func (resolution *bindingPolicyResolution) matchesBindingSpec(bindingSpec *v1alp
 
 	for objIdentifier, objData := range resolution.objectIdentifierToData {
 		// check if object ref exists, then check if the object data matches
+		modulation := effectiveModulation(objIdentifier, objData.Modulation, prevailing)
 		if objDataFromWorkload := objRefToDataFromWorkload[objectRef{
 			GroupVersionResource: objIdentifier.GVR(),
 			ObjectName:           objIdentifier.ObjectName,
 		}]; objDataFromWorkload == nil ||
 			objData.ResourceVersion != objDataFromWorkload.ResourceVersion ||
-			!objData.Modulation.Equal(objDataFromWorkload.Modulation) {
+			objData.Implicit != objDataFromWorkload.Implicit ||
+			!modulation.Equal(objDataFromWorkload.Modulation) {
 			return false
 		}
 	} // this check works because both groups have unique members and are of equal size
@@ -246,6 +431,13 @@ func (resolution *bindingPolicyResolution) getWorkloadReferences() []util.Object
 	return abstract.PrimitiveMapKeySlice(resolution.objectIdentifierToData)
 }
 
+func (resolution *bindingPolicyResolution) getPriority() int32 {
+	resolution.RLock()
+	defer resolution.RUnlock()
+
+	return resolution.priority
+}
+
 // getOwnerReference returns the owner reference of the resolution.
 func (resolution *bindingPolicyResolution) getOwnerReference() metav1.OwnerReference {
 	resolution.RLock()
@@ -316,6 +508,7 @@ func bindingObjectRefToDataFromWorkload(bindingSpecWorkload *v1alpha1.DownsyncOb
 		}] = &ObjectData{
 			ResourceVersion: clusterScopeDownsyncClause.ResourceVersion,
 			Modulation:      DownsyncModulationFromExternal(clusterScopeDownsyncClause.DownsyncModulation),
+			Implicit:        clusterScopeDownsyncClause.Implicit,
 		}
 	}
 
@@ -329,6 +522,7 @@ func bindingObjectRefToDataFromWorkload(bindingSpecWorkload *v1alpha1.DownsyncOb
 		}] = &ObjectData{
 			ResourceVersion: namespacedScopeDownsyncClause.ResourceVersion,
 			Modulation:      DownsyncModulationFromExternal(namespacedScopeDownsyncClause.DownsyncModulation),
+			Implicit:        namespacedScopeDownsyncClause.Implicit,
 		}
 	}
 
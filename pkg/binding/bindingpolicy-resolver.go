@@ -22,8 +22,10 @@ import (
 	"sync"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/ptr"
 
 	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
 	"github.com/kubestellar/kubestellar/pkg/util"
@@ -54,7 +56,8 @@ type BindingPolicyResolver interface {
 	// - The destinations in the BindingSpec are an exact match
 	//of those in the resolution.
 	//
-	// - The same is true for every selected object.
+	// - The same is true for every selected object, with the modulation
+	// that results from precedence among bindingpolicies.
 	//
 	// It is possible to output a false negative due to a temporary state of
 	// internal caches being out of sync.
@@ -62,7 +65,8 @@ type BindingPolicyResolver interface {
 		bindingSpec *v1alpha1.BindingSpec) bool
 
 	// NoteBindingPolicy ensures that the resolver has an entry whose key
-	// is the given BindingPolicy's name.
+	// is the given BindingPolicy's name, and that the entry reflects
+	// the BindingPolicy's limit on and ranking of destinations.
 	// If an entry is introduced, it is introduced with empty destination set
 	// and no workload references.
 	// `*bindingPolicy` is immutable.
@@ -86,6 +90,30 @@ type BindingPolicyResolver interface {
 	// changed. If no resolution is associated with the given key, false is
 	// returned.
 	RemoveObjectIdentifier(bindingPolicyKey string, objIdentifier util.ObjectIdentifier) bool
+	// SetDependencies records the objects that the given selected object
+	// depends on (see `includeDependencies`), for the given bindingpolicy key.
+	// `existing` holds the UID and resource version of those dependencies that
+	// exist; they are added to the resolution as implicitly selected unless
+	// already selected explicitly. Implicitly selected objects that are no
+	// longer depended upon are removed. Neither argument is mutated or retained.
+	//
+	// The returned bool indicates whether the bindingpolicy resolution was
+	// changed. If no resolution is associated with the given key, false is
+	// returned.
+	SetDependencies(bindingPolicyKey string, objIdentifier util.ObjectIdentifier,
+		dependencies sets.Set[util.ObjectIdentifier], existing map[util.ObjectIdentifier]ObjectData) bool
+	// NoteImplicitObject is for an object that the bindingpolicy does not select.
+	// If some object selected by the bindingpolicy depends on the given one,
+	// the given one is ensured to be in the resolution as implicitly selected
+	// and the first returned bool is true.
+	// The second returned bool indicates whether the bindingpolicy resolution
+	// was changed. If no resolution is associated with the given key, both are false.
+	NoteImplicitObject(bindingPolicyKey string, objIdentifier util.ObjectIdentifier,
+		objUID, resourceVersion string) (bool, bool)
+	// IsDependency tells whether some object selected by the bindingpolicy
+	// depends on the given one.
+	// If no resolution is associated with the given key, false is returned.
+	IsDependency(bindingPolicyKey string, objIdentifier util.ObjectIdentifier) bool
 	// GetObjectIdentifiers returns the object identifiers associated with the
 	// given bindingpolicy key.
 	// If no resolution is associated with the given key, an error is returned.
@@ -93,12 +121,15 @@ type BindingPolicyResolver interface {
 
 	// SetDestinations updates the maintained bindingpolicy's
 	// destinations resolution for the given bindingpolicy key.
-	// The given destinations set is expected not to be mutated during and
-	// after this call by the caller.
+	// The given map holds the clusters that pass the BindingPolicy's
+	// cluster selection criteria, mapping cluster name to cluster labels.
+	// If the BindingPolicy limits the number of clusters then the
+	// candidates are ranked and only the top ones become destinations.
+	// The given map is not mutated or retained.
 	// If no resolution is associated with the given key, an error is returned.
 	// Must not be called concurrently with any call that can add a resolution
 	// with the same name.
-	SetDestinations(bindingPolicyKey string, destinations sets.Set[string]) error
+	SetDestinations(bindingPolicyKey string, candidates map[string]labels.Set) error
 
 	// ResolutionExists returns true if a resolution is associated with the
 	// given bindingpolicy key.
@@ -120,6 +151,20 @@ type BindingPolicyResolver interface {
 	// If the resolution doesn't exist then returns `nil`.
 	GetSingletonReportedStateRequestsForBinding(bindingPolicyKey string) []SingletonReportedStateReturnStatus
 
+	// GetModulationConflicts returns the workload objects of the given bindingpolicy
+	// whose downsync modulation is partly overridden by another bindingpolicy
+	// that takes precedence, sorted by object identifier.
+	GetModulationConflicts(bindingPolicyKey string) []ModulationConflict
+
+	// GetBindingPoliciesSharingObjects returns the names of the other bindingpolicies
+	// whose resolutions include at least one of the workload objects
+	// of the given bindingpolicy's resolution.
+	GetBindingPoliciesSharingObjects(bindingPolicyKey string) []string
+
+	// GetBindingPoliciesSelectingObject returns the names of the bindingpolicies
+	// whose resolutions include the given workload object.
+	GetBindingPoliciesSelectingObject(objId util.ObjectIdentifier) []string
+
 	// DeleteResolution deletes the resolution associated with the given key,
 	// if it exists.
 	DeleteResolution(bindingPolicyKey string)
@@ -131,11 +176,19 @@ type BindingPolicyResolver interface {
 // DownsyncModulation is a convenient internal representation of v1alpha1.DownsyncModulation
 type DownsyncModulation struct {
 	CreateOnly                 bool
+	Orphan                     bool
 	StatusCollectors           sets.Set[string]
 	WantSingletonReportedState bool
 	WantMultiWECReportedState  bool
+	// ReplicaSplit is immutable
+	ReplicaSplit        *v1alpha1.ReplicaSplit
+	IncludeDependencies bool
+	DriftPolicy         v1alpha1.DriftPolicy
 }
 
+// driftPolicyStrength orders the drift policies for combining them; the strongest wins.
+var driftPolicyStrength = map[v1alpha1.DriftPolicy]int{"": 0, v1alpha1.DriftPolicyReport: 1, v1alpha1.DriftPolicyReapply: 2}
+
 func ZeroDownsyncModulation() DownsyncModulation {
 	return DownsyncModulation{StatusCollectors: sets.New[string]()}
 }
@@ -143,33 +196,62 @@ func ZeroDownsyncModulation() DownsyncModulation {
 func DownsyncModulationFromExternal(external v1alpha1.DownsyncModulation) DownsyncModulation {
 	return DownsyncModulation{
 		CreateOnly:                 external.CreateOnly,
+		Orphan:                     external.DeletionPolicy == v1alpha1.DeletionPolicyOrphan,
 		StatusCollectors:           sets.New(external.StatusCollectors...),
 		WantSingletonReportedState: external.WantSingletonReportedState,
 		WantMultiWECReportedState:  external.WantMultiWECReportedState,
+		ReplicaSplit:               external.ReplicaSplit.DeepCopy(),
+		IncludeDependencies:        external.IncludeDependencies,
+		DriftPolicy:                external.DriftPolicy,
 	}
 }
 
 func (dm *DownsyncModulation) ToExternal() v1alpha1.DownsyncModulation {
 	return v1alpha1.DownsyncModulation{
 		CreateOnly:                 dm.CreateOnly,
+		DeletionPolicy:             dm.DeletionPolicy(),
 		StatusCollectors:           sets.List(dm.StatusCollectors),
 		WantSingletonReportedState: dm.WantSingletonReportedState,
 		WantMultiWECReportedState:  dm.WantMultiWECReportedState,
+		ReplicaSplit:               dm.ReplicaSplit.DeepCopy(),
+		IncludeDependencies:        dm.IncludeDependencies,
+		DriftPolicy:                dm.DriftPolicy,
+	}
+}
+
+// DeletionPolicy returns the external form of dm.Orphan.
+// The default, deletion, is left implicit.
+func (dm *DownsyncModulation) DeletionPolicy() v1alpha1.DeletionPolicy {
+	if dm.Orphan {
+		return v1alpha1.DeletionPolicyOrphan
 	}
+	return ""
 }
 
 func (left *DownsyncModulation) Equal(right DownsyncModulation) bool {
 	return left.CreateOnly == right.CreateOnly &&
+		left.Orphan == right.Orphan &&
 		left.WantSingletonReportedState == right.WantSingletonReportedState &&
 		left.WantMultiWECReportedState == right.WantMultiWECReportedState &&
-		left.StatusCollectors.Equal(right.StatusCollectors)
+		left.IncludeDependencies == right.IncludeDependencies &&
+		left.DriftPolicy == right.DriftPolicy &&
+		left.StatusCollectors.Equal(right.StatusCollectors) &&
+		ptr.Equal(left.ReplicaSplit, right.ReplicaSplit)
 }
 
 func (dm *DownsyncModulation) AddExternal(external v1alpha1.DownsyncModulation) {
 	dm.CreateOnly = dm.CreateOnly || external.CreateOnly
+	dm.Orphan = dm.Orphan || external.DeletionPolicy == v1alpha1.DeletionPolicyOrphan
 	dm.StatusCollectors.Insert(external.StatusCollectors...)
 	dm.WantSingletonReportedState = dm.WantSingletonReportedState || external.WantSingletonReportedState
 	dm.WantMultiWECReportedState = dm.WantMultiWECReportedState || external.WantMultiWECReportedState
+	dm.IncludeDependencies = dm.IncludeDependencies || external.IncludeDependencies
+	if driftPolicyStrength[external.DriftPolicy] > driftPolicyStrength[dm.DriftPolicy] {
+		dm.DriftPolicy = external.DriftPolicy
+	}
+	if dm.ReplicaSplit == nil {
+		dm.ReplicaSplit = external.ReplicaSplit.DeepCopy()
+	}
 }
 
 // SingletonReportedStateReturnStatus reports the resolver's state regarding
@@ -183,6 +265,7 @@ type SingletonReportedStateReturnStatus struct {
 func NewBindingPolicyResolver() BindingPolicyResolver {
 	bpResolver := &bindingPolicyResolver{
 		bindingPolicyToResolution: make(map[string]*bindingPolicyResolution),
+		objectToPolicies:          newPoliciesByObject(),
 	}
 	bpResolver.broker = newResolutionBroker(bpResolver.getResolution, bpResolver.getAllResolutionKeys)
 
@@ -198,6 +281,9 @@ type bindingPolicyResolver struct {
 	sync.RWMutex
 
 	bindingPolicyToResolution map[string]*bindingPolicyResolution
+
+	// objectToPolicies indexes the resolutions by the object identifiers in them.
+	objectToPolicies *policiesByObject
 }
 
 // GenerateBinding returns the binding for the given
@@ -212,7 +298,7 @@ func (resolver *bindingPolicyResolver) GenerateBinding(bindingPolicyKey string)
 	}
 
 	// thread-safe
-	return bindingPolicyResolution.toBindingSpec()
+	return bindingPolicyResolution.toBindingSpec(resolver.getPrevailingModulations(bindingPolicyKey))
 }
 
 // GetOwnerReference returns the owner reference for the given
@@ -248,11 +334,20 @@ func (resolver *bindingPolicyResolver) CompareBinding(bindingPolicyKey string,
 		return false
 	}
 
-	return bindingPolicyResolution.matchesBindingSpec(bindingSpec)
+	return bindingPolicyResolution.matchesBindingSpec(bindingSpec, resolver.getPrevailingModulations(bindingPolicyKey))
 }
 
 func (resolver *bindingPolicyResolver) NoteBindingPolicy(bindingpolicy *v1alpha1.BindingPolicy) {
 	if resolution := resolver.getResolution(bindingpolicy.GetName()); resolution != nil {
+		resolution.Lock()
+		defer resolution.Unlock()
+		resolution.placement = clusterPlacementFromBindingPolicy(bindingpolicy)
+		resolution.overrides = bindingpolicy.Spec.Overrides
+		resolution.rollout = bindingpolicy.Spec.Rollout
+		resolution.propagationWindows = bindingpolicy.Spec.PropagationWindows
+		resolution.upsync = bindingpolicy.Spec.Upsync
+		resolution.priority = bindingpolicy.Spec.Priority
+		resolution.suspended = bindingpolicy.Spec.Suspend
 		return
 	}
 	// Because concurrent calls with the same BindingPolicy name are not allowed,
@@ -312,6 +407,50 @@ func (resolver *bindingPolicyResolver) RemoveObjectIdentifier(bindingPolicyKey s
 	return bindingPolicyResolution.removeObjectIdentifier(objIdentifier)
 }
 
+// SetDependencies records the objects that the given selected object
+// depends on, for the given bindingpolicy key.
+// If no resolution is associated with the given key, false is returned.
+func (resolver *bindingPolicyResolver) SetDependencies(bindingPolicyKey string, objIdentifier util.ObjectIdentifier,
+	dependencies sets.Set[util.ObjectIdentifier], existing map[util.ObjectIdentifier]ObjectData) bool {
+	bindingPolicyResolution := resolver.getResolution(bindingPolicyKey) // thread-safe
+
+	if bindingPolicyResolution == nil {
+		return false
+	}
+
+	// setDependencies is thread-safe
+	return bindingPolicyResolution.setDependencies(objIdentifier, dependencies, existing)
+}
+
+// NoteImplicitObject ensures that the given object, which the bindingpolicy
+// does not select, is implicitly selected if some selected object depends on it.
+// If no resolution is associated with the given key, (false, false) is returned.
+func (resolver *bindingPolicyResolver) NoteImplicitObject(bindingPolicyKey string, objIdentifier util.ObjectIdentifier,
+	objUID, resourceVersion string) (bool, bool) {
+	bindingPolicyResolution := resolver.getResolution(bindingPolicyKey) // thread-safe
+
+	if bindingPolicyResolution == nil {
+		return false, false
+	}
+
+	// noteImplicitObject is thread-safe
+	return bindingPolicyResolution.noteImplicitObject(objIdentifier, objUID, resourceVersion)
+}
+
+// IsDependency tells whether some object selected by the bindingpolicy
+// depends on the given one.
+// If no resolution is associated with the given key, false is returned.
+func (resolver *bindingPolicyResolver) IsDependency(bindingPolicyKey string, objIdentifier util.ObjectIdentifier) bool {
+	bindingPolicyResolution := resolver.getResolution(bindingPolicyKey) // thread-safe
+
+	if bindingPolicyResolution == nil {
+		return false
+	}
+
+	// isDependency is thread-safe
+	return bindingPolicyResolution.isDependency(objIdentifier)
+}
+
 // GetObjectIdentifiers returns a copy of the object identifiers associated
 // with the given bindingpolicy key.
 // If no resolution is associated with the given key, an error is returned.
@@ -331,7 +470,7 @@ func (resolver *bindingPolicyResolver) GetObjectIdentifiers(bindingPolicyKey str
 }
 
 func (resolver *bindingPolicyResolver) SetDestinations(bindingPolicyKey string,
-	destinations sets.Set[string]) error {
+	candidates map[string]labels.Set) error {
 	bindingPolicyResolution := resolver.getResolution(bindingPolicyKey) // thread-safe
 	// Now the resolver's mutex is not held, so the resolution just fetched could be removed.
 	// The prohibition against calling concurrently with methods that add a resolution ensures
@@ -344,7 +483,7 @@ func (resolver *bindingPolicyResolver) SetDestinations(bindingPolicyKey string,
 	bindingPolicyResolution.Lock()
 	defer bindingPolicyResolution.Unlock()
 
-	bindingPolicyResolution.destinations = destinations
+	bindingPolicyResolution.destinations = bindingPolicyResolution.placement.selectClusters(candidates, bindingPolicyResolution.destinations)
 	return nil
 }
 
@@ -409,6 +548,11 @@ func (resolver *bindingPolicyResolver) DeleteResolution(bindingPolicyKey string)
 	resolver.Lock() // lock for modifying map
 	defer resolver.Unlock()
 
+	if resolution := resolver.bindingPolicyToResolution[bindingPolicyKey]; resolution != nil {
+		for _, objId := range resolution.getWorkloadReferences() {
+			resolver.objectToPolicies.note(objId, bindingPolicyKey, false)
+		}
+	}
 	delete(resolver.bindingPolicyToResolution, bindingPolicyKey)
 	resolver.broker.NotifyBindingPolicyCallbacks(bindingPolicyKey)
 }
@@ -451,15 +595,30 @@ func (resolver *bindingPolicyResolver) createResolution(bindingpolicy *v1alpha1.
 		return bindingPolicyResolution
 	}
 
-	ownerReference := metav1.NewControllerRef(bindingpolicy, v1alpha1.SchemeGroupVersion.WithKind(util.BindingPolicyKind))
+	kind := util.BindingPolicyKind
+	if bindingpolicy.Namespace != "" {
+		kind = util.NamespacedBindingPolicyKind
+	}
+	ownerReference := metav1.NewControllerRef(bindingpolicy, v1alpha1.SchemeGroupVersion.WithKind(kind))
 	ownerReference.BlockOwnerDeletion = &[]bool{false}[0]
 
 	bindingPolicyResolution := &bindingPolicyResolution{
 		reportedStateRequestChangeConsumer: func(objId util.ObjectIdentifier) {
 			resolver.broker.NotifyReportedStateRequestCallbacks(bindingpolicy.Name, objId)
 		},
+		membershipChangeConsumer: func(objId util.ObjectIdentifier, present bool) {
+			resolver.objectToPolicies.note(objId, bindingpolicy.Name, present)
+		},
 		objectIdentifierToData: make(map[util.ObjectIdentifier]*ObjectData),
+		dependencies:           make(map[util.ObjectIdentifier]sets.Set[util.ObjectIdentifier]),
 		destinations:           sets.New[string](),
+		placement:              clusterPlacementFromBindingPolicy(bindingpolicy),
+		overrides:              bindingpolicy.Spec.Overrides,
+		rollout:                bindingpolicy.Spec.Rollout,
+		propagationWindows:     bindingpolicy.Spec.PropagationWindows,
+		upsync:                 bindingpolicy.Spec.Upsync,
+		priority:               bindingpolicy.Spec.Priority,
+		suspended:              bindingpolicy.Spec.Suspend,
 		ownerReference:         ownerReference,
 	}
 	klog.InfoS("Created bindingPolicyResolution", "binding", bindingpolicy.Name, "resolution", fmt.Sprintf("%p", bindingPolicyResolution))
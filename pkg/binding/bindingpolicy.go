@@ -19,14 +19,17 @@ package binding
 import (
 	"context"
 	"fmt"
+	"reflect"
 
 	"github.com/go-logr/logr"
+	managedclusterapi "open-cluster-management.io/api/cluster/v1"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -52,7 +55,7 @@ const (
 func (c *Controller) syncBindingPolicy(ctx context.Context, bindingPolicyName string) error {
 	logger := klog.FromContext(ctx)
 
-	bindingPolicy, err := c.bindingPolicyLister.Get(bindingPolicyName)
+	bindingPolicy, err := c.getBindingPolicy(bindingPolicyName)
 	// `*bindingPolicy` is immutable
 	if errors.IsNotFound(err) {
 		// binding policy is deleted, update resolver.
@@ -76,17 +79,55 @@ func (c *Controller) syncBindingPolicy(ctx context.Context, bindingPolicyName st
 		if err != nil {
 			return fmt.Errorf("failed to ocm.FindClustersBySelectors: %w", err)
 		}
-		if len(clusterSet) == 0 {
+		exprClusterSet, err := ocm.FindClustersByExpressions(ctx, c.managedClusterClient, c.clusterSelectionEvaluator, bindingPolicy.Spec.ClusterSelectorExpressions)
+		if err != nil {
+			return fmt.Errorf("failed to ocm.FindClustersByExpressions: %w", err)
+		}
+		clusterSet = clusterSet.Union(exprClusterSet)
+
+		// the labels of the candidates are needed for applying the exclusions
+		// and for ranking them when the number of clusters is limited
+		candidates := make(map[string]labels.Set, len(clusterSet))
+		for clusterName := range clusterSet {
+			var clusterLabels labels.Set
+			cluster, err := c.clusterLister.Get(clusterName)
+			if err == nil {
+				clusterLabels = cluster.Labels
+			} else if !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to get ManagedCluster from informer cache (name=%v): %w", clusterName, err)
+			} // otherwise the informer has not caught up yet; treat the cluster as if it has no labels
+			excluded, err := util.SelectorsMatchLabels(bindingPolicy.Spec.ExcludeClusterSelectors, clusterLabels)
+			if err != nil {
+				return fmt.Errorf("failed to apply excludeClusterSelectors: %w", err)
+			}
+			if excluded {
+				continue
+			}
+			granted, err := c.clusterIsGranted(bindingPolicy, clusterLabels)
+			if err != nil {
+				return err
+			}
+			if granted {
+				candidates[clusterName] = clusterLabels
+			}
+		}
+		if len(candidates) == 0 {
 			logger.V(4).Info("No clusters are selected by BindingPolicy", "name", bindingPolicy.Name)
 		}
 
 		// set destinations and enqueue binding for syncing
 		// we can skip handling the error since the call to BindingPolicyResolver::NoteBindingPolicy above
 		// guarantees that an error won't be returned here
-		_ = c.bindingPolicyResolver.SetDestinations(bindingPolicy.GetName(), clusterSet)
+		_ = c.bindingPolicyResolver.SetDestinations(bindingPolicy.GetName(), candidates)
 		logger.V(5).Info("Enqueued Binding for syncing, while handling BindingPolicy", "name", bindingPolicy.Name)
 		c.enqueueBinding(bindingPolicy.GetName())
 
+		// a change in priority can change which modulations prevail in other Bindings
+		for _, otherName := range c.bindingPolicyResolver.GetBindingPoliciesSharingObjects(bindingPolicy.GetName()) {
+			logger.V(5).Info("Enqueued Binding for syncing, because it shares workload objects with BindingPolicy", "binding", otherName, "bindingPolicy", bindingPolicy.Name)
+			c.enqueueBinding(otherName)
+		}
+
 		// requeue all objects to account for changes in bindingpolicy.
 		// this does not include bindingpolicy/binding objects.
 		return c.requeueWorkloadObjects(ctx, bindingPolicy.Name)
@@ -102,13 +143,24 @@ func (c *Controller) syncBindingPolicy(ctx context.Context, bindingPolicyName st
 
 func (c *Controller) deleteResolutionForBindingPolicy(ctx context.Context, bindingPolicyName string) error {
 	logger := klog.FromContext(ctx)
+	sharers := c.bindingPolicyResolver.GetBindingPoliciesSharingObjects(bindingPolicyName)
 	c.bindingPolicyResolver.DeleteResolution(bindingPolicyName)
+	c.forgetAccessDenials(bindingPolicyName)
 	logger.V(2).Info("Deleted resolution for bindingpolicy", "name", bindingPolicyName)
+	if err := c.deleteNamespacedPolicyBinding(ctx, bindingPolicyName); err != nil {
+		return err
+	}
+	// the deleted BindingPolicy may have prevailed for some workload objects
+	for _, otherName := range sharers {
+		c.enqueueBinding(otherName)
+	}
 	return nil
 }
 
-func (c *Controller) evaluateBindingPoliciesForUpdate(ctx context.Context, clusterId string, oldLabels labels.Set, newLabels labels.Set) {
+func (c *Controller) evaluateBindingPoliciesForUpdate(ctx context.Context, oldCluster, newCluster *managedclusterapi.ManagedCluster) {
 	logger := klog.FromContext(ctx)
+	clusterId := newCluster.Name
+	labelsChanged := !reflect.DeepEqual(oldCluster.Labels, newCluster.Labels)
 
 	logger.V(5).Info("Evaluating BindingPolicies for cluster", "clusterId", clusterId)
 	bindingPolicies, err := c.listBindingPolicies()
@@ -117,25 +169,32 @@ func (c *Controller) evaluateBindingPoliciesForUpdate(ctx context.Context, clust
 		return
 	}
 	for _, bindingPolicy := range bindingPolicies {
-		match1, err := util.SelectorsMatchLabels(bindingPolicy.Spec.ClusterSelectors, oldLabels)
+		// a cluster change that does not involve labels can only matter through expressions
+		if !labelsChanged && len(bindingPolicy.Spec.ClusterSelectorExpressions) == 0 {
+			continue
+		}
+		match1, err := c.clusterMatchesBindingPolicy(logger, bindingPolicy, oldCluster)
 		if err != nil {
 			utilruntime.HandleError(err)
 			return
 		}
-		match2, err := util.SelectorsMatchLabels(bindingPolicy.Spec.ClusterSelectors, newLabels)
+		match2, err := c.clusterMatchesBindingPolicy(logger, bindingPolicy, newCluster)
 		if err != nil {
 			utilruntime.HandleError(err)
 			return
 		}
-		if match1 != match2 {
-			logger.V(5).Info("Enqueuing reference to bindingPolicy because of changing match with cluster", "clusterId", clusterId, "bindingPolicyName", bindingPolicy.Name, "oldMatch", match1, "newMatch", match2, "oldLabels", oldLabels, "newLabels", newLabels)
+		// when the number of clusters is limited, a label change on a candidate can change the ranking
+		rankingMayChange := labelsChanged && bindingPolicy.Spec.NumberOfClusters != nil && (match1 || match2)
+		if match1 != match2 || rankingMayChange {
+			logger.V(5).Info("Enqueuing reference to bindingPolicy because of changing match with cluster", "clusterId", clusterId, "bindingPolicyName", bindingPolicy.Name, "oldMatch", match1, "newMatch", match2, "rankingMayChange", rankingMayChange, "oldLabels", oldCluster.Labels, "newLabels", newCluster.Labels)
 			c.workqueue.Add(bindingPolicyRef(bindingPolicy.Name))
 		}
 	}
 }
 
-func (c *Controller) evaluateBindingPolicies(ctx context.Context, clusterId string, labelsSet labels.Set) {
+func (c *Controller) evaluateBindingPolicies(ctx context.Context, cluster *managedclusterapi.ManagedCluster) {
 	logger := klog.FromContext(ctx)
+	clusterId := cluster.Name
 
 	logger.V(5).Info("evaluating BindingPolicies", "clusterId", clusterId)
 	bindingPolicies, err := c.listBindingPolicies()
@@ -144,7 +203,7 @@ func (c *Controller) evaluateBindingPolicies(ctx context.Context, clusterId stri
 		return
 	}
 	for _, bindingPolicy := range bindingPolicies {
-		match, err := util.SelectorsMatchLabels(bindingPolicy.Spec.ClusterSelectors, labelsSet)
+		match, err := c.clusterMatchesBindingPolicy(logger, bindingPolicy, cluster)
 		if err != nil {
 			utilruntime.HandleError(err)
 			return
@@ -156,14 +215,50 @@ func (c *Controller) evaluateBindingPolicies(ctx context.Context, clusterId stri
 	}
 }
 
-// Returns all the BindingPolicy objects in the informer's local cache.
+// clusterMatchesBindingPolicy tests whether the given cluster passes any of the
+// `clusterSelectors` or `clusterSelectorExpressions` of the given BindingPolicy
+// and none of its `excludeClusterSelectors`.
+// Errors in evaluating expressions are logged rather than returned,
+// since they only mean that the expression does not select the cluster.
+func (c *Controller) clusterMatchesBindingPolicy(logger logr.Logger, bindingPolicy *v1alpha1.BindingPolicy, cluster *managedclusterapi.ManagedCluster) (bool, error) {
+	match, err := util.SelectorsMatchLabels(bindingPolicy.Spec.ClusterSelectors, cluster.Labels)
+	if err != nil {
+		return false, err
+	}
+	if !match {
+		match, err = ocm.ClusterMatchesExpressions(c.clusterSelectionEvaluator, cluster, bindingPolicy.Spec.ClusterSelectorExpressions)
+		if err != nil {
+			logger.V(4).Info("Error in evaluating cluster selector expression", "bindingPolicyName", bindingPolicy.Name, "clusterId", cluster.Name, "err", err)
+		}
+	}
+	if !match {
+		return false, nil
+	}
+	// exclusions are applied after the inclusions
+	excluded, err := util.SelectorsMatchLabels(bindingPolicy.Spec.ExcludeClusterSelectors, cluster.Labels)
+	if err != nil || excluded {
+		return false, err
+	}
+	return c.clusterIsGranted(bindingPolicy, cluster.Labels)
+}
+
+// Returns all the BindingPolicy objects in the informer's local cache,
+// followed by the effective BindingPolicy of each NamespacedBindingPolicy.
 // These are immutable.
 func (c *Controller) listBindingPolicies() ([]*v1alpha1.BindingPolicy, error) {
 	list, err := c.bindingPolicyLister.List(labels.Everything())
 	if err != nil {
 		return nil, err
 	}
-	return list, nil
+	names := sets.New[string]()
+	for _, bindingPolicy := range list {
+		names.Insert(bindingPolicy.Name)
+	}
+	namespaced, err := c.listNamespacedBindingPolicies(names)
+	if err != nil {
+		return nil, err
+	}
+	return append(list, namespaced...), nil
 }
 
 // read objects from all workload listers and enqueue
@@ -202,7 +297,7 @@ func (c *Controller) handleBindingPolicyFinalizer(ctx context.Context, bindingPo
 		if controllerutil.ContainsFinalizer(bindingPolicy, KSFinalizer) {
 			bindingPolicy = bindingPolicy.DeepCopy()
 			controllerutil.RemoveFinalizer(bindingPolicy, KSFinalizer)
-			_, err := c.bindingPolicyClient.Update(ctx, bindingPolicy, metav1.UpdateOptions{FieldManager: ControllerName})
+			err := c.updateBindingPolicyFinalizers(ctx, bindingPolicy)
 			if err != nil {
 				if errors.IsNotFound(err) {
 					// object was deleted after getting into this function. This is not an error.
@@ -218,7 +313,7 @@ func (c *Controller) handleBindingPolicyFinalizer(ctx context.Context, bindingPo
 	if !controllerutil.ContainsFinalizer(bindingPolicy, KSFinalizer) {
 		bindingPolicy = bindingPolicy.DeepCopy()
 		controllerutil.AddFinalizer(bindingPolicy, KSFinalizer)
-		_, err := c.bindingPolicyClient.Update(ctx, bindingPolicy, metav1.UpdateOptions{FieldManager: ControllerName})
+		err := c.updateBindingPolicyFinalizers(ctx, bindingPolicy)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,208 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+// podSpecPaths maps the well-known workload kinds to the path of their pod spec.
+var podSpecPaths = map[schema.GroupKind][]string{
+	{Group: "", Kind: "Pod"}:                   {"spec"},
+	{Group: "", Kind: "ReplicationController"}: {"spec", "template", "spec"},
+	{Group: "apps", Kind: "Deployment"}:        {"spec", "template", "spec"},
+	{Group: "apps", Kind: "StatefulSet"}:       {"spec", "template", "spec"},
+	{Group: "apps", Kind: "DaemonSet"}:         {"spec", "template", "spec"},
+	{Group: "apps", Kind: "ReplicaSet"}:        {"spec", "template", "spec"},
+	{Group: "batch", Kind: "Job"}:              {"spec", "template", "spec"},
+	{Group: "batch", Kind: "CronJob"}:          {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// dependencyKind identifies a kind of object that a pod spec can reference.
+type dependencyKind struct {
+	kind     string
+	resource string
+}
+
+var (
+	configMapKind      = dependencyKind{kind: "ConfigMap", resource: "configmaps"}
+	secretKind         = dependencyKind{kind: "Secret", resource: "secrets"}
+	serviceAccountKind = dependencyKind{kind: "ServiceAccount", resource: "serviceaccounts"}
+	pvcKind            = dependencyKind{kind: "PersistentVolumeClaim", resource: "persistentvolumeclaims"}
+)
+
+// podSpecDependencies returns the identifiers of the ConfigMaps, Secrets,
+// ServiceAccount, and PersistentVolumeClaims that the pod spec of the given
+// workload object references. The answer is empty if the object is not of
+// a well-known workload kind. The default ServiceAccount is not included.
+func podSpecDependencies(objIdentifier util.ObjectIdentifier, obj mrObject) (sets.Set[util.ObjectIdentifier], error) {
+	ans := sets.New[util.ObjectIdentifier]()
+	path, known := podSpecPaths[objIdentifier.GVK.GroupKind()]
+	if !known {
+		return ans, nil
+	}
+	var content map[string]interface{}
+	if objU, is := obj.(*unstructured.Unstructured); is {
+		content = objU.Object
+	} else {
+		var err error
+		content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert object to unstructured: %w", err)
+		}
+	}
+	podSpecU, found, err := unstructured.NestedMap(content, path...)
+	if err != nil || !found {
+		return ans, err
+	}
+	var podSpec corev1.PodSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(podSpecU, &podSpec); err != nil {
+		return nil, fmt.Errorf("failed to parse pod spec: %w", err)
+	}
+	namespace := objIdentifier.ObjectName.Namespace
+	add := func(depKind dependencyKind, name string) {
+		if name == "" {
+			return
+		}
+		ans.Insert(util.ObjectIdentifier{
+			GVK:        corev1.SchemeGroupVersion.WithKind(depKind.kind),
+			Resource:   depKind.resource,
+			ObjectName: cache.NewObjectName(namespace, name),
+		})
+	}
+	if podSpec.ServiceAccountName != "default" {
+		add(serviceAccountKind, podSpec.ServiceAccountName)
+	}
+	for _, ref := range podSpec.ImagePullSecrets {
+		add(secretKind, ref.Name)
+	}
+	for _, volume := range podSpec.Volumes {
+		switch {
+		case volume.ConfigMap != nil:
+			add(configMapKind, volume.ConfigMap.Name)
+		case volume.Secret != nil:
+			add(secretKind, volume.Secret.SecretName)
+		case volume.PersistentVolumeClaim != nil:
+			add(pvcKind, volume.PersistentVolumeClaim.ClaimName)
+		case volume.Projected != nil:
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					add(configMapKind, source.ConfigMap.Name)
+				}
+				if source.Secret != nil {
+					add(secretKind, source.Secret.Name)
+				}
+			}
+		}
+	}
+	addContainer := func(envFrom []corev1.EnvFromSource, env []corev1.EnvVar) {
+		for _, source := range envFrom {
+			if source.ConfigMapRef != nil {
+				add(configMapKind, source.ConfigMapRef.Name)
+			}
+			if source.SecretRef != nil {
+				add(secretKind, source.SecretRef.Name)
+			}
+		}
+		for _, envVar := range env {
+			if envVar.ValueFrom == nil {
+				continue
+			}
+			if ref := envVar.ValueFrom.ConfigMapKeyRef; ref != nil {
+				add(configMapKind, ref.Name)
+			}
+			if ref := envVar.ValueFrom.SecretKeyRef; ref != nil {
+				add(secretKind, ref.Name)
+			}
+		}
+	}
+	for _, container := range podSpec.InitContainers {
+		addContainer(container.EnvFrom, container.Env)
+	}
+	for _, container := range podSpec.Containers {
+		addContainer(container.EnvFrom, container.Env)
+	}
+	for _, container := range podSpec.EphemeralContainers {
+		addContainer(container.EnvFrom, container.Env)
+	}
+	return ans, nil
+}
+
+// noteDependencies updates the given BindingPolicy's record of the objects that
+// the given selected workload object depends on, and the implicit selection
+// of those that exist and that the policy's creator and last modifier may get.
+// The returned bool indicates whether the resolution changed.
+func (c *Controller) noteDependencies(ctx context.Context, bindingPolicy *v1alpha1.BindingPolicy, objIdentifier util.ObjectIdentifier,
+	obj mrObject, includeDependencies bool) (bool, error) {
+	logger := klog.FromContext(ctx)
+	bindingPolicyName := bindingPolicy.GetName()
+	var dependencies sets.Set[util.ObjectIdentifier]
+	existing := map[util.ObjectIdentifier]ObjectData{}
+	denied := []util.ObjectIdentifier{}
+	if includeDependencies {
+		var err error
+		dependencies, err = podSpecDependencies(objIdentifier, obj)
+		if err != nil {
+			logger.V(3).Info("Failed to find dependencies of workload object, ignoring them",
+				"objectIdentifier", objIdentifier, "bindingPolicy", bindingPolicyName, "err", err)
+		}
+		for depId := range dependencies {
+			dep, err := c.getObjectFromIdentifier(depId)
+			if errors.IsNotFound(err) {
+				continue // it will be noted when it appears
+			} else if err != nil {
+				logger.V(3).Info("Failed to get dependency of workload object", "objectIdentifier", objIdentifier,
+					"dependency", depId, "err", err)
+				continue
+			}
+			allowed, err := c.usersMayGet(ctx, bindingPolicy, depId)
+			if err != nil {
+				return false, err
+			}
+			if c.noteAccess(bindingPolicyName, depId, allowed) {
+				c.enqueueBinding(bindingPolicyName)
+			}
+			if !allowed {
+				denied = append(denied, depId)
+				continue
+			}
+			depMR := dep.(mrObject)
+			existing[depId] = ObjectData{UID: string(depMR.GetUID()), ResourceVersion: depMR.GetResourceVersion()}
+		}
+	}
+	changed := c.bindingPolicyResolver.SetDependencies(bindingPolicyName, objIdentifier, dependencies, existing)
+	for _, depId := range denied {
+		// the dependency may have been implicitly selected before access to it was lost
+		if c.bindingPolicyResolver.RemoveObjectIdentifier(bindingPolicyName, depId) {
+			changed = true
+		}
+	}
+	return changed, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+func TestPodSpecDependencies(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "demo", Name: "web"},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			ServiceAccountName: "web",
+			Volumes: []corev1.Volume{
+				{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "web-config"}}}},
+				{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: "web-data"}}},
+			},
+			Containers: []corev1.Container{{
+				Name: "web",
+				EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "web-creds"}}}},
+				Env: []corev1.EnvVar{{Name: "MODE", ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "web-config"}, Key: "mode"}}}},
+			}},
+		}}},
+	}
+	objId := util.IdentifierForObject(deployment, "deployments")
+	actual, err := podSpecDependencies(objId, deployment)
+	if err != nil {
+		t.Fatalf("Failed to find dependencies: %s", err)
+	}
+	depId := func(kind, resource, name string) util.ObjectIdentifier {
+		return util.ObjectIdentifier{GVK: corev1.SchemeGroupVersion.WithKind(kind), Resource: resource,
+			ObjectName: cache.NewObjectName("demo", name)}
+	}
+	expected := sets.New(
+		depId("ServiceAccount", "serviceaccounts", "web"),
+		depId("ConfigMap", "configmaps", "web-config"),
+		depId("PersistentVolumeClaim", "persistentvolumeclaims", "web-data"),
+		depId("Secret", "secrets", "web-creds"),
+	)
+	if !actual.Equal(expected) {
+		t.Errorf("Expected %v, got %v", expected.UnsortedList(), actual.UnsortedList())
+	}
+
+	configMap := &corev1.ConfigMap{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}}
+	if deps, err := podSpecDependencies(util.IdentifierForObject(configMap, "configmaps"), configMap); err != nil || deps.Len() != 0 {
+		t.Errorf("Expected no dependencies for a ConfigMap, got %v, %v", deps, err)
+	}
+}
+
+func TestImplicitSelection(t *testing.T) {
+	resolver := NewBindingPolicyResolver()
+	resolver.NoteBindingPolicy(&v1alpha1.BindingPolicy{ObjectMeta: metav1.ObjectMeta{Name: "bp", UID: types.UID("uid-bp")}})
+	workloadId := util.ObjectIdentifier{GVK: appsv1.SchemeGroupVersion.WithKind("Deployment"), Resource: "deployments",
+		ObjectName: cache.NewObjectName("demo", "web")}
+	configId := util.ObjectIdentifier{GVK: corev1.SchemeGroupVersion.WithKind("ConfigMap"), Resource: "configmaps",
+		ObjectName: cache.NewObjectName("demo", "web-config")}
+	mod := ZeroDownsyncModulation()
+	mod.IncludeDependencies = true
+	if _, err := resolver.EnsureObjectData("bp", workloadId, "u1", "1", mod); err != nil {
+		t.Fatalf("EnsureObjectData: %s", err)
+	}
+	if !resolver.SetDependencies("bp", workloadId, sets.New(configId), map[util.ObjectIdentifier]ObjectData{configId: {UID: "u2", ResourceVersion: "5"}}) {
+		t.Errorf("Expected SetDependencies to change the resolution")
+	}
+	spec := resolver.GenerateBinding("bp")
+	if len(spec.Workload.NamespaceScope) != 2 || !spec.Workload.NamespaceScope[0].Implicit || spec.Workload.NamespaceScope[1].Implicit {
+		t.Fatalf("Expected implicit ConfigMap and explicit Deployment, got %#v", spec.Workload.NamespaceScope)
+	}
+	if !resolver.CompareBinding("bp", spec) {
+		t.Errorf("Generated Binding does not compare equal to itself")
+	}
+
+	// the ConfigMap does not match the BindingPolicy itself, but stays while it is depended upon
+	if required, changed := resolver.NoteImplicitObject("bp", configId, "u2", "6"); !required || !changed {
+		t.Errorf("Expected required and changed, got %v and %v", required, changed)
+	}
+	if required, changed := resolver.NoteImplicitObject("bp", configId, "u2", "6"); !required || changed {
+		t.Errorf("Expected required and unchanged, got %v and %v", required, changed)
+	}
+
+	// removing the Deployment removes its implicitly selected dependencies
+	if !resolver.RemoveObjectIdentifier("bp", workloadId) {
+		t.Errorf("Expected RemoveObjectIdentifier to change the resolution")
+	}
+	if objIds, _ := resolver.GetObjectIdentifiers("bp"); objIds.Len() != 0 {
+		t.Errorf("Expected empty resolution, got %v", objIds.UnsortedList())
+	}
+	if required, _ := resolver.NoteImplicitObject("bp", configId, "u2", "6"); required {
+		t.Errorf("Expected ConfigMap to no longer be required")
+	}
+}
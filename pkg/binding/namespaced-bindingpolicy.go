@@ -0,0 +1,298 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+// The binding controller handles a NamespacedBindingPolicy through its "effective"
+// BindingPolicy, which has the same spec except that the clauses are restricted to
+// the policy's namespace. The name of the effective BindingPolicy is the key under which
+// the policy is resolved, which is also the name of its Binding; the namespace of the
+// effective BindingPolicy is the namespace of the NamespacedBindingPolicy, which
+// distinguishes it from a real BindingPolicy.
+
+// namespacedBindingPolicyKey returns the key, and Binding name, for the
+// NamespacedBindingPolicy with the given namespace and name.
+// Namespace names can not contain dots, so the key determines the namespace.
+func namespacedBindingPolicyKey(namespace, name string) string {
+	return namespace + "." + name
+}
+
+// namespacedBindingPolicyName returns the name of the NamespacedBindingPolicy
+// that the given effective BindingPolicy is for.
+func namespacedBindingPolicyName(effective *v1alpha1.BindingPolicy) string {
+	return strings.TrimPrefix(effective.Name, effective.Namespace+".")
+}
+
+// effectiveBindingPolicy returns the BindingPolicy that the binding controller
+// handles in place of the given NamespacedBindingPolicy.
+func effectiveBindingPolicy(nbp *v1alpha1.NamespacedBindingPolicy) *v1alpha1.BindingPolicy {
+	effective := &v1alpha1.BindingPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.GroupVersion.String(), Kind: util.NamespacedBindingPolicyKind},
+		ObjectMeta: *nbp.ObjectMeta.DeepCopy(),
+		Spec:       *nbp.Spec.DeepCopy(),
+		Status:     *nbp.Status.DeepCopy(),
+	}
+	effective.Name = namespacedBindingPolicyKey(nbp.Namespace, nbp.Name)
+	effective.Spec.Downsync = restrictDownsyncToNamespace(effective.Spec.Downsync, nbp.Namespace)
+	effective.Spec.Upsync = restrictUpsyncToNamespace(effective.Spec.Upsync, nbp.Namespace)
+	return effective
+}
+
+// restrictDownsyncToNamespace returns the given clauses with each one restricted to
+// selecting objects in the given namespace, omitting the ones that can not.
+func restrictDownsyncToNamespace(clauses []v1alpha1.DownsyncPolicyClause, namespace string) []v1alpha1.DownsyncPolicyClause {
+	var ans []v1alpha1.DownsyncPolicyClause
+	for _, clause := range clauses {
+		if !namespaceListAllows(clause.Namespaces, namespace) {
+			continue
+		}
+		clause.Namespaces = []string{namespace}
+		ans = append(ans, clause)
+	}
+	return ans
+}
+
+// restrictUpsyncToNamespace returns the given clauses with each one restricted to
+// copying objects from the given namespace, omitting the ones that can not
+// or that would put the copies in other namespaces.
+func restrictUpsyncToNamespace(clauses []v1alpha1.UpsyncPolicyClause, namespace string) []v1alpha1.UpsyncPolicyClause {
+	var ans []v1alpha1.UpsyncPolicyClause
+	for _, clause := range clauses {
+		if clause.Placement != v1alpha1.UpsyncPlacementClusterNamePrefix || !namespaceListAllows(clause.Namespaces, namespace) {
+			continue
+		}
+		clause.Namespaces = []string{namespace}
+		ans = append(ans, clause)
+	}
+	return ans
+}
+
+func namespaceListAllows(namespaces []string, namespace string) bool {
+	return len(namespaces) == 0 || slices.Contains(namespaces, "*") || slices.Contains(namespaces, namespace)
+}
+
+// getBindingPolicy returns, from the informer caches, the BindingPolicy with the given key,
+// or else the effective BindingPolicy of the NamespacedBindingPolicy with that key.
+// The returned object is immutable.
+func (c *Controller) getBindingPolicy(key string) (*v1alpha1.BindingPolicy, error) {
+	bindingPolicy, err := c.bindingPolicyLister.Get(key)
+	if !errors.IsNotFound(err) {
+		return bindingPolicy, err
+	}
+	namespace, name, found := strings.Cut(key, ".")
+	if !found {
+		return nil, err
+	}
+	nbp, err := c.namespacedBindingPolicyLister.NamespacedBindingPolicies(namespace).Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return effectiveBindingPolicy(nbp), nil
+}
+
+// listNamespacedBindingPolicies returns the effective BindingPolicy of each
+// NamespacedBindingPolicy whose key is not the name of a BindingPolicy.
+// The given set holds the names of the BindingPolicies.
+func (c *Controller) listNamespacedBindingPolicies(bindingPolicyNames sets.Set[string]) ([]*v1alpha1.BindingPolicy, error) {
+	nbps, err := c.namespacedBindingPolicyLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	ans := make([]*v1alpha1.BindingPolicy, 0, len(nbps))
+	for _, nbp := range nbps {
+		if bindingPolicyNames.Has(namespacedBindingPolicyKey(nbp.Namespace, nbp.Name)) {
+			continue
+		}
+		ans = append(ans, effectiveBindingPolicy(nbp))
+	}
+	return ans, nil
+}
+
+// clusterIsGranted tests whether the given policy may select a cluster with the given labels.
+// A BindingPolicy may select any cluster; the effective BindingPolicy of a
+// NamespacedBindingPolicy may only select a cluster that some ClusterGrant grants
+// to the policy's namespace.
+func (c *Controller) clusterIsGranted(bindingPolicy *v1alpha1.BindingPolicy, clusterLabels labels.Set) (bool, error) {
+	if bindingPolicy.Namespace == "" {
+		return true, nil
+	}
+	grants, err := c.clusterGrantLister.List(labels.Everything())
+	if err != nil {
+		return false, fmt.Errorf("failed to list ClusterGrants: %w", err)
+	}
+	for _, grant := range grants {
+		if !slices.Contains(grant.Spec.Namespaces, bindingPolicy.Namespace) {
+			continue
+		}
+		granted, err := util.SelectorsMatchLabels(grant.Spec.ClusterSelectors, clusterLabels)
+		if err != nil {
+			return false, fmt.Errorf("failed to apply clusterSelectors of ClusterGrant %s: %w", grant.Name, err)
+		}
+		if granted {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// updateBindingPolicyFinalizers writes the finalizers of the given BindingPolicy,
+// which may be the effective BindingPolicy of a NamespacedBindingPolicy.
+func (c *Controller) updateBindingPolicyFinalizers(ctx context.Context, bindingPolicy *v1alpha1.BindingPolicy) error {
+	if bindingPolicy.Namespace == "" {
+		_, err := c.bindingPolicyClient.Update(ctx, bindingPolicy, metav1.UpdateOptions{FieldManager: ControllerName})
+		return err
+	}
+	nbp, err := c.namespacedBindingPolicyLister.NamespacedBindingPolicies(bindingPolicy.Namespace).Get(namespacedBindingPolicyName(bindingPolicy))
+	if err != nil {
+		return err
+	}
+	nbp = nbp.DeepCopy()
+	nbp.ResourceVersion = bindingPolicy.ResourceVersion
+	nbp.Finalizers = bindingPolicy.Finalizers
+	_, err = c.namespacedBindingPolicyClient.Namespace(nbp.Namespace).Update(ctx, nbp, metav1.UpdateOptions{FieldManager: ControllerName})
+	return err
+}
+
+// updateBindingPolicyStatus writes the status of the given BindingPolicy,
+// which may be the effective BindingPolicy of a NamespacedBindingPolicy.
+// Returns the resulting resourceVersion.
+func (c *Controller) updateBindingPolicyStatus(ctx context.Context, bindingPolicy *v1alpha1.BindingPolicy) (string, error) {
+	if bindingPolicy.Namespace == "" {
+		echo, err := c.bindingPolicyClient.UpdateStatus(ctx, bindingPolicy, metav1.UpdateOptions{FieldManager: ControllerName})
+		if err != nil {
+			return "", err
+		}
+		return echo.ResourceVersion, nil
+	}
+	nbp, err := c.namespacedBindingPolicyLister.NamespacedBindingPolicies(bindingPolicy.Namespace).Get(namespacedBindingPolicyName(bindingPolicy))
+	if err != nil {
+		return "", err
+	}
+	nbp = nbp.DeepCopy()
+	nbp.ResourceVersion = bindingPolicy.ResourceVersion
+	nbp.Status = bindingPolicy.Status
+	echo, err := c.namespacedBindingPolicyClient.Namespace(nbp.Namespace).UpdateStatus(ctx, nbp, metav1.UpdateOptions{FieldManager: ControllerName})
+	if err != nil {
+		return "", err
+	}
+	return echo.ResourceVersion, nil
+}
+
+// deleteNamespacedPolicyBinding deletes the Binding with the given name if it is
+// for a NamespacedBindingPolicy, since garbage collection does not do that.
+func (c *Controller) deleteNamespacedPolicyBinding(ctx context.Context, bindingName string) error {
+	binding, err := c.bindingLister.Get(bindingName)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get Binding from informer cache (name=%v): %w", bindingName, err)
+	}
+	if _, has := binding.Labels[v1alpha1.PolicyNamespaceLabelKey]; !has {
+		return nil
+	}
+	uid := binding.UID
+	err = c.bindingClient.Delete(ctx, bindingName, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}})
+	if err != nil && !errors.IsNotFound(err) && !errors.IsConflict(err) {
+		return fmt.Errorf("failed to delete Binding of NamespacedBindingPolicy (name=%v): %w", bindingName, err)
+	}
+	klog.FromContext(ctx).V(2).Info("Deleted Binding of NamespacedBindingPolicy", "name", bindingName)
+	return nil
+}
+
+func (c *Controller) setupNamespacedBindingPolicyInformer(ctx context.Context) error {
+	logger := klog.FromContext(ctx)
+	enqueue := func(obj any, event string) {
+		if typed, is := obj.(cache.DeletedFinalStateUnknown); is {
+			obj = typed.Obj
+		}
+		nbp := obj.(*v1alpha1.NamespacedBindingPolicy)
+		key := namespacedBindingPolicyKey(nbp.Namespace, nbp.Name)
+		logger.V(5).Info("Enqueuing reference to NamespacedBindingPolicy because of informer "+event+" event", "key", key, "resourceVersion", nbp.ResourceVersion)
+		c.workqueue.Add(bindingPolicyRef(key))
+	}
+	_, err := c.namespacedBindingPolicyInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) { enqueue(obj, "add") },
+		UpdateFunc: func(old, new any) {
+			if old.(*v1alpha1.NamespacedBindingPolicy).Generation != new.(*v1alpha1.NamespacedBindingPolicy).Generation {
+				enqueue(new, "update")
+			}
+		},
+		DeleteFunc: func(obj any) { enqueue(obj, "delete") },
+	})
+	if err != nil {
+		c.logger.Error(err, "failed to add namespacedbindingpolicies informer event handler")
+		return err
+	}
+	return nil
+}
+
+// setupClusterGrantInformer arranges for the NamespacedBindingPolicies in the namespaces
+// of a ClusterGrant to be re-evaluated when that ClusterGrant changes.
+func (c *Controller) setupClusterGrantInformer(ctx context.Context) error {
+	logger := klog.FromContext(ctx)
+	enqueue := func(grant *v1alpha1.ClusterGrant) {
+		for _, namespace := range grant.Spec.Namespaces {
+			nbps, err := c.namespacedBindingPolicyLister.NamespacedBindingPolicies(namespace).List(labels.Everything())
+			if err != nil {
+				logger.Error(err, "Failed to list NamespacedBindingPolicies", "namespace", namespace)
+				continue
+			}
+			for _, nbp := range nbps {
+				key := namespacedBindingPolicyKey(nbp.Namespace, nbp.Name)
+				logger.V(5).Info("Enqueuing reference to NamespacedBindingPolicy because of ClusterGrant change", "key", key, "clusterGrant", grant.Name)
+				c.workqueue.Add(bindingPolicyRef(key))
+			}
+		}
+	}
+	_, err := c.clusterGrantInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) { enqueue(obj.(*v1alpha1.ClusterGrant)) },
+		UpdateFunc: func(old, new any) {
+			oldGrant, newGrant := old.(*v1alpha1.ClusterGrant), new.(*v1alpha1.ClusterGrant)
+			if oldGrant.Generation != newGrant.Generation {
+				enqueue(oldGrant)
+				enqueue(newGrant)
+			}
+		},
+		DeleteFunc: func(obj any) {
+			if typed, is := obj.(cache.DeletedFinalStateUnknown); is {
+				obj = typed.Obj
+			}
+			enqueue(obj.(*v1alpha1.ClusterGrant))
+		},
+	})
+	if err != nil {
+		c.logger.Error(err, "failed to add clustergrants informer event handler")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"testing"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	controllisters "github.com/kubestellar/kubestellar/pkg/generated/listers/control/v1alpha1"
+)
+
+func TestEffectiveBindingPolicy(t *testing.T) {
+	nbp := &v1alpha1.NamespacedBindingPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "web"},
+		Spec: v1alpha1.BindingPolicySpec{
+			Downsync: []v1alpha1.DownsyncPolicyClause{
+				{DownsyncObjectTest: v1alpha1.DownsyncObjectTest{Resources: []string{"deployments"}}},
+				{DownsyncObjectTest: v1alpha1.DownsyncObjectTest{Namespaces: []string{"team-b"}}},
+				{DownsyncObjectTest: v1alpha1.DownsyncObjectTest{Namespaces: []string{"team-b", "team-a"}, ObjectNames: []string{"cm"}}},
+			},
+			Upsync: []v1alpha1.UpsyncPolicyClause{
+				{Resources: []string{"configmaps"}, Placement: v1alpha1.UpsyncPlacementClusterNamespace},
+				{Resources: []string{"secrets"}, Namespaces: []string{"*"}, Placement: v1alpha1.UpsyncPlacementClusterNamePrefix},
+			},
+		},
+	}
+	effective := effectiveBindingPolicy(nbp)
+	if effective.Name != "team-a.web" || effective.Namespace != "team-a" {
+		t.Errorf("Unexpected identity %s/%s", effective.Namespace, effective.Name)
+	}
+	if actual := namespacedBindingPolicyName(effective); actual != "web" {
+		t.Errorf("Expected name web, got %s", actual)
+	}
+	expectedDownsync := []v1alpha1.DownsyncPolicyClause{
+		{DownsyncObjectTest: v1alpha1.DownsyncObjectTest{Resources: []string{"deployments"}, Namespaces: []string{"team-a"}}},
+		{DownsyncObjectTest: v1alpha1.DownsyncObjectTest{Namespaces: []string{"team-a"}, ObjectNames: []string{"cm"}}},
+	}
+	if !apiequality.Semantic.DeepEqual(effective.Spec.Downsync, expectedDownsync) {
+		t.Errorf("Expected downsync %+v, got %+v", expectedDownsync, effective.Spec.Downsync)
+	}
+	expectedUpsync := []v1alpha1.UpsyncPolicyClause{
+		{Resources: []string{"secrets"}, Namespaces: []string{"team-a"}, Placement: v1alpha1.UpsyncPlacementClusterNamePrefix},
+	}
+	if !apiequality.Semantic.DeepEqual(effective.Spec.Upsync, expectedUpsync) {
+		t.Errorf("Expected upsync %+v, got %+v", expectedUpsync, effective.Spec.Upsync)
+	}
+	if len(nbp.Spec.Downsync[0].Namespaces) != 0 {
+		t.Errorf("Input policy was modified")
+	}
+}
+
+func TestClusterIsGranted(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	_ = indexer.Add(&v1alpha1.ClusterGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "edge"},
+		Spec: v1alpha1.ClusterGrantSpec{
+			Namespaces:       []string{"team-a"},
+			ClusterSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"location-group": "edge"}}},
+		},
+	})
+	c := &Controller{clusterGrantLister: controllisters.NewClusterGrantLister(indexer)}
+	for _, testCase := range []struct {
+		name      string
+		namespace string
+		labels    labels.Set
+		expected  bool
+	}{
+		{name: "cluster-scoped-policy", labels: labels.Set{"location-group": "cloud"}, expected: true},
+		{name: "granted", namespace: "team-a", labels: labels.Set{"location-group": "edge"}, expected: true},
+		{name: "other-cluster", namespace: "team-a", labels: labels.Set{"location-group": "cloud"}},
+		{name: "other-namespace", namespace: "team-b", labels: labels.Set{"location-group": "edge"}},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			policy := &v1alpha1.BindingPolicy{ObjectMeta: metav1.ObjectMeta{Namespace: testCase.namespace, Name: "p"}}
+			granted, err := c.clusterIsGranted(policy, testCase.labels)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if granted != testCase.expected {
+				t.Errorf("Expected %v, got %v", testCase.expected, granted)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/celeval"
+)
+
+const (
+	// objectFilterObjectKey is the key used to store the whole workload object.
+	objectFilterObjectKey = "obj"
+	// objectFilterLabelsKey is the key used to store the labels of the workload object.
+	objectFilterLabelsKey = "labels"
+	// objectFilterAnnotationsKey is the key used to store the annotations of the workload object.
+	objectFilterAnnotationsKey = "annotations"
+)
+
+// newObjectFilterEvaluator returns a CEL evaluator whose environment
+// is suited to the `objectFilter` of a DownsyncObjectTest.
+func newObjectFilterEvaluator() (*celeval.Evaluator, error) {
+	return celeval.NewEvaluator(
+		cel.Variable(objectFilterObjectKey, cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable(objectFilterLabelsKey, cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable(objectFilterAnnotationsKey, cel.MapType(cel.StringType, cel.StringType)),
+	)
+}
+
+// checkObjectFilters returns one error for each of the given clauses
+// whose `objectFilter` is not a valid expression.
+func checkObjectFilters(evaluator *celeval.Evaluator, clauses []v1alpha1.DownsyncPolicyClause) []error {
+	var errs []error
+	for idx, clause := range clauses {
+		if clause.ObjectFilter == nil {
+			continue
+		}
+		if err := evaluator.CheckBoolExpression(*clause.ObjectFilter); err != nil {
+			errs = append(errs, fmt.Errorf("invalid downsync[%d].objectFilter (%q): %w", idx, *clause.ObjectFilter, err))
+		}
+	}
+	return errs
+}
+
+// objectFilterVariables returns the values of the variables for evaluating
+// an `objectFilter` on the given workload object.
+func objectFilterVariables(obj mrObject) (map[string]interface{}, error) {
+	var content map[string]interface{}
+	if objU, is := obj.(*unstructured.Unstructured); is {
+		content = objU.Object
+	} else {
+		var err error
+		content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert object to unstructured: %w", err)
+		}
+	}
+	return map[string]interface{}{
+		objectFilterObjectKey:      content,
+		objectFilterLabelsKey:      celeval.NonNilStringMap(obj.GetLabels()),
+		objectFilterAnnotationsKey: celeval.NonNilStringMap(obj.GetAnnotations()),
+	}, nil
+}
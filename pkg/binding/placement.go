@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+// clusterPlacement holds the parts of a BindingPolicySpec that limit and rank
+// the clusters that pass the BindingPolicy's cluster selection criteria.
+// A clusterPlacement is immutable.
+type clusterPlacement struct {
+	// numberOfClusters is nil when there is no limit.
+	numberOfClusters *int32
+	prioritizers     []v1alpha1.ClusterPrioritizer
+}
+
+// `*bindingPolicy` is immutable, and so is the returned value.
+func clusterPlacementFromBindingPolicy(bindingPolicy *v1alpha1.BindingPolicy) clusterPlacement {
+	return clusterPlacement{
+		numberOfClusters: bindingPolicy.Spec.NumberOfClusters,
+		prioritizers:     bindingPolicy.Spec.Prioritizers,
+	}
+}
+
+// selectClusters returns the subset of the candidates that is selected.
+// `candidates` maps cluster name to the labels of that cluster.
+// `current` is the set of clusters that are currently selected,
+// which are preferred over other clusters that are equally ranked.
+// Neither argument is mutated, and the returned set is not retained here.
+func (placement clusterPlacement) selectClusters(candidates map[string]labels.Set, current sets.Set[string]) sets.Set[string] {
+	if placement.numberOfClusters == nil || len(candidates) <= int(*placement.numberOfClusters) {
+		return sets.KeySet(candidates)
+	}
+	names := make([]string, 0, len(candidates))
+	for name := range candidates {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		for _, prioritizer := range placement.prioritizers {
+			if cmp := comparePrioritizerValues(prioritizer, candidates[a], candidates[b]); cmp != 0 {
+				return cmp
+			}
+		}
+		if aCurrent, bCurrent := current.Has(a), current.Has(b); aCurrent != bCurrent {
+			if aCurrent {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	return sets.New(names[:*placement.numberOfClusters]...)
+}
+
+// comparePrioritizerValues returns a negative number if the cluster with labels `a`
+// ranks ahead of the cluster with labels `b` according to the given prioritizer,
+// a positive number if the reverse is true, and zero if they are tied.
+func comparePrioritizerValues(prioritizer v1alpha1.ClusterPrioritizer, a, b labels.Set) int {
+	aVal, aHas := a[prioritizer.Label]
+	bVal, bHas := b[prioritizer.Label]
+	switch {
+	case !aHas && !bHas:
+		return 0
+	case !bHas:
+		return -1
+	case !aHas:
+		return 1
+	}
+	var cmp int
+	aNum, aErr := strconv.ParseFloat(aVal, 64)
+	bNum, bErr := strconv.ParseFloat(bVal, 64)
+	if aErr == nil && bErr == nil {
+		switch {
+		case aNum < bNum:
+			cmp = -1
+		case aNum > bNum:
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(aVal, bVal)
+	}
+	if prioritizer.Order == v1alpha1.PrioritizerOrderDescending {
+		return -cmp
+	}
+	return cmp
+}
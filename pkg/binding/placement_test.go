@@ -0,0 +1,65 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestSelectClusters(t *testing.T) {
+	candidates := map[string]labels.Set{
+		"c1": {"cost-tier": "3", "zone": "a"},
+		"c2": {"cost-tier": "10", "zone": "b"},
+		"c3": {"cost-tier": "1", "zone": "b"},
+		"c4": {"cost-tier": "3", "zone": "c"},
+		"c5": {"zone": "a"},
+	}
+	byCost := []v1alpha1.ClusterPrioritizer{{Label: "cost-tier"}}
+	for _, tc := range []struct {
+		name      string
+		placement clusterPlacement
+		current   sets.Set[string]
+		expected  sets.Set[string]
+	}{
+		{name: "no limit", placement: clusterPlacement{}, expected: sets.KeySet(candidates)},
+		{name: "limit above candidates", placement: clusterPlacement{numberOfClusters: ptrInt32(9), prioritizers: byCost}, expected: sets.KeySet(candidates)},
+		{name: "lowest cost, numerically", placement: clusterPlacement{numberOfClusters: ptrInt32(2), prioritizers: byCost}, expected: sets.New("c3", "c1")},
+		{name: "tie broken by name", placement: clusterPlacement{numberOfClusters: ptrInt32(3), prioritizers: byCost}, expected: sets.New("c3", "c1", "c4")},
+		{name: "tie broken by current", placement: clusterPlacement{numberOfClusters: ptrInt32(2), prioritizers: byCost}, current: sets.New("c4"), expected: sets.New("c3", "c4")},
+		{name: "highest cost", placement: clusterPlacement{numberOfClusters: ptrInt32(1), prioritizers: []v1alpha1.ClusterPrioritizer{{Label: "cost-tier", Order: v1alpha1.PrioritizerOrderDescending}}}, expected: sets.New("c2")},
+		{name: "missing label ranks last", placement: clusterPlacement{numberOfClusters: ptrInt32(4), prioritizers: byCost}, current: sets.New("c5"), expected: sets.New("c1", "c2", "c3", "c4")},
+		{name: "second prioritizer", placement: clusterPlacement{numberOfClusters: ptrInt32(2), prioritizers: []v1alpha1.ClusterPrioritizer{{Label: "zone"}, {Label: "cost-tier", Order: v1alpha1.PrioritizerOrderDescending}}}, expected: sets.New("c1", "c5")},
+		{name: "no prioritizers", placement: clusterPlacement{numberOfClusters: ptrInt32(2)}, current: sets.New("c5", "c9"), expected: sets.New("c1", "c5")},
+		{name: "zero", placement: clusterPlacement{numberOfClusters: ptrInt32(0), prioritizers: byCost}, expected: sets.New[string]()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := tc.placement.selectClusters(candidates, tc.current)
+			if !actual.Equal(tc.expected) {
+				t.Errorf("Expected %v, got %v", sets.List(tc.expected), sets.List(actual))
+			}
+		})
+	}
+}
+
+func ptrInt32(val int32) *int32 {
+	return &val
+}
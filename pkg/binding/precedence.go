@@ -0,0 +1,211 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/utils/ptr"
+
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+// ModulationConflict describes a workload object for which some of a BindingPolicy's
+// downsync modulation is overridden by another BindingPolicy that takes precedence.
+type ModulationConflict struct {
+	ObjectId util.ObjectIdentifier
+	// WinningPolicy is the name of the BindingPolicy that prevails.
+	WinningPolicy string
+	// Fields lists the names of the overridden modulation fields.
+	Fields []string
+}
+
+func (conflict ModulationConflict) String() string {
+	return fmt.Sprintf("%s of %s overridden by BindingPolicy %q", strings.Join(conflict.Fields, " and "), conflict.ObjectId, conflict.WinningPolicy)
+}
+
+// prevailingModulation identifies the BindingPolicy that prevails for a given workload object,
+// and holds that BindingPolicy's modulation for that object.
+type prevailingModulation struct {
+	policyName string
+	priority   int32
+	modulation DownsyncModulation
+}
+
+// takesPrecedence tells whether the BindingPolicy with priority `priorityA` and name `nameA`
+// takes precedence over the one with priority `priorityB` and name `nameB`.
+func takesPrecedence(priorityA int32, nameA string, priorityB int32, nameB string) bool {
+	if priorityA != priorityB {
+		return priorityA > priorityB
+	}
+	return nameA < nameB
+}
+
+// applyPrecedence returns the given own modulation, adjusted so that the fields that
+// are not combined across BindingPolicies come from the prevailing modulation.
+// The returned strings are the names of the fields that were changed.
+func applyPrecedence(own, prevailing DownsyncModulation) (DownsyncModulation, []string) {
+	var changed []string
+	if own.CreateOnly != prevailing.CreateOnly {
+		own.CreateOnly = prevailing.CreateOnly
+		changed = append(changed, "createOnly")
+	}
+	if own.Orphan != prevailing.Orphan {
+		own.Orphan = prevailing.Orphan
+		changed = append(changed, "deletionPolicy")
+	}
+	if !ptr.Equal(own.ReplicaSplit, prevailing.ReplicaSplit) {
+		own.ReplicaSplit = prevailing.ReplicaSplit
+		changed = append(changed, "replicaSplit")
+	}
+	return own, changed
+}
+
+// effectiveModulation returns the modulation to use in a Binding for the given object,
+// given the resolution's own modulation and the prevailing ones from other BindingPolicies.
+func effectiveModulation(objId util.ObjectIdentifier, own DownsyncModulation, prevailing map[util.ObjectIdentifier]prevailingModulation) DownsyncModulation {
+	if winner, has := prevailing[objId]; has {
+		own, _ = applyPrecedence(own, winner.modulation)
+	}
+	return own
+}
+
+// getPrevailingModulations returns, for each workload object of the given bindingpolicy's resolution
+// that is also selected by another BindingPolicy that takes precedence, the
+// prevailing BindingPolicy and its modulation for that object.
+// Suspended BindingPolicies do not prevail.
+// Returns nil if no resolution is associated with the given key.
+func (resolver *bindingPolicyResolver) getPrevailingModulations(bindingPolicyKey string) map[util.ObjectIdentifier]prevailingModulation {
+	resolver.RLock()
+	defer resolver.RUnlock()
+
+	own := resolver.bindingPolicyToResolution[bindingPolicyKey]
+	if own == nil {
+		return nil
+	}
+	ownPriority := own.getPriority()
+	ans := map[util.ObjectIdentifier]prevailingModulation{}
+	for _, objId := range own.getWorkloadReferences() {
+		for _, otherKey := range resolver.objectToPolicies.get(objId) {
+			other := resolver.bindingPolicyToResolution[otherKey]
+			if otherKey == bindingPolicyKey || other == nil {
+				continue
+			}
+			other.RLock()
+			otherData := other.objectIdentifierToData[objId]
+			if otherData != nil && !other.suspended && takesPrecedence(other.priority, otherKey, ownPriority, bindingPolicyKey) {
+				if current, has := ans[objId]; !has || takesPrecedence(other.priority, otherKey, current.priority, current.policyName) {
+					ans[objId] = prevailingModulation{policyName: otherKey, priority: other.priority, modulation: otherData.Modulation}
+				}
+			}
+			other.RUnlock()
+		}
+	}
+	return ans
+}
+
+// GetModulationConflicts returns the conflicts that the given bindingpolicy loses,
+// sorted by object identifier.
+func (resolver *bindingPolicyResolver) GetModulationConflicts(bindingPolicyKey string) []ModulationConflict {
+	resolution := resolver.getResolution(bindingPolicyKey)
+	if resolution == nil {
+		return nil
+	}
+	prevailing := resolver.getPrevailingModulations(bindingPolicyKey)
+	var conflicts []ModulationConflict
+	resolution.RLock()
+	for objId, winner := range prevailing {
+		objData := resolution.objectIdentifierToData[objId]
+		if objData == nil {
+			continue
+		}
+		if _, fields := applyPrecedence(objData.Modulation, winner.modulation); len(fields) > 0 {
+			conflicts = append(conflicts, ModulationConflict{ObjectId: objId, WinningPolicy: winner.policyName, Fields: fields})
+		}
+	}
+	resolution.RUnlock()
+	slices.SortFunc(conflicts, func(a, b ModulationConflict) int {
+		return strings.Compare(a.ObjectId.String(), b.ObjectId.String())
+	})
+	return conflicts
+}
+
+// GetBindingPoliciesSharingObjects returns the names of the other bindingpolicies
+// whose resolutions include at least one of the workload objects
+// of the given bindingpolicy's resolution.
+func (resolver *bindingPolicyResolver) GetBindingPoliciesSharingObjects(bindingPolicyKey string) []string {
+	resolver.RLock()
+	defer resolver.RUnlock()
+
+	own := resolver.bindingPolicyToResolution[bindingPolicyKey]
+	if own == nil {
+		return nil
+	}
+	sharers := sets.New[string]()
+	for _, objId := range own.getWorkloadReferences() {
+		sharers.Insert(resolver.objectToPolicies.get(objId)...)
+	}
+	sharers.Delete(bindingPolicyKey)
+	return sets.List(sharers)
+}
+
+// GetBindingPoliciesSelectingObject returns the names of the bindingpolicies
+// whose resolutions include the given workload object.
+func (resolver *bindingPolicyResolver) GetBindingPoliciesSelectingObject(objId util.ObjectIdentifier) []string {
+	return resolver.objectToPolicies.get(objId)
+}
+
+// policiesByObject indexes bindingpolicy names by the workload objects in their resolutions.
+// Its mutex is not held while acquiring any other.
+type policiesByObject struct {
+	sync.Mutex
+	objectToPolicies map[util.ObjectIdentifier]sets.Set[string]
+}
+
+func newPoliciesByObject() *policiesByObject {
+	return &policiesByObject{objectToPolicies: map[util.ObjectIdentifier]sets.Set[string]{}}
+}
+
+// note records whether the given object is in the resolution of the given bindingpolicy.
+func (index *policiesByObject) note(objId util.ObjectIdentifier, bindingPolicyKey string, present bool) {
+	index.Lock()
+	defer index.Unlock()
+	policies := index.objectToPolicies[objId]
+	if present {
+		if policies == nil {
+			policies = sets.New[string]()
+			index.objectToPolicies[objId] = policies
+		}
+		policies.Insert(bindingPolicyKey)
+	} else if policies != nil {
+		policies.Delete(bindingPolicyKey)
+		if policies.Len() == 0 {
+			delete(index.objectToPolicies, objId)
+		}
+	}
+}
+
+// get returns the names of the bindingpolicies whose resolutions include the given object.
+func (index *policiesByObject) get(objId util.ObjectIdentifier) []string {
+	index.Lock()
+	defer index.Unlock()
+	return sets.List(index.objectToPolicies[objId])
+}
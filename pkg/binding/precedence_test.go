@@ -0,0 +1,140 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"slices"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+func TestModulationPrecedence(t *testing.T) {
+	resolver := NewBindingPolicyResolver()
+	newPolicy := func(name string, priority int32) *v1alpha1.BindingPolicy {
+		return &v1alpha1.BindingPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID("uid-" + name)},
+			Spec:       v1alpha1.BindingPolicySpec{Priority: priority},
+		}
+	}
+	objId := util.ObjectIdentifier{
+		GVK:        schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		Resource:   "deployments",
+		ObjectName: cache.NewObjectName("demo", "web"),
+	}
+	noteObject := func(policyName string, createOnly bool) {
+		mod := ZeroDownsyncModulation()
+		mod.CreateOnly = createOnly
+		if _, err := resolver.EnsureObjectData(policyName, objId, "u1", "1", mod); err != nil {
+			t.Fatalf("EnsureObjectData(%s): %s", policyName, err)
+		}
+	}
+	generatedCreateOnly := func(policyName string) bool {
+		spec := resolver.GenerateBinding(policyName)
+		if len(spec.Workload.NamespaceScope) != 1 {
+			t.Fatalf("Expected one object in Binding %s, got %v", policyName, spec.Workload)
+		}
+		if !resolver.CompareBinding(policyName, spec) {
+			t.Errorf("Generated Binding %s does not compare equal to itself", policyName)
+		}
+		return spec.Workload.NamespaceScope[0].CreateOnly
+	}
+
+	resolver.NoteBindingPolicy(newPolicy("a", 0))
+	resolver.NoteBindingPolicy(newPolicy("b", 0))
+	noteObject("a", false)
+	noteObject("b", true)
+	if shared := resolver.GetBindingPoliciesSelectingObject(objId); !sets.New(shared...).Equal(sets.New("a", "b")) {
+		t.Errorf("Expected both policies to select the object, got %v", shared)
+	}
+
+	// equal priority, "a" sorts first
+	if generatedCreateOnly("a") || generatedCreateOnly("b") {
+		t.Errorf("Expected createOnly=false from policy a to prevail")
+	}
+	if conflicts := resolver.GetModulationConflicts("a"); len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts for a, got %v", conflicts)
+	}
+	conflicts := resolver.GetModulationConflicts("b")
+	if len(conflicts) != 1 || conflicts[0].WinningPolicy != "a" || !slices.Equal(conflicts[0].Fields, []string{"createOnly"}) {
+		t.Errorf("Expected b to lose createOnly to a, got %v", conflicts)
+	}
+
+	// higher priority wins
+	resolver.NoteBindingPolicy(newPolicy("b", 10))
+	if !generatedCreateOnly("a") || !generatedCreateOnly("b") {
+		t.Errorf("Expected createOnly=true from policy b to prevail")
+	}
+	conflicts = resolver.GetModulationConflicts("a")
+	if len(conflicts) != 1 || conflicts[0].WinningPolicy != "b" {
+		t.Errorf("Expected a to lose to b, got %v", conflicts)
+	}
+
+	// a suspended policy does not prevail
+	suspendedB := newPolicy("b", 10)
+	suspendedB.Spec.Suspend = true
+	resolver.NoteBindingPolicy(suspendedB)
+	if generatedCreateOnly("a") {
+		t.Errorf("Expected createOnly=false from policy a to prevail over suspended b")
+	}
+	if conflicts := resolver.GetModulationConflicts("a"); len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts for a while b is suspended, got %v", conflicts)
+	}
+	resolver.NoteBindingPolicy(newPolicy("b", 10))
+
+	// no conflict once the loser stops selecting the object
+	resolver.RemoveObjectIdentifier("a", objId)
+	if conflicts := resolver.GetModulationConflicts("b"); len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts for b, got %v", conflicts)
+	}
+	if shared := resolver.GetBindingPoliciesSelectingObject(objId); !slices.Equal(shared, []string{"b"}) {
+		t.Errorf("Expected only b to select the object, got %v", shared)
+	}
+	resolver.DeleteResolution("b")
+	if shared := resolver.GetBindingPoliciesSelectingObject(objId); len(shared) != 0 {
+		t.Errorf("Expected no policy to select the object, got %v", shared)
+	}
+}
+
+func TestDeletionPolicyModulation(t *testing.T) {
+	mod := ZeroDownsyncModulation()
+	mod.AddExternal(v1alpha1.DownsyncModulation{DeletionPolicy: v1alpha1.DeletionPolicyDelete})
+	if ext := mod.ToExternal(); ext.DeletionPolicy != "" {
+		t.Errorf("Expected the default deletion policy to be left implicit, got %q", ext.DeletionPolicy)
+	}
+	mod.AddExternal(v1alpha1.DownsyncModulation{DeletionPolicy: v1alpha1.DeletionPolicyOrphan})
+	mod.AddExternal(v1alpha1.DownsyncModulation{})
+	ext := mod.ToExternal()
+	if ext.DeletionPolicy != v1alpha1.DeletionPolicyOrphan {
+		t.Errorf("Expected Orphan to win, got %q", ext.DeletionPolicy)
+	}
+	if roundTrip := DownsyncModulationFromExternal(ext); !roundTrip.Equal(mod) {
+		t.Errorf("Expected %#v to survive a round trip, got %#v", mod, roundTrip)
+	}
+
+	own, changed := applyPrecedence(mod, ZeroDownsyncModulation())
+	if own.Orphan || !slices.Equal(changed, []string{"deletionPolicy"}) {
+		t.Errorf("Expected deletionPolicy to come from the prevailing modulation, got %v and %v", own.Orphan, changed)
+	}
+}
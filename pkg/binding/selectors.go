@@ -52,14 +52,43 @@ func (c *Controller) updateResolutions(ctx context.Context, objIdentifier util.O
 
 	objMR := obj.(mrObject)
 	objBeingDeleted := isBeingDeleted(obj)
+	anyResolutionUpdated := false
 
 	for _, bindingPolicy := range bindingPolicies {
 		if !c.bindingPolicyResolver.ResolutionExists(bindingPolicy.GetName()) {
 			continue // resolution does not exist, skip
 		}
 
-		matchedAny, modFromPolicy := c.testObject(ctx, bindingPolicy.GetName(), objIdentifier, objMR.GetLabels(), bindingPolicy.Spec.Downsync)
-		if !matchedAny {
+		matchedAny, modFromPolicy := c.testObject(ctx, bindingPolicy.GetName(), objIdentifier, objMR, bindingPolicy.Spec.Downsync)
+		// an object that the policy's creator or last modifier may not get is left out
+		allowed := true
+		if matchedAny || c.bindingPolicyResolver.IsDependency(bindingPolicy.GetName(), objIdentifier) {
+			allowed, err = c.usersMayGet(ctx, bindingPolicy, objIdentifier)
+			if err != nil {
+				return err
+			}
+		}
+		if c.noteAccess(bindingPolicy.GetName(), objIdentifier, allowed) {
+			logger.V(4).Info("Enqueuing Binding for syncing due to a change in access to a workload object",
+				"binding", bindingPolicy.GetName(), "objectIdentifier", objIdentifier, "allowed", allowed)
+			c.enqueueBinding(bindingPolicy.GetName())
+		}
+		if !matchedAny || !allowed {
+			// an object that a selected object depends on stays, implicitly selected
+			if !allowed {
+				logger.V(4).Info("Leaving workload object out of resolution because the BindingPolicy's creator or last modifier may not get it",
+					"binding", bindingPolicy.GetName(), "objectIdentifier", objIdentifier)
+			} else if required, resolutionUpdated := c.bindingPolicyResolver.NoteImplicitObject(bindingPolicy.GetName(),
+				objIdentifier, string(objMR.GetUID()), objMR.GetResourceVersion()); required {
+				if resolutionUpdated {
+					logger.V(4).Info("Enqueuing Binding for syncing due to a change of an "+
+						"implicitly selected object", "binding", bindingPolicy.GetName(),
+						"objectIdentifier", objIdentifier)
+					c.enqueueBinding(bindingPolicy.GetName())
+					anyResolutionUpdated = true
+				}
+				continue
+			}
 			// if previously selected, remove
 			if resolutionUpdated := c.bindingPolicyResolver.RemoveObjectIdentifier(bindingPolicy.GetName(),
 				objIdentifier); resolutionUpdated {
@@ -68,6 +97,7 @@ func (c *Controller) updateResolutions(ctx context.Context, objIdentifier util.O
 					"object from its resolution", "binding", bindingPolicy.GetName(),
 					"objectIdentifier", objIdentifier)
 				c.enqueueBinding(bindingPolicy.GetName())
+				anyResolutionUpdated = true
 			} else {
 				logger.V(5).Info("Not enqueuing Binding for syncing, because its resolution continues "+
 					"to not include workload object", "binding", bindingPolicy.GetName(),
@@ -94,6 +124,13 @@ func (c *Controller) updateResolutions(ctx context.Context, objIdentifier util.O
 			return fmt.Errorf("failed to update resolution for bindingpolicy %s for object (identifier: %v): %v",
 				bindingPolicy.GetName(), objIdentifier, err)
 		}
+		dependenciesUpdated, err := c.noteDependencies(ctx, bindingPolicy, objIdentifier, objMR, modFromPolicy.IncludeDependencies)
+		if err != nil {
+			return err
+		}
+		if dependenciesUpdated {
+			resolutionUpdated = true
+		}
 
 		if resolutionUpdated {
 			// enqueue binding to be synced since an object was added to its bindingpolicy's resolution
@@ -102,6 +139,7 @@ func (c *Controller) updateResolutions(ctx context.Context, objIdentifier util.O
 				"objectIdentifier", objIdentifier, "objBeingDeleted", objBeingDeleted,
 				"resourceVersion", objMR.GetResourceVersion())
 			c.enqueueBinding(bindingPolicy.GetName())
+			anyResolutionUpdated = true
 		} else {
 			logger.V(5).Info("Not enqueuing Binding, due to no change in resolution",
 				"binding", bindingPolicy.GetName(),
@@ -111,6 +149,15 @@ func (c *Controller) updateResolutions(ctx context.Context, objIdentifier util.O
 		}
 	}
 
+	if anyResolutionUpdated {
+		// the modulation that prevails for this object may have changed
+		for _, policyName := range c.bindingPolicyResolver.GetBindingPoliciesSelectingObject(objIdentifier) {
+			logger.V(5).Info("Enqueuing Binding for syncing because another resolution of its workload object changed",
+				"binding", policyName, "objectIdentifier", objIdentifier)
+			c.enqueueBinding(policyName)
+		}
+	}
+
 	return nil
 }
 
@@ -118,8 +165,9 @@ func (c *Controller) removeObjectFromBindingPolicies(ctx context.Context, objIde
 	bindingPolicies []*v1alpha1.BindingPolicy) error {
 	logger := klog.FromContext(ctx)
 	for _, bindingPolicy := range bindingPolicies {
+		accessChanged := c.noteAccess(bindingPolicy.GetName(), objIdentifier, true)
 		if resolutionUpdated := c.bindingPolicyResolver.RemoveObjectIdentifier(bindingPolicy.GetName(),
-			objIdentifier); resolutionUpdated {
+			objIdentifier); resolutionUpdated || accessChanged {
 			// enqueue binding to be synced since object was removed from its bindingpolicy's resolution
 			logger.V(5).Info("Enqueuing Binding due to deletion of matching object", "bindingPolicy", bindingPolicy.Name, "object", objIdentifier)
 			c.enqueueBinding(bindingPolicy.GetName())
@@ -137,7 +185,7 @@ func (c *Controller) removeObjectFromBindingPolicies(ctx context.Context, objIde
 //   - bool: whether any test that matches the object also says CreateOnly==true
 //   - sets.Set[string]: the UNION of the statuscollector names that appear within
 //     EACH of the tests that the object matches
-func (c *Controller) testObject(ctx context.Context, bindingName string, objIdentifier util.ObjectIdentifier, objLabels map[string]string,
+func (c *Controller) testObject(ctx context.Context, bindingName string, objIdentifier util.ObjectIdentifier, obj mrObject,
 	tests []v1alpha1.DownsyncPolicyClause) (bool, DownsyncModulation) {
 
 	logger := klog.FromContext(ctx)
@@ -145,7 +193,14 @@ func (c *Controller) testObject(ctx context.Context, bindingName string, objIden
 	var matched bool
 	mod := ZeroDownsyncModulation()
 
+	objLabels := obj.GetLabels()
+	if _, upsynced := objLabels[v1alpha1.UpsyncedFromLabelKey]; upsynced {
+		// an upsynced copy is never downsynced, lest it go back to the WEC it came from
+		logger.V(5).Info("Not downsyncing upsynced copy", "objIdentifier", objIdentifier, "binding", bindingName)
+		return false, mod
+	}
 	var objNS *corev1.Namespace
+	var filterVars map[string]interface{}
 	for _, test := range tests {
 		if test.APIGroup != nil && (*test.APIGroup) != objIdentifier.GVK.Group {
 			continue
@@ -165,6 +220,28 @@ func (c *Controller) testObject(ctx context.Context, bindingName string, objIden
 		if len(test.ObjectSelectors) > 0 && !labelsMatchAny(c.logger, objLabels, test.ObjectSelectors) {
 			continue
 		}
+		if len(test.AnnotationSelectors) > 0 && !labelsMatchAny(c.logger, obj.GetAnnotations(), test.AnnotationSelectors) {
+			continue
+		}
+		if test.ObjectFilter != nil {
+			if filterVars == nil {
+				var err error
+				filterVars, err = objectFilterVariables(obj)
+				if err != nil {
+					logger.V(3).Info("Failed to prepare object for objectFilter, assuming object does not match",
+						"object identifier", objIdentifier, "binding", bindingName, "err", err)
+					continue
+				}
+			}
+			pass, err := c.objectFilterEvaluator.EvaluateBool(*test.ObjectFilter, filterVars)
+			if err != nil {
+				logger.V(4).Info("Error in evaluating objectFilter, assuming object does not match",
+					"object identifier", objIdentifier, "binding", bindingName, "objectFilter", *test.ObjectFilter, "err", err)
+			}
+			if !pass {
+				continue
+			}
+		}
 		if len(test.NamespaceSelectors) > 0 && !ALabelSelectorIsEmpty(test.NamespaceSelectors...) {
 			if objNS == nil {
 				var err error
@@ -180,6 +257,16 @@ func (c *Controller) testObject(ctx context.Context, bindingName string, objIden
 				continue
 			}
 		}
+		// exclusions are applied after the inclusions
+		if slices.Contains(test.ExcludeNamespaces, objIdentifier.ObjectName.Namespace) {
+			continue
+		}
+		if slices.Contains(test.ExcludeObjectNames, objIdentifier.ObjectName.Name) {
+			continue
+		}
+		if len(test.ExcludeObjectSelectors) > 0 && labelsMatchAny(c.logger, objLabels, test.ExcludeObjectSelectors) {
+			continue
+		}
 
 		klog.FromContext(ctx).V(5).Info("Workload object matched clause", "objIdentifier", objIdentifier, "objLabels", objLabels, "clause", test, "binding", bindingName)
 		// test is a match
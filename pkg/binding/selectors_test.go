@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+func TestTestObjectExclusions(t *testing.T) {
+	evaluator, err := newObjectFilterEvaluator()
+	if err != nil {
+		t.Fatalf("Failed to create evaluator: %s", err)
+	}
+	c := &Controller{logger: klog.Background(), objectFilterEvaluator: evaluator}
+	cmId := func(namespace, name string) util.ObjectIdentifier {
+		return util.ObjectIdentifier{
+			GVK:        schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+			Resource:   "configmaps",
+			ObjectName: cache.NewObjectName(namespace, name),
+		}
+	}
+	clause := v1alpha1.DownsyncPolicyClause{DownsyncObjectTest: v1alpha1.DownsyncObjectTest{
+		Resources:              []string{"configmaps"},
+		Namespaces:             []string{"*"},
+		ExcludeNamespaces:      []string{"kube-system"},
+		ExcludeObjectNames:     []string{"kube-root-ca.crt"},
+		ExcludeObjectSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"internal": "true"}}},
+	}}
+	for _, tc := range []struct {
+		name     string
+		objId    util.ObjectIdentifier
+		labels   map[string]string
+		expected bool
+	}{
+		{name: "included", objId: cmId("demo", "settings"), expected: true},
+		{name: "excluded namespace", objId: cmId("kube-system", "settings"), expected: false},
+		{name: "excluded name", objId: cmId("demo", "kube-root-ca.crt"), expected: false},
+		{name: "excluded labels", objId: cmId("demo", "settings"), labels: map[string]string{"internal": "true"}, expected: false},
+		{name: "other labels", objId: cmId("demo", "settings"), labels: map[string]string{"internal": "false"}, expected: true},
+		{name: "upsynced copy", objId: cmId("wec1", "settings"), labels: map[string]string{v1alpha1.UpsyncedFromLabelKey: "wec1"}, expected: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{}
+			obj.SetAPIVersion("v1")
+			obj.SetKind("ConfigMap")
+			obj.SetNamespace(tc.objId.ObjectName.Namespace)
+			obj.SetName(tc.objId.ObjectName.Name)
+			obj.SetLabels(tc.labels)
+			matched, _ := c.testObject(context.Background(), "test", tc.objId, obj, []v1alpha1.DownsyncPolicyClause{clause})
+			if matched != tc.expected {
+				t.Errorf("Expected match=%v, got %v", tc.expected, matched)
+			}
+		})
+	}
+}
+
+func TestTestObjectAnnotationsAndFilter(t *testing.T) {
+	evaluator, err := newObjectFilterEvaluator()
+	if err != nil {
+		t.Fatalf("Failed to create evaluator: %s", err)
+	}
+	c := &Controller{logger: klog.Background(), objectFilterEvaluator: evaluator}
+	newDeployment := func(release string, replicas int64) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]any{"namespace": "demo", "name": "web"},
+			"spec":       map[string]any{"replicas": replicas},
+		}}
+		if release != "" {
+			obj.SetAnnotations(map[string]string{"meta.helm.sh/release-name": release})
+		}
+		return obj
+	}
+	objId := util.ObjectIdentifier{
+		GVK:        schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		Resource:   "deployments",
+		ObjectName: cache.NewObjectName("demo", "web"),
+	}
+	byRelease := v1alpha1.DownsyncPolicyClause{DownsyncObjectTest: v1alpha1.DownsyncObjectTest{
+		AnnotationSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"meta.helm.sh/release-name": "x"}}},
+	}}
+	bigOnes := v1alpha1.Expression(`obj.kind == "Deployment" && obj.spec.replicas > 2`)
+	byReplicas := v1alpha1.DownsyncPolicyClause{DownsyncObjectTest: v1alpha1.DownsyncObjectTest{ObjectFilter: &bigOnes}}
+	broken := v1alpha1.Expression(`obj.spec.nosuch > 2`)
+	byBroken := v1alpha1.DownsyncPolicyClause{DownsyncObjectTest: v1alpha1.DownsyncObjectTest{ObjectFilter: &broken}}
+	for _, tc := range []struct {
+		name     string
+		obj      *unstructured.Unstructured
+		clause   v1alpha1.DownsyncPolicyClause
+		expected bool
+	}{
+		{name: "release matches", obj: newDeployment("x", 1), clause: byRelease, expected: true},
+		{name: "other release", obj: newDeployment("y", 1), clause: byRelease, expected: false},
+		{name: "no annotations", obj: newDeployment("", 1), clause: byRelease, expected: false},
+		{name: "enough replicas", obj: newDeployment("", 3), clause: byReplicas, expected: true},
+		{name: "too few replicas", obj: newDeployment("", 2), clause: byReplicas, expected: false},
+		{name: "evaluation error", obj: newDeployment("", 3), clause: byBroken, expected: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			matched, _ := c.testObject(context.Background(), "test", objId, tc.obj, []v1alpha1.DownsyncPolicyClause{tc.clause})
+			if matched != tc.expected {
+				t.Errorf("Expected match=%v, got %v", tc.expected, matched)
+			}
+		})
+	}
+}
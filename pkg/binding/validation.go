@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/celeval"
+	"github.com/kubestellar/kubestellar/pkg/ocm"
+	transportgeneric "github.com/kubestellar/kubestellar/pkg/transport/generic"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+// BindingPolicyValidator checks BindingPolicy objects the same way that
+// the binding controller does, for use at admission time.
+type BindingPolicyValidator struct {
+	clusterSelectionEvaluator *celeval.Evaluator
+	objectFilterEvaluator     *celeval.Evaluator
+	rolloutGateEvaluator      *celeval.Evaluator
+}
+
+func NewBindingPolicyValidator() (*BindingPolicyValidator, error) {
+	clusterSelectionEvaluator, err := ocm.NewClusterSelectionEvaluator()
+	if err != nil {
+		return nil, err
+	}
+	objectFilterEvaluator, err := newObjectFilterEvaluator()
+	if err != nil {
+		return nil, err
+	}
+	rolloutGateEvaluator, err := transportgeneric.NewRolloutGateEvaluator()
+	if err != nil {
+		return nil, err
+	}
+	return &BindingPolicyValidator{clusterSelectionEvaluator: clusterSelectionEvaluator, objectFilterEvaluator: objectFilterEvaluator,
+		rolloutGateEvaluator: rolloutGateEvaluator}, nil
+}
+
+// Validate returns the errors in the given BindingPolicy, if any.
+func (v *BindingPolicyValidator) Validate(policy *v1alpha1.BindingPolicy) []error {
+	return validateBindingPolicySpec(v.clusterSelectionEvaluator, v.objectFilterEvaluator, v.rolloutGateEvaluator, &policy.Spec)
+}
+
+// ValidateNamespaced returns the errors in the given NamespacedBindingPolicy, if any.
+func (v *BindingPolicyValidator) ValidateNamespaced(policy *v1alpha1.NamespacedBindingPolicy) []error {
+	return validateBindingPolicySpec(v.clusterSelectionEvaluator, v.objectFilterEvaluator, v.rolloutGateEvaluator, &policy.Spec)
+}
+
+// validateBindingPolicySpec returns the errors in the given BindingPolicySpec
+// that the API server's schema validation does not catch.
+func validateBindingPolicySpec(clusterSelectionEvaluator, objectFilterEvaluator, rolloutGateEvaluator *celeval.Evaluator, spec *v1alpha1.BindingPolicySpec) []error {
+	errs := ocm.CheckClusterSelectorExpressions(clusterSelectionEvaluator, spec.ClusterSelectorExpressions)
+	errs = append(errs, checkObjectFilters(objectFilterEvaluator, spec.Downsync)...)
+	errs = append(errs, transportgeneric.CheckPropagationWindows(spec.PropagationWindows)...)
+	errs = append(errs, transportgeneric.CheckRolloutStrategy(rolloutGateEvaluator, spec.Rollout)...)
+	errs = append(errs, util.CheckUpsyncClauses(spec.Upsync)...)
+	return append(errs, checkLabelSelectors(spec)...)
+}
+
+// checkLabelSelectors returns one error for each label selector in the given
+// BindingPolicySpec that can not be converted to a labels.Selector.
+func checkLabelSelectors(spec *v1alpha1.BindingPolicySpec) []error {
+	var errs []error
+	check := func(field string, selectors []metav1.LabelSelector) {
+		for idx := range selectors {
+			if _, err := metav1.LabelSelectorAsSelector(&selectors[idx]); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s[%d]: %w", field, idx, err))
+			}
+		}
+	}
+	check("clusterSelectors", spec.ClusterSelectors)
+	check("excludeClusterSelectors", spec.ExcludeClusterSelectors)
+	for idx, clause := range spec.Downsync {
+		check(fmt.Sprintf("downsync[%d].namespaceSelectors", idx), clause.NamespaceSelectors)
+		check(fmt.Sprintf("downsync[%d].objectSelectors", idx), clause.ObjectSelectors)
+		check(fmt.Sprintf("downsync[%d].annotationSelectors", idx), clause.AnnotationSelectors)
+		check(fmt.Sprintf("downsync[%d].excludeObjectSelectors", idx), clause.ExcludeObjectSelectors)
+	}
+	for idx, clause := range spec.Upsync {
+		check(fmt.Sprintf("upsync[%d].objectSelectors", idx), clause.ObjectSelectors)
+	}
+	return errs
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package binding
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestBindingPolicyValidator(t *testing.T) {
+	validator, err := NewBindingPolicyValidator()
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+	badSelector := metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "env", Operator: metav1.LabelSelectorOpIn}}}
+	goodSelector := metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}
+	for _, testCase := range []struct {
+		name       string
+		spec       v1alpha1.BindingPolicySpec
+		expectErrs int
+	}{
+		{name: "valid", spec: v1alpha1.BindingPolicySpec{
+			ClusterSelectors: []metav1.LabelSelector{goodSelector},
+			Downsync:         []v1alpha1.DownsyncPolicyClause{{DownsyncObjectTest: v1alpha1.DownsyncObjectTest{ObjectSelectors: []metav1.LabelSelector{goodSelector}}}},
+		}},
+		{name: "bad-cluster-selector", expectErrs: 1, spec: v1alpha1.BindingPolicySpec{
+			ClusterSelectors: []metav1.LabelSelector{goodSelector, badSelector},
+		}},
+		{name: "bad-downsync-and-upsync-selectors", expectErrs: 2, spec: v1alpha1.BindingPolicySpec{
+			Downsync: []v1alpha1.DownsyncPolicyClause{{DownsyncObjectTest: v1alpha1.DownsyncObjectTest{NamespaceSelectors: []metav1.LabelSelector{badSelector}}}},
+			Upsync:   []v1alpha1.UpsyncPolicyClause{{ObjectSelectors: []metav1.LabelSelector{badSelector}}},
+		}},
+		{name: "upsync-resources", expectErrs: 2, spec: v1alpha1.BindingPolicySpec{
+			Upsync: []v1alpha1.UpsyncPolicyClause{
+				{Resources: []string{"configmaps"}},
+				{Resources: []string{"*"}},
+				{Resources: []string{"secrets"}, Namespaces: []string{"demo"}},
+			},
+		}},
+		{name: "good-propagation-window", spec: v1alpha1.BindingPolicySpec{
+			PropagationWindows: []v1alpha1.PropagationWindow{{Schedule: "0 2 * * *", TimeZone: "Europe/Paris", Duration: metav1.Duration{Duration: time.Hour}}},
+		}},
+		{name: "bad-rollout", expectErrs: 2, spec: v1alpha1.BindingPolicySpec{
+			Rollout: &v1alpha1.RolloutStrategy{
+				Waves: []v1alpha1.RolloutWave{{ClusterSelector: &goodSelector}, {ClusterSelector: &badSelector}},
+				Gate:  v1alpha1.RolloutGate{Expression: "clusters.size() +"},
+			},
+		}},
+		{name: "bad-propagation-window", expectErrs: 1, spec: v1alpha1.BindingPolicySpec{
+			PropagationWindows: []v1alpha1.PropagationWindow{{Schedule: "0 2 * *", Duration: metav1.Duration{Duration: time.Hour}}},
+		}},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			errs := validator.Validate(&v1alpha1.BindingPolicy{Spec: testCase.spec})
+			if len(errs) != testCase.expectErrs {
+				t.Errorf("Expected %d errors, got %v", testCase.expectErrs, errs)
+			}
+		})
+	}
+}
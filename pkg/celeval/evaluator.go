@@ -0,0 +1,164 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package celeval holds the CEL machinery that is shared by the various
+// KubeStellar controllers that evaluate user-supplied `Expression`s.
+// Each user of this package defines its own set of variables.
+package celeval
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+
+	"k8s.io/utils/lru"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+// programCacheSize bounds the number of compiled programs that an Evaluator remembers.
+const programCacheSize = 1024
+
+// Evaluator holds a CEL environment and provides methods to check and
+// evaluate expressions in that environment.
+// An Evaluator may be used concurrently.
+type Evaluator struct {
+	env *cel.Env
+
+	// programs caches the compiled programs, keyed by expression.
+	// Only successful compilations are cached.
+	programs *lru.Cache
+}
+
+// NewEvaluator initializes a CEL environment with the given options,
+// which typically declare the variables available to expressions.
+func NewEvaluator(opts ...cel.EnvOption) (*Evaluator, error) {
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %v", err)
+	}
+
+	return &Evaluator{env: env, programs: lru.New(programCacheSize)}, nil
+}
+
+// Compile parses and type-checks the given expression.
+func (e *Evaluator) Compile(expression v1alpha1.Expression) (*cel.Ast, error) {
+	ast, issues := e.env.Parse(string(expression))
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("failed to parse expression: %w", issues.Err())
+	}
+
+	checked, issues := e.env.Check(ast)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("failed to check expression: %w", issues.Err())
+	}
+
+	return checked, nil
+}
+
+// CheckExpression checks if an expression is valid.
+// If the expression is nil, it returns nil.
+func (e *Evaluator) CheckExpression(expression *v1alpha1.Expression) error {
+	if expression == nil {
+		return nil
+	}
+
+	_, err := e.Compile(*expression)
+	return err
+}
+
+// CheckBoolExpression checks that an expression is valid and that
+// its type is either boolean or not known until evaluation time.
+func (e *Evaluator) CheckBoolExpression(expression v1alpha1.Expression) error {
+	checked, err := e.Compile(expression)
+	if err != nil {
+		return err
+	}
+
+	outputType := checked.OutputType()
+	if !outputType.IsExactType(types.BoolType) && !outputType.IsExactType(types.DynType) {
+		return fmt.Errorf("expression must evaluate to a bool but has type %s", outputType)
+	}
+
+	return nil
+}
+
+// Program compiles the given expression into a program that can be evaluated repeatedly.
+// Recently compiled programs are reused.
+func (e *Evaluator) Program(expression v1alpha1.Expression) (cel.Program, error) {
+	if prog, have := e.programs.Get(expression); have {
+		return prog.(cel.Program), nil
+	}
+	checked, err := e.Compile(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	prog, err := e.env.Program(checked)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create program: %w", err)
+	}
+
+	e.programs.Add(expression, prog)
+	return prog, nil
+}
+
+// Evaluate takes an expression and the values of the variables,
+// and returns the evaluation of the expression in that context.
+func (e *Evaluator) Evaluate(expression v1alpha1.Expression, vars map[string]interface{}) (ref.Val, error) {
+	prog, err := e.Program(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	// evaluate the expression with the given variables
+	result, _, err := prog.Eval(vars)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate expression: %w", err)
+	}
+
+	return result, nil
+}
+
+// EvaluateBool evaluates the given expression and requires the result to be a boolean.
+// A result of `null` is treated as false.
+func (e *Evaluator) EvaluateBool(expression v1alpha1.Expression, vars map[string]interface{}) (bool, error) {
+	result, err := e.Evaluate(expression, vars)
+	if err != nil {
+		return false, err
+	}
+
+	if result.Type() == types.NullType {
+		return false, nil
+	}
+	typed, is := result.(types.Bool)
+	if !is {
+		return false, fmt.Errorf("expression evaluated to a %s rather than a bool", result.Type().TypeName())
+	}
+	return bool(typed), nil
+}
+
+// NonNilStringMap returns the given map, or an empty map if the given one is nil,
+// so that it can be the value of a CEL variable of map type.
+func NonNilStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
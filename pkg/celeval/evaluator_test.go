@@ -0,0 +1,51 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package celeval
+
+import (
+	"testing"
+
+	"github.com/google/cel-go/cel"
+)
+
+func TestProgramCache(t *testing.T) {
+	evaluator, err := NewEvaluator(cel.Variable("x", cel.IntType))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prog1, err := evaluator.Program("x > 1")
+	if err != nil {
+		t.Fatalf("Failed to compile: %s", err)
+	}
+	prog2, err := evaluator.Program("x > 1")
+	if err != nil {
+		t.Fatalf("Failed to compile: %s", err)
+	}
+	if prog1 != prog2 {
+		t.Errorf("Expected the compiled program to be reused")
+	}
+	if _, err := evaluator.Program("x >"); err == nil {
+		t.Errorf("Expected an error for an invalid expression")
+	}
+	if _, err := evaluator.Program("x >"); err == nil {
+		t.Errorf("Expected an error for an invalid expression the second time")
+	}
+	match, err := evaluator.EvaluateBool("x > 1", map[string]any{"x": 2})
+	if err != nil || !match {
+		t.Errorf("Expected true, got %v, %v", match, err)
+	}
+}
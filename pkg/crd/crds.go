@@ -47,6 +47,8 @@ import (
 var crdNames = sets.New(
 	"bindings.control.kubestellar.io",
 	"bindingpolicies.control.kubestellar.io",
+	"namespacedbindingpolicies.control.kubestellar.io",
+	"clustergrants.control.kubestellar.io",
 	"customtransforms.control.kubestellar.io",
 	"statuscollectors.control.kubestellar.io",
 	"combinedstatuses.control.kubestellar.io",
@@ -16,8 +16,12 @@ limitations under the License.
 
 package jsonpath
 
-// This file implements JSONPath querying for the small subset
-// of JSONPath that this package currently supports.
+import (
+	"slices"
+	"sort"
+)
+
+// This file implements JSONPath querying.
 
 // The algorithms and data structures in here are designed for serialized usage,
 // not concurrent usage.
@@ -26,6 +30,8 @@ package jsonpath
 // to a nil `any`.
 // That is: `bool`, `float64`, `string`, `nil`, `[]any`, or `map[string]any` --- where those
 // nested `any` have the same restriction.
+// Integers may also appear as `int64`, as in the content of a Kubernetes
+// `unstructured.Unstructured`.
 type JSONValue = any
 
 // Node is a JSON document node.
@@ -36,6 +42,10 @@ type Node interface {
 
 	// Remove deletes the node from the JSON document.
 	Remove()
+
+	// Set puts the given value in the JSON document at this node,
+	// replacing whatever was there.
+	Set(JSONValue)
 }
 
 // RootNode is the Node implementation to use for the document's root node.
@@ -58,6 +68,10 @@ func (vn *RootNode) Remove() {
 	vn.Value = nil
 }
 
+func (vn *RootNode) Set(value JSONValue) {
+	vn.Value = &value
+}
+
 // FieldNode is a member of a JSON object
 type FieldNode struct {
 	Object map[string]any
@@ -75,20 +89,461 @@ func (fn FieldNode) Remove() {
 	delete(fn.Object, fn.Key)
 }
 
+func (fn FieldNode) Set(value JSONValue) {
+	fn.Object[fn.Key] = value
+}
+
+// ElementNode is an element of a JSON array.
+// It is produced by query evaluation. The element is identified by its index
+// in the array as it was when the query was evaluated, so that removing
+// some elements does not disturb the identity of the others.
+// A removed element is no longer in the document, and can not be set.
+type ElementNode struct {
+	array *arrayState
+	index int
+}
+
+var _ Node = ElementNode{}
+
+// arrayState tracks the elements removed from an array since a query selected from it.
+type arrayState struct {
+	// parent is the node whose value is the array.
+	// Removing an element replaces that value with a new array.
+	parent Node
+
+	// removed holds the original indices of the removed elements, in increasing order.
+	removed []int
+}
+
+// position returns the current index of the element, and whether it is still there.
+func (en ElementNode) position() (int, bool) {
+	numBefore, removed := slices.BinarySearch(en.array.removed, en.index)
+	return en.index - numBefore, !removed
+}
+
+// currentArray returns the current value of the array.
+func (en ElementNode) currentArray() []any {
+	val, _ := en.array.parent.Get()
+	arr, _ := val.([]any)
+	return arr
+}
+
+func (en ElementNode) Get() (JSONValue, bool) {
+	pos, present := en.position()
+	arr := en.currentArray()
+	if !present || pos >= len(arr) {
+		return nil, false
+	}
+	return arr[pos], true
+}
+
+func (en ElementNode) Remove() {
+	pos, present := en.position()
+	arr := en.currentArray()
+	if !present || pos >= len(arr) {
+		return
+	}
+	// make a new array rather than shift the elements of the old one
+	newArr := make([]any, 0, len(arr)-1)
+	newArr = append(newArr, arr[:pos]...)
+	newArr = append(newArr, arr[pos+1:]...)
+	en.array.parent.Set(newArr)
+	numBefore, _ := slices.BinarySearch(en.array.removed, en.index)
+	en.array.removed = slices.Insert(en.array.removed, numBefore, en.index)
+}
+
+func (en ElementNode) Set(value JSONValue) {
+	pos, present := en.position()
+	arr := en.currentArray()
+	if !present || pos >= len(arr) {
+		return
+	}
+	arr[pos] = value
+}
+
 // QueryValue applies `query` to `node`, invoking `yield` on each
 // of the nodes that the query produces, in a context where the document
-// root is `root`.
+// root is `node`.
+// The whole query is evaluated before `yield` is first called,
+// so `yield` may modify the document (e.g., by removing the node).
 func QueryValue(query Query, node Node, yield func(Node)) {
-	for _, fieldName := range query {
-		objA, ok := node.Get()
-		if !ok {
-			return
+	ctx := newEvalContext(node)
+	for _, selected := range ctx.evalQuery(query, node, false) {
+		yield(selected)
+	}
+}
+
+// QueryOrCreate is like QueryValue except that a segment of only member name selectors
+// produces the named members whether or not they are present, and creates
+// the object that it selects from, as an empty object, if that is absent.
+// Thus a node yielded may or may not be present in the document.
+func QueryOrCreate(query Query, node Node, yield func(Node)) {
+	ctx := newEvalContext(node)
+	for _, selected := range ctx.evalQuery(query, node, true) {
+		yield(selected)
+	}
+}
+
+// evalContext holds what is needed while evaluating a query.
+type evalContext struct {
+	// root is the node that `$` refers to
+	root Node
+
+	// arrays holds the arrayState for each array visited so far,
+	// indexed by the address of the first element.
+	// This is so that multiple nodes for the same element share the same arrayState.
+	arrays map[*any]*arrayState
+}
+
+func newEvalContext(root Node) *evalContext {
+	return &evalContext{root: root, arrays: map[*any]*arrayState{}}
+}
+
+// evalQuery returns the nodes that the query produces when applied to the given node.
+// When `create` is true, absent objects are created as described for QueryOrCreate.
+func (ctx *evalContext) evalQuery(query Query, node Node, create bool) []Node {
+	nodes := []Node{node}
+	for _, segment := range query {
+		nameOnly := !segment.Descendant && allNames(segment.Selectors)
+		var next []Node
+		for _, node := range nodes {
+			if create && nameOnly {
+				ctx.selectNamesOrCreate(node, segment.Selectors, func(child Node) { next = append(next, child) })
+			} else {
+				ctx.applySegment(segment, node, func(child Node) { next = append(next, child) })
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+func allNames(selectors []Selector) bool {
+	for _, selector := range selectors {
+		if _, isName := selector.(NameSelector); !isName {
+			return false
+		}
+	}
+	return true
+}
+
+// selectNamesOrCreate applies member name selectors to a node, creating
+// the node as an empty object if it is absent, and producing absent members too.
+func (ctx *evalContext) selectNamesOrCreate(node Node, selectors []Selector, yield func(Node)) {
+	val, present := node.Get()
+	if !present {
+		val = map[string]any{}
+		node.Set(val)
+	}
+	obj, isObject := val.(map[string]any)
+	if !isObject {
+		return
+	}
+	for _, selector := range selectors {
+		yield(FieldNode{obj, selector.(NameSelector).Name})
+	}
+}
+
+// applySegment yields the nodes that the segment produces from the given node.
+func (ctx *evalContext) applySegment(segment Segment, node Node, yield func(Node)) {
+	if !segment.Descendant {
+		for _, selector := range segment.Selectors {
+			selector.selectFrom(ctx, node, yield)
+		}
+		return
+	}
+	ctx.visitDescendants(node, func(visited Node) {
+		for _, selector := range segment.Selectors {
+			selector.selectFrom(ctx, visited, yield)
 		}
-		objM, ok := objA.(map[string]any)
-		if !ok {
+	})
+}
+
+// visitDescendants calls `visit` on the given node and then on each of its
+// descendants, in document order.
+func (ctx *evalContext) visitDescendants(node Node, visit func(Node)) {
+	visit(node)
+	ctx.children(node, func(child Node) { ctx.visitDescendants(child, visit) })
+}
+
+// children yields the members of an object node, in order of name,
+// or the elements of an array node, in order.
+// Nothing is yielded for any other sort of node.
+func (ctx *evalContext) children(node Node, yield func(Node)) {
+	val, present := node.Get()
+	if !present {
+		return
+	}
+	switch typed := val.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(typed))
+		for key := range typed {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			yield(FieldNode{typed, key})
+		}
+	case []any:
+		if len(typed) == 0 {
 			return
 		}
-		node = FieldNode{objM, fieldName}
+		state := ctx.arrayStateFor(node, typed)
+		for index := range typed {
+			yield(ElementNode{state, index})
+		}
+	}
+}
+
+// element returns the node for the element at the given index of the given array node,
+// if the index is in range.
+func (ctx *evalContext) element(node Node, index int) (Node, bool) {
+	val, present := node.Get()
+	if !present {
+		return nil, false
+	}
+	arr, isArray := val.([]any)
+	if !isArray {
+		return nil, false
+	}
+	if index < 0 {
+		index += len(arr)
+	}
+	if index < 0 || index >= len(arr) {
+		return nil, false
+	}
+	return ElementNode{ctx.arrayStateFor(node, arr), index}, true
+}
+
+func (ctx *evalContext) arrayStateFor(node Node, arr []any) *arrayState {
+	key := &arr[0]
+	state, has := ctx.arrays[key]
+	if !has {
+		state = &arrayState{parent: node}
+		ctx.arrays[key] = state
+	}
+	return state
+}
+
+func (sel NameSelector) selectFrom(ctx *evalContext, node Node, yield func(Node)) {
+	val, present := node.Get()
+	if !present {
+		return
+	}
+	if obj, isObject := val.(map[string]any); isObject {
+		if _, has := obj[sel.Name]; has {
+			yield(FieldNode{obj, sel.Name})
+		}
+	}
+}
+
+func (WildcardSelector) selectFrom(ctx *evalContext, node Node, yield func(Node)) {
+	ctx.children(node, yield)
+}
+
+func (sel IndexSelector) selectFrom(ctx *evalContext, node Node, yield func(Node)) {
+	if element, ok := ctx.element(node, sel.Index); ok {
+		yield(element)
+	}
+}
+
+// selectFrom follows the normative algorithm in RFC 9535 section 2.3.4.2.2.
+func (sel SliceSelector) selectFrom(ctx *evalContext, node Node, yield func(Node)) {
+	val, present := node.Get()
+	if !present {
+		return
+	}
+	arr, isArray := val.([]any)
+	if !isArray || sel.Step == 0 {
+		return
+	}
+	length := len(arr)
+	normalize := func(index int) int {
+		if index >= 0 {
+			return index
+		}
+		return length + index
+	}
+	var start, end int
+	if sel.Step > 0 {
+		start, end = 0, length
+	} else {
+		start, end = length-1, -length-1
+	}
+	if sel.Start != nil {
+		start = *sel.Start
+	}
+	if sel.End != nil {
+		end = *sel.End
+	}
+	start, end = normalize(start), normalize(end)
+	if sel.Step > 0 {
+		lower, upper := min(max(start, 0), length), min(max(end, 0), length)
+		for index := lower; index < upper; index += sel.Step {
+			element, _ := ctx.element(node, index)
+			yield(element)
+		}
+	} else {
+		upper, lower := min(max(start, -1), length-1), min(max(end, -1), length-1)
+		for index := upper; lower < index; index += sel.Step {
+			element, _ := ctx.element(node, index)
+			yield(element)
+		}
+	}
+}
+
+func (sel FilterSelector) selectFrom(ctx *evalContext, node Node, yield func(Node)) {
+	ctx.children(node, func(child Node) {
+		if sel.expr.test(ctx, child) {
+			yield(child)
+		}
+	})
+}
+
+func (expr orExpr) test(ctx *evalContext, current Node) bool {
+	for _, operand := range expr {
+		if operand.test(ctx, current) {
+			return true
+		}
+	}
+	return false
+}
+
+func (expr andExpr) test(ctx *evalContext, current Node) bool {
+	for _, operand := range expr {
+		if !operand.test(ctx, current) {
+			return false
+		}
+	}
+	return true
+}
+
+func (expr notExpr) test(ctx *evalContext, current Node) bool {
+	return !expr.operand.test(ctx, current)
+}
+
+func (expr existenceExpr) test(ctx *evalContext, current Node) bool {
+	return len(expr.query.eval(ctx, current)) > 0
+}
+
+// test follows RFC 9535 section 2.3.5.2.2.
+func (expr comparisonExpr) test(ctx *evalContext, current Node) bool {
+	left, leftPresent := expr.left.value(ctx, current)
+	right, rightPresent := expr.right.value(ctx, current)
+	equal := func() bool {
+		if !leftPresent || !rightPresent {
+			return leftPresent == rightPresent
+		}
+		return jsonEqual(left, right)
+	}
+	less := func(left, right JSONValue) bool {
+		if !leftPresent || !rightPresent {
+			return false
+		}
+		return jsonLess(left, right)
+	}
+	switch expr.op {
+	case opEQ:
+		return equal()
+	case opNE:
+		return !equal()
+	case opLT:
+		return less(left, right)
+	case opLE:
+		return less(left, right) || equal()
+	case opGT:
+		return less(right, left)
+	case opGE:
+		return less(right, left) || equal()
+	}
+	return false
+}
+
+func (lit literal) value(ctx *evalContext, current Node) (JSONValue, bool) {
+	return lit.val, true
+}
+
+// value returns the value of the node that the singular query produces, if any.
+func (query filterQuery) value(ctx *evalContext, current Node) (JSONValue, bool) {
+	nodes := query.eval(ctx, current)
+	if len(nodes) != 1 {
+		return nil, false
+	}
+	return nodes[0].Get()
+}
+
+func (query filterQuery) eval(ctx *evalContext, current Node) []Node {
+	start := ctx.root
+	if query.relative {
+		start = current
+	}
+	return ctx.evalQuery(query.query, start, false)
+}
+
+// toFloat returns the given value as a float64, if it is a number.
+func toFloat(val JSONValue) (float64, bool) {
+	switch typed := val.(type) {
+	case float64:
+		return typed, true
+	case int64:
+		return float64(typed), true
+	case int:
+		return float64(typed), true
+	case int32:
+		return float64(typed), true
+	case float32:
+		return float64(typed), true
+	}
+	return 0, false
+}
+
+// jsonEqual tells whether two JSON values are equal, comparing numbers by value.
+func jsonEqual(left, right JSONValue) bool {
+	if leftNum, isNum := toFloat(left); isNum {
+		rightNum, isNum := toFloat(right)
+		return isNum && leftNum == rightNum
+	}
+	switch leftTyped := left.(type) {
+	case nil:
+		return right == nil
+	case bool, string:
+		return left == right
+	case []any:
+		rightTyped, isArray := right.([]any)
+		if !isArray || len(leftTyped) != len(rightTyped) {
+			return false
+		}
+		for idx := range leftTyped {
+			if !jsonEqual(leftTyped[idx], rightTyped[idx]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		rightTyped, isObject := right.(map[string]any)
+		if !isObject || len(leftTyped) != len(rightTyped) {
+			return false
+		}
+		for key, leftVal := range leftTyped {
+			rightVal, has := rightTyped[key]
+			if !has || !jsonEqual(leftVal, rightVal) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// jsonLess tells whether left < right; only numbers and strings are ordered.
+func jsonLess(left, right JSONValue) bool {
+	if leftNum, isNum := toFloat(left); isNum {
+		rightNum, isNum := toFloat(right)
+		return isNum && leftNum < rightNum
+	}
+	if leftString, isString := left.(string); isString {
+		rightString, isString := right.(string)
+		return isString && leftString < rightString
 	}
-	yield(node)
+	return false
 }
@@ -46,6 +46,84 @@ func TestEval(t *testing.T) {
 	}
 }
 
+func TestEvalRFC9535(t *testing.T) {
+	var root RootNode
+	err := json.Unmarshal([]byte(`{"spec": {"containers": [
+		{"name": "app", "image": "app:1", "ports": [80, 443]},
+		{"name": "istio-proxy", "image": "proxy:1", "ports": [15001]},
+		{"name": "log", "image": "log:1"}], "replicas": 3}}`), &root.Value)
+	if err != nil {
+		t.Fatalf("Failed to parse doc, err=%s", err.Error())
+	}
+	for _, testCase := range []struct {
+		path     string
+		expected []JSONValue
+	}{
+		{`$.spec.containers[0].name`, []JSONValue{"app"}},
+		{`$.spec.containers[-1].name`, []JSONValue{"log"}},
+		{`$.spec.containers[5].name`, []JSONValue{}},
+		{`$.spec.containers[*].image`, []JSONValue{"app:1", "proxy:1", "log:1"}},
+		{`$.spec.containers.*.name`, []JSONValue{"app", "istio-proxy", "log"}},
+		{`$.spec.containers[1:].name`, []JSONValue{"istio-proxy", "log"}},
+		{`$.spec.containers[::-2].name`, []JSONValue{"log", "app"}},
+		{`$..ports[*]`, []JSONValue{float64(80), float64(443), float64(15001)}},
+		{`$.spec.containers[?@.name=="istio-proxy"].image`, []JSONValue{"proxy:1"}},
+		{`$.spec.containers[?@.ports && @.name != 'app'].name`, []JSONValue{"istio-proxy"}},
+		{`$.spec.containers[?!@.ports].name`, []JSONValue{"log"}},
+		{`$.spec.containers[?@.ports[0] < 100].name`, []JSONValue{"app"}},
+		{`$.spec.containers[?@.name > 'c' && $.spec.replicas == 3].name`, []JSONValue{"istio-proxy", "log"}},
+		{`$.spec[?@ == 3]`, []JSONValue{float64(3)}},
+	} {
+		actual := GetQuery(&root, testCase.path)
+		if !jsonEqualities.DeepEqual(testCase.expected, actual) {
+			t.Errorf("For %s, expected %#v, got %#v", testCase.path, testCase.expected, actual)
+		}
+	}
+}
+
+func TestRemoveFromArrays(t *testing.T) {
+	var root RootNode
+	err := json.Unmarshal([]byte(`{"containers": [
+		{"name": "a", "image": "a:1"}, {"name": "istio-proxy"}, {"name": "b", "image": "b:1"}, {"name": "istio-proxy"}],
+		"matrix": [[1, 2], [3, 4]]}`), &root.Value)
+	if err != nil {
+		t.Fatalf("Failed to parse doc, err=%s", err.Error())
+	}
+	for _, pathS := range []string{`$.containers[?@.name=="istio-proxy"]`, `$.containers[*].image`, `$.matrix[*][0]`, `$.matrix[0, 0]`} {
+		query, err := ParseQuery(pathS)
+		if err != nil {
+			t.Fatalf("Failed to parse %q, err=%s", pathS, err.Error())
+		}
+		QueryValue(query, &root, Node.Remove)
+	}
+	expected := map[string]any{
+		"containers": []any{map[string]any{"name": "a"}, map[string]any{"name": "b"}},
+		"matrix":     []any{[]any{float64(4)}},
+	}
+	if !jsonEqualities.DeepEqual(expected, *root.Value) {
+		t.Errorf("Expected %#v, got %#v", expected, *root.Value)
+	}
+}
+
+func TestQueryOrCreate(t *testing.T) {
+	var root RootNode
+	err := json.Unmarshal([]byte(`{"metadata": {"name": "x"}, "spec": 1}`), &root.Value)
+	if err != nil {
+		t.Fatalf("Failed to parse doc, err=%s", err.Error())
+	}
+	for _, pathS := range []string{`$.metadata.labels["a"]`, `$.metadata.name`, `$.spec.replicas`, `$.status[*].x`} {
+		query, err := ParseQuery(pathS)
+		if err != nil {
+			t.Fatalf("Failed to parse %q, err=%s", pathS, err.Error())
+		}
+		QueryOrCreate(query, &root, func(node Node) { node.Set("y") })
+	}
+	expected := map[string]any{"metadata": map[string]any{"name": "y", "labels": map[string]any{"a": "y"}}, "spec": float64(1)}
+	if !jsonEqualities.DeepEqual(expected, *root.Value) {
+		t.Errorf("Expected %#v, got %#v", expected, *root.Value)
+	}
+}
+
 var jsonEqualities = k8sreflect.Equalities{}
 
 func GetQuery(root Node, pathS string) []JSONValue {
@@ -18,21 +18,18 @@ package jsonpath
 
 import (
 	"fmt"
-	"io"
-	"strings"
+	"strconv"
+	"unicode/utf8"
 
 	"github.com/dop251/goja"
 	js_ast "github.com/dop251/goja/ast"
 )
 
-// Query represents a parsed query in a very restricted subset of JSONPath (RFC 9535).
-// The only supported segment functionality is selecting one definite member of a JSON object.
-type Query []string
-
-// ParseQuery parses a very restricted form of JSONPath expression into a Query.
-// This parser only accepts segments of the form `.name` or `[string]`.
-// A string must be enclosed with double-quotes (`"`).
-// No whitespace is allowed.
+// ParseQuery parses a JSONPath expression (RFC 9535) into a Query.
+// All of the RFC's segments and selectors are supported: member names,
+// wildcards, array indices and slices, descendant segments, and filters.
+// Filter expressions can use comparisons, existence tests, `&&`, `||`, `!`,
+// and parentheses, but not function extensions.
 func ParseQuery(queryS string) (Query, error) {
 	lexer, err := NewLexer(queryS, 0)
 	if err != nil {
@@ -52,8 +49,12 @@ func ParseQuery(queryS string) (Query, error) {
 // Lexer is intended ONLY for serialized usage, not concurrency.
 type Lexer struct {
 	source string
-	reader io.RuneReader
+	lexerState
+}
 
+// lexerState is the part of a Lexer that changes as it scans,
+// saved in order to back up after looking ahead.
+type lexerState struct {
 	chr     rune // next rune to process
 	chrPos  int  // index of start of chr
 	nextPos int  // index after chr
@@ -62,11 +63,12 @@ type Lexer struct {
 
 func NewLexer(source string, startPos int) (*Lexer, error) {
 	lxr := &Lexer{
-		source:  source,
-		reader:  strings.NewReader(source),
-		chrPos:  startPos,
-		nextPos: startPos,
-		eof:     startPos >= len(source),
+		source: source,
+		lexerState: lexerState{
+			chrPos:  startPos,
+			nextPos: startPos,
+			eof:     startPos >= len(source),
+		},
 	}
 	err := lxr.advance()
 	return lxr, err
@@ -82,64 +84,390 @@ func (lxr *Lexer) GetPosition() (int, bool) { return lxr.chrPos, lxr.eof }
 // or EOF.
 func (lxr *Lexer) ScanQuery() (Query, error) {
 	query := Query{}
-	if lxr.chr != '$' {
+	if lxr.chr != '$' || lxr.eof {
 		return query, fmt.Errorf("syntax error at %d: missing root identifier (dollar sign)", lxr.chrPos)
 	}
 	if err := lxr.advance(); err != nil {
 		return query, err
 	}
-	for !lxr.eof {
-		if lxr.chr == '.' {
-			if err := lxr.advance(); err != nil {
-				return query, err
-			}
-			if !isNameFirst(lxr.chr) {
-				return query, fmt.Errorf("syntax error at %d: expected member-name-shorthand, got %q", lxr.chrPos, lxr.chr)
-			}
-			if next, err := lxr.nextIdentifier(); err != nil {
-				return query, err
-			} else {
-				query = append(query, next)
-			}
-		} else if lxr.chr == '[' {
-			if err := lxr.advance(); err != nil {
-				return query, err
-			}
-			if lxr.chr != '"' {
-				return query, fmt.Errorf("syntax error at %d: expected open quote, got %q", lxr.chrPos, lxr.chr)
-			}
-			if next, err := lxr.nextString(); err != nil {
-				return query, err
-			} else {
-				query = append(query, next)
-			}
-			if lxr.chr != ']' {
-				return query, fmt.Errorf("syntax error at %d: missing close bracket, got %q", lxr.chrPos, lxr.chr)
-			}
+	return lxr.scanSegments()
+}
+
+// scanSegments consumes as many segments as are available.
+// Whitespace is consumed only if followed by a segment.
+func (lxr *Lexer) scanSegments() (Query, error) {
+	query := Query{}
+	for {
+		saved := lxr.lexerState
+		if err := lxr.skipSpace(); err != nil {
+			return query, err
+		}
+		if lxr.eof || lxr.chr != '.' && lxr.chr != '[' {
+			lxr.lexerState = saved
+			return query, nil
+		}
+		segment, err := lxr.scanSegment()
+		if err != nil {
+			return query, err
+		}
+		query = append(query, segment)
+	}
+}
+
+// scanSegment consumes one segment; the Lexer is looking at its first character.
+func (lxr *Lexer) scanSegment() (Segment, error) {
+	if lxr.chr == '[' {
+		selectors, err := lxr.scanBracketedSelection()
+		return Segment{Selectors: selectors}, err
+	}
+	// lxr.chr == '.'
+	if err := lxr.advance(); err != nil {
+		return Segment{}, err
+	}
+	descendant := false
+	if !lxr.eof && lxr.chr == '.' {
+		descendant = true
+		if err := lxr.advance(); err != nil {
+			return Segment{}, err
+		}
+		if !lxr.eof && lxr.chr == '[' {
+			selectors, err := lxr.scanBracketedSelection()
+			return Segment{Descendant: true, Selectors: selectors}, err
+		}
+	}
+	if lxr.eof {
+		return Segment{}, fmt.Errorf("syntax error at %d: expected member-name-shorthand or wildcard, got EOF", lxr.chrPos)
+	}
+	if lxr.chr == '*' {
+		err := lxr.advance()
+		return Segment{Descendant: descendant, Selectors: []Selector{WildcardSelector{}}}, err
+	}
+	if !isNameFirst(lxr.chr) {
+		return Segment{}, fmt.Errorf("syntax error at %d: expected member-name-shorthand, got %q", lxr.chrPos, lxr.chr)
+	}
+	name, err := lxr.nextIdentifier()
+	return Segment{Descendant: descendant, Selectors: []Selector{NameSelector{Name: name}}}, err
+}
+
+// scanBracketedSelection consumes a bracketed list of selectors;
+// the Lexer is looking at the open bracket.
+func (lxr *Lexer) scanBracketedSelection() ([]Selector, error) {
+	var selectors []Selector
+	if err := lxr.advance(); err != nil {
+		return nil, err
+	}
+	for {
+		if err := lxr.skipSpace(); err != nil {
+			return nil, err
+		}
+		selector, err := lxr.scanSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+		if err := lxr.skipSpace(); err != nil {
+			return nil, err
+		}
+		if lxr.eof {
+			return nil, fmt.Errorf("syntax error at %d: missing close bracket", lxr.chrPos)
+		}
+		switch lxr.chr {
+		case ',':
 			if err := lxr.advance(); err != nil {
-				return query, err
+				return nil, err
 			}
-		} else {
+		case ']':
+			return selectors, lxr.advance()
+		default:
+			return nil, fmt.Errorf("syntax error at %d: missing close bracket, got %q", lxr.chrPos, lxr.chr)
+		}
+	}
+}
+
+func (lxr *Lexer) scanSelector() (Selector, error) {
+	if lxr.eof {
+		return nil, fmt.Errorf("syntax error at %d: expected selector, got EOF", lxr.chrPos)
+	}
+	switch {
+	case lxr.chr == '"' || lxr.chr == '\'':
+		name, err := lxr.nextString()
+		return NameSelector{Name: name}, err
+	case lxr.chr == '*':
+		return WildcardSelector{}, lxr.advance()
+	case lxr.chr == '?':
+		if err := lxr.advance(); err != nil {
+			return nil, err
+		}
+		if err := lxr.skipSpace(); err != nil {
+			return nil, err
+		}
+		expr, err := lxr.scanLogicalOr()
+		return FilterSelector{expr: expr}, err
+	case lxr.chr == '-' || lxr.chr == ':' || isDigit(lxr.chr):
+		return lxr.scanIndexOrSlice()
+	}
+	return nil, fmt.Errorf("syntax error at %d: expected selector, got %q", lxr.chrPos, lxr.chr)
+}
+
+func (lxr *Lexer) scanIndexOrSlice() (Selector, error) {
+	// scanOptionalInt consumes an int, if one is there, and following whitespace.
+	scanOptionalInt := func() (*int, error) {
+		if lxr.eof || lxr.chr != '-' && !isDigit(lxr.chr) {
+			return nil, nil
+		}
+		val, err := lxr.nextInt()
+		if err != nil {
+			return nil, err
+		}
+		return &val, lxr.skipSpace()
+	}
+	start, err := scanOptionalInt()
+	if err != nil {
+		return nil, err
+	}
+	if lxr.eof || lxr.chr != ':' {
+		if start == nil {
+			return nil, fmt.Errorf("syntax error at %d: expected index", lxr.chrPos)
+		}
+		return IndexSelector{Index: *start}, nil
+	}
+	if err := lxr.advance(); err != nil {
+		return nil, err
+	}
+	if err := lxr.skipSpace(); err != nil {
+		return nil, err
+	}
+	end, err := scanOptionalInt()
+	if err != nil {
+		return nil, err
+	}
+	slice := SliceSelector{Start: start, End: end, Step: 1}
+	if lxr.eof || lxr.chr != ':' {
+		return slice, nil
+	}
+	if err := lxr.advance(); err != nil {
+		return nil, err
+	}
+	if err := lxr.skipSpace(); err != nil {
+		return nil, err
+	}
+	step, err := scanOptionalInt()
+	if step != nil {
+		slice.Step = *step
+	}
+	return slice, err
+}
+
+func (lxr *Lexer) scanLogicalOr() (logicalExpr, error) {
+	return lxr.scanLogicalSequence("||", lxr.scanLogicalAnd, func(operands []logicalExpr) logicalExpr { return orExpr(operands) })
+}
+
+func (lxr *Lexer) scanLogicalAnd() (logicalExpr, error) {
+	return lxr.scanLogicalSequence("&&", lxr.scanBasicExpr, func(operands []logicalExpr) logicalExpr { return andExpr(operands) })
+}
+
+// scanLogicalSequence consumes one or more operands separated by the given operator.
+func (lxr *Lexer) scanLogicalSequence(operator string, scanOperand func() (logicalExpr, error), combine func([]logicalExpr) logicalExpr) (logicalExpr, error) {
+	var operands []logicalExpr
+	for {
+		operand, err := scanOperand()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+		saved := lxr.lexerState
+		if err := lxr.skipSpace(); err != nil {
+			return nil, err
+		}
+		if !lxr.hasPrefix(operator) {
+			lxr.lexerState = saved
 			break
 		}
+		if err := lxr.advanceBy(len(operator)); err != nil {
+			return nil, err
+		}
+		if err := lxr.skipSpace(); err != nil {
+			return nil, err
+		}
 	}
-	return query, nil
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return combine(operands), nil
+}
+
+func (lxr *Lexer) scanBasicExpr() (logicalExpr, error) {
+	if lxr.eof {
+		return nil, fmt.Errorf("syntax error at %d: expected logical expression, got EOF", lxr.chrPos)
+	}
+	if lxr.chr == '!' {
+		if err := lxr.advance(); err != nil {
+			return nil, err
+		}
+		if err := lxr.skipSpace(); err != nil {
+			return nil, err
+		}
+		if !lxr.eof && lxr.chr == '(' {
+			operand, err := lxr.scanParenExpr()
+			return notExpr{operand}, err
+		}
+		if lxr.eof || lxr.chr != '@' && lxr.chr != '$' {
+			return nil, fmt.Errorf("syntax error at %d: expected query or parenthesized expression after logical not", lxr.chrPos)
+		}
+		query, err := lxr.scanFilterQuery()
+		return notExpr{existenceExpr{query}}, err
+	}
+	if lxr.chr == '(' {
+		return lxr.scanParenExpr()
+	}
+	startPos := lxr.chrPos
+	left, err := lxr.scanComparand()
+	if err != nil {
+		return nil, err
+	}
+	saved := lxr.lexerState
+	if err := lxr.skipSpace(); err != nil {
+		return nil, err
+	}
+	op := lxr.scanComparisonOp()
+	if op == "" {
+		lxr.lexerState = saved
+		if query, isQuery := left.(filterQuery); isQuery {
+			return existenceExpr{query}, nil
+		}
+		return nil, fmt.Errorf("syntax error at %d: a literal must be compared with something", startPos)
+	}
+	if err := lxr.skipSpace(); err != nil {
+		return nil, err
+	}
+	right, err := lxr.scanComparand()
+	if err != nil {
+		return nil, err
+	}
+	for _, side := range []comparand{left, right} {
+		if query, isQuery := side.(filterQuery); isQuery && !query.query.IsSingular() {
+			return nil, fmt.Errorf("syntax error at %d: only a singular query can be compared", startPos)
+		}
+	}
+	return comparisonExpr{left: left, right: right, op: op}, nil
+}
+
+func (lxr *Lexer) scanParenExpr() (logicalExpr, error) {
+	if err := lxr.advance(); err != nil {
+		return nil, err
+	}
+	if err := lxr.skipSpace(); err != nil {
+		return nil, err
+	}
+	expr, err := lxr.scanLogicalOr()
+	if err != nil {
+		return nil, err
+	}
+	if err := lxr.skipSpace(); err != nil {
+		return nil, err
+	}
+	if lxr.eof || lxr.chr != ')' {
+		return nil, fmt.Errorf("syntax error at %d: missing close parenthesis", lxr.chrPos)
+	}
+	return expr, lxr.advance()
+}
+
+// scanComparisonOp consumes a comparison operator, if one is there,
+// and returns it; otherwise it returns the empty string.
+func (lxr *Lexer) scanComparisonOp() comparisonOp {
+	for _, op := range []comparisonOp{opEQ, opNE, opLE, opGE, opLT, opGT} {
+		if lxr.hasPrefix(string(op)) {
+			if lxr.advanceBy(len(op)) != nil {
+				return ""
+			}
+			return op
+		}
+	}
+	return ""
+}
+
+func (lxr *Lexer) scanComparand() (comparand, error) {
+	switch {
+	case lxr.eof:
+		return nil, fmt.Errorf("syntax error at %d: expected comparable, got EOF", lxr.chrPos)
+	case lxr.chr == '@' || lxr.chr == '$':
+		return lxr.scanFilterQuery()
+	case lxr.chr == '"' || lxr.chr == '\'':
+		val, err := lxr.nextString()
+		return literal{val}, err
+	case lxr.chr == '-' || isDigit(lxr.chr):
+		val, err := lxr.nextNumber()
+		return literal{val}, err
+	case isAlpha(lxr.chr):
+		startPos := lxr.chrPos
+		word, err := lxr.nextIdentifier()
+		if err != nil {
+			return nil, err
+		}
+		switch word {
+		case "true":
+			return literal{true}, nil
+		case "false":
+			return literal{false}, nil
+		case "null":
+			return literal{nil}, nil
+		}
+		return nil, fmt.Errorf("syntax error at %d: function extensions are not supported (%q)", startPos, word)
+	}
+	return nil, fmt.Errorf("syntax error at %d: expected comparable, got %q", lxr.chrPos, lxr.chr)
+}
+
+// scanFilterQuery consumes a query that starts with `@` or `$`.
+func (lxr *Lexer) scanFilterQuery() (filterQuery, error) {
+	relative := lxr.chr == '@'
+	if err := lxr.advance(); err != nil {
+		return filterQuery{}, err
+	}
+	query, err := lxr.scanSegments()
+	return filterQuery{relative: relative, query: query}, err
 }
 
 func (lxr *Lexer) advance() error {
 	if lxr.eof {
-		return io.EOF
+		return nil
 	}
 	lxr.chrPos = lxr.nextPos
-	startPos := lxr.chrPos
-	var err error
+	if lxr.chrPos >= len(lxr.source) {
+		lxr.chr = 0
+		lxr.eof = true
+		return nil
+	}
 	var size int
-	lxr.chr, size, err = lxr.reader.ReadRune()
+	lxr.chr, size = utf8.DecodeRuneInString(lxr.source[lxr.chrPos:])
 	lxr.nextPos = lxr.chrPos + size
-	if err == io.EOF {
-		lxr.eof = true
-	} else if err != nil {
-		return fmt.Errorf("run reader error at %d: %w", startPos, err)
+	if lxr.chr == utf8.RuneError && size == 1 {
+		return fmt.Errorf("invalid UTF-8 at %d", lxr.chrPos)
+	}
+	return nil
+}
+
+// advanceBy advances over the given number of bytes, which must be whole runes.
+func (lxr *Lexer) advanceBy(numBytes int) error {
+	endPos := lxr.chrPos + numBytes
+	for !lxr.eof && lxr.chrPos < endPos {
+		if err := lxr.advance(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasPrefix tells whether the remaining input starts with the given string.
+func (lxr *Lexer) hasPrefix(prefix string) bool {
+	return !lxr.eof && len(lxr.source)-lxr.chrPos >= len(prefix) && lxr.source[lxr.chrPos:lxr.chrPos+len(prefix)] == prefix
+}
+
+// skipSpace consumes blank space (RFC 9535 `S`).
+func (lxr *Lexer) skipSpace() error {
+	for !lxr.eof && (lxr.chr == ' ' || lxr.chr == '\t' || lxr.chr == '\n' || lxr.chr == '\r') {
+		if err := lxr.advance(); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -200,6 +528,77 @@ func (lxr *Lexer) nextIdentifier() (string, error) {
 	return lxr.source[startPos:lxr.chrPos], nil
 }
 
+// nextInt consumes an integer, which has no leading zeroes and is not `-0`.
+func (lxr *Lexer) nextInt() (int, error) {
+	startPos := lxr.chrPos
+	if err := lxr.scanDigits(true); err != nil {
+		return 0, err
+	}
+	intSrc := lxr.source[startPos:lxr.chrPos]
+	if intSrc == "-0" || len(intSrc) > 1 && (intSrc[0] == '0' || intSrc[:2] == "-0") {
+		return 0, fmt.Errorf("syntax error at %d: invalid integer %q", startPos, intSrc)
+	}
+	val, err := strconv.Atoi(intSrc)
+	if err != nil {
+		return 0, fmt.Errorf("syntax error at %d: %w", startPos, err)
+	}
+	return val, nil
+}
+
+// nextNumber consumes a JSON number.
+func (lxr *Lexer) nextNumber() (float64, error) {
+	startPos := lxr.chrPos
+	if err := lxr.scanDigits(true); err != nil {
+		return 0, err
+	}
+	if !lxr.eof && lxr.chr == '.' {
+		if err := lxr.advance(); err != nil {
+			return 0, err
+		}
+		if err := lxr.scanDigits(false); err != nil {
+			return 0, err
+		}
+	}
+	if !lxr.eof && (lxr.chr == 'e' || lxr.chr == 'E') {
+		if err := lxr.advance(); err != nil {
+			return 0, err
+		}
+		if !lxr.eof && lxr.chr == '+' {
+			if err := lxr.advance(); err != nil {
+				return 0, err
+			}
+		}
+		if err := lxr.scanDigits(true); err != nil {
+			return 0, err
+		}
+	}
+	numSrc := lxr.source[startPos:lxr.chrPos]
+	val, err := strconv.ParseFloat(numSrc, 64)
+	if err != nil {
+		return 0, fmt.Errorf("syntax error at %d: invalid number %q", startPos, numSrc)
+	}
+	return val, nil
+}
+
+// scanDigits consumes one or more decimal digits,
+// optionally preceded by a minus sign.
+func (lxr *Lexer) scanDigits(allowMinus bool) error {
+	if allowMinus && !lxr.eof && lxr.chr == '-' {
+		if err := lxr.advance(); err != nil {
+			return err
+		}
+	}
+	if lxr.eof || !isDigit(lxr.chr) {
+		return fmt.Errorf("syntax error at %d: expected digit", lxr.chrPos)
+	}
+	for !lxr.eof && isDigit(lxr.chr) {
+		if err := lxr.advance(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func isNameFirst(r rune) bool {
 	return isAlpha(r) || r == '_' || 0x80 <= r && r <= 0xD7FF || 0xE000 <= r && r <= 0x10FFFF
 }
@@ -17,14 +17,16 @@ limitations under the License.
 package jsonpath
 
 import (
+	"reflect"
 	"testing"
 )
 
 func TestLexer(t *testing.T) {
-CaseLoop:
+	name := func(name string) Segment { return Segment{Selectors: []Selector{NameSelector{Name: name}}} }
+	intPtr := func(val int) *int { return &val }
 	for _, testCase := range []struct {
 		source  string
-		results []string
+		results Query
 		goodEnd func(error) bool
 	}{
 		{"", nil, badEnd},
@@ -32,34 +34,58 @@ CaseLoop:
 			nil,
 			badEnd},
 		{`$.xyz`,
-			[]string{"xyz"},
+			Query{name("xyz")},
 			cleanEOF},
 		{`$["foo.bar/baz"]`,
-			[]string{"foo.bar/baz"},
+			Query{name("foo.bar/baz")},
 			cleanEOF},
 		{`$["foo.bar/baz"].zork`,
-			[]string{"foo.bar/baz", "zork"},
+			Query{name("foo.bar/baz"), name("zork")},
 			cleanEOF},
 		{`$.zot["foo.bar/baz"]`,
-			[]string{"zot", "foo.bar/baz"},
+			Query{name("zot"), name("foo.bar/baz")},
+			cleanEOF},
+		{`$.a[1, -2]['b'] [*].*`,
+			Query{name("a"), {Selectors: []Selector{IndexSelector{1}, IndexSelector{-2}}}, name("b"),
+				{Selectors: []Selector{WildcardSelector{}}}, {Selectors: []Selector{WildcardSelector{}}}},
+			cleanEOF},
+		{`$..x..[0]..*`,
+			Query{{Descendant: true, Selectors: []Selector{NameSelector{"x"}}},
+				{Descendant: true, Selectors: []Selector{IndexSelector{0}}},
+				{Descendant: true, Selectors: []Selector{WildcardSelector{}}}},
+			cleanEOF},
+		{`$[1:3][::-1][:2:]`,
+			Query{{Selectors: []Selector{SliceSelector{Start: intPtr(1), End: intPtr(3), Step: 1}}},
+				{Selectors: []Selector{SliceSelector{Step: -1}}},
+				{Selectors: []Selector{SliceSelector{End: intPtr(2), Step: 1}}}},
+			cleanEOF},
+		{`$.containers[?@.name=="istio-proxy"]`,
+			Query{name("containers"), {Selectors: []Selector{FilterSelector{comparisonExpr{
+				left:  filterQuery{relative: true, query: Query{name("name")}},
+				right: literal{"istio-proxy"},
+				op:    opEQ}}}}},
+			cleanEOF},
+		{`$[?(@.a || !@.b) && $.c >= -1.5e1]`,
+			Query{{Selectors: []Selector{FilterSelector{andExpr{
+				orExpr{existenceExpr{filterQuery{relative: true, query: Query{name("a")}}},
+					notExpr{existenceExpr{filterQuery{relative: true, query: Query{name("b")}}}}},
+				comparisonExpr{left: filterQuery{query: Query{name("c")}}, right: literal{-15.0}, op: opGE}}}}}},
 			cleanEOF},
 		{`$.`, nil, badEnd},
 		{`$[`, nil, badEnd},
 		{`$[]`, nil, badEnd},
+		{`$[01]`, nil, badEnd},
+		{`$[?@.a == 'x'`, nil, badEnd},
+		{`$[?@..a == 1]`, nil, badEnd},
+		{`$[?'x']`, nil, badEnd},
+		{`$[?length(@) == 1]`, nil, badEnd},
+		{`$.a `, nil, badEnd},
 	} {
 		query, err := ParseQuery(testCase.source)
-		for idx, good := range testCase.results {
-			if idx >= len(query) {
-				t.Errorf("For source %q, parse produced only %d results", testCase.source, len(query))
-				continue
-			}
-			if query[idx] != good {
-				t.Errorf("For source %q, segment %d is %q but expected token %q", testCase.source, idx, query[idx], good)
-				continue CaseLoop
-			}
-		}
 		if !testCase.goodEnd(err) {
 			t.Errorf("For source %q, Parse returned wrong err=%#+v", testCase.source, err)
+		} else if err == nil && !reflect.DeepEqual(query, testCase.results) {
+			t.Errorf("For source %q, expected %#v but got %#v", testCase.source, testCase.results, query)
 		} else {
 			t.Logf("Success for source %q", testCase.source)
 		}
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package jsonpath
+
+// This file defines the parsed form of JSONPath (RFC 9535) queries.
+// Function extensions (RFC 9535 section 2.4) are not supported.
+
+// Query represents a parsed JSONPath query: the sequence of segments
+// that follow the root identifier (`$`), or the current node identifier (`@`)
+// in a relative query within a filter.
+// An empty Query identifies the node it is applied to.
+type Query []Segment
+
+// Segment is one segment of a Query.
+type Segment struct {
+	// Descendant distinguishes a descendant segment (`..`), which applies its
+	// selectors to the input node and to every descendant of that node,
+	// from a child segment, which applies its selectors to just the input node.
+	Descendant bool
+
+	// Selectors is not empty.
+	Selectors []Selector
+}
+
+// Selector selects some of the children of a node.
+// The implementations are NameSelector, WildcardSelector, IndexSelector,
+// SliceSelector, and FilterSelector.
+type Selector interface {
+	// selectFrom calls yield on each of the children of the given node
+	// that this selector selects.
+	selectFrom(ctx *evalContext, node Node, yield func(Node))
+}
+
+// NameSelector selects the member with the given name of an object
+// (`.name` or `["name"]`).
+type NameSelector struct {
+	Name string
+}
+
+// WildcardSelector selects all the members of an object or elements of an array
+// (`*`).
+type WildcardSelector struct{}
+
+// IndexSelector selects one element of an array (e.g., `[2]`).
+// A negative Index counts back from the end of the array.
+type IndexSelector struct {
+	Index int
+}
+
+// SliceSelector selects a slice of an array (`[start:end:step]`).
+// Nil Start or End means the default for the direction of Step.
+type SliceSelector struct {
+	Start, End *int
+	Step       int
+}
+
+// FilterSelector selects the members of an object or elements of an array
+// for which a logical expression is true (e.g., `[?@.name=="istio-proxy"]`).
+type FilterSelector struct {
+	expr logicalExpr
+}
+
+// IsSingular tells whether the query is a singular query,
+// which identifies at most one node: it has no descendant segments
+// and every segment has one selector, which is a name or index selector.
+func (query Query) IsSingular() bool {
+	for _, segment := range query {
+		if segment.Descendant || len(segment.Selectors) != 1 {
+			return false
+		}
+		switch segment.Selectors[0].(type) {
+		case NameSelector, IndexSelector:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// logicalExpr is a parsed logical expression of a filter selector.
+type logicalExpr interface {
+	// test evaluates the expression in the context of the given current node (`@`).
+	test(ctx *evalContext, current Node) bool
+}
+
+type orExpr []logicalExpr
+
+type andExpr []logicalExpr
+
+type notExpr struct {
+	operand logicalExpr
+}
+
+// existenceExpr is a test expression: true when the query selects at least one node.
+type existenceExpr struct {
+	query filterQuery
+}
+
+type comparisonExpr struct {
+	left, right comparand
+	op          comparisonOp
+}
+
+type comparisonOp string
+
+const (
+	opEQ comparisonOp = "=="
+	opNE comparisonOp = "!="
+	opLT comparisonOp = "<"
+	opLE comparisonOp = "<="
+	opGT comparisonOp = ">"
+	opGE comparisonOp = ">="
+)
+
+// comparand is one side of a comparison.
+type comparand interface {
+	// value returns the value of the comparand and true,
+	// or false if the comparand is a query that selects nothing.
+	value(ctx *evalContext, current Node) (JSONValue, bool)
+}
+
+// literal is a literal value in a filter expression.
+type literal struct {
+	val JSONValue
+}
+
+// filterQuery is a query within a filter expression.
+type filterQuery struct {
+	// relative tells whether the query starts at the current node (`@`)
+	// rather than the root node (`$`).
+	relative bool
+	query    Query
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ocm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	managedclusterapi "open-cluster-management.io/api/cluster/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/celeval"
+	ksmetrics "github.com/kubestellar/kubestellar/pkg/metrics"
+)
+
+const (
+	// clusterObjectKey is the key used to store the whole inventory object.
+	clusterObjectKey = "obj"
+	// clusterLabelsKey is the key used to store the labels of the inventory object.
+	clusterLabelsKey = "labels"
+	// clusterAnnotationsKey is the key used to store the annotations of the inventory object.
+	clusterAnnotationsKey = "annotations"
+	// clusterConditionsKey is the key used to store a map from condition type
+	// to condition status for the inventory object.
+	clusterConditionsKey = "conditions"
+)
+
+// NewClusterSelectionEvaluator returns a CEL evaluator whose environment
+// is suited to the `clusterSelectorExpressions` of a BindingPolicy.
+func NewClusterSelectionEvaluator() (*celeval.Evaluator, error) {
+	return celeval.NewEvaluator(
+		cel.Variable(clusterObjectKey, cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable(clusterLabelsKey, cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable(clusterAnnotationsKey, cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable(clusterConditionsKey, cel.MapType(cel.StringType, cel.StringType)),
+	)
+}
+
+// CheckClusterSelectorExpressions returns one error for each of the given expressions
+// that is not a valid cluster selection expression.
+func CheckClusterSelectorExpressions(evaluator *celeval.Evaluator, expressions []v1alpha1.Expression) []error {
+	var errs []error
+	for idx, expression := range expressions {
+		if err := evaluator.CheckBoolExpression(expression); err != nil {
+			errs = append(errs, fmt.Errorf("invalid clusterSelectorExpressions[%d] (%q): %w", idx, expression, err))
+		}
+	}
+	return errs
+}
+
+// ClusterMatchesExpressions tests whether the given cluster passes any of the given expressions.
+// An expression that fails to evaluate or does not evaluate to a boolean does not match;
+// the first such error is returned along with the result of the test.
+func ClusterMatchesExpressions(evaluator *celeval.Evaluator, cluster *managedclusterapi.ManagedCluster, expressions []v1alpha1.Expression) (bool, error) {
+	if len(expressions) == 0 {
+		return false, nil
+	}
+	vars, err := clusterExpressionVariables(cluster)
+	if err != nil {
+		return false, err
+	}
+	var firstErr error
+	for _, expression := range expressions {
+		match, err := evaluator.EvaluateBool(expression, vars)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to evaluate %q for cluster %s: %w", expression, cluster.Name, err)
+			}
+			continue
+		}
+		if match {
+			return true, nil
+		}
+	}
+	return false, firstErr
+}
+
+// FindClustersByExpressions returns the names of the clusters that pass any of the given expressions.
+// Evaluation errors for individual clusters do not stop the search; they are logged.
+func FindClustersByExpressions(ctx context.Context, client ksmetrics.ClientModNamespace[*managedclusterapi.ManagedCluster, *managedclusterapi.ManagedClusterList], evaluator *celeval.Evaluator, expressions []v1alpha1.Expression) (sets.Set[string], error) {
+	logger := klog.FromContext(ctx)
+	clusterNames := sets.New[string]()
+	if len(expressions) == 0 {
+		return clusterNames, nil
+	}
+	clusters, err := client.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing clusters: %w", err)
+	}
+
+	for idx := range clusters.Items {
+		cluster := &clusters.Items[idx]
+		match, err := ClusterMatchesExpressions(evaluator, cluster, expressions)
+		if err != nil {
+			logger.V(4).Info("Error in evaluating cluster selector expression", "cluster", cluster.Name, "err", err)
+		}
+		if match {
+			clusterNames.Insert(cluster.GetName())
+		}
+	}
+
+	return clusterNames, nil
+}
+
+func clusterExpressionVariables(cluster *managedclusterapi.ManagedCluster) (map[string]interface{}, error) {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert cluster %s to unstructured: %w", cluster.Name, err)
+	}
+	conditions := make(map[string]string, len(cluster.Status.Conditions))
+	for _, cond := range cluster.Status.Conditions {
+		conditions[cond.Type] = string(cond.Status)
+	}
+	return map[string]interface{}{
+		clusterObjectKey:      obj,
+		clusterLabelsKey:      celeval.NonNilStringMap(cluster.Labels),
+		clusterAnnotationsKey: celeval.NonNilStringMap(cluster.Annotations),
+		clusterConditionsKey:  conditions,
+	}, nil
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ocm
+
+import (
+	"testing"
+
+	managedclusterapi "open-cluster-management.io/api/cluster/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestClusterMatchesExpressions(t *testing.T) {
+	evaluator, err := NewClusterSelectionEvaluator()
+	if err != nil {
+		t.Fatalf("Failed to create evaluator: %v", err)
+	}
+	cluster := &managedclusterapi.ManagedCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "cluster1",
+			Labels:      map[string]string{"gpu-count": "4", "region": "east"},
+			Annotations: map[string]string{"owner": "team-a"},
+		},
+		Status: managedclusterapi.ManagedClusterStatus{
+			Conditions: []metav1.Condition{{Type: managedclusterapi.ManagedClusterConditionAvailable, Status: metav1.ConditionTrue}},
+		},
+	}
+	for _, tc := range []struct {
+		expressions []v1alpha1.Expression
+		expectMatch bool
+		expectErr   bool
+	}{
+		{expressions: nil, expectMatch: false},
+		{expressions: []v1alpha1.Expression{`int(labels["gpu-count"]) >= 2`}, expectMatch: true},
+		{expressions: []v1alpha1.Expression{`int(labels["gpu-count"]) >= 8`}, expectMatch: false},
+		{expressions: []v1alpha1.Expression{`annotations["owner"] == "team-a"`}, expectMatch: true},
+		{expressions: []v1alpha1.Expression{`conditions["ManagedClusterConditionAvailable"] == "True"`}, expectMatch: true},
+		{expressions: []v1alpha1.Expression{`obj.metadata.name == "cluster1"`}, expectMatch: true},
+		{expressions: []v1alpha1.Expression{`int(labels["missing"]) > 1`, `labels["region"] == "east"`}, expectMatch: true},
+		{expressions: []v1alpha1.Expression{`int(labels["missing"]) > 1`}, expectMatch: false, expectErr: true},
+		{expressions: []v1alpha1.Expression{`obj.metadata.name`}, expectMatch: false, expectErr: true},
+	} {
+		match, err := ClusterMatchesExpressions(evaluator, cluster, tc.expressions)
+		if match != tc.expectMatch || (err != nil) != tc.expectErr {
+			t.Errorf("For expressions %v: expected match=%v, err?=%v; got match=%v, err=%v", tc.expressions, tc.expectMatch, tc.expectErr, match, err)
+		}
+	}
+}
+
+func TestCheckClusterSelectorExpressions(t *testing.T) {
+	evaluator, err := NewClusterSelectionEvaluator()
+	if err != nil {
+		t.Fatalf("Failed to create evaluator: %v", err)
+	}
+	errs := CheckClusterSelectorExpressions(evaluator, []v1alpha1.Expression{
+		`labels["a"] == "b"`,
+		`labels["a"]`,
+		`labels[`,
+		`obj.spec.hubAcceptsClient`,
+	})
+	if len(errs) != 2 {
+		t.Errorf("Expected 2 errors, got %v", errs)
+	}
+}
@@ -38,6 +38,11 @@ func (c *Controller) syncBinding(ctx context.Context, key string) error {
 	}
 	logger.V(5).Info("In syncBinding", "bindingName", key, "isDeleted", isDeleted, "resolutionIsNil", resolution == nil, "resolution", resolution, "resolutionType", fmt.Sprintf("%T", resolution))
 
+	// the Binding's upsync clauses or destinations may have changed
+	if c.upsyncEnabled {
+		c.enqueueUpsyncWorkStatuses(ctx, key)
+	}
+
 	// NoteBindingResolution does not use the resolution if isDeleted is true
 	changedCombinedStatuses := c.combinedStatusResolver.NoteBindingResolution(ctx, key, resolution, isDeleted,
 		c.workStatusIndexer, c.statusCollectorLister)
@@ -56,7 +61,7 @@ func (c *Controller) syncBinding(ctx context.Context, key string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get Binding %s from cache: %w", key, err)
 	}
-	if err = c.createOrUpdateStatusCollectorAvailableCondition(ctx, bdg, missingStatusCollectors); err != nil {
+	if err = c.updateBindingStatus(ctx, bdg, missingStatusCollectors); err != nil {
 		return fmt.Errorf("failed to update status for Binding %s: %w", key, err)
 	}
 
@@ -64,10 +69,10 @@ func (c *Controller) syncBinding(ctx context.Context, key string) error {
 	return nil
 }
 
-// createOrUpdateStatusCollectorAvailableCondition maintains a Condition of type StatusCollectorsAvailable
-// in a Binding object's status.
+// updateBindingStatus maintains a Condition of type StatusCollectorsAvailable
+// and the drift report in a Binding object's status.
 // missingSCs, a slice of the missing StatusCollector object name(s), must be sorted.
-func (c *Controller) createOrUpdateStatusCollectorAvailableCondition(ctx context.Context, bdg *v1alpha1.Binding, missingSCs []string) error {
+func (c *Controller) updateBindingStatus(ctx context.Context, bdg *v1alpha1.Binding, missingSCs []string) error {
 	// compose tentative condition where LastTransitionTime is TBD
 	var conditionTentative v1alpha1.BindingPolicyCondition
 	if len(missingSCs) != 0 {
@@ -86,13 +91,14 @@ func (c *Controller) createOrUpdateStatusCollectorAvailableCondition(ctx context
 		}
 	}
 	// create or update if necessary
-	bdgWithProposedCondition := bdg.DeepCopy()
-	conditions, changed := v1alpha1.SetCondition(bdgWithProposedCondition.Status.Conditions, conditionTentative)
-	if !changed {
+	bdgWithProposedStatus := bdg.DeepCopy()
+	conditions, changed := v1alpha1.SetCondition(bdgWithProposedStatus.Status.Conditions, conditionTentative)
+	bdgWithProposedStatus.Status.Conditions = conditions
+	driftChanged := c.driftDetectionEnabled && c.setDriftStatus(bdgWithProposedStatus)
+	if !changed && !driftChanged {
 		return nil
 	}
-	bdgWithProposedCondition.Status.Conditions = conditions
-	if _, err := c.bindingClient.UpdateStatus(ctx, bdgWithProposedCondition, metav1.UpdateOptions{FieldManager: ControllerName}); err != nil {
+	if _, err := c.bindingClient.UpdateStatus(ctx, bdgWithProposedStatus, metav1.UpdateOptions{FieldManager: ControllerName}); err != nil {
 		return err
 	}
 	return nil
@@ -0,0 +1,360 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+// driftKey identifies a workload object in the drift reports.
+type driftKey struct {
+	Group    string
+	Resource string
+	cache.ObjectName
+}
+
+func driftKeyForIdentifier(objId util.ObjectIdentifier) driftKey {
+	return driftKey{Group: objId.GVK.Group, Resource: objId.Resource, ObjectName: objId.ObjectName}
+}
+
+// desiredStateIndexName is the name of the index, on desired-state ConfigMaps, whose values
+// are "namespace/hash" for each desired state in the ConfigMap.
+const desiredStateIndexName = "desiredState"
+
+func desiredStateIndexFunc(obj any) ([]string, error) {
+	cm := obj.(*unstructured.Unstructured)
+	data, _, err := unstructured.NestedStringMap(cm.Object, "data")
+	if err != nil {
+		return nil, nil
+	}
+	ans := make([]string, 0, len(data))
+	for hash := range data {
+		ans = append(ans, cm.GetNamespace()+"/"+hash)
+	}
+	return ans, nil
+}
+
+// setupDesiredStateInformer starts the informer on desired-state ConfigMaps in the ITS.
+// A change in those of a WEC brings back the drift WorkStatuses from that WEC.
+func (c *Controller) setupDesiredStateInformer(ctx context.Context) {
+	logger := klog.FromContext(ctx)
+	informerFactory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(c.itsDynClient, 0, metav1.NamespaceAll,
+		func(opts *metav1.ListOptions) {
+			opts.LabelSelector = v1alpha1.DesiredStateConfigMapLabelKey + "=true," + originWdsLabelKey + "=" + c.wdsName
+		})
+	informer := informerFactory.ForResource(schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}).Informer()
+	if err := informer.AddIndexers(cache.Indexers{desiredStateIndexName: desiredStateIndexFunc}); err != nil {
+		logger.Error(err, "Failed to add index to desired-state ConfigMap informer")
+	}
+	enqueue := func(obj any) {
+		if dfsu, is := obj.(cache.DeletedFinalStateUnknown); is {
+			obj = dfsu.Obj
+		}
+		c.workqueue.Add(desiredStatesRef(obj.(metav1.Object).GetNamespace()))
+	}
+	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueue,
+		UpdateFunc: func(old, new any) { enqueue(new) },
+		DeleteFunc: enqueue,
+	})
+	if err != nil {
+		logger.Error(err, "Failed to add event handler to desired-state ConfigMap informer")
+	}
+	c.desiredStateIndexer = informer.GetIndexer()
+	informerFactory.Start(ctx.Done())
+}
+
+// syncDesiredStates enqueues the drift WorkStatuses from the given WEC.
+func (c *Controller) syncDesiredStates(ctx context.Context, wecName string) error {
+	logger := klog.FromContext(ctx)
+	objs, err := c.workStatusLister.ByNamespace(wecName).List(labels.SelectorFromSet(labels.Set{v1alpha1.DriftWorkStatusLabelKey: "true"}))
+	if err != nil { // listers do not fail
+		return fmt.Errorf("failed to list drift WorkStatuses of %s: %w", wecName, err)
+	}
+	for _, obj := range objs {
+		if objNotInThisWDS(obj, c.wdsName) {
+			continue
+		}
+		ref, err := runtimeObjectToWorkStatusRef(obj)
+		if err != nil {
+			logger.Error(err, "Failed to get source ref", "object", util.RefToRuntimeObj(obj))
+			continue
+		}
+		c.workqueue.Add(driftRef{*ref})
+	}
+	return nil
+}
+
+// getDesiredState returns the desired state, from the desired-state ConfigMaps for the given WEC,
+// that has the given hash. The returned bool is false when there is no such desired state.
+func (c *Controller) getDesiredState(wecName, hash string) (string, bool) {
+	cms, err := c.desiredStateIndexer.ByIndex(desiredStateIndexName, wecName+"/"+hash)
+	if err != nil || len(cms) == 0 {
+		return "", false
+	}
+	state, found, _ := unstructured.NestedString(cms[0].(*unstructured.Unstructured).Object, "data", hash)
+	return state, found
+}
+
+// isDriftWorkStatus tells whether the given WorkStatus reports an object for drift detection
+// rather than the status of a downsynced object.
+func isDriftWorkStatus(obj metav1.Object) bool {
+	return obj.GetLabels()[v1alpha1.DriftWorkStatusLabelKey] == "true"
+}
+
+// syncDrift updates the drift recorded for the object and WEC of the referenced WorkStatus,
+// and enqueues the Bindings of that object if the recorded drift changed.
+func (c *Controller) syncDrift(ctx context.Context, ref driftRef) error {
+	logger := klog.FromContext(ctx)
+	var destDrift *v1alpha1.DestinationDrift
+	obj, err := c.workStatusLister.ByNamespace(ref.WECName).Get(ref.Name)
+	if err == nil {
+		content, err := util.GetWorkStatusStatus(obj)
+		if err != nil {
+			logger.Error(err, "Failed to get status from workstatus", "workStatusRef", ref.workStatusRef)
+		} else if content != nil {
+			destDrift, err = computeDestinationDrift(ref.WECName, content, c.getDesiredState)
+			if err != nil {
+				logger.Error(err, "Failed to compare reported object with its desired state", "workStatusRef", ref.workStatusRef)
+			}
+		}
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get workstatus (%v): %w", ref.workStatusRef, err)
+	}
+	if !c.recordDrift(driftKeyForIdentifier(ref.SourceObjectIdentifier), ref.WECName, destDrift) {
+		return nil
+	}
+	for _, bindingName := range c.bindingPolicyResolver.GetBindingPoliciesSelectingObject(ref.SourceObjectIdentifier) {
+		logger.V(5).Info("Enqueuing reference to Binding due to change in drift", "binding", bindingName, "workStatusRef", ref.workStatusRef)
+		c.workqueue.Add(bindingRef(bindingName))
+	}
+	return nil
+}
+
+// recordDrift records the drift of the given object in the given WEC,
+// or the absence of drift if destDrift is nil.
+// The returned bool tells whether the record changed.
+func (c *Controller) recordDrift(key driftKey, wecName string, destDrift *v1alpha1.DestinationDrift) bool {
+	c.driftMutex.Lock()
+	defer c.driftMutex.Unlock()
+	wecToDrift := c.drift[key]
+	existing, had := wecToDrift[wecName]
+	if destDrift == nil {
+		if !had {
+			return false
+		}
+		delete(wecToDrift, wecName)
+		if len(wecToDrift) == 0 {
+			delete(c.drift, key)
+		}
+		return true
+	}
+	if had && apiequality.Semantic.DeepEqual(existing, *destDrift) {
+		return false
+	}
+	if wecToDrift == nil {
+		wecToDrift = map[string]v1alpha1.DestinationDrift{}
+		c.drift[key] = wecToDrift
+	}
+	wecToDrift[wecName] = *destDrift
+	return true
+}
+
+// computeDestinationDrift compares the given object, as reported from the given WEC, with the
+// desired state that its DesiredStateAnnotationKey annotation identifies, found by getDesiredState.
+// Returns nil if there is no desired state identified or found, or no difference.
+func computeDestinationDrift(wecName string, observed map[string]any, getDesiredState func(wecName, hash string) (string, bool)) (*v1alpha1.DestinationDrift, error) {
+	observedU := &unstructured.Unstructured{Object: observed}
+	hash, has := observedU.GetAnnotations()[v1alpha1.DesiredStateAnnotationKey]
+	if !has {
+		return nil, nil
+	}
+	desiredJSON, found := getDesiredState(wecName, hash)
+	if !found {
+		// The desired-state ConfigMap has not arrived yet or has moved on; its arrival brings this back.
+		return nil, nil
+	}
+	var desired map[string]any
+	if err := json.Unmarshal([]byte(desiredJSON), &desired); err != nil {
+		return nil, fmt.Errorf("failed to parse desired state: %w", err)
+	}
+	paths := driftPaths(desired, observed, "", nil)
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	return &v1alpha1.DestinationDrift{ClusterId: wecName, Paths: paths, ObservedResourceVersion: observedU.GetResourceVersion(), DesiredStateHash: hash}, nil
+}
+
+// simpleFieldName matches the field names that can appear unquoted in a JSON path.
+var simpleFieldName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// driftPaths appends to `paths` the JSON paths, under the given one, where the observed
+// value lacks or differs from the desired value, and returns the result.
+// Fields of observed maps that are not in the desired map are not drift.
+func driftPaths(desired, observed any, path string, paths []string) []string {
+	switch desiredT := desired.(type) {
+	case map[string]any:
+		observedT, ok := observed.(map[string]any)
+		if !ok {
+			return append(paths, path)
+		}
+		keys := make([]string, 0, len(desiredT))
+		for key := range desiredT {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			var keyPath string
+			if simpleFieldName.MatchString(key) {
+				keyPath = path + "." + key
+			} else {
+				keyPath = path + "['" + strings.ReplaceAll(key, "'", "\\'") + "']"
+			}
+			observedVal, has := observedT[key]
+			if !has {
+				paths = append(paths, keyPath)
+				continue
+			}
+			paths = driftPaths(desiredT[key], observedVal, keyPath, paths)
+		}
+		return paths
+	case []any:
+		observedT, ok := observed.([]any)
+		if !ok || len(observedT) != len(desiredT) {
+			return append(paths, path)
+		}
+		for idx := range desiredT {
+			paths = driftPaths(desiredT[idx], observedT[idx], fmt.Sprintf("%s[%d]", path, idx), paths)
+		}
+		return paths
+	default:
+		if !jsonScalarsEqual(desired, observed) {
+			return append(paths, path)
+		}
+		return paths
+	}
+}
+
+// jsonScalarsEqual compares two JSON scalars, treating all numbers alike
+// regardless of whether they were decoded as integers or floats.
+func jsonScalarsEqual(left, right any) bool {
+	leftNum, leftIsNum := jsonNumber(left)
+	rightNum, rightIsNum := jsonNumber(right)
+	if leftIsNum || rightIsNum {
+		return leftIsNum && rightIsNum && leftNum == rightNum
+	}
+	return left == right
+}
+
+func jsonNumber(val any) (float64, bool) {
+	switch typed := val.(type) {
+	case float64:
+		return typed, true
+	case int64:
+		return float64(typed), true
+	case int:
+		return float64(typed), true
+	case int32:
+		return float64(typed), true
+	}
+	return 0, false
+}
+
+// setDriftStatus puts, into the status of the given Binding, the drift recorded for its
+// workload objects whose `driftPolicy` is set, and the corresponding Drifted condition.
+// The condition is maintained only for Bindings that check some object for drift or already have it.
+// The returned bool tells whether the status changed.
+func (c *Controller) setDriftStatus(bdg *v1alpha1.Binding) bool {
+	destinations := make(map[string]bool, len(bdg.Spec.Destinations))
+	for _, dest := range bdg.Spec.Destinations {
+		destinations[dest.ClusterId] = true
+	}
+	var checked int
+	var drift []v1alpha1.ObjectDrift
+	noteObject := func(modulation v1alpha1.DownsyncModulation, gvr metav1.GroupVersionResource, namespace, name string) {
+		if modulation.DriftPolicy == "" {
+			return
+		}
+		checked++
+		if objDrift := c.getObjectDrift(gvr, namespace, name, destinations); objDrift != nil {
+			drift = append(drift, *objDrift)
+		}
+	}
+	for _, clause := range bdg.Spec.Workload.ClusterScope {
+		noteObject(clause.DownsyncModulation, clause.GroupVersionResource, "", clause.Name)
+	}
+	for _, clause := range bdg.Spec.Workload.NamespaceScope {
+		noteObject(clause.DownsyncModulation, clause.GroupVersionResource, clause.Namespace, clause.Name)
+	}
+	changed := !apiequality.Semantic.DeepEqual(bdg.Status.Drift, drift)
+	bdg.Status.Drift = drift
+	if checked == 0 && !slices.ContainsFunc(bdg.Status.Conditions, func(cond v1alpha1.BindingPolicyCondition) bool { return cond.Type == v1alpha1.TypeDrifted }) {
+		return changed
+	}
+	condition := v1alpha1.BindingPolicyCondition{
+		Type:    v1alpha1.TypeDrifted,
+		Status:  corev1.ConditionFalse,
+		Reason:  v1alpha1.ReasonNoDrift,
+		Message: fmt.Sprintf("None of the %d object(s) checked for drift has drifted", checked),
+	}
+	if len(drift) > 0 {
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = v1alpha1.ReasonDrifted
+		condition.Message = fmt.Sprintf("%d of the %d object(s) checked for drift have drifted", len(drift), checked)
+	}
+	var conditionChanged bool
+	bdg.Status.Conditions, conditionChanged = v1alpha1.SetCondition(bdg.Status.Conditions, condition)
+	return changed || conditionChanged
+}
+
+// getObjectDrift returns the drift recorded for the given object in the given destinations,
+// or nil if there is none.
+func (c *Controller) getObjectDrift(gvr metav1.GroupVersionResource, namespace, name string, destinations map[string]bool) *v1alpha1.ObjectDrift {
+	c.driftMutex.Lock()
+	defer c.driftMutex.Unlock()
+	wecToDrift := c.drift[driftKey{Group: gvr.Group, Resource: gvr.Resource, ObjectName: cache.NewObjectName(namespace, name)}]
+	var destDrifts []v1alpha1.DestinationDrift
+	for wecName, destDrift := range wecToDrift {
+		if destinations[wecName] {
+			destDrifts = append(destDrifts, destDrift)
+		}
+	}
+	if len(destDrifts) == 0 {
+		return nil
+	}
+	slices.SortFunc(destDrifts, func(a, b v1alpha1.DestinationDrift) int { return strings.Compare(a.ClusterId, b.ClusterId) })
+	return &v1alpha1.ObjectDrift{GroupVersionResource: gvr, Namespace: namespace, Name: name, Destinations: destDrifts}
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2025 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestDriftPaths(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		desired  string
+		observed string
+		expected []string
+	}{
+		{name: "equal", desired: `{"a":1,"b":["x"]}`, observed: `{"a":1.0,"b":["x"]}`},
+		{name: "extra observed field", desired: `{"a":1}`, observed: `{"a":1,"status":{"ready":true}}`},
+		{name: "changed scalar", desired: `{"spec":{"replicas":2}}`, observed: `{"spec":{"replicas":3}}`, expected: []string{".spec.replicas"}},
+		{name: "missing field", desired: `{"data":{"a":"1","b":"2"}}`, observed: `{"data":{"a":"1"}}`, expected: []string{".data.b"}},
+		{name: "quoted field", desired: `{"labels":{"app.kubernetes.io/name":"x"}}`, observed: `{"labels":{}}`,
+			expected: []string{".labels['app.kubernetes.io/name']"}},
+		{name: "list element", desired: `{"l":[{"a":1},{"a":2}]}`, observed: `{"l":[{"a":1},{"a":3}]}`, expected: []string{".l[1].a"}},
+		{name: "list length", desired: `{"l":[1,2]}`, observed: `{"l":[1]}`, expected: []string{".l"}},
+		{name: "type change", desired: `{"a":{"b":1}}`, observed: `{"a":"b"}`, expected: []string{".a"}},
+		{name: "number and string", desired: `{"a":1}`, observed: `{"a":"1"}`, expected: []string{".a"}},
+		{name: "sorted", desired: `{"b":1,"a":1}`, observed: `{"b":2,"a":2}`, expected: []string{".a", ".b"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var desired, observed map[string]any
+			if err := json.Unmarshal([]byte(tc.desired), &desired); err != nil {
+				t.Fatalf("Failed to parse desired: %s", err)
+			}
+			if err := json.Unmarshal([]byte(tc.observed), &observed); err != nil {
+				t.Fatalf("Failed to parse observed: %s", err)
+			}
+			actual := driftPaths(desired, observed, "", nil)
+			if !slices.Equal(actual, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestComputeDestinationDrift(t *testing.T) {
+	desiredStates := map[string]string{"wec1/h1": `{"data":{"a":"1"}}`, "wec1/bad": `{`}
+	getDesiredState := func(wecName, hash string) (string, bool) {
+		state, found := desiredStates[wecName+"/"+hash]
+		return state, found
+	}
+	observed := func(hash, value string) map[string]any {
+		obj := &unstructured.Unstructured{Object: map[string]any{"data": map[string]any{"a": value}}}
+		if hash != "" {
+			obj.SetAnnotations(map[string]string{v1alpha1.DesiredStateAnnotationKey: hash})
+		}
+		obj.SetResourceVersion("7")
+		return obj.Object
+	}
+
+	drift, err := computeDestinationDrift("wec1", observed("h1", "2"), getDesiredState)
+	if err != nil {
+		t.Fatalf("Failed to compute drift: %s", err)
+	}
+	expected := v1alpha1.DestinationDrift{ClusterId: "wec1", Paths: []string{".data.a"}, ObservedResourceVersion: "7", DesiredStateHash: "h1"}
+	if drift == nil || drift.ClusterId != expected.ClusterId || !slices.Equal(drift.Paths, expected.Paths) ||
+		drift.ObservedResourceVersion != expected.ObservedResourceVersion || drift.DesiredStateHash != expected.DesiredStateHash {
+		t.Errorf("Expected %+v, got %+v", expected, drift)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		wecName  string
+		observed map[string]any
+	}{
+		{name: "no drift", wecName: "wec1", observed: observed("h1", "1")},
+		{name: "no annotation", wecName: "wec1", observed: observed("", "2")},
+		{name: "unknown hash", wecName: "wec1", observed: observed("h2", "2")},
+		{name: "other WEC", wecName: "wec2", observed: observed("h1", "2")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			drift, err := computeDestinationDrift(tc.wecName, tc.observed, getDesiredState)
+			if err != nil || drift != nil {
+				t.Errorf("Expected no drift and no error, got %+v and %v", drift, err)
+			}
+		})
+	}
+
+	if _, err := computeDestinationDrift("wec1", observed("bad", "2"), getDesiredState); err == nil {
+		t.Error("Expected an error for a desired state that does not parse")
+	}
+}
+
+func TestGetDesiredState(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{desiredStateIndexName: desiredStateIndexFunc})
+	cm := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"data":       map[string]any{"h1": `{"a":1}`},
+	}}
+	cm.SetNamespace("wec1")
+	cm.SetName("b1-wds1-0")
+	if err := indexer.Add(cm); err != nil {
+		t.Fatalf("Failed to add ConfigMap: %s", err)
+	}
+	c := &Controller{desiredStateIndexer: indexer}
+	if state, found := c.getDesiredState("wec1", "h1"); !found || state != `{"a":1}` {
+		t.Errorf("Expected to find the desired state, got %q, %v", state, found)
+	}
+	if _, found := c.getDesiredState("wec2", "h1"); found {
+		t.Error("Expected no desired state for another WEC")
+	}
+	if _, found := c.getDesiredState("wec1", "h2"); found {
+		t.Error("Expected no desired state for another hash")
+	}
+}
+
+func TestSetDriftStatus(t *testing.T) {
+	cmGVR := metav1.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	newBinding := func(driftPolicy v1alpha1.DriftPolicy) *v1alpha1.Binding {
+		return &v1alpha1.Binding{
+			ObjectMeta: metav1.ObjectMeta{Name: "b1"},
+			Spec: v1alpha1.BindingSpec{
+				Destinations: []v1alpha1.Destination{{ClusterId: "wec1"}, {ClusterId: "wec2"}},
+				Workload: v1alpha1.DownsyncObjectClauses{NamespaceScope: []v1alpha1.NamespaceScopeDownsyncClause{{
+					NamespaceScopeDownsyncObject: v1alpha1.NamespaceScopeDownsyncObject{GroupVersionResource: cmGVR, Namespace: "demo", Name: "cm1"},
+					DownsyncModulation:           v1alpha1.DownsyncModulation{DriftPolicy: driftPolicy},
+				}}},
+			},
+		}
+	}
+	expectCondition := func(bdg *v1alpha1.Binding, status corev1.ConditionStatus, reason v1alpha1.ConditionReason) {
+		t.Helper()
+		idx := slices.IndexFunc(bdg.Status.Conditions, func(cond v1alpha1.BindingPolicyCondition) bool { return cond.Type == v1alpha1.TypeDrifted })
+		if idx < 0 {
+			t.Fatalf("Expected a Drifted condition, got %+v", bdg.Status.Conditions)
+		}
+		if cond := bdg.Status.Conditions[idx]; cond.Status != status || cond.Reason != reason {
+			t.Errorf("Expected Drifted condition with status %s and reason %s, got %+v", status, reason, cond)
+		}
+	}
+	c := &Controller{drift: map[driftKey]map[string]v1alpha1.DestinationDrift{}}
+	key := driftKey{Resource: "configmaps", ObjectName: cache.NewObjectName("demo", "cm1")}
+
+	bdg := newBinding("")
+	if c.setDriftStatus(bdg) || len(bdg.Status.Conditions) != 0 {
+		t.Errorf("Expected no change for a Binding that checks nothing for drift, got %+v", bdg.Status)
+	}
+
+	bdg = newBinding(v1alpha1.DriftPolicyReport)
+	if !c.setDriftStatus(bdg) {
+		t.Error("Expected the first Drifted condition to be a change")
+	}
+	expectCondition(bdg, corev1.ConditionFalse, v1alpha1.ReasonNoDrift)
+	if c.setDriftStatus(bdg) {
+		t.Error("Expected no change when nothing changed")
+	}
+
+	wec2Drift := v1alpha1.DestinationDrift{ClusterId: "wec2", Paths: []string{".data.a"}, DesiredStateHash: "h1"}
+	if !c.recordDrift(key, "wec2", &wec2Drift) || c.recordDrift(key, "wec2", &wec2Drift) {
+		t.Error("Expected recording drift to be a change only the first time")
+	}
+	wec3Drift := v1alpha1.DestinationDrift{ClusterId: "wec3", Paths: []string{".data.a"}, DesiredStateHash: "h1"}
+	c.recordDrift(key, "wec3", &wec3Drift)
+	if !c.setDriftStatus(bdg) {
+		t.Error("Expected recorded drift to change the status")
+	}
+	expectCondition(bdg, corev1.ConditionTrue, v1alpha1.ReasonDrifted)
+	if len(bdg.Status.Drift) != 1 || len(bdg.Status.Drift[0].Destinations) != 1 || bdg.Status.Drift[0].Destinations[0].ClusterId != "wec2" {
+		t.Errorf("Expected drift in wec2 only, got %+v", bdg.Status.Drift)
+	}
+
+	if !c.recordDrift(key, "wec2", nil) || c.recordDrift(key, "wec2", nil) {
+		t.Error("Expected recording the absence of drift to be a change only the first time")
+	}
+	if !c.setDriftStatus(bdg) {
+		t.Error("Expected the end of drift to change the status")
+	}
+	expectCondition(bdg, corev1.ConditionFalse, v1alpha1.ReasonNoDrift)
+	if len(bdg.Status.Drift) != 0 {
+		t.Errorf("Expected no drift, got %+v", bdg.Status.Drift)
+	}
+
+	// A Binding that no longer checks anything keeps its condition up to date
+	noLonger := newBinding("")
+	noLonger.Status = bdg.Status
+	c.setDriftStatus(noLonger)
+	expectCondition(noLonger, corev1.ConditionFalse, v1alpha1.ReasonNoDrift)
+}
@@ -94,6 +94,25 @@ type Controller struct {
 	workStatusToObject abstract.MutableMapToComparable[cache.ObjectName, util.ObjectIdentifier]
 
 	mutex sync.RWMutex // used in workStatusToObject
+
+	// upsyncEnabled is set by EnableUpsync.
+	upsyncEnabled bool
+	// driftDetectionEnabled is set by EnableDriftDetection.
+	driftDetectionEnabled bool
+
+	// drift holds, for each workload object reported for drift detection,
+	// the drift found in each WEC where there is some.
+	drift      map[driftKey]map[string]v1alpha1.DestinationDrift
+	driftMutex sync.Mutex
+
+	// desiredStateIndexer holds the desired-state ConfigMaps in the ITS,
+	// indexed by desiredStateIndexName.
+	desiredStateIndexer cache.Indexer
+
+	// bindingToUpsyncScope holds, for each Binding last synced with some upsync clauses,
+	// what it then said about upsync.
+	bindingToUpsyncScope map[string]upsyncScope
+	upsyncMutex          sync.Mutex
 }
 
 type workloadObjectRef struct{ util.ObjectIdentifier }
@@ -115,6 +134,18 @@ func (wsr workStatusRef) ObjectName() cache.ObjectName {
 	return cache.ObjectName{Namespace: wsr.WECName, Name: wsr.Name}
 }
 
+// upsyncRef is a workqueue item that references a WorkStatus
+// that reports an object for upsync
+type upsyncRef struct{ workStatusRef }
+
+// driftRef is a workqueue item that references a WorkStatus
+// that reports an object for drift detection
+type driftRef struct{ workStatusRef }
+
+// desiredStatesRef is a workqueue item that references the mailbox namespace, in the ITS,
+// of a WEC whose desired-state ConfigMaps changed
+type desiredStatesRef string
+
 // combinedStatusRef is a workqueue item that references a CombinedStatus
 type combinedStatusRef string
 
@@ -162,6 +193,8 @@ func NewController(logger logr.Logger,
 		}),
 		workqueue:             workqueue.NewRateLimitingQueueWithConfig(ratelimiter, workqueue.RateLimitingQueueConfig{Name: ControllerName + "-" + wdsName}),
 		bindingPolicyResolver: bindingPolicyResolver,
+		drift:                 map[driftKey]map[string]v1alpha1.DestinationDrift{},
+		bindingToUpsyncScope:  map[string]upsyncScope{},
 	}
 	controller.workStatusToObject = abstract.NewLockedMapToComparable(&controller.mutex,
 		abstract.NewPrimitiveMapToComparable[cache.ObjectName, util.ObjectIdentifier]())
@@ -186,6 +219,24 @@ func NewController(logger logr.Logger,
 	return controller, nil
 }
 
+// EnableUpsync makes the controller maintain the copies of the objects that the WEC's
+// status agents report for upsync (see v1alpha1.UpsyncWorkStatusLabelKey).
+// Without this, upsync clauses have no effect. This requires status agents that
+// report objects for upsync, which the OCM status add-on agent that KubeStellar
+// currently deploys does not do. It must be called before Start.
+func (c *Controller) EnableUpsync() {
+	c.upsyncEnabled = true
+}
+
+// EnableDriftDetection makes the controller compare the objects that the WEC's status agents
+// report for drift detection (see v1alpha1.DriftWorkStatusLabelKey) with their desired states,
+// and report the drift in the status of the Bindings. Without this, drift policies have no effect.
+// This requires status agents that report objects for drift detection, which the OCM status
+// add-on agent that KubeStellar currently deploys does not do. It must be called before Start.
+func (c *Controller) EnableDriftDetection() {
+	c.driftDetectionEnabled = true
+}
+
 func (c *Controller) HandleWorkloadObjectEvent(gvr schema.GroupVersionResource, oldObj, obj util.MRObject, eventType binding.WorkloadEventType, wasDeletedFinalStateUnknown bool) {
 	objId := util.IdentifierForObject(obj, gvr.Resource)
 	labels := obj.GetLabels()
@@ -223,6 +274,9 @@ func (c *Controller) run(ctx context.Context, workers int, cListers chan interfa
 			"cluster-scoped objects: %w", util.ClusterScopedObjectsCombinedStatusNamespace, err)
 	}
 
+	if c.driftDetectionEnabled {
+		c.setupDesiredStateInformer(ctx)
+	}
 	go c.runWorkStatusInformer(ctx)
 
 	ksInformerFactory := ksinformers.NewSharedInformerFactory(c.wdsKsClient, defaultResyncPeriod)
@@ -351,6 +405,9 @@ func (c *Controller) runWorkStatusInformer(ctx context.Context) {
 	// add indexer on key from (wecName, sourceRef) for workstatus fetching efficiency
 	c.workStatusInformer.AddIndexers(cache.Indexers{
 		workStatusIdentificationIndexKey: func(obj interface{}) ([]string, error) {
+			if isUpsyncWorkStatus(obj.(metav1.Object)) || isDriftWorkStatus(obj.(metav1.Object)) {
+				return nil, nil
+			}
 			wecName := obj.(metav1.Object).GetNamespace()
 			sourceRef, err := util.GetWorkStatusSourceRef(obj.(runtime.Object))
 			if err != nil {
@@ -425,6 +482,18 @@ func (c *Controller) handleWorkStatus(ctx context.Context, eventType string, obj
 	logger.V(5).Info("Enqueuing reference to WorkStatus because of informer event", "eventType", eventType,
 		"sourceObjectName", wsRef.SourceObjectIdentifier.ObjectName,
 		"sourceObjectGVK", wsRef.SourceObjectIdentifier.GVK, "wecName", wsRef.WECName)
+	if isUpsyncWorkStatus(obj.(metav1.Object)) {
+		if c.upsyncEnabled {
+			c.workqueue.Add(upsyncRef{*wsRef})
+		}
+		return
+	}
+	if isDriftWorkStatus(obj.(metav1.Object)) {
+		if c.driftDetectionEnabled {
+			c.workqueue.Add(driftRef{*wsRef})
+		}
+		return
+	}
 	c.workqueue.Add(*wsRef)
 }
 
@@ -484,6 +553,12 @@ func (c *Controller) reconcile(ctx context.Context, item any) error {
 		return c.syncWorkloadObject(ctx, ref.ObjectIdentifier)
 	case workStatusRef:
 		return c.syncWorkStatus(ctx, ref)
+	case upsyncRef:
+		return c.syncUpsync(ctx, ref)
+	case driftRef:
+		return c.syncDrift(ctx, ref)
+	case desiredStatesRef:
+		return c.syncDesiredStates(ctx, string(ref))
 	case bindingRef:
 		return c.syncBinding(ctx, string(ref))
 	case statusCollectorRef:
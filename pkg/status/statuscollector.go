@@ -46,7 +46,7 @@ func (c *Controller) syncStatusCollector(ctx context.Context, ref string) error
 	}
 
 	// Validate the StatusCollector
-	if errs := c.validateStatusCollector(statusCollector); len(errs) > 0 {
+	if errs := validateStatusCollector(c.celEvaluator, statusCollector); len(errs) > 0 {
 		if err := c.updateStatusCollectorErrors(ctx, statusCollector.DeepCopy(), errs); err != nil {
 			return err
 		}
@@ -72,10 +72,29 @@ func (c *Controller) syncStatusCollector(ctx context.Context, ref string) error
 	return nil
 }
 
+// StatusCollectorValidator checks StatusCollector objects the same way that
+// the status controller does, for use at admission time.
+type StatusCollectorValidator struct {
+	celEvaluator *celEvaluator
+}
+
+func NewStatusCollectorValidator() (*StatusCollectorValidator, error) {
+	celEvaluator, err := newCELEvaluator()
+	if err != nil {
+		return nil, err
+	}
+	return &StatusCollectorValidator{celEvaluator: celEvaluator}, nil
+}
+
+// Validate returns the errors in the given StatusCollector, if any.
+func (v *StatusCollectorValidator) Validate(statusCollector *v1alpha1.StatusCollector) []error {
+	return validateStatusCollector(v.celEvaluator, statusCollector)
+}
+
 // validateStatusCollector validates the StatusCollector resource
 // and returns a list of errors if any.
 // The passed statuscollector is not mutated.
-func (c *Controller) validateStatusCollector(statusCollector *v1alpha1.StatusCollector) []error {
+func validateStatusCollector(celEvaluator *celEvaluator, statusCollector *v1alpha1.StatusCollector) []error {
 	var errs []error
 	// groupBy & CombinedFields empty if select is not
 	if len(statusCollector.Spec.Select) > 0 &&
@@ -93,20 +112,20 @@ func (c *Controller) validateStatusCollector(statusCollector *v1alpha1.StatusCol
 	}
 
 	// validate filter expression
-	if err := c.celEvaluator.CheckExpression(statusCollector.Spec.Filter); err != nil {
+	if err := celEvaluator.CheckExpression(statusCollector.Spec.Filter); err != nil {
 		errs = append(errs, fmt.Errorf("filter expression invalid: %w", err))
 	}
 
 	// validate select expression
 	for _, selectExpr := range statusCollector.Spec.Select {
-		if err := c.celEvaluator.CheckExpression(&selectExpr.Def); err != nil {
+		if err := celEvaluator.CheckExpression(&selectExpr.Def); err != nil {
 			errs = append(errs, fmt.Errorf("select expression (%s) invalid: %w", selectExpr.Name, err))
 		}
 	}
 
 	// validate groupBy expression
 	for _, groupByExpr := range statusCollector.Spec.GroupBy {
-		if err := c.celEvaluator.CheckExpression(&groupByExpr.Def); err != nil {
+		if err := celEvaluator.CheckExpression(&groupByExpr.Def); err != nil {
 			errs = append(errs, fmt.Errorf("groupBy expression (%s) invalid: %w", groupByExpr.Name, err))
 		}
 	}
@@ -129,7 +148,7 @@ func (c *Controller) validateStatusCollector(statusCollector *v1alpha1.StatusCol
 			continue
 		}
 
-		if err := c.celEvaluator.CheckExpression(combinedField.Subject); err != nil {
+		if err := celEvaluator.CheckExpression(combinedField.Subject); err != nil {
 			errs = append(errs, fmt.Errorf("combinedField expression (%s) subject invalid: %w",
 				combinedField.Name, err))
 		}
@@ -0,0 +1,307 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+// upsyncPlacements are all the values of v1alpha1.UpsyncPlacement.
+var upsyncPlacements = []v1alpha1.UpsyncPlacement{v1alpha1.UpsyncPlacementClusterNamespace, v1alpha1.UpsyncPlacementClusterNamePrefix}
+
+// isUpsyncWorkStatus tells whether the given WorkStatus reports an object for upsync
+// rather than the status of a downsynced object.
+func isUpsyncWorkStatus(obj metav1.Object) bool {
+	return obj.GetLabels()[v1alpha1.UpsyncWorkStatusLabelKey] == "true"
+}
+
+// upsyncScope is what a Binding says about upsync.
+type upsyncScope struct {
+	// wecs holds the names of the Binding's destinations.
+	wecs    sets.Set[string]
+	clauses []v1alpha1.UpsyncPolicyClause
+}
+
+// upsyncScopeOf returns what the given Binding says about upsync.
+// The zero value is returned for a Binding that does not exist or has no upsync clauses.
+func upsyncScopeOf(binding *v1alpha1.Binding) upsyncScope {
+	if binding == nil || len(binding.Spec.Upsync) == 0 {
+		return upsyncScope{}
+	}
+	scope := upsyncScope{wecs: sets.New[string](), clauses: binding.Spec.Upsync}
+	for _, dest := range binding.Spec.Destinations {
+		scope.wecs.Insert(dest.ClusterId)
+	}
+	return scope
+}
+
+// enqueueUpsyncWorkStatuses enqueues the WorkStatus objects, from this WDS, that report
+// objects for upsync and that the given Binding, as it is now or as it was when last synced,
+// has an upsync clause for.
+func (c *Controller) enqueueUpsyncWorkStatuses(ctx context.Context, bindingName string) {
+	logger := klog.FromContext(ctx)
+	binding, err := c.bindingLister.Get(bindingName)
+	if err != nil && !errors.IsNotFound(err) { // listers do not fail
+		logger.Error(err, "Failed to get Binding", "binding", bindingName)
+		return
+	}
+	scope := upsyncScopeOf(binding)
+	c.upsyncMutex.Lock()
+	prevScope := c.bindingToUpsyncScope[bindingName]
+	if len(scope.clauses) == 0 {
+		delete(c.bindingToUpsyncScope, bindingName)
+	} else {
+		c.bindingToUpsyncScope[bindingName] = scope
+	}
+	c.upsyncMutex.Unlock()
+	if len(scope.clauses) == 0 && len(prevScope.clauses) == 0 {
+		return
+	}
+	clauses := slices.Concat(scope.clauses, prevScope.clauses)
+	upsyncSelector := labels.SelectorFromSet(labels.Set{v1alpha1.UpsyncWorkStatusLabelKey: "true"})
+	for wecName := range scope.wecs.Union(prevScope.wecs) {
+		objs, err := c.workStatusLister.ByNamespace(wecName).List(upsyncSelector)
+		if err != nil { // listers do not fail
+			logger.Error(err, "Failed to list upsync WorkStatus objects", "wec", wecName)
+			continue
+		}
+		for _, obj := range objs {
+			if objNotInThisWDS(obj, c.wdsName) {
+				continue
+			}
+			ref, err := runtimeObjectToWorkStatusRef(obj)
+			if err != nil {
+				logger.Error(err, "Failed to get source ref", "object", util.RefToRuntimeObj(obj))
+				continue
+			}
+			var objLabels labels.Set
+			if content, err := util.GetWorkStatusStatus(obj); err == nil && content != nil {
+				objLabels = (&unstructured.Unstructured{Object: content}).GetLabels()
+			}
+			if !slices.ContainsFunc(clauses, func(clause v1alpha1.UpsyncPolicyClause) bool {
+				return util.UpsyncClauseMatches(clause, ref.SourceObjectIdentifier, objLabels)
+			}) {
+				continue
+			}
+			c.workqueue.Add(upsyncRef{*ref})
+		}
+	}
+}
+
+// syncUpsync makes the WDS have, or not have, the copy of the object that
+// the referenced WorkStatus reports for upsync, according to the upsync clauses
+// of the Bindings that have the WorkStatus's WEC as a destination.
+func (c *Controller) syncUpsync(ctx context.Context, ref upsyncRef) error {
+	logger := klog.FromContext(ctx)
+	var content map[string]any
+	obj, err := c.workStatusLister.ByNamespace(ref.WECName).Get(ref.Name)
+	if err == nil {
+		content, err = util.GetWorkStatusStatus(obj)
+		if err != nil {
+			logger.Error(err, "Failed to get status from workstatus", "workStatusRef", ref.workStatusRef)
+		}
+	} else if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get workstatus (%v): %w", ref.workStatusRef, err)
+	}
+	if content == nil {
+		return c.deleteUpsyncCopies(ctx, ref, nil)
+	}
+	objLabels := (&unstructured.Unstructured{Object: content}).GetLabels()
+	placement, matched, err := c.upsyncPlacementFor(ref.WECName, ref.SourceObjectIdentifier, objLabels)
+	if err != nil {
+		return err
+	}
+	if !matched {
+		logger.V(5).Info("No upsync clause matches reported object", "workStatusRef", ref.workStatusRef)
+		return c.deleteUpsyncCopies(ctx, ref, nil)
+	}
+	return c.ensureUpsyncCopy(ctx, ref, placement, content)
+}
+
+// upsyncPlacementFor returns the placement from the first upsync clause, of the Bindings
+// (in order of name) that have the given WEC as a destination, that matches the given object.
+// The returned bool tells whether any clause matches.
+func (c *Controller) upsyncPlacementFor(wecName string, objId util.ObjectIdentifier, objLabels labels.Set) (v1alpha1.UpsyncPlacement, bool, error) {
+	bindings, err := c.bindingLister.List(labels.Everything())
+	if err != nil {
+		return "", false, fmt.Errorf("failed to list Bindings: %w", err)
+	}
+	slices.SortFunc(bindings, func(a, b *v1alpha1.Binding) int { return strings.Compare(a.Name, b.Name) })
+	for _, binding := range bindings {
+		if !slices.ContainsFunc(binding.Spec.Destinations, func(dest v1alpha1.Destination) bool { return dest.ClusterId == wecName }) {
+			continue
+		}
+		for _, clause := range binding.Spec.Upsync {
+			if util.UpsyncClauseMatches(clause, objId, objLabels) {
+				if clause.Placement == "" {
+					return v1alpha1.UpsyncPlacementClusterNamespace, true, nil
+				}
+				return clause.Placement, true, nil
+			}
+		}
+	}
+	return "", false, nil
+}
+
+// upsyncTarget returns the name, in the WDS, of the copy of the given object from the given WEC.
+func upsyncTarget(placement v1alpha1.UpsyncPlacement, wecName string, source cache.ObjectName) cache.ObjectName {
+	if source.Namespace == "" || placement == v1alpha1.UpsyncPlacementClusterNamePrefix {
+		return cache.NewObjectName(source.Namespace, wecName+"-"+source.Name)
+	}
+	return cache.NewObjectName(wecName, source.Name)
+}
+
+// upsyncCopy returns the copy to maintain in the WDS of the given reported object.
+// The copy has the object's labels and annotations, plus the ones that identify it
+// as an upsynced copy, and the object's content other than metadata and status.
+func upsyncCopy(wecName string, objId util.ObjectIdentifier, target cache.ObjectName, content map[string]any) *unstructured.Unstructured {
+	source := &unstructured.Unstructured{Object: content}
+	ans := &unstructured.Unstructured{Object: map[string]any{}}
+	for key, val := range content {
+		if key == "metadata" || key == "status" {
+			continue
+		}
+		ans.Object[key] = runtime.DeepCopyJSONValue(val)
+	}
+	ans.SetAPIVersion(objId.GVK.GroupVersion().String())
+	ans.SetKind(objId.GVK.Kind)
+	ans.SetNamespace(target.Namespace)
+	ans.SetName(target.Name)
+	objLabels := source.GetLabels()
+	if objLabels == nil {
+		objLabels = map[string]string{}
+	}
+	objLabels[v1alpha1.UpsyncedFromLabelKey] = wecName
+	ans.SetLabels(objLabels)
+	objAnnotations := source.GetAnnotations()
+	if objAnnotations == nil {
+		objAnnotations = map[string]string{}
+	}
+	objAnnotations[v1alpha1.UpsyncSourceAnnotationKey] = objId.ObjectName.String()
+	ans.SetAnnotations(objAnnotations)
+	return ans
+}
+
+// isUpsyncCopyOf tells whether the given object in the WDS is an upsynced copy
+// of the given object in the given WEC.
+func isUpsyncCopyOf(obj *unstructured.Unstructured, wecName string, source cache.ObjectName) bool {
+	return obj.GetLabels()[v1alpha1.UpsyncedFromLabelKey] == wecName &&
+		obj.GetAnnotations()[v1alpha1.UpsyncSourceAnnotationKey] == source.String()
+}
+
+// upsyncCopyMatches tells whether the existing copy has the desired labels, annotations, and content.
+func upsyncCopyMatches(existing, desired *unstructured.Unstructured) bool {
+	if !apiequality.Semantic.DeepEqual(existing.GetLabels(), desired.GetLabels()) ||
+		!apiequality.Semantic.DeepEqual(existing.GetAnnotations(), desired.GetAnnotations()) {
+		return false
+	}
+	for key := range existing.Object {
+		if _, has := desired.Object[key]; !has && key != "metadata" && key != "status" {
+			return false
+		}
+	}
+	for key, val := range desired.Object {
+		if key != "metadata" && !apiequality.Semantic.DeepEqual(existing.Object[key], val) {
+			return false
+		}
+	}
+	return true
+}
+
+// ensureUpsyncCopy creates or updates the copy, at the given placement, of the
+// object reported by the referenced WorkStatus, and deletes its copies at other placements.
+func (c *Controller) ensureUpsyncCopy(ctx context.Context, ref upsyncRef, placement v1alpha1.UpsyncPlacement, content map[string]any) error {
+	logger := klog.FromContext(ctx)
+	objId := ref.SourceObjectIdentifier
+	target := upsyncTarget(placement, ref.WECName, objId.ObjectName)
+	desired := upsyncCopy(ref.WECName, objId, target, content)
+	if placement == v1alpha1.UpsyncPlacementClusterNamespace && target.Namespace != "" {
+		if err := c.ensureNamespaceExists(ctx, target.Namespace); err != nil {
+			return fmt.Errorf("failed to ensure namespace (%s) for upsynced objects: %w", target.Namespace, err)
+		}
+	}
+	rscIfc := util.DynamicForResource(c.wdsDynClient, objId.GVR(), target.Namespace)
+	existing, err := rscIfc.Get(ctx, target.Name, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		if _, err := rscIfc.Create(ctx, desired, metav1.CreateOptions{FieldManager: ControllerName}); err != nil {
+			return fmt.Errorf("failed to create upsynced copy %v of %v from %s: %w", target, objId, ref.WECName, err)
+		}
+		logger.V(4).Info("Created upsynced copy", "target", target, "objectIdentifier", objId, "wec", ref.WECName)
+	case err != nil:
+		return fmt.Errorf("failed to get upsynced copy %v: %w", target, err)
+	case !isUpsyncCopyOf(existing, ref.WECName, objId.ObjectName):
+		logger.Error(nil, "Not upsyncing over an object that is not a copy of the reported one", "target", target,
+			"objectIdentifier", objId, "wec", ref.WECName)
+	case upsyncCopyMatches(existing, desired):
+		logger.V(5).Info("Upsynced copy is up to date", "target", target, "objectIdentifier", objId, "wec", ref.WECName)
+	default:
+		desired.SetResourceVersion(existing.GetResourceVersion())
+		if _, err := rscIfc.Update(ctx, desired, metav1.UpdateOptions{FieldManager: ControllerName}); err != nil {
+			return fmt.Errorf("failed to update upsynced copy %v of %v from %s: %w", target, objId, ref.WECName, err)
+		}
+		logger.V(4).Info("Updated upsynced copy", "target", target, "objectIdentifier", objId, "wec", ref.WECName)
+	}
+	return c.deleteUpsyncCopies(ctx, ref, &target)
+}
+
+// deleteUpsyncCopies deletes the copies, at every placement other than the given one
+// (if any), of the object reported by the referenced WorkStatus.
+func (c *Controller) deleteUpsyncCopies(ctx context.Context, ref upsyncRef, except *cache.ObjectName) error {
+	objId := ref.SourceObjectIdentifier
+	var done []cache.ObjectName
+	for _, placement := range upsyncPlacements {
+		target := upsyncTarget(placement, ref.WECName, objId.ObjectName)
+		if except != nil && target == *except || slices.Contains(done, target) {
+			continue
+		}
+		done = append(done, target)
+		rscIfc := util.DynamicForResource(c.wdsDynClient, objId.GVR(), target.Namespace)
+		existing, err := rscIfc.Get(ctx, target.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to get upsynced copy %v: %w", target, err)
+		}
+		if !isUpsyncCopyOf(existing, ref.WECName, objId.ObjectName) {
+			continue
+		}
+		uid := existing.GetUID()
+		err = rscIfc.Delete(ctx, target.Name, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{UID: &uid}})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete upsynced copy %v: %w", target, err)
+		}
+		klog.FromContext(ctx).V(4).Info("Deleted upsynced copy", "target", target, "objectIdentifier", objId, "wec", ref.WECName)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2025 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	controllisters "github.com/kubestellar/kubestellar/pkg/generated/listers/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+func TestUpsyncTarget(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		placement v1alpha1.UpsyncPlacement
+		source    cache.ObjectName
+		expected  cache.ObjectName
+	}{
+		{name: "cluster namespace", placement: v1alpha1.UpsyncPlacementClusterNamespace,
+			source: cache.NewObjectName("demo", "report"), expected: cache.NewObjectName("wec1", "report")},
+		{name: "cluster name prefix", placement: v1alpha1.UpsyncPlacementClusterNamePrefix,
+			source: cache.NewObjectName("demo", "report"), expected: cache.NewObjectName("demo", "wec1-report")},
+		{name: "cluster-scoped", placement: v1alpha1.UpsyncPlacementClusterNamespace,
+			source: cache.NewObjectName("", "report"), expected: cache.NewObjectName("", "wec1-report")},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := upsyncTarget(tc.placement, "wec1", tc.source)
+			if actual != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUpsyncCopy(t *testing.T) {
+	objId := util.ObjectIdentifier{
+		GVK:        schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+		Resource:   "configmaps",
+		ObjectName: cache.NewObjectName("demo", "report"),
+	}
+	content := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]any{
+			"namespace":       "demo",
+			"name":            "report",
+			"uid":             "1234",
+			"resourceVersion": "42",
+			"labels":          map[string]any{"kind": "report"},
+		},
+		"data": map[string]any{"result": "pass"},
+	}
+	target := cache.NewObjectName("wec1", "report")
+	desired := upsyncCopy("wec1", objId, target, content)
+	if desired.GetNamespace() != "wec1" || desired.GetName() != "report" || desired.GetUID() != "" || desired.GetResourceVersion() != "" {
+		t.Errorf("Wrong metadata in copy: %#v", desired.Object["metadata"])
+	}
+	if !isUpsyncCopyOf(desired, "wec1", objId.ObjectName) || isUpsyncCopyOf(desired, "wec2", objId.ObjectName) {
+		t.Errorf("Copy is not identified as a copy from wec1 only: %#v", desired.Object["metadata"])
+	}
+	if desired.GetLabels()["kind"] != "report" {
+		t.Errorf("Copy lacks the source's labels: %#v", desired.GetLabels())
+	}
+	if (&unstructured.Unstructured{Object: content}).GetLabels()[v1alpha1.UpsyncedFromLabelKey] != "" {
+		t.Error("Making the copy modified the source's labels")
+	}
+
+	existing := desired.DeepCopy()
+	existing.SetUID("5678")
+	existing.SetResourceVersion("7")
+	existing.Object["status"] = map[string]any{"phase": "done"}
+	if !upsyncCopyMatches(existing, desired) {
+		t.Error("Expected copy with other server-set metadata and status to match")
+	}
+	existing.Object["data"] = map[string]any{"result": "fail"}
+	if upsyncCopyMatches(existing, desired) {
+		t.Error("Expected copy with other data to not match")
+	}
+	existing = desired.DeepCopy()
+	existing.Object["binaryData"] = map[string]any{}
+	if upsyncCopyMatches(existing, desired) {
+		t.Error("Expected copy with an extra field to not match")
+	}
+}
+
+func TestEnqueueUpsyncWorkStatuses(t *testing.T) {
+	ctx := context.Background()
+	newWorkStatus := func(wecName, name, resource, kind string) *unstructured.Unstructured {
+		ws := &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "control.kubestellar.io/v1alpha1",
+			"kind":       "WorkStatus",
+			"spec": map[string]any{"sourceRef": map[string]any{
+				"group": "", "version": "v1", "resource": resource, "kind": kind, "namespace": "demo", "name": name,
+			}},
+			"status": map[string]any{"metadata": map[string]any{"name": name}},
+		}}
+		ws.SetNamespace(wecName)
+		ws.SetName(wecName + "-" + name)
+		ws.SetLabels(map[string]string{v1alpha1.UpsyncWorkStatusLabelKey: "true"})
+		return ws
+	}
+	wsIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, ws := range []*unstructured.Unstructured{
+		newWorkStatus("wec1", "report", "configmaps", "ConfigMap"),
+		newWorkStatus("wec1", "other", "configmaps", "ConfigMap"),
+		newWorkStatus("wec1", "creds", "secrets", "Secret"),
+		newWorkStatus("wec2", "report", "configmaps", "ConfigMap"),
+		newWorkStatus("wec2", "other", "configmaps", "ConfigMap"),
+	} {
+		if err := wsIndexer.Add(ws); err != nil {
+			t.Fatalf("Failed to add WorkStatus: %s", err)
+		}
+	}
+	binding := &v1alpha1.Binding{
+		ObjectMeta: metav1.ObjectMeta{Name: "b1"},
+		Spec: v1alpha1.BindingSpec{
+			Destinations: []v1alpha1.Destination{{ClusterId: "wec1"}},
+			Upsync:       []v1alpha1.UpsyncPolicyClause{{Resources: []string{"configmaps"}, ObjectNames: []string{"report"}}},
+		},
+	}
+	bindingIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := bindingIndexer.Add(binding); err != nil {
+		t.Fatalf("Failed to add Binding: %s", err)
+	}
+	c := &Controller{
+		bindingLister:        controllisters.NewBindingLister(bindingIndexer),
+		workStatusLister:     cache.NewGenericLister(wsIndexer, v1alpha1.Resource("workstatuses")),
+		workqueue:            workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		bindingToUpsyncScope: map[string]upsyncScope{},
+	}
+	defer c.workqueue.ShutDown()
+	drain := func() sets.Set[string] {
+		names := sets.New[string]()
+		for c.workqueue.Len() > 0 {
+			item, _ := c.workqueue.Get()
+			names.Insert(item.(upsyncRef).ObjectName().String())
+			c.workqueue.Done(item)
+		}
+		return names
+	}
+
+	c.enqueueUpsyncWorkStatuses(ctx, "b1")
+	if actual, expected := drain(), sets.New("wec1/wec1-report"); !actual.Equal(expected) {
+		t.Errorf("Expected %v to be enqueued, got %v", sets.List(expected), sets.List(actual))
+	}
+
+	// A destination and a clause that are removed still count once,
+	// and are combined with the added ones
+	updated := binding.DeepCopy()
+	updated.Spec.Destinations = []v1alpha1.Destination{{ClusterId: "wec2"}}
+	updated.Spec.Upsync = []v1alpha1.UpsyncPolicyClause{{Resources: []string{"configmaps"}, ObjectNames: []string{"other"}}}
+	if err := bindingIndexer.Update(updated); err != nil {
+		t.Fatalf("Failed to update Binding: %s", err)
+	}
+	c.enqueueUpsyncWorkStatuses(ctx, "b1")
+	if actual, expected := drain(), sets.New("wec1/wec1-report", "wec1/wec1-other", "wec2/wec2-report", "wec2/wec2-other"); !actual.Equal(expected) {
+		t.Errorf("Expected %v to be enqueued, got %v", sets.List(expected), sets.List(actual))
+	}
+
+	if err := bindingIndexer.Delete(updated); err != nil {
+		t.Fatalf("Failed to delete Binding: %s", err)
+	}
+	c.enqueueUpsyncWorkStatuses(ctx, "b1")
+	if actual, expected := drain(), sets.New("wec2/wec2-other"); !actual.Equal(expected) {
+		t.Errorf("Expected %v to be enqueued, got %v", sets.List(expected), sets.List(actual))
+	}
+	if len(c.bindingToUpsyncScope) != 0 {
+		t.Errorf("Expected no remembered upsync scopes, got %v", c.bindingToUpsyncScope)
+	}
+}
@@ -40,6 +40,7 @@ import (
 	ksmetrics "github.com/kubestellar/kubestellar/pkg/metrics"
 	"github.com/kubestellar/kubestellar/pkg/transport"
 	transportgeneric "github.com/kubestellar/kubestellar/pkg/transport/generic"
+	"github.com/kubestellar/kubestellar/pkg/transport/generic/filtering"
 )
 
 // The following code is responsible for running a transport controller with a given
@@ -130,16 +131,37 @@ func GenericMain(transportImplementation transport.Transport) {
 
 	itsK8sInformerFactory := k8sinformers.NewSharedInformerFactory(transportClientset, defaultResyncPeriod)
 
+	cleanupRuleList := filtering.DefaultCleanupRules()
+	if options.CleanupRulesFile != "" {
+		moreRules, err := filtering.LoadCleanupRules(options.CleanupRulesFile)
+		if err != nil {
+			logger.Error(err, "Failed to load cleanup rules", "file", options.CleanupRulesFile)
+			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+		}
+		cleanupRuleList = append(cleanupRuleList, moreRules...)
+	}
+	cleanupRules, err := filtering.NewCleanupRules(cleanupRuleList)
+	if err != nil {
+		logger.Error(err, "Invalid cleanup rules", "file", options.CleanupRulesFile)
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
 	transportController, err := transportgeneric.NewTransportController(ctx, wdsClientMetrics, itsClientMetrics, inventoryPreInformer,
 		wdsClientset.ControlV1alpha1().Bindings(), wdsControlInformers.Bindings(),
-		wdsControlInformers.CustomTransforms(),
+		wdsControlInformers.CustomTransforms(), wdsControlInformers.CombinedStatuses(),
 		transportImplementation, wdsClientset, wdsDynamicClient, transportClientset.CoreV1().Namespaces(), itsK8sInformerFactory.Core().V1().ConfigMaps(),
-		transportClientset, transportDynamicClient, options.MaxSizeWrapped, options.MaxNumWrapped, options.WdsName)
+		transportClientset, transportDynamicClient, cleanupRules, options.MaxSizeWrapped, options.MaxNumWrapped, options.WdsName)
 	if err != nil {
 		logger.Error(err, "failed to construct transport controller")
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
 	transportController.RegisterMetrics(legacyregistry.Register)
+	if options.EnableUpsync {
+		transportController.EnableUpsync()
+	}
+	if options.EnableDriftDetection {
+		transportController.EnableDriftDetection()
+	}
 
 	// notice that there is no need to run Start method in a separate goroutine.
 	// Start method is non-blocking and runs each of the factory's informers in its own dedicated goroutine.
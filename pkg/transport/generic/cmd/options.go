@@ -33,6 +33,9 @@ type TransportOptions struct {
 	MaxSizeWrapped         int
 	MaxNumWrapped          int
 	WdsName                string
+	CleanupRulesFile       string
+	EnableUpsync           bool
+	EnableDriftDetection   bool
 	ksopts.ProcessOptions
 }
 
@@ -58,5 +61,8 @@ func (options *TransportOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.IntVar(&options.MaxSizeWrapped, "max-size-wrapped", options.MaxSizeWrapped, "Max size of the wrapped object in bytes")
 	fs.IntVar(&options.MaxNumWrapped, "max-num-wrapped", options.MaxNumWrapped, "Max number of objects inside the wrapped object")
 	fs.StringVar(&options.WdsName, "wds-name", options.WdsName, "name of the wds to connect to. name should be unique")
+	fs.StringVar(&options.CleanupRulesFile, "cleanup-rules-file", options.CleanupRulesFile, "pathname of a YAML file holding a list of cleanup rules to use in addition to the built-in ones")
+	fs.BoolVar(&options.EnableUpsync, "enable-upsync", options.EnableUpsync, "convey the upsync clauses of Bindings to the status agents in the WECs; requires status agents that report objects for upsync, which the currently deployed OCM status add-on agent does not")
+	fs.BoolVar(&options.EnableDriftDetection, "enable-drift-detection", options.EnableDriftDetection, "convey desired states for drift detection and reapply drifted objects; requires status agents that report objects for drift detection, which the currently deployed OCM status add-on agent does not")
 	options.ProcessOptions.AddToFlags(fs)
 }
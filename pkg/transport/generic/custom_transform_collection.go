@@ -18,15 +18,25 @@ package transport
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
 
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
 	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
 	"github.com/kubestellar/kubestellar/pkg/abstract"
+	"github.com/kubestellar/kubestellar/pkg/celeval"
 	"github.com/kubestellar/kubestellar/pkg/jsonpath"
 	ksmetrics "github.com/kubestellar/kubestellar/pkg/metrics"
 )
@@ -34,8 +44,9 @@ import (
 // customTransformCollection digests CustomTransform objects and caches the results.
 type customTransformCollection interface {
 	// getCustomTransformChanges notes the use of the given GroupResource by the named Binding and
-	// returns the customTransformChanges to use for that GroupResource.
-	getCustomTransformChanges(ctx context.Context, groupResource metav1.GroupResource, bindingName string) customTransformChanges
+	// returns the selectiveChanges to use for that GroupResource, one per relevant CustomTransform,
+	// in order of CustomTransform name.
+	getCustomTransformChanges(ctx context.Context, groupResource metav1.GroupResource, bindingName string) []selectiveChanges
 
 	// noteCustomTransform reacts to a notification of a create/update/delete of a CustomTransform.
 	noteCustomTransform(ctx context.Context, name string, ct *v1alpha1.CustomTransform)
@@ -43,16 +54,153 @@ type customTransformCollection interface {
 	// setBindingGroupResources updates the customTransformCollection with the knowledge of the full set of GroupResources that
 	// a given Binding depends on.
 	setBindingGroupResources(bindingName string, newGroupResources sets.Set[metav1.GroupResource])
+
+	// setComputeErrors updates the customTransformCollection with the knowledge of the failures
+	// of computations in processing the named Binding. The map is keyed by CustomTransform name;
+	// a CustomTransform with no entry had no failures. The failures are reported in the status of
+	// the CustomTransform.
+	setComputeErrors(ctx context.Context, bindingName string, ctNameToErrors map[string][]string)
 }
 
 type customTransformChanges struct {
-	removes []jsonpath.Query // immutable
+	removes      []jsonpath.Query      // immutable
+	renames      []jsonpathRename      // immutable
+	sets         []jsonpathSetting     // immutable
+	setIfAbsents []jsonpathSetting     // immutable
+	computes     []jsonpathComputation // immutable
+}
+
+// selectiveChanges is the digested form of one CustomTransform:
+// its changes and the selectors that limit where they apply.
+type selectiveChanges struct {
+	customTransformChanges
+
+	// ctName is the name of the CustomTransform
+	ctName string
+
+	// Each selector is nil if the CustomTransform does not have it.
+	objectSelector    labels.Selector
+	namespaceSelector labels.Selector
+	clusterSelector   labels.Selector
+}
+
+// appliesToObject tests whether the object and namespace selectors match
+// the given object, whose namespace's labels are returned by getNamespaceLabels.
+func (changes selectiveChanges) appliesToObject(object *unstructured.Unstructured, getNamespaceLabels func(namespace string) (labels.Set, error)) (bool, error) {
+	if changes.objectSelector != nil && !changes.objectSelector.Matches(labels.Set(object.GetLabels())) {
+		return false, nil
+	}
+	if changes.namespaceSelector == nil {
+		return true, nil
+	}
+	if object.GetNamespace() == "" {
+		// Following admission webhooks, only a Namespace among the cluster-scoped objects
+		// is subject to a namespace selector.
+		if object.GetAPIVersion() != "v1" || object.GetKind() != "Namespace" {
+			return true, nil
+		}
+		return changes.namespaceSelector.Matches(labels.Set(object.GetLabels())), nil
+	}
+	nsLabels, err := getNamespaceLabels(object.GetNamespace())
+	if err != nil {
+		return false, err
+	}
+	return changes.namespaceSelector.Matches(nsLabels), nil
+}
+
+// destinationDependent tells whether these changes depend on the destination,
+// and thus have to be applied during customization.
+func (changes selectiveChanges) destinationDependent() bool {
+	return changes.clusterSelector != nil || len(changes.computes) > 0
+}
+
+// applyForDestination makes the changes to the given object content,
+// including the computations using the given destination properties.
+// The returned strings describe the computations that failed.
+func (changes selectiveChanges) applyForDestination(objectData map[string]any, props clusterProperties) []string {
+	changes.apply(objectData)
+	var errs []string
+	var objectDataAny any = objectData
+	rootNode := jsonpath.RootNode{Value: &objectDataAny}
+	for _, computation := range changes.computes {
+		value, err := computation.evaluate(objectData, props)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Failed to evaluate spec.compute[%d].expression of CustomTransform %q: %s", computation.index, changes.ctName, err))
+			continue
+		}
+		jsonpath.QueryOrCreate(computation.query, &rootNode, func(node jsonpath.Node) {
+			node.Set(runtime.DeepCopyJSONValue(value))
+		})
+	}
+	return errs
+}
+
+// jsonpathRename is a parsed RenameOperation.
+type jsonpathRename struct {
+	from, to jsonpath.Query
+}
+
+// jsonpathSetting is a parsed SetOperation.
+type jsonpathSetting struct {
+	query jsonpath.Query
+	value jsonpath.JSONValue // immutable; copy it before putting it in an object
+}
+
+func (changes customTransformChanges) isEmpty() bool {
+	return len(changes.removes) == 0 && len(changes.renames) == 0 && len(changes.sets) == 0 && len(changes.setIfAbsents) == 0 &&
+		len(changes.computes) == 0
 }
 
+// apply makes the destination-independent changes to the given object content:
+// first the removals, then the renames, then the sets, and finally the setIfAbsents.
+func (changes customTransformChanges) apply(objectData map[string]any) {
+	var objectDataAny any = objectData
+	rootNode := jsonpath.RootNode{Value: &objectDataAny}
+	for _, query := range changes.removes {
+		jsonpath.QueryValue(query, &rootNode, jsonpath.Node.Remove)
+	}
+	for _, rename := range changes.renames {
+		var value jsonpath.JSONValue
+		var found bool
+		jsonpath.QueryValue(rename.from, &rootNode, func(node jsonpath.Node) {
+			value, found = node.Get()
+			if found {
+				node.Remove()
+			}
+		})
+		if found {
+			jsonpath.QueryOrCreate(rename.to, &rootNode, func(node jsonpath.Node) { node.Set(value) })
+		}
+	}
+	for _, setting := range changes.sets {
+		jsonpath.QueryOrCreate(setting.query, &rootNode, func(node jsonpath.Node) {
+			node.Set(runtime.DeepCopyJSONValue(setting.value))
+		})
+	}
+	for _, setting := range changes.setIfAbsents {
+		jsonpath.QueryOrCreate(setting.query, &rootNode, func(node jsonpath.Node) {
+			if _, found := node.Get(); !found {
+				node.Set(runtime.DeepCopyJSONValue(setting.value))
+			}
+		})
+	}
+}
+
+// customTransformStatusWriter is the part of a CustomTransform client
+// that the customTransformCollection uses.
+type customTransformStatusWriter interface {
+	UpdateStatus(ctx context.Context, ct *v1alpha1.CustomTransform, opts metav1.UpdateOptions) (*v1alpha1.CustomTransform, error)
+}
+
+var _ customTransformStatusWriter = ksmetrics.ClientModNamespace[*v1alpha1.CustomTransform, *v1alpha1.CustomTransformList](nil)
+
 // customTransformCollectionImpl implements customTransformCollection
 type customTransformCollectionImpl struct {
 	// client is here for updating the status of a CustomTransform
-	client ksmetrics.ClientModNamespace[*v1alpha1.CustomTransform, *v1alpha1.CustomTransformList]
+	client customTransformStatusWriter
+
+	// computeEvaluator compiles the expressions of ComputeOperations
+	computeEvaluator *celeval.Evaluator
 
 	// getTransformObjects is the part of the CustomTransform informer's cache.Indexer behavior
 	// that is needed here, for using the index named in `customTransformDomainIndexName`.
@@ -76,6 +224,15 @@ type customTransformCollectionImpl struct {
 	// entry in grToTransformData, that CustomTransformSpec.
 	ctNameToSpec map[string]v1alpha1.CustomTransformSpec
 
+	// ctNameToDigested holds, for each CustomTransform in ctNameToSpec, the object
+	// as most recently written with the status from digesting it.
+	ctNameToDigested map[string]*v1alpha1.CustomTransform
+
+	// ctNameToComputeErrors holds, for each CustomTransform in ctNameToSpec that has
+	// had computations fail, a map from Binding name to the failures in processing that Binding.
+	// No inner map is empty.
+	ctNameToComputeErrors map[string]map[string][]string
+
 	// bindingNameToGroupResources tracks the set of GroupResource that each Binding
 	// references. This is so that when the set for a given Binding changes,
 	// for the GroupResources that are no longer in the set, the Binding's Name can
@@ -90,26 +247,29 @@ var _ customTransformCollection = &customTransformCollectionImpl{}
 type groupResourceTransformData struct {
 	bindingsThatCare sets.Set[string /*Binding name*/] // not empty
 	ctNames          sets.Set[string /* CustomTransform name*/]
-	changes          customTransformChanges
+	changes          []selectiveChanges // immutable
 }
 
-func newCustomTransformCollection(client ksmetrics.ClientModNamespace[*v1alpha1.CustomTransform, *v1alpha1.CustomTransformList], getTransformObjects func(indexName, indexedValue string) ([]any, error), enqueue func(any)) customTransformCollection {
+func newCustomTransformCollection(client customTransformStatusWriter, computeEvaluator *celeval.Evaluator, getTransformObjects func(indexName, indexedValue string) ([]any, error), enqueue func(any)) customTransformCollection {
 	return &customTransformCollectionImpl{
 		client:                      client,
+		computeEvaluator:            computeEvaluator,
 		getTransformObjects:         getTransformObjects,
 		enqueue:                     enqueue,
 		grToTransformData:           make(map[metav1.GroupResource]*groupResourceTransformData),
 		ctNameToSpec:                make(map[string]v1alpha1.CustomTransformSpec),
+		ctNameToDigested:            make(map[string]*v1alpha1.CustomTransform),
+		ctNameToComputeErrors:       make(map[string]map[string][]string),
 		bindingNameToGroupResources: make(map[string]sets.Set[metav1.GroupResource]),
 	}
 }
 
-// getCustomTransformData returns the customTransformChanges to use
+// getCustomTransformData returns the selectiveChanges to use
 // for the given GroupResource and notes that the result is relevant to the named Binding.
 // This method returns a cached answer if one is available, otherwise
 // digests the relevant CustomTransform object(s) and caches the result.
 // Always records the fact that the given binding depends on the answer.
-func (ctc *customTransformCollectionImpl) getCustomTransformChanges(ctx context.Context, groupResource metav1.GroupResource, bindingName string) customTransformChanges {
+func (ctc *customTransformCollectionImpl) getCustomTransformChanges(ctx context.Context, groupResource metav1.GroupResource, bindingName string) []selectiveChanges {
 	logger := klog.FromContext(ctx)
 	ctc.mutex.Lock()
 	defer ctc.mutex.Unlock()
@@ -128,6 +288,8 @@ func (ctc *customTransformCollectionImpl) getCustomTransformChanges(ctx context.
 	}
 
 	cts := abstract.SliceMap(ctAnys, func(ctAny any) *v1alpha1.CustomTransform { return ctAny.(*v1alpha1.CustomTransform) })
+	// The changes are applied in order of CustomTransform name
+	slices.SortFunc(cts, func(a, b *v1alpha1.CustomTransform) int { return strings.Compare(a.Name, b.Name) })
 	grTransformData = &groupResourceTransformData{
 		bindingsThatCare: sets.New(bindingName),
 		ctNames:          abstract.SliceMapToK8sSet(cts, (*v1alpha1.CustomTransform).GetName),
@@ -136,11 +298,13 @@ func (ctc *customTransformCollectionImpl) getCustomTransformChanges(ctx context.
 	if len(cts) > 1 {
 		commonWarnings = []string{fmt.Sprintf("multiple CustomTransform objects specify the same GroupResource; their names are %v", grTransformData.ctNames)}
 	}
-	// Digest each relevant CustomTransform, accumulating remove instructions in groupResourceTransformData.removes.
+	// Digest each relevant CustomTransform, accumulating its changes in groupResourceTransformData.changes.
 	// Invalidate cache entry for each CustomTransform that changed its Spec's .Group or .Resource.
 	for _, ct := range cts {
-		removes := ctc.digestCustomTransformLocked(ctx, groupResource, bindingName, ct, commonWarnings)
-		grTransformData.changes.removes = append(grTransformData.changes.removes, removes...)
+		changes := ctc.digestCustomTransformLocked(ctx, groupResource, bindingName, ct, commonWarnings)
+		if !changes.isEmpty() {
+			grTransformData.changes = append(grTransformData.changes, changes)
+		}
 	}
 	ctc.grToTransformData[groupResource] = grTransformData
 	return grTransformData.changes
@@ -150,8 +314,8 @@ func (ctc *customTransformCollectionImpl) getCustomTransformChanges(ctx context.
 // This done in the context of processing a Binding, whose name is a parameter (for the sake of logging).
 // Caller asserts that grToTransformData does not have an entry for this GroupResource.
 // Caller asserts that the ctc's mutex is locked.
-func (ctc *customTransformCollectionImpl) digestCustomTransformLocked(ctx context.Context, groupResource metav1.GroupResource, bindingName string, ct *v1alpha1.CustomTransform, commonWarnings []string) []jsonpath.Query {
-	removes := ctc.parseRemovesAndUpdateStatus(ctx, ct, commonWarnings)
+func (ctc *customTransformCollectionImpl) digestCustomTransformLocked(ctx context.Context, groupResource metav1.GroupResource, bindingName string, ct *v1alpha1.CustomTransform, commonWarnings []string) selectiveChanges {
+	changes := ctc.parseChangesAndUpdateStatus(ctx, ct, commonWarnings)
 	// Invalidate cache if ct.Spec changed its .Group or .Resource since last processed in this method
 	oldSpec, had := ctc.ctNameToSpec[ct.Name]
 	if had {
@@ -163,36 +327,164 @@ func (ctc *customTransformCollectionImpl) digestCustomTransformLocked(ctx contex
 		}
 	}
 	ctc.ctNameToSpec[ct.Name] = ct.Spec
-	return removes
+	delete(ctc.ctNameToComputeErrors, ct.Name)
+	return changes
 }
 
 func ctSpecGroupResource(spec v1alpha1.CustomTransformSpec) metav1.GroupResource {
 	return metav1.GroupResource{Group: spec.APIGroup, Resource: spec.Resource}
 }
 
-func (ctc *customTransformCollectionImpl) parseRemovesAndUpdateStatus(ctx context.Context, ct *v1alpha1.CustomTransform, commonWarnings []string) (removes []jsonpath.Query) {
-	logger := klog.FromContext(ctx)
-	ctCopy := ct.DeepCopy()
-	ctCopy.Status = v1alpha1.CustomTransformStatus{ObservedGeneration: ct.Generation, Warnings: commonWarnings}
-	for idx, queryS := range ct.Spec.Remove {
+// CustomTransformValidator checks CustomTransform objects.
+type CustomTransformValidator struct {
+	computeEvaluator *celeval.Evaluator
+}
+
+// NewCustomTransformValidator returns a new CustomTransformValidator.
+func NewCustomTransformValidator() (*CustomTransformValidator, error) {
+	computeEvaluator, err := newComputeEvaluator()
+	if err != nil {
+		return nil, err
+	}
+	return &CustomTransformValidator{computeEvaluator: computeEvaluator}, nil
+}
+
+// Validate returns the errors in the given CustomTransform, if any,
+// as the transport controller would report them in its status.
+func (v *CustomTransformValidator) Validate(ct *v1alpha1.CustomTransform) []error {
+	_, msgs, _ := parseChanges(v.computeEvaluator, &ct.Spec)
+	return abstract.SliceMap(msgs, func(msg string) error { return errors.New(msg) })
+}
+
+// parseChanges parses the selectors and operations of the given CustomTransformSpec.
+// It returns the operations that are valid, the errors in the others,
+// and warnings about valid operations that are superseded by others.
+// If any selector is invalid then no operations are returned.
+func parseChanges(computeEvaluator *celeval.Evaluator, spec *v1alpha1.CustomTransformSpec) (changes selectiveChanges, errs, warnings []string) {
+	// parseSelector parses the selector in the given field of the spec.
+	parseSelector := func(field string, selector *metav1.LabelSelector) labels.Selector {
+		if selector == nil {
+			return nil
+		}
+		ans, err := metav1.LabelSelectorAsSelector(selector)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Invalid spec.%s: %s", field, err.Error()))
+			return nil
+		}
+		return ans
+	}
+	changes.objectSelector = parseSelector("objectSelector", spec.ObjectSelector)
+	changes.namespaceSelector = parseSelector("namespaceSelector", spec.NamespaceSelector)
+	changes.clusterSelector = parseSelector("clusterSelector", spec.ClusterSelector)
+	selectorsValid := len(errs) == 0
+	// parsePath parses the JSONPath at the given place in the spec.
+	parsePath := func(where string, queryS string) jsonpath.Query {
 		query, err := jsonpath.ParseQuery(queryS)
 		if err != nil {
-			ctCopy.Status.Errors = append(ctCopy.Status.Errors, fmt.Sprintf("Error in spec.remove[%d]: %s", idx, err.Error()))
+			errs = append(errs, fmt.Sprintf("Error in spec.%s: %s", where, err.Error()))
+			return nil
 		} else if len(query) == 0 {
-			ctCopy.Status.Errors = append(ctCopy.Status.Errors, fmt.Sprintf("Invalid spec.remove[%d]: it identifies the whole object", idx))
-		} else {
-			removes = append(removes, query)
+			errs = append(errs, fmt.Sprintf("Invalid spec.%s: it identifies the whole object", where))
+			return nil
 		}
+		return query
 	}
-	ctEcho, err := ctc.client.UpdateStatus(ctx, ctCopy, metav1.UpdateOptions{FieldManager: ControllerName})
-	if err != nil {
-		logger.Error(err, "Failed to write status of CustomTransform", "name", ct.Name, "resourceVersion", ct.ResourceVersion, "status", ctCopy.Status)
-	} else {
-		logger.V(2).Info("Wrote status of CustomTransform", "name", ct.Name, "resourceVersion", ctEcho.ResourceVersion, "observedGeneration", ctCopy.Status.ObservedGeneration)
+	for idx, queryS := range spec.Remove {
+		if query := parsePath(fmt.Sprintf("remove[%d]", idx), queryS); query != nil {
+			changes.removes = append(changes.removes, query)
+		}
 	}
+	for idx, rename := range spec.Rename {
+		from := parsePath(fmt.Sprintf("rename[%d].from", idx), rename.From)
+		to := parsePath(fmt.Sprintf("rename[%d].to", idx), rename.To)
+		valid := from != nil && to != nil
+		for _, end := range []struct {
+			name  string
+			query jsonpath.Query
+		}{{"from", from}, {"to", to}} {
+			if end.query != nil && !end.query.IsSingular() {
+				errs = append(errs, fmt.Sprintf("Invalid spec.rename[%d].%s: it is not a singular query", idx, end.name))
+				valid = false
+			}
+		}
+		if valid {
+			changes.renames = append(changes.renames, jsonpathRename{from: from, to: to})
+		}
+	}
+	parseSettings := func(field string, operations []v1alpha1.SetOperation) []jsonpathSetting {
+		var settings []jsonpathSetting
+		for idx, operation := range operations {
+			query := parsePath(fmt.Sprintf("%s[%d].path", field, idx), operation.Path)
+			var value jsonpath.JSONValue
+			if err := utiljson.Unmarshal(operation.Value.Raw, &value); err != nil {
+				errs = append(errs, fmt.Sprintf("Invalid spec.%s[%d].value: %s", field, idx, err.Error()))
+				continue
+			}
+			if query != nil {
+				settings = append(settings, jsonpathSetting{query: query, value: value})
+			}
+		}
+		return settings
+	}
+	changes.sets = parseSettings("set", spec.Set)
+	changes.setIfAbsents = parseSettings("setIfAbsent", spec.SetIfAbsent)
+	for idx, operation := range spec.Compute {
+		query := parsePath(fmt.Sprintf("compute[%d].path", idx), operation.Path)
+		program, err := computeEvaluator.Program(operation.Expression)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Invalid spec.compute[%d].expression: %s", idx, err.Error()))
+			continue
+		}
+		if query != nil {
+			changes.computes = append(changes.computes, jsonpathComputation{index: idx, query: query, program: program})
+		}
+	}
+	removed := sets.New(spec.Remove...)
+	set := sets.New[string]()
+	for idx, operation := range spec.Set {
+		if removed.Has(operation.Path) {
+			warnings = append(warnings, fmt.Sprintf("spec.set[%d] overrides a removal of the same path", idx))
+		}
+		set.Insert(operation.Path)
+	}
+	for idx, operation := range spec.SetIfAbsent {
+		if set.Has(operation.Path) {
+			warnings = append(warnings, fmt.Sprintf("spec.setIfAbsent[%d] has no effect because spec.set sets the same path", idx))
+		}
+	}
+	if !selectorsValid {
+		changes = selectiveChanges{}
+	}
+	return
+}
+
+// parseChangesAndUpdateStatus parses the given CustomTransform, writes the resulting status,
+// and records the written object in ctNameToDigested.
+// Caller asserts that the ctc's mutex is locked.
+func (ctc *customTransformCollectionImpl) parseChangesAndUpdateStatus(ctx context.Context, ct *v1alpha1.CustomTransform, commonWarnings []string) (changes selectiveChanges) {
+	ctCopy := ct.DeepCopy()
+	ctCopy.Status = v1alpha1.CustomTransformStatus{ObservedGeneration: ct.Generation}
+	var warnings []string
+	changes, ctCopy.Status.Errors, warnings = parseChanges(ctc.computeEvaluator, &ct.Spec)
+	changes.ctName = ct.Name
+	ctCopy.Status.Warnings = append(slices.Clone(commonWarnings), warnings...)
+	ctc.ctNameToDigested[ct.Name] = ctc.writeStatus(ctx, ctCopy)
 	return
 }
 
+// writeStatus writes the status of the given CustomTransform and returns the object
+// as written, which is the given one if the write fails.
+func (ctc *customTransformCollectionImpl) writeStatus(ctx context.Context, ct *v1alpha1.CustomTransform) *v1alpha1.CustomTransform {
+	logger := klog.FromContext(ctx)
+	ctEcho, err := ctc.client.UpdateStatus(ctx, ct, metav1.UpdateOptions{FieldManager: ControllerName})
+	if err != nil {
+		logger.Error(err, "Failed to write status of CustomTransform", "name", ct.Name, "resourceVersion", ct.ResourceVersion, "status", ct.Status)
+		return ct
+	}
+	logger.V(2).Info("Wrote status of CustomTransform", "name", ct.Name, "resourceVersion", ctEcho.ResourceVersion, "observedGeneration", ct.Status.ObservedGeneration)
+	return ctEcho
+}
+
 // invalidateCacheEntryLocked removes the cached entry for the given GroupResource.
 // Caller asserts that this is being done because of some change to the CustomTransform having the given name.
 // `shouldHave` asserts that ctc.ctNameToSpec has an entry for this name.
@@ -217,10 +509,18 @@ func (ctc *customTransformCollectionImpl) invalidateCacheEntryLocked(ctx context
 		ctc.enqueue(bindingName)
 	}
 	for ctName := range oldGRTransformData.ctNames {
-		delete(ctc.ctNameToSpec, ctName)
+		ctc.forgetLocked(ctName)
 	}
 }
 
+// forgetLocked removes what is remembered about the named CustomTransform's digestion.
+// Caller asserts that the ctc's mutex is locked.
+func (ctc *customTransformCollectionImpl) forgetLocked(ctName string) {
+	delete(ctc.ctNameToSpec, ctName)
+	delete(ctc.ctNameToDigested, ctName)
+	delete(ctc.ctNameToComputeErrors, ctName)
+}
+
 // noteCustomTransform is the work that the customTransformCollection has to do
 // in order to react to a notification of a create/update/delete of a CustomTransform.
 // This method will invalidate the cache entry(s) for the given CustomTransform if
@@ -243,7 +543,14 @@ func (ctc *customTransformCollectionImpl) noteCustomTransform(ctx context.Contex
 	}
 	if ct != nil && hadSpec &&
 		oldGroupResource == newGroupResource &&
-		sets.New(oldSpec.Remove...).Equal(sets.New(ct.Spec.Remove...)) {
+		sets.New(oldSpec.Remove...).Equal(sets.New(ct.Spec.Remove...)) &&
+		apiequality.Semantic.DeepEqual(oldSpec.Rename, ct.Spec.Rename) &&
+		apiequality.Semantic.DeepEqual(oldSpec.Set, ct.Spec.Set) &&
+		apiequality.Semantic.DeepEqual(oldSpec.SetIfAbsent, ct.Spec.SetIfAbsent) &&
+		apiequality.Semantic.DeepEqual(oldSpec.Compute, ct.Spec.Compute) &&
+		apiequality.Semantic.DeepEqual(oldSpec.ObjectSelector, ct.Spec.ObjectSelector) &&
+		apiequality.Semantic.DeepEqual(oldSpec.NamespaceSelector, ct.Spec.NamespaceSelector) &&
+		apiequality.Semantic.DeepEqual(oldSpec.ClusterSelector, ct.Spec.ClusterSelector) {
 		return // unchanged
 	}
 	if ct != nil && hadSpec && oldGroupResource != newGroupResource {
@@ -251,7 +558,7 @@ func (ctc *customTransformCollectionImpl) noteCustomTransform(ctx context.Contex
 		ctc.invalidateCacheEntryLocked(ctx, true, name, "", oldGroupResource, "CustomTransformSpec changed its GroupResource", "newGroupResource", newGroupResource)
 	}
 	ctc.invalidateCacheEntryLocked(ctx, false, name, "", theGroupResource, "CustomTransformSpec changed")
-	delete(ctc.ctNameToSpec, name)
+	ctc.forgetLocked(name)
 }
 
 // setBindingGroupResources updates the customTransformCollection with the knowledge of the full set of GroupResources that
@@ -284,3 +591,53 @@ func (ctc *customTransformCollectionImpl) setBindingGroupResources(bindingName s
 		ctc.bindingNameToGroupResources[bindingName] = newGroupResources
 	}
 }
+
+// setComputeErrors updates the customTransformCollection with the knowledge of the failures
+// of computations in processing the named Binding, and writes the status of each
+// CustomTransform whose failures changed.
+// The status of a CustomTransform holds the errors from digesting it followed by
+// the failures of its computations, ordered by Binding name.
+func (ctc *customTransformCollectionImpl) setComputeErrors(ctx context.Context, bindingName string, ctNameToErrors map[string][]string) {
+	ctc.mutex.Lock()
+	defer ctc.mutex.Unlock()
+	ctNames := sets.KeySet(ctNameToErrors)
+	for ctName, bindingToErrors := range ctc.ctNameToComputeErrors {
+		if _, has := bindingToErrors[bindingName]; has {
+			ctNames.Insert(ctName)
+		}
+	}
+	for _, ctName := range sets.List(ctNames) {
+		digested, known := ctc.ctNameToDigested[ctName]
+		if !known { // not digested since its last change; the status will be written when it is
+			continue
+		}
+		bindingToErrors := ctc.ctNameToComputeErrors[ctName]
+		newErrors := ctNameToErrors[ctName]
+		if slices.Equal(bindingToErrors[bindingName], newErrors) {
+			continue
+		}
+		if len(newErrors) == 0 {
+			delete(bindingToErrors, bindingName)
+			if len(bindingToErrors) == 0 {
+				delete(ctc.ctNameToComputeErrors, ctName)
+			}
+		} else {
+			if bindingToErrors == nil {
+				bindingToErrors = map[string][]string{}
+				ctc.ctNameToComputeErrors[ctName] = bindingToErrors
+			}
+			bindingToErrors[bindingName] = newErrors
+		}
+		ctCopy := digested.DeepCopy()
+		for _, someBindingName := range slices.Sorted(maps.Keys(bindingToErrors)) {
+			ctCopy.Status.Errors = append(ctCopy.Status.Errors, abstract.SliceMap(bindingToErrors[someBindingName], func(msg string) string {
+				return fmt.Sprintf("%s, in Binding %q", msg, someBindingName)
+			})...)
+		}
+		written := ctc.writeStatus(ctx, ctCopy)
+		// Keep the digested status, with the latest ResourceVersion
+		digested = digested.DeepCopy()
+		digested.ResourceVersion = written.ResourceVersion
+		ctc.ctNameToDigested[ctName] = digested
+	}
+}
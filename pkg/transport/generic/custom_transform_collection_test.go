@@ -0,0 +1,280 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/celeval"
+	"github.com/kubestellar/kubestellar/pkg/transport/generic/filtering"
+)
+
+func TestValidateCustomTransform(t *testing.T) {
+	ct := &v1alpha1.CustomTransform{Spec: v1alpha1.CustomTransformSpec{
+		APIGroup: "apps",
+		Resource: "deployments",
+		Remove:   []string{"$.spec.replicas", "$.spec[.replicas", "$"},
+	}}
+	validator, err := NewCustomTransformValidator()
+	if err != nil {
+		t.Fatalf("Failed to create validator: %s", err)
+	}
+	errs := validator.Validate(ct)
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 errors, got %q", errs)
+	}
+	if expected := "Invalid spec.remove[2]: it identifies the whole object"; errs[1].Error() != expected {
+		t.Errorf("Expected second error %q, got %q", expected, errs[1])
+	}
+	changes, _, _ := parseChanges(validator.computeEvaluator, &ct.Spec)
+	if len(changes.removes) != 1 {
+		t.Errorf("Expected 1 valid query, got %d", len(changes.removes))
+	}
+}
+
+func testComputeEvaluator(t *testing.T) *celeval.Evaluator {
+	evaluator, err := newComputeEvaluator()
+	if err != nil {
+		t.Fatalf("Failed to create CEL evaluator: %s", err)
+	}
+	return evaluator
+}
+
+func TestCustomTransformChanges(t *testing.T) {
+	spec := &v1alpha1.CustomTransformSpec{
+		APIGroup: "apps",
+		Resource: "deployments",
+		Remove:   []string{"$.spec.replicas"},
+		Rename: []v1alpha1.RenameOperation{
+			{From: `$.metadata.annotations["old"]`, To: `$.metadata.labels["new"]`},
+			{From: `$.metadata.annotations["absent"]`, To: `$.metadata.annotations["other"]`},
+		},
+		Set: []v1alpha1.SetOperation{
+			{Path: "$.spec.template.spec.priorityClassName", Value: apiextensionsv1.JSON{Raw: []byte(`"edge-critical"`)}},
+			{Path: "$.spec.replicas", Value: apiextensionsv1.JSON{Raw: []byte(`2`)}},
+		},
+		SetIfAbsent: []v1alpha1.SetOperation{
+			{Path: "$.spec.paused", Value: apiextensionsv1.JSON{Raw: []byte(`false`)}},
+			{Path: "$.spec.template.spec.priorityClassName", Value: apiextensionsv1.JSON{Raw: []byte(`"low"`)}},
+			{Path: "$.spec.strategy", Value: apiextensionsv1.JSON{Raw: []byte(`{"type":`)}},
+		},
+	}
+	changes, errs, warnings := parseChanges(testComputeEvaluator(t), spec)
+	if len(errs) != 1 {
+		t.Errorf("Expected 1 error, got %q", errs)
+	}
+	if len(warnings) != 2 {
+		t.Errorf("Expected 2 warnings, got %q", warnings)
+	}
+	objectData := map[string]any{
+		"metadata": map[string]any{"annotations": map[string]any{"old": "x", "keep": "y"}},
+		"spec":     map[string]any{"replicas": int64(5), "paused": true},
+	}
+	changes.apply(objectData)
+	expected := map[string]any{
+		"metadata": map[string]any{"annotations": map[string]any{"keep": "y"}, "labels": map[string]any{"new": "x"}},
+		"spec": map[string]any{"replicas": int64(2), "paused": true,
+			"template": map[string]any{"spec": map[string]any{"priorityClassName": "edge-critical"}}},
+	}
+	if !apiequality.Semantic.DeepEqual(expected, objectData) {
+		t.Errorf("Expected %#v, got %#v", expected, objectData)
+	}
+}
+
+func TestCustomTransformChangesInArrays(t *testing.T) {
+	spec := &v1alpha1.CustomTransformSpec{
+		APIGroup: "apps",
+		Resource: "deployments",
+		Remove:   []string{`$.spec.containers[?@.name == "istio-proxy"]`, "$.spec.containers[*].resources"},
+		Rename:   []v1alpha1.RenameOperation{{From: "$.spec.containers[*].args", To: "$.spec.args"}},
+		Set: []v1alpha1.SetOperation{
+			{Path: "$.spec.containers[*].imagePullPolicy", Value: apiextensionsv1.JSON{Raw: []byte(`"IfNotPresent"`)}},
+		},
+	}
+	changes, errs, _ := parseChanges(testComputeEvaluator(t), spec)
+	if len(errs) != 1 {
+		t.Errorf("Expected 1 error, got %q", errs)
+	}
+	objectData := map[string]any{"spec": map[string]any{"containers": []any{
+		map[string]any{"name": "app", "resources": map[string]any{}},
+		map[string]any{"name": "istio-proxy"},
+		map[string]any{"name": "log", "imagePullPolicy": "Always"},
+	}}}
+	changes.apply(objectData)
+	expected := map[string]any{"spec": map[string]any{"containers": []any{
+		map[string]any{"name": "app", "imagePullPolicy": "IfNotPresent"},
+		map[string]any{"name": "log", "imagePullPolicy": "IfNotPresent"},
+	}}}
+	if !apiequality.Semantic.DeepEqual(expected, objectData) {
+		t.Errorf("Expected %#v, got %#v", expected, objectData)
+	}
+}
+
+func TestCustomTransformSelectors(t *testing.T) {
+	spec := &v1alpha1.CustomTransformSpec{
+		APIGroup:          "",
+		Resource:          "configmaps",
+		ObjectSelector:    &metav1.LabelSelector{MatchLabels: map[string]string{"strip": "true"}},
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+		ClusterSelector:   &metav1.LabelSelector{MatchLabels: map[string]string{"location": "edge"}},
+		Remove:            []string{"$.data.big"},
+	}
+	changes, errs, _ := parseChanges(testComputeEvaluator(t), spec)
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected errors: %q", errs)
+	}
+	nsToLabels := map[string]labels.Set{"ns-a": {"team": "a"}, "ns-b": {"team": "b"}}
+	getNamespaceLabels := func(namespace string) (labels.Set, error) { return nsToLabels[namespace], nil }
+	newObject := func(apiVersion, kind, namespace string, objLabels map[string]any) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": apiVersion,
+			"kind":       kind,
+			"metadata":   map[string]any{"name": "x", "namespace": namespace, "labels": objLabels},
+			"data":       map[string]any{"big": "xxx", "small": "x"},
+		}}
+	}
+	for _, testCase := range []struct {
+		name     string
+		object   *unstructured.Unstructured
+		expected bool
+	}{
+		{name: "match", object: newObject("v1", "ConfigMap", "ns-a", map[string]any{"strip": "true"}), expected: true},
+		{name: "object-mismatch", object: newObject("v1", "ConfigMap", "ns-a", nil)},
+		{name: "namespace-mismatch", object: newObject("v1", "ConfigMap", "ns-b", map[string]any{"strip": "true"})},
+		{name: "namespace-itself", object: newObject("v1", "Namespace", "", map[string]any{"strip": "true", "team": "a"}), expected: true},
+		{name: "cluster-scoped", object: newObject("example.com/v1", "Widget", "", map[string]any{"strip": "true"}), expected: true},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			applies, err := changes.appliesToObject(testCase.object, getNamespaceLabels)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if applies != testCase.expected {
+				t.Errorf("Expected %v, got %v", testCase.expected, applies)
+			}
+		})
+	}
+
+	object := newObject("v1", "ConfigMap", "ns-a", nil)
+	if applied, _ := applyDestinationChanges(object, labels.Set{"location": "cloud"}, nil, []selectiveChanges{changes}); applied != object {
+		t.Errorf("Expected no change for a non-matching cluster, got %#v", applied.Object)
+	}
+	applied, _ := applyDestinationChanges(object, labels.Set{"location": "edge"}, nil, []selectiveChanges{changes})
+	if expected := map[string]any{"small": "x"}; !apiequality.Semantic.DeepEqual(expected, applied.Object["data"]) {
+		t.Errorf("Expected data %#v, got %#v", expected, applied.Object["data"])
+	}
+	if _, found := object.Object["data"].(map[string]any)["big"]; !found {
+		t.Errorf("Expected the given object to not be mutated")
+	}
+
+	spec.ClusterSelector = &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "location", Operator: "Near"}}}
+	changes, errs, _ = parseChanges(testComputeEvaluator(t), spec)
+	if len(errs) != 1 {
+		t.Errorf("Expected 1 error, got %q", errs)
+	}
+	if !changes.isEmpty() {
+		t.Errorf("Expected no changes from a CustomTransform with an invalid selector")
+	}
+}
+
+func TestCustomTransformCompute(t *testing.T) {
+	spec := &v1alpha1.CustomTransformSpec{
+		APIGroup: "apps",
+		Resource: "deployments",
+		Remove:   []string{"$.spec.paused"},
+		Compute: []v1alpha1.ComputeOperation{
+			{Path: "$.spec.replicas", Expression: `obj.spec.replicas * int(props["scale"])`},
+			{Path: "$.spec.template.metadata.annotations.host", Expression: `props["region"] + ".example.com"`},
+			{Path: "$.spec.template.metadata.labels", Expression: `{"region": props["region"], "paused": has(obj.spec.paused)}`},
+			{Path: "$.spec.minReadySeconds", Expression: `int(props["delay"])`},
+			{Path: "$.spec.strategy", Expression: `obj.spec.(`},
+		},
+	}
+	changes, errs, _ := parseChanges(testComputeEvaluator(t), spec)
+	if len(errs) != 1 {
+		t.Errorf("Expected 1 error, got %q", errs)
+	}
+	changes.ctName = "scale"
+	if !changes.destinationDependent() {
+		t.Errorf("Expected changes with computations to depend on the destination")
+	}
+	objectData := map[string]any{"spec": map[string]any{"replicas": int64(2), "paused": true}}
+	errs = changes.applyForDestination(objectData, clusterProperties{"scale": "3", "region": "eu"})
+	if len(errs) != 1 {
+		t.Errorf("Expected 1 evaluation error, got %q", errs)
+	}
+	expected := map[string]any{"spec": map[string]any{
+		"replicas": int64(6),
+		"template": map[string]any{"metadata": map[string]any{
+			"annotations": map[string]any{"host": "eu.example.com"},
+			"labels":      map[string]any{"region": "eu", "paused": false},
+		}},
+	}}
+	if !apiequality.Semantic.DeepEqual(expected, objectData) {
+		t.Errorf("Expected %#v, got %#v", expected, objectData)
+	}
+}
+
+func TestCustomTransformOrder(t *testing.T) {
+	setX := func(value string) []v1alpha1.SetOperation {
+		return []v1alpha1.SetOperation{{Path: "$.data.x", Value: apiextensionsv1.JSON{Raw: []byte(`"` + value + `"`)}}}
+	}
+	cts := []any{
+		&v1alpha1.CustomTransform{ObjectMeta: metav1.ObjectMeta{Name: "c-all"},
+			Spec: v1alpha1.CustomTransformSpec{Resource: "configmaps", Set: setX("c")}},
+		&v1alpha1.CustomTransform{ObjectMeta: metav1.ObjectMeta{Name: "b-edge"},
+			Spec: v1alpha1.CustomTransformSpec{Resource: "configmaps", Set: setX("b"),
+				ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"location": "edge"}}}},
+		&v1alpha1.CustomTransform{ObjectMeta: metav1.ObjectMeta{Name: "a-all"},
+			Spec: v1alpha1.CustomTransformSpec{Resource: "configmaps", Set: setX("a")}},
+	}
+	ctc := newCustomTransformCollection(&customTransformStatusRecorder{}, testComputeEvaluator(t),
+		func(string, string) ([]any, error) { return cts, nil }, func(any) {})
+	object := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1", "kind": "ConfigMap",
+		"metadata": map[string]any{"name": "cm", "namespace": "ns"},
+	}}
+	cleanupRules, err := filtering.NewCleanupRules(nil)
+	if err != nil {
+		t.Fatalf("Failed to digest cleanup rules: %s", err)
+	}
+	transformed, destChanges, err := transformObject(context.Background(), ctc, cleanupRules, metav1.GroupResource{Resource: "configmaps"}, object, nil, "b1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	// a-all precedes the destination-dependent b-edge, c-all follows it
+	if expected := map[string]any{"x": "a"}; !apiequality.Semantic.DeepEqual(expected, transformed.Object["data"]) {
+		t.Errorf("Expected data %#v before customization, got %#v", expected, transformed.Object["data"])
+	}
+	if len(destChanges) != 2 || destChanges[0].ctName != "b-edge" || destChanges[1].ctName != "c-all" {
+		t.Errorf("Expected b-edge and c-all to be applied for each destination, got %d changes", len(destChanges))
+	}
+	for _, location := range []string{"edge", "cloud"} {
+		applied, _ := applyDestinationChanges(transformed, labels.Set{"location": location}, nil, destChanges)
+		if expected := map[string]any{"x": "c"}; !apiequality.Semantic.DeepEqual(expected, applied.Object["data"]) {
+			t.Errorf("Expected data %#v for %s, got %#v", expected, location, applied.Object["data"])
+		}
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+
+	"github.com/kubestellar/kubestellar/pkg/celeval"
+	"github.com/kubestellar/kubestellar/pkg/jsonpath"
+)
+
+const (
+	// computeObjectKey is the key used to store the object being transformed.
+	computeObjectKey = "obj"
+	// computePropertiesKey is the key used to store the properties of the destination.
+	computePropertiesKey = "props"
+)
+
+// newComputeEvaluator returns a CEL evaluator whose environment
+// is suited to the `expression` of a ComputeOperation.
+func newComputeEvaluator() (*celeval.Evaluator, error) {
+	return celeval.NewEvaluator(
+		cel.Variable(computeObjectKey, cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable(computePropertiesKey, cel.MapType(cel.StringType, cel.StringType)),
+		cel.CrossTypeNumericComparisons(true),
+	)
+}
+
+// jsonpathComputation is a parsed ComputeOperation.
+type jsonpathComputation struct {
+	// index is the position of the ComputeOperation in its CustomTransformSpec
+	index   int
+	query   jsonpath.Query
+	program cel.Program
+}
+
+// evaluate computes the value to put in the given object content,
+// for the destination that has the given properties.
+func (computation jsonpathComputation) evaluate(objectData map[string]any, props clusterProperties) (jsonpath.JSONValue, error) {
+	result, _, err := computation.program.Eval(map[string]any{
+		computeObjectKey:     objectData,
+		computePropertiesKey: props,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return celToJSON(result)
+}
+
+// celToJSON converts the result of a CEL evaluation into
+// the form of JSON data used in unstructured objects.
+func celToJSON(val ref.Val) (jsonpath.JSONValue, error) {
+	switch typed := val.(type) {
+	case types.Null:
+		return nil, nil
+	case types.Bool:
+		return bool(typed), nil
+	case types.Int:
+		return int64(typed), nil
+	case types.Uint:
+		if typed > math.MaxInt64 {
+			return nil, fmt.Errorf("uint %d is too large", uint64(typed))
+		}
+		return int64(typed), nil
+	case types.Double:
+		return float64(typed), nil
+	case types.String:
+		return string(typed), nil
+	case traits.Lister:
+		size, ok := typed.Size().(types.Int)
+		if !ok {
+			return nil, fmt.Errorf("list has no size")
+		}
+		ans := make([]any, int(size))
+		for idx := range ans {
+			elt, err := celToJSON(typed.Get(types.Int(idx)))
+			if err != nil {
+				return nil, err
+			}
+			ans[idx] = elt
+		}
+		return ans, nil
+	case traits.Mapper:
+		ans := map[string]any{}
+		for it := typed.Iterator(); it.HasNext() == types.True; {
+			key := it.Next()
+			keyS, ok := key.(types.String)
+			if !ok {
+				return nil, fmt.Errorf("map key of type %s is not a string", key.Type().TypeName())
+			}
+			member, err := celToJSON(typed.Get(key))
+			if err != nil {
+				return nil, err
+			}
+			ans[string(keyS)] = member
+		}
+		return ans, nil
+	default:
+		return nil, fmt.Errorf("a value of type %s can not be represented in JSON", val.Type().TypeName())
+	}
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/transport"
+)
+
+// reasonNotReported is the reason of a summarized delivery condition
+// that some of the wrapped objects do not report.
+const reasonNotReported = "NotReported"
+
+// computeDestinationStatuses summarizes, for each destination of the given Binding,
+// the given wrapped objects of that Binding.
+func (c *genericTransportController) computeDestinationStatuses(ctx context.Context, binding *v1alpha1.Binding, wrappedObjects []unstructured.Unstructured) []v1alpha1.DestinationStatus {
+	logger := klog.FromContext(ctx)
+	reporter, _ := c.transport.(transport.DeliveryReporter)
+	namespaceToConditions := map[string][][]metav1.Condition{}
+	for idx := range wrappedObjects {
+		wrappedObject := &wrappedObjects[idx]
+		var conditions []metav1.Condition
+		if reporter != nil {
+			var err error
+			conditions, err = reporter.DeliveryConditions(wrappedObject)
+			if err != nil {
+				logger.Error(err, "Failed to extract delivery conditions", "wrappedObject", klog.KObj(wrappedObject))
+			}
+		}
+		namespaceToConditions[wrappedObject.GetNamespace()] = append(namespaceToConditions[wrappedObject.GetNamespace()], conditions)
+	}
+	if len(binding.Spec.Destinations) == 0 {
+		return nil
+	}
+	ans := make([]v1alpha1.DestinationStatus, len(binding.Spec.Destinations))
+	for idx, dest := range binding.Spec.Destinations {
+		perObject := namespaceToConditions[dest.ClusterId]
+		ans[idx] = v1alpha1.DestinationStatus{
+			ClusterId:      dest.ClusterId,
+			WrappedObjects: int32(len(perObject)),
+		}
+		if reporter != nil {
+			ans[idx].Conditions = summarizeDeliveryConditions(perObject)
+		}
+	}
+	return ans
+}
+
+// summarizeDeliveryConditions combines the given conditions of several wrapped objects
+// into one condition per type, sorted by type.
+func summarizeDeliveryConditions(perObject [][]metav1.Condition) []metav1.Condition {
+	var types []string
+	for _, conditions := range perObject {
+		for _, condition := range conditions {
+			if !slices.Contains(types, condition.Type) {
+				types = append(types, condition.Type)
+			}
+		}
+	}
+	slices.Sort(types)
+	ans := make([]metav1.Condition, 0, len(types))
+	for _, condType := range types {
+		var summary, exemplar *metav1.Condition
+		missing := 0
+		for _, conditions := range perObject {
+			idx := slices.IndexFunc(conditions, func(condition metav1.Condition) bool { return condition.Type == condType })
+			if idx < 0 {
+				missing++
+				continue
+			}
+			condition := conditions[idx]
+			if summary == nil {
+				summary = &metav1.Condition{Type: condType, Status: metav1.ConditionTrue}
+			}
+			if condition.LastTransitionTime.After(summary.LastTransitionTime.Time) {
+				summary.LastTransitionTime = condition.LastTransitionTime
+			}
+			switch {
+			case condition.Status == metav1.ConditionFalse:
+				summary.Status = metav1.ConditionFalse
+				if exemplar == nil || exemplar.Status != metav1.ConditionFalse {
+					exemplar = &condition
+				}
+			case condition.Status != metav1.ConditionTrue:
+				if summary.Status == metav1.ConditionTrue {
+					summary.Status = metav1.ConditionUnknown
+				}
+				if exemplar == nil || exemplar.Status == metav1.ConditionTrue {
+					exemplar = &condition
+				}
+			case exemplar == nil:
+				exemplar = &condition
+			}
+		}
+		summary.Reason, summary.Message = exemplar.Reason, exemplar.Message
+		if missing > 0 && summary.Status == metav1.ConditionTrue {
+			summary.Status = metav1.ConditionUnknown
+			summary.Reason = reasonNotReported
+			summary.Message = fmt.Sprintf("Not reported by %d of %d wrapped objects", missing, len(perObject))
+		}
+		ans = append(ans, *summary)
+	}
+	return ans
+}
+
+// maintainCondition maintains the condition of the given type in the given conditions.
+// The given func computes that condition from the current one (nil if none)
+// and returns false when no condition is called for.
+// The returned bool tells whether the conditions changed.
+func maintainCondition(conditions []v1alpha1.BindingPolicyCondition, condType v1alpha1.ConditionType,
+	desired func(*v1alpha1.BindingPolicyCondition) (v1alpha1.BindingPolicyCondition, bool)) ([]v1alpha1.BindingPolicyCondition, bool) {
+	var current *v1alpha1.BindingPolicyCondition
+	if idx := slices.IndexFunc(conditions, func(cond v1alpha1.BindingPolicyCondition) bool {
+		return cond.Type == condType
+	}); idx >= 0 {
+		current = &conditions[idx]
+	}
+	condition, wanted := desired(current)
+	if !wanted {
+		return conditions, false
+	}
+	return v1alpha1.SetCondition(conditions, condition)
+}
+
+// updateDeliveryStatus maintains the parts of the given Binding's status that report
+// on delivery: the per-destination statuses and the PendingWindow and Frozen conditions,
+// given the destinations whose changes are being held back, the freeze,
+// the Binding's wrapped objects as read from the ITS before this reconciliation changed any,
+// and whether it changed any. When it did, the read objects are out of date and the
+// per-destination statuses are left as they are; the informer notifications about
+// those changes bring the Binding back for another reconciliation.
+func (c *genericTransportController) updateDeliveryStatus(ctx context.Context, binding *v1alpha1.Binding, held []v1alpha1.Destination, freeze *deliveryFreeze,
+	observedWrappedObjects []unstructured.Unstructured, wrote bool) error {
+	bindingCopy := binding.DeepCopy()
+	conditions, windowChanged := setPendingWindowCondition(bindingCopy.Status.Conditions, held)
+	conditions, frozenChanged := maintainCondition(conditions, v1alpha1.TypeFrozen, func(current *v1alpha1.BindingPolicyCondition) (v1alpha1.BindingPolicyCondition, bool) {
+		return frozenCondition(current, freeze)
+	})
+	bindingCopy.Status.Conditions = conditions
+	changed := windowChanged || frozenChanged
+	if !wrote {
+		destinationStatuses := c.computeDestinationStatuses(ctx, binding, observedWrappedObjects)
+		if !apiequality.Semantic.DeepEqual(destinationStatuses, binding.Status.Destinations) {
+			bindingCopy.Status.Destinations = destinationStatuses
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	binding2, err := c.bindingClient.UpdateStatus(ctx, bindingCopy, metav1.UpdateOptions{FieldManager: ControllerName})
+	if err != nil {
+		return fmt.Errorf("failed to update delivery status of Binding '%s' - %w", binding.Name, err)
+	}
+	klog.FromContext(ctx).V(2).Info("Updated delivery status of Binding", "bindingName", binding.Name, "held", len(held), "resourceVersion", binding2.ResourceVersion)
+	return nil
+}
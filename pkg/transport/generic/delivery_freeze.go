@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+// deliveryFreeze records which destinations of a Binding are frozen: their wrapped objects
+// are left as they are, neither updated nor deleted, because user errors keep
+// this controller from knowing what they should hold.
+// Unlike the errors that make all the wrapped objects be deleted, these errors
+// are in parts of the Binding that do not say what the workload is.
+// The zero value freezes nothing.
+type deliveryFreeze struct {
+	// all is true when every destination is frozen.
+	all bool
+	// destinations holds the individually frozen destinations.
+	destinations sets.Set[v1alpha1.Destination]
+	// errors describes the user errors that caused the freezing.
+	errors []string
+}
+
+// freezeAll freezes every destination if there are any errors.
+func (df *deliveryFreeze) freezeAll(errors ...string) {
+	if len(errors) == 0 {
+		return
+	}
+	df.all = true
+	df.errors = append(df.errors, errors...)
+}
+
+// freezeDestination freezes the given destination and records the given errors.
+// There may be no given errors when the same problem has already been recorded for another destination,
+// so as not to overwhelm the user.
+func (df *deliveryFreeze) freezeDestination(dest v1alpha1.Destination, errors ...string) {
+	if df.destinations == nil {
+		df.destinations = sets.New[v1alpha1.Destination]()
+	}
+	df.destinations.Insert(dest)
+	df.errors = append(df.errors, errors...)
+}
+
+// frozen tells whether the given destination is frozen.
+func (df *deliveryFreeze) frozen(dest v1alpha1.Destination) bool {
+	return df.all || df.destinations.Has(dest)
+}
+
+// frozenCondition returns the Frozen condition to put in the status of a Binding,
+// given the condition currently there (nil if none) and the freeze.
+// The returned bool is false when no condition is called for.
+func frozenCondition(current *v1alpha1.BindingPolicyCondition, freeze *deliveryFreeze) (v1alpha1.BindingPolicyCondition, bool) {
+	if !freeze.all && freeze.destinations.Len() == 0 {
+		if current == nil {
+			return v1alpha1.BindingPolicyCondition{}, false
+		}
+		return v1alpha1.BindingPolicyCondition{
+			Type:    v1alpha1.TypeFrozen,
+			Status:  corev1.ConditionFalse,
+			Reason:  v1alpha1.ReasonNothingFrozen,
+			Message: "No destination has its wrapped objects frozen",
+		}, true
+	}
+	var message string
+	if freeze.all {
+		message = "Wrapped objects are left as they are for all destinations because of errors in the Binding"
+	} else {
+		names := make([]string, 0, freeze.destinations.Len())
+		for dest := range freeze.destinations {
+			names = append(names, dest.ClusterId)
+		}
+		slices.Sort(names)
+		message = "Wrapped objects are left as they are, because of errors in the Binding, for destinations: "
+		if len(names) > maxHeldInCondition {
+			message += fmt.Sprintf("%s, and %d more", strings.Join(names[:maxHeldInCondition], ", "), len(names)-maxHeldInCondition)
+		} else {
+			message += strings.Join(names, ", ")
+		}
+	}
+	return v1alpha1.BindingPolicyCondition{
+		Type:    v1alpha1.TypeFrozen,
+		Status:  corev1.ConditionTrue,
+		Reason:  v1alpha1.ReasonUserErrors,
+		Message: message,
+	}, true
+}
@@ -0,0 +1,420 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	clusterlisters "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog/v2/ktesting"
+	testingclock "k8s.io/utils/clock/testing"
+	"k8s.io/utils/ptr"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	ksclientfake "github.com/kubestellar/kubestellar/pkg/generated/clientset/versioned/fake"
+	"github.com/kubestellar/kubestellar/pkg/transport/generic/filtering"
+)
+
+var testWrapperGVR = schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "wrappers"}
+
+// deliveryTestHarness drives updateWrappedObjectsAndFinalizer of a controller built on fakes.
+// The wrapped objects are made by phaseTestTransport.
+type deliveryTestHarness struct {
+	t     *testing.T
+	ctx   context.Context
+	ctlr  *genericTransportController
+	ks    *ksclientfake.Clientset
+	its   *dynamicfake.FakeDynamicClient
+	clock *testingclock.FakeClock
+}
+
+func newDeliveryTestHarness(t *testing.T, now time.Time, binding *v1alpha1.Binding, inventory []*clusterv1.ManagedCluster, wdsObjects []runtime.Object, wrappedObjects []runtime.Object) *deliveryTestHarness {
+	logger, ctx := ktesting.NewTestContext(t)
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %s", err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatalf("Failed to build scheme: %s", err)
+	}
+	ks := ksclientfake.NewSimpleClientset(binding)
+	its := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{testWrapperGVR: "WrapperList"}, wrappedObjects...)
+	inventoryIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, invObj := range inventory {
+		if err := inventoryIndexer.Add(invObj); err != nil {
+			t.Fatalf("Failed to add inventory object: %s", err)
+		}
+	}
+	rolloutGateEvaluator, err := NewRolloutGateEvaluator()
+	if err != nil {
+		t.Fatalf("Failed to create CEL evaluator: %s", err)
+	}
+	computeEvaluator, err := newComputeEvaluator()
+	if err != nil {
+		t.Fatalf("Failed to create CEL evaluator: %s", err)
+	}
+	cleanupRules, err := filtering.NewCleanupRules(filtering.DefaultCleanupRules())
+	if err != nil {
+		t.Fatalf("Failed to digest default cleanup rules: %s", err)
+	}
+	namespaceIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, obj := range wdsObjects {
+		if ns, is := obj.(*corev1.Namespace); is {
+			if err := namespaceIndexer.Add(ns); err != nil {
+				t.Fatalf("Failed to add Namespace: %s", err)
+			}
+		}
+	}
+	fakeClock := testingclock.NewFakeClock(now)
+	ctlr := &genericTransportController{
+		logger:               logger,
+		inventoryLister:      clusterlisters.NewManagedClusterLister(inventoryIndexer),
+		bindingClient:        ks.ControlV1alpha1().Bindings(),
+		propCfgMapLister:     corev1listers.NewConfigMapLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})).ConfigMaps(v1alpha1.PropertyConfigMapNamespace),
+		wrappedObjectLister:  cache.NewGenericLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}), testWrapperGVR.GroupResource()),
+		namespaceLister:      cache.NewGenericLister(namespaceIndexer, namespaceGVR.GroupResource()),
+		rolloutGateEvaluator: rolloutGateEvaluator,
+		clock:                fakeClock,
+		workqueue:            workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		transport:            phaseTestTransport{},
+		transportClient:      its,
+		wrappedObjectGVR:     testWrapperGVR,
+		wdsDynamicClient:     dynamicfake.NewSimpleDynamicClient(scheme, wdsObjects...),
+		MaxSizeWrapped:       1 << 20,
+		MaxNumWrapped:        10,
+		wdsName:              "wds1",
+		cleanupRules:         cleanupRules,
+		customTransformCollection: newCustomTransformCollection(ks.ControlV1alpha1().CustomTransforms(), computeEvaluator,
+			func(string, string) ([]any, error) { return nil, nil },
+			func(any) {}),
+		bindingSensitiveDestinations: make(map[string]sets.Set[v1alpha1.Destination]),
+		destinationProperties:        make(map[v1alpha1.Destination]clusterProperties),
+		destinationLabels:            make(map[v1alpha1.Destination]labels.Set),
+		destinationWindows:           make(map[v1alpha1.Destination]string),
+		bindingNamespaces:            make(map[string]sets.Set[string]),
+	}
+	t.Cleanup(ctlr.workqueue.ShutDown)
+	return &deliveryTestHarness{t: t, ctx: ctx, ctlr: ctlr, ks: ks, its: its, clock: fakeClock}
+}
+
+// update reconciles the named Binding, as currently in the fake client.
+func (h *deliveryTestHarness) update(bindingName string) *v1alpha1.Binding {
+	binding, err := h.ks.ControlV1alpha1().Bindings().Get(h.ctx, bindingName, metav1.GetOptions{})
+	if err != nil {
+		h.t.Fatalf("Failed to get Binding: %s", err)
+	}
+	if err := h.ctlr.updateWrappedObjectsAndFinalizer(h.ctx, binding); err != nil {
+		h.t.Fatalf("Failed to update wrapped objects: %s", err)
+	}
+	binding, err = h.ks.ControlV1alpha1().Bindings().Get(h.ctx, bindingName, metav1.GetOptions{})
+	if err != nil {
+		h.t.Fatalf("Failed to get Binding: %s", err)
+	}
+	return binding
+}
+
+// wrappedIDs returns namespace/name of every wrapped object in the ITS, sorted.
+func (h *deliveryTestHarness) wrappedIDs() []string {
+	list, err := h.its.Resource(testWrapperGVR).List(h.ctx, metav1.ListOptions{})
+	if err != nil {
+		h.t.Fatalf("Failed to list wrapped objects: %s", err)
+	}
+	ans := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		ans = append(ans, item.GetNamespace()+"/"+item.GetName())
+	}
+	slices.Sort(ans)
+	return ans
+}
+
+func newTestBinding(name string, destinations ...string) *v1alpha1.Binding {
+	binding := &v1alpha1.Binding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.GroupVersion.String(), Kind: "Binding"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Generation: 1},
+		Spec: v1alpha1.BindingSpec{Workload: v1alpha1.DownsyncObjectClauses{
+			NamespaceScope: []v1alpha1.NamespaceScopeDownsyncClause{{NamespaceScopeDownsyncObject: v1alpha1.NamespaceScopeDownsyncObject{
+				GroupVersionResource: metav1.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+				Namespace:            "ns1", Name: "cm1"}}},
+		}},
+	}
+	for _, dest := range destinations {
+		binding.Spec.Destinations = append(binding.Spec.Destinations, v1alpha1.Destination{ClusterId: dest})
+	}
+	return binding
+}
+
+func newTestConfigMap(namespace, name string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Data:       map[string]string{"k": "v"},
+	}
+}
+
+// newTestWrapped returns a wrapped object of the given Binding, as made by wrapBatch.
+func newTestWrapped(bindingName, namespace, name string) *unstructured.Unstructured {
+	ans := &unstructured.Unstructured{Object: map[string]any{"apiVersion": "test/v1", "kind": "Wrapper"}}
+	ans.SetNamespace(namespace)
+	ans.SetName(name)
+	ans.SetLabels(map[string]string{originOwnerReferenceLabel: bindingName, originWdsLabel: "wds1"})
+	return ans
+}
+
+func findCondition(conditions []v1alpha1.BindingPolicyCondition, condType v1alpha1.ConditionType) *v1alpha1.BindingPolicyCondition {
+	idx := slices.IndexFunc(conditions, func(cond v1alpha1.BindingPolicyCondition) bool { return cond.Type == condType })
+	if idx < 0 {
+		return nil
+	}
+	return &conditions[idx]
+}
+
+func TestInvalidWindowsFreeze(t *testing.T) {
+	binding := newTestBinding("b1", "wec1", "wec2")
+	binding.Spec.PropagationWindows = []v1alpha1.PropagationWindow{{Schedule: "not cron", Duration: metav1.Duration{Duration: time.Hour}}}
+	h := newDeliveryTestHarness(t, time.Now(), binding, nil,
+		[]runtime.Object{newTestConfigMap("ns1", "cm1")},
+		[]runtime.Object{newTestWrapped("b1", "wec1", "b1-wds1-configuration-0"), newTestWrapped("b1", "wec3", "b1-wds1-configuration-0")})
+	binding = h.update("b1")
+	if actual, expected := h.wrappedIDs(), []string{"wec1/b1-wds1-configuration-0", "wec3/b1-wds1-configuration-0"}; !slices.Equal(actual, expected) {
+		t.Errorf("Expected wrapped objects %v to be left as they were, got %v", expected, actual)
+	}
+	if len(binding.Status.Errors) != 1 {
+		t.Errorf("Expected one error in Binding status, got %v", binding.Status.Errors)
+	}
+	if cond := findCondition(binding.Status.Conditions, v1alpha1.TypeFrozen); cond == nil || cond.Status != corev1.ConditionTrue {
+		t.Errorf("Expected Frozen condition to be true, got %#v", cond)
+	}
+
+	// Fixing the windows thaws
+	binding.Spec.PropagationWindows = nil
+	if _, err := h.ks.ControlV1alpha1().Bindings().Update(h.ctx, binding, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update Binding: %s", err)
+	}
+	binding = h.update("b1")
+	if actual, expected := h.wrappedIDs(), []string{"wec1/b1-wds1-configuration-0", "wec2/b1-wds1-configuration-0"}; !slices.Equal(actual, expected) {
+		t.Errorf("Expected wrapped objects %v, got %v", expected, actual)
+	}
+	if cond := findCondition(binding.Status.Conditions, v1alpha1.TypeFrozen); cond == nil || cond.Status != corev1.ConditionFalse {
+		t.Errorf("Expected Frozen condition to be false, got %#v", cond)
+	}
+}
+
+func TestWindowsHoldDeletion(t *testing.T) {
+	binding := newTestBinding("b1", "wec1")
+	binding.Spec.PropagationWindows = []v1alpha1.PropagationWindow{{Schedule: "0 2 * * *", Duration: metav1.Duration{Duration: time.Hour}}}
+	h := newDeliveryTestHarness(t, time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC), binding, nil,
+		[]runtime.Object{newTestConfigMap("ns1", "cm1")},
+		[]runtime.Object{newTestWrapped("b1", "wec3", "b1-wds1-configuration-0")})
+	binding = h.update("b1")
+	if actual, expected := h.wrappedIDs(), []string{"wec3/b1-wds1-configuration-0"}; !slices.Equal(actual, expected) {
+		t.Errorf("Expected wrapped objects %v outside the window, got %v", expected, actual)
+	}
+	if cond := findCondition(binding.Status.Conditions, v1alpha1.TypePendingWindow); cond == nil || cond.Status != corev1.ConditionTrue ||
+		cond.Message != "Changes held back for destinations outside their propagation windows: wec1, wec3" {
+		t.Errorf("Expected PendingWindow condition to hold wec1 and wec3, got %#v", cond)
+	}
+	if len(binding.Status.Errors) != 0 {
+		t.Errorf("Expected no errors in Binding status, got %v", binding.Status.Errors)
+	}
+
+	h.clock.SetTime(time.Date(2024, 6, 4, 2, 30, 0, 0, time.UTC))
+	binding = h.update("b1")
+	if actual, expected := h.wrappedIDs(), []string{"wec1/b1-wds1-configuration-0"}; !slices.Equal(actual, expected) {
+		t.Errorf("Expected wrapped objects %v inside the window, got %v", expected, actual)
+	}
+	if cond := findCondition(binding.Status.Conditions, v1alpha1.TypePendingWindow); cond == nil || cond.Status != corev1.ConditionFalse {
+		t.Errorf("Expected PendingWindow condition to be false, got %#v", cond)
+	}
+}
+
+func TestInvalidRolloutFreezes(t *testing.T) {
+	binding := newTestBinding("b1", "wec1")
+	binding.Spec.Rollout = &v1alpha1.RolloutStrategy{
+		Waves: []v1alpha1.RolloutWave{{Count: ptr.To[int32](1)}},
+		Gate:  v1alpha1.RolloutGate{StatusCollector: "health", Expression: "clusters.size() +"},
+	}
+	h := newDeliveryTestHarness(t, time.Now(), binding, nil,
+		[]runtime.Object{newTestConfigMap("ns1", "cm1")},
+		[]runtime.Object{newTestWrapped("b1", "wec2", "b1-wds1-configuration-0")})
+	binding = h.update("b1")
+	if actual, expected := h.wrappedIDs(), []string{"wec2/b1-wds1-configuration-0"}; !slices.Equal(actual, expected) {
+		t.Errorf("Expected wrapped objects %v to be left as they were, got %v", expected, actual)
+	}
+	if cond := findCondition(binding.Status.Conditions, v1alpha1.TypeFrozen); cond == nil || cond.Status != corev1.ConditionTrue {
+		t.Errorf("Expected Frozen condition to be true, got %#v", cond)
+	}
+}
+
+func TestReplicaSplitErrorsFreeze(t *testing.T) {
+	binding := newTestBinding("b1", "wec1", "wec2")
+	binding.Spec.Workload.NamespaceScope = append(binding.Spec.Workload.NamespaceScope, v1alpha1.NamespaceScopeDownsyncClause{
+		NamespaceScopeDownsyncObject: v1alpha1.NamespaceScopeDownsyncObject{
+			GroupVersionResource: metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			Namespace:            "ns1", Name: "web"},
+		DownsyncModulation: v1alpha1.DownsyncModulation{ReplicaSplit: &v1alpha1.ReplicaSplit{WeightProperty: "capacity"}},
+	})
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "web"},
+		Spec:       appsv1.DeploymentSpec{Replicas: ptr.To[int32](4)},
+	}
+	// No destination has the weight property
+	h := newDeliveryTestHarness(t, time.Now(), binding, nil,
+		[]runtime.Object{newTestConfigMap("ns1", "cm1"), deployment},
+		[]runtime.Object{newTestWrapped("b1", "wec1", "b1-wds1-configuration-0")})
+	binding = h.update("b1")
+	if actual, expected := h.wrappedIDs(), []string{"wec1/b1-wds1-configuration-0"}; !slices.Equal(actual, expected) {
+		t.Errorf("Expected wrapped objects %v to be left as they were, got %v", expected, actual)
+	}
+	if len(binding.Status.Errors) != 1 {
+		t.Errorf("Expected one error in Binding status, got %v", binding.Status.Errors)
+	}
+	if cond := findCondition(binding.Status.Conditions, v1alpha1.TypeFrozen); cond == nil || cond.Status != corev1.ConditionTrue {
+		t.Errorf("Expected Frozen condition to be true, got %#v", cond)
+	}
+}
+
+func TestOverrideErrorsFreezeDestination(t *testing.T) {
+	binding := newTestBinding("b1", "wec1", "wec2")
+	binding.Spec.Overrides = []v1alpha1.Override{{
+		ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}},
+		JSONPatch:       &apiextensionsv1.JSON{Raw: []byte(`[{"op":"remove","path":"/spec/nosuch"}]`)},
+	}}
+	inventory := []*clusterv1.ManagedCluster{
+		{ObjectMeta: metav1.ObjectMeta{Name: "wec1", Labels: map[string]string{"env": "dev"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "wec2", Labels: map[string]string{"env": "prod"}}},
+	}
+	h := newDeliveryTestHarness(t, time.Now(), binding, inventory,
+		[]runtime.Object{newTestConfigMap("ns1", "cm1")},
+		[]runtime.Object{newTestWrapped("b1", "wec2", "b1-wds1-configuration-0"), newTestWrapped("b1", "wec2", "b1-wds1-configuration-1")})
+	binding = h.update("b1")
+	if actual, expected := h.wrappedIDs(), []string{"wec1/b1-wds1-configuration-0", "wec2/b1-wds1-configuration-0", "wec2/b1-wds1-configuration-1"}; !slices.Equal(actual, expected) {
+		t.Errorf("Expected wrapped objects %v, got %v", expected, actual)
+	}
+	wec2Wrapped, err := h.its.Resource(testWrapperGVR).Namespace("wec2").Get(h.ctx, "b1-wds1-configuration-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get wrapped object: %s", err)
+	}
+	if _, has := wec2Wrapped.GetAnnotations()[originOwnerGenerationAnnotation]; has {
+		t.Errorf("Expected wrapped object in frozen destination to be left as it was, got %v", wec2Wrapped)
+	}
+	if len(binding.Status.Errors) != 1 {
+		t.Errorf("Expected one error in Binding status, got %v", binding.Status.Errors)
+	}
+	if cond := findCondition(binding.Status.Conditions, v1alpha1.TypeFrozen); cond == nil || cond.Status != corev1.ConditionTrue ||
+		cond.Message != "Wrapped objects are left as they are, because of errors in the Binding, for destinations: wec2" {
+		t.Errorf("Expected Frozen condition for wec2, got %#v", cond)
+	}
+}
+
+func TestComputeErrorsFreezeDestination(t *testing.T) {
+	binding := newTestBinding("b1", "wec1", "wec2")
+	inventory := []*clusterv1.ManagedCluster{
+		{ObjectMeta: metav1.ObjectMeta{Name: "wec1", Labels: map[string]string{"region": "eu"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "wec2"}},
+	}
+	h := newDeliveryTestHarness(t, time.Now(), binding, inventory,
+		[]runtime.Object{newTestConfigMap("ns1", "cm1")},
+		[]runtime.Object{newTestWrapped("b1", "wec2", "b1-wds1-configuration-0")})
+	ct := &v1alpha1.CustomTransform{
+		ObjectMeta: metav1.ObjectMeta{Name: "host"},
+		Spec: v1alpha1.CustomTransformSpec{Resource: "configmaps", Compute: []v1alpha1.ComputeOperation{
+			{Path: "$.data.host", Expression: `props["region"] + ".example.com"`},
+		}},
+	}
+	ct, err := h.ks.ControlV1alpha1().CustomTransforms().Create(h.ctx, ct, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create CustomTransform: %s", err)
+	}
+	computeEvaluator, err := newComputeEvaluator()
+	if err != nil {
+		t.Fatalf("Failed to create CEL evaluator: %s", err)
+	}
+	h.ctlr.customTransformCollection = newCustomTransformCollection(h.ks.ControlV1alpha1().CustomTransforms(), computeEvaluator,
+		func(_, key string) ([]any, error) {
+			if key == customTransformDomainKey("", "configmaps") {
+				return []any{ct}, nil
+			}
+			return nil, nil
+		},
+		func(any) {})
+	getCTErrors := func() []string {
+		ct, err := h.ks.ControlV1alpha1().CustomTransforms().Get(h.ctx, "host", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("Failed to get CustomTransform: %s", err)
+		}
+		return ct.Status.Errors
+	}
+
+	binding = h.update("b1")
+	if actual, expected := h.wrappedIDs(), []string{"wec1/b1-wds1-configuration-0", "wec2/b1-wds1-configuration-0"}; !slices.Equal(actual, expected) {
+		t.Errorf("Expected wrapped objects %v, got %v", expected, actual)
+	}
+	wec2Wrapped, err := h.its.Resource(testWrapperGVR).Namespace("wec2").Get(h.ctx, "b1-wds1-configuration-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get wrapped object: %s", err)
+	}
+	if _, has := wec2Wrapped.GetAnnotations()[originOwnerGenerationAnnotation]; has {
+		t.Errorf("Expected wrapped object in frozen destination to be left as it was, got %v", wec2Wrapped)
+	}
+	if len(binding.Status.Errors) != 1 {
+		t.Errorf("Expected one error in Binding status, got %v", binding.Status.Errors)
+	}
+	if cond := findCondition(binding.Status.Conditions, v1alpha1.TypeFrozen); cond == nil || cond.Status != corev1.ConditionTrue ||
+		cond.Message != "Wrapped objects are left as they are, because of errors in the Binding, for destinations: wec2" {
+		t.Errorf("Expected Frozen condition for wec2, got %#v", cond)
+	}
+	if ctErrors := getCTErrors(); len(ctErrors) != 1 || !strings.Contains(ctErrors[0], `in Binding "b1"`) {
+		t.Errorf("Expected one error about Binding b1 in CustomTransform status, got %q", ctErrors)
+	}
+
+	// Dropping the troubled destination clears the error from the CustomTransform status
+	binding.Spec.Destinations = binding.Spec.Destinations[:1]
+	if _, err := h.ks.ControlV1alpha1().Bindings().Update(h.ctx, binding, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update Binding: %s", err)
+	}
+	binding = h.update("b1")
+	if actual, expected := h.wrappedIDs(), []string{"wec1/b1-wds1-configuration-0"}; !slices.Equal(actual, expected) {
+		t.Errorf("Expected wrapped objects %v, got %v", expected, actual)
+	}
+	if len(binding.Status.Errors) != 0 {
+		t.Errorf("Expected no errors in Binding status, got %v", binding.Status.Errors)
+	}
+	if ctErrors := getCTErrors(); len(ctErrors) != 0 {
+		t.Errorf("Expected no errors in CustomTransform status, got %q", ctErrors)
+	}
+}
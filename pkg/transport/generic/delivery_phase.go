@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kubestellar/kubestellar/pkg/transport"
+)
+
+// deliveryPhase is the position of a workload object in the order of delivery
+// to a destination. The objects of one phase are wrapped separately from those
+// of other phases, and a phase is released to a destination only after all the
+// earlier phases report that they have been applied there.
+type deliveryPhase int
+
+const (
+	// phaseFoundation holds the objects that others need to exist first:
+	// CustomResourceDefinitions and Namespaces.
+	phaseFoundation deliveryPhase = iota
+	// phaseConfiguration holds RBAC and configuration objects.
+	phaseConfiguration
+	// phaseWorkload holds everything else.
+	phaseWorkload
+)
+
+// namePart returns what distinguishes the names of the wrapped objects of this phase
+// from those of the other phases. It is empty for phaseWorkload, so that the wrapped
+// objects of a Binding without foundation or configuration objects keep the names
+// that they had before there were delivery phases.
+func (phase deliveryPhase) namePart() string {
+	switch phase {
+	case phaseFoundation:
+		return "foundation"
+	case phaseConfiguration:
+		return "configuration"
+	default:
+		return ""
+	}
+}
+
+// configurationKinds are the core kinds that go in phaseConfiguration.
+var configurationKinds = map[string]bool{
+	"ServiceAccount":        true,
+	"ConfigMap":             true,
+	"Secret":                true,
+	"PersistentVolumeClaim": true,
+	"ResourceQuota":         true,
+	"LimitRange":            true,
+}
+
+// deliveryPhaseOf returns the delivery phase of objects of the given kind.
+func deliveryPhaseOf(gk schema.GroupKind) deliveryPhase {
+	switch {
+	case gk.Group == "apiextensions.k8s.io" && gk.Kind == "CustomResourceDefinition",
+		gk.Group == "" && gk.Kind == "Namespace":
+		return phaseFoundation
+	case gk.Group == "rbac.authorization.k8s.io",
+		gk.Group == "" && configurationKinds[gk.Kind]:
+		return phaseConfiguration
+	default:
+		return phaseWorkload
+	}
+}
+
+// deliveryApplied tells whether the given wrapped object reports, for its
+// current generation, that its contents have been applied at its destination.
+// When the transport does not report on delivery, the answer is always true.
+func deliveryApplied(reporter transport.DeliveryReporter, wrapped *unstructured.Unstructured) (bool, error) {
+	if reporter == nil {
+		return true, nil
+	}
+	conditions, err := reporter.DeliveryConditions(wrapped)
+	if err != nil {
+		return false, err
+	}
+	for _, condition := range conditions {
+		if condition.Type != transport.DeliveryConditionApplied {
+			continue
+		}
+		current := condition.ObservedGeneration == 0 || condition.ObservedGeneration == wrapped.GetGeneration()
+		return current && condition.Status == metav1.ConditionTrue, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,217 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/transport"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+// phaseTestTransport wraps objects into a bare unstructured object that lists their identities,
+// and reports delivery conditions from the wrapped object's annotations.
+type phaseTestTransport struct{}
+
+func (phaseTestTransport) WrapObjects(wrapees []transport.Wrapee, kindToResource func(schema.GroupKind) string) runtime.Object {
+	ids := make([]any, 0, len(wrapees))
+	for _, wrapee := range wrapees {
+		id := wrapee.GetID()
+		ids = append(ids, strings.Join([]string{id.GK.Group, id.GK.Kind, id.OR.Namespace, id.OR.Name}, "/"))
+	}
+	return &unstructured.Unstructured{Object: map[string]any{"apiVersion": "test/v1", "kind": "Wrapper", "ids": ids}}
+}
+
+func (phaseTestTransport) UnwrapObjects(wrapped runtime.Object, kindToResource func(schema.GroupKind) (string, bool)) (transport.Gloss, error) {
+	ids, _, err := unstructured.NestedStringSlice(wrapped.(*unstructured.Unstructured).Object, "ids")
+	if err != nil {
+		return nil, err
+	}
+	gloss := transport.Gloss{}
+	for _, id := range ids {
+		parts := strings.Split(id, "/")
+		gloss.Insert(util.GKObjRef{GK: schema.GroupKind{Group: parts[0], Kind: parts[1]}, OR: klog.ObjectRef{Namespace: parts[2], Name: parts[3]}})
+	}
+	return gloss, nil
+}
+
+func (phaseTestTransport) DeliveryConditions(wrapped runtime.Object) ([]metav1.Condition, error) {
+	wrappedU := wrapped.(*unstructured.Unstructured)
+	status, has := wrappedU.GetAnnotations()["applied"]
+	if !has {
+		return nil, nil
+	}
+	return []metav1.Condition{{Type: transport.DeliveryConditionApplied, Status: metav1.ConditionStatus(status), ObservedGeneration: 2}}, nil
+}
+
+func TestWrapByDeliveryPhase(t *testing.T) {
+	newWrapee := func(apiVersion, kind, name string) WrapeeWithUID {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(apiVersion)
+		obj.SetKind(kind)
+		obj.SetName(name)
+		return WrapeeWithUID{Wrapee: transport.NewWrapee(obj, false, ""), UID: name}
+	}
+	c := &genericTransportController{transport: phaseTestTransport{}, MaxNumWrapped: 10, MaxSizeWrapped: 1 << 20, wdsName: "wds1"}
+	tasks, err := c.wrap([]WrapeeWithUID{
+		newWrapee("apps/v1", "Deployment", "web"),
+		newWrapee("v1", "ConfigMap", "web-config"),
+		newWrapee("apiextensions.k8s.io/v1", "CustomResourceDefinition", "widgets.example.com"),
+		newWrapee("v1", "Namespace", "demo"),
+		newWrapee("rbac.authorization.k8s.io/v1", "Role", "reader"),
+		newWrapee("example.com/v1", "Widget", "w1"),
+	}, func(schema.GroupKind) (string, bool) { return "", true }, &v1alpha1.Binding{ObjectMeta: metav1.ObjectMeta{Name: "b1"}})
+	if err != nil {
+		t.Fatalf("Failed to wrap: %s", err)
+	}
+	expected := []struct {
+		phase deliveryPhase
+		count int
+	}{{phaseFoundation, 2}, {phaseConfiguration, 2}, {phaseWorkload, 2}}
+	if len(tasks) != len(expected) {
+		t.Fatalf("Expected %d wrapped objects, got %d", len(expected), len(tasks))
+	}
+	for idx, task := range tasks {
+		if task.Phase != expected[idx].phase || len(task.Gloss) != expected[idx].count {
+			t.Errorf("Wrapped object %d: expected phase %d with %d objects, got phase %d with %d objects",
+				idx, expected[idx].phase, expected[idx].count, task.Phase, len(task.Gloss))
+		}
+	}
+}
+
+func TestWrappedNamesStableAcrossPhases(t *testing.T) {
+	newWrapee := func(apiVersion, kind, name string) WrapeeWithUID {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(apiVersion)
+		obj.SetKind(kind)
+		obj.SetName(name)
+		return WrapeeWithUID{Wrapee: transport.NewWrapee(obj, false, ""), UID: name}
+	}
+	c := &genericTransportController{transport: phaseTestTransport{}, MaxNumWrapped: 2, MaxSizeWrapped: 1 << 20, wdsName: "wds1"}
+	binding := &v1alpha1.Binding{ObjectMeta: metav1.ObjectMeta{Name: "b1"}}
+	kindToResource := func(schema.GroupKind) (string, bool) { return "", true }
+	workload := []WrapeeWithUID{
+		newWrapee("apps/v1", "Deployment", "web"),
+		newWrapee("apps/v1", "Deployment", "api"),
+		newWrapee("example.com/v1", "Widget", "w1"),
+	}
+	names := func(wrapees []WrapeeWithUID) []string {
+		t.Helper()
+		tasks, err := c.wrap(wrapees, kindToResource, binding)
+		if err != nil {
+			t.Fatalf("Failed to wrap: %s", err)
+		}
+		var ans []string
+		for _, task := range tasks {
+			ans = append(ans, task.ObjU.GetName())
+		}
+		return ans
+	}
+	if actual, expected := names(workload), []string{"b1-wds1-0", "b1-wds1-1"}; !slices.Equal(actual, expected) {
+		t.Errorf("Expected names %v, got %v", expected, actual)
+	}
+	// adding a CRD, a Namespace and a Role does not rename the wrapped workload objects
+	withMore := append([]WrapeeWithUID{
+		newWrapee("apiextensions.k8s.io/v1", "CustomResourceDefinition", "widgets.example.com"),
+		newWrapee("v1", "Namespace", "demo"),
+		newWrapee("v1", "Namespace", "demo2"),
+		newWrapee("rbac.authorization.k8s.io/v1", "Role", "reader"),
+	}, workload...)
+	expected := []string{"b1-wds1-foundation-0", "b1-wds1-foundation-1", "b1-wds1-configuration-0", "b1-wds1-0", "b1-wds1-1"}
+	if actual := names(withMore); !slices.Equal(actual, expected) {
+		t.Errorf("Expected names %v, got %v", expected, actual)
+	}
+}
+
+func TestDeliveryApplied(t *testing.T) {
+	newWrapped := func(generation int64, annotations map[string]string) *unstructured.Unstructured {
+		ans := &unstructured.Unstructured{}
+		ans.SetGeneration(generation)
+		ans.SetAnnotations(annotations)
+		return ans
+	}
+	for _, tc := range []struct {
+		name     string
+		reporter transport.DeliveryReporter
+		wrapped  *unstructured.Unstructured
+		expected bool
+	}{
+		{name: "no reporter", wrapped: newWrapped(1, nil), expected: true},
+		{name: "not reported", reporter: phaseTestTransport{}, wrapped: newWrapped(2, nil), expected: false},
+		{name: "applied", reporter: phaseTestTransport{}, wrapped: newWrapped(2, map[string]string{"applied": "True"}), expected: true},
+		{name: "not applied", reporter: phaseTestTransport{}, wrapped: newWrapped(2, map[string]string{"applied": "False"}), expected: false},
+		{name: "stale", reporter: phaseTestTransport{}, wrapped: newWrapped(3, map[string]string{"applied": "True"}), expected: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := deliveryApplied(tc.reporter, tc.wrapped)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if actual != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestLaterPhasesWaitUntilEarlierApplied(t *testing.T) {
+	binding := newTestBinding("b1", "wec1")
+	binding.Spec.Workload.ClusterScope = []v1alpha1.ClusterScopeDownsyncClause{{ClusterScopeDownsyncObject: v1alpha1.ClusterScopeDownsyncObject{
+		GroupVersionResource: metav1.GroupVersionResource{Version: "v1", Resource: "namespaces"},
+		Name:                 "ns1"}}}
+	namespace := &corev1.Namespace{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{Name: "ns1"},
+	}
+	h := newDeliveryTestHarness(t, time.Now(), binding, nil,
+		[]runtime.Object{namespace, newTestConfigMap("ns1", "cm1")}, nil)
+	// Only the foundation phase, holding the Namespace, is delivered at first
+	h.update("b1")
+	if actual, expected := h.wrappedIDs(), []string{"wec1/b1-wds1-foundation-0"}; !slices.Equal(actual, expected) {
+		t.Errorf("Expected wrapped objects %v, got %v", expected, actual)
+	}
+	// Nothing more while the foundation phase is not applied
+	h.update("b1")
+	if actual, expected := h.wrappedIDs(), []string{"wec1/b1-wds1-foundation-0"}; !slices.Equal(actual, expected) {
+		t.Errorf("Expected wrapped objects %v while the foundation phase is not applied, got %v", expected, actual)
+	}
+	foundation, err := h.its.Resource(testWrapperGVR).Namespace("wec1").Get(h.ctx, "b1-wds1-foundation-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get wrapped object: %s", err)
+	}
+	foundation.SetGeneration(2)
+	foundation.SetAnnotations(map[string]string{originOwnerGenerationAnnotation: foundation.GetAnnotations()[originOwnerGenerationAnnotation], "applied": "True"})
+	if _, err := h.its.Resource(testWrapperGVR).Namespace("wec1").Update(h.ctx, foundation, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update wrapped object: %s", err)
+	}
+	// Once the foundation phase is applied, the configuration phase follows
+	h.update("b1")
+	if actual, expected := h.wrappedIDs(), []string{"wec1/b1-wds1-configuration-0", "wec1/b1-wds1-foundation-0"}; !slices.Equal(actual, expected) {
+		t.Errorf("Expected wrapped objects %v once the foundation phase is applied, got %v", expected, actual)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"testing"
+	"time"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestSummarizeDeliveryConditions(t *testing.T) {
+	t1 := metav1.NewTime(time.Date(2024, 6, 3, 10, 0, 0, 0, time.UTC))
+	t2 := metav1.NewTime(time.Date(2024, 6, 3, 11, 0, 0, 0, time.UTC))
+	cond := func(condType string, status metav1.ConditionStatus, reason string, when metav1.Time) metav1.Condition {
+		return metav1.Condition{Type: condType, Status: status, Reason: reason, Message: reason + " message", LastTransitionTime: when}
+	}
+	for _, tc := range []struct {
+		name      string
+		perObject [][]metav1.Condition
+		expected  []metav1.Condition
+	}{
+		{name: "no wrapped objects", expected: []metav1.Condition{}},
+		{name: "all true",
+			perObject: [][]metav1.Condition{
+				{cond("Applied", metav1.ConditionTrue, "AppliedManifestWorkComplete", t1)},
+				{cond("Applied", metav1.ConditionTrue, "AppliedManifestWorkComplete", t2)},
+			},
+			expected: []metav1.Condition{cond("Applied", metav1.ConditionTrue, "AppliedManifestWorkComplete", t2)}},
+		{name: "one false",
+			perObject: [][]metav1.Condition{
+				{cond("Applied", metav1.ConditionTrue, "AppliedManifestWorkComplete", t2), cond("Available", metav1.ConditionTrue, "ResourcesAvailable", t1)},
+				{cond("Applied", metav1.ConditionFalse, "AppliedManifestWorkFailed", t1), cond("Available", metav1.ConditionUnknown, "ResourcesStatusUnknown", t1)},
+			},
+			expected: []metav1.Condition{
+				cond("Applied", metav1.ConditionFalse, "AppliedManifestWorkFailed", t2),
+				cond("Available", metav1.ConditionUnknown, "ResourcesStatusUnknown", t1),
+			}},
+		{name: "not reported by all",
+			perObject: [][]metav1.Condition{
+				{cond("Applied", metav1.ConditionTrue, "AppliedManifestWorkComplete", t1)},
+				{},
+			},
+			expected: []metav1.Condition{{Type: "Applied", Status: metav1.ConditionUnknown, Reason: reasonNotReported,
+				Message: "Not reported by 1 of 2 wrapped objects", LastTransitionTime: t1}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := summarizeDeliveryConditions(tc.perObject)
+			if !apiequality.Semantic.DeepEqual(actual, tc.expected) {
+				t.Errorf("Expected %#v, got %#v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDestinationStatusesAfterWrites(t *testing.T) {
+	binding := newTestBinding("b1", "wec1")
+	h := newDeliveryTestHarness(t, time.Now(), binding, nil,
+		[]runtime.Object{newTestConfigMap("ns1", "cm1")}, nil)
+	// The wrapped object is created here, so what was read before is out of date
+	binding = h.update("b1")
+	if len(binding.Status.Destinations) != 0 {
+		t.Errorf("Expected no destination statuses right after writing wrapped objects, got %#v", binding.Status.Destinations)
+	}
+	// The informer notification about the creation brings the Binding back
+	binding = h.update("b1")
+	expected := []v1alpha1.DestinationStatus{{ClusterId: "wec1", WrappedObjects: 1}}
+	if !apiequality.Semantic.DeepEqual(binding.Status.Destinations, expected) {
+		t.Errorf("Expected destination statuses %#v, got %#v", expected, binding.Status.Destinations)
+	}
+	// Nothing changed, so no status is written
+	h.ks.ClearActions()
+	h.update("b1")
+	for _, action := range h.ks.Actions() {
+		if action.GetVerb() == "update" && action.GetSubresource() == "status" {
+			t.Errorf("Expected no status write, got %#v", action)
+		}
+	}
+}
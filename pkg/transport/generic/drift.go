@@ -0,0 +1,178 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/transport"
+)
+
+// reapplyAnnotation is the annotation, on a wrapped object, that holds the reapply
+// markers of its Binding. A change in the markers forces the wrapped objects to be updated.
+const reapplyAnnotation = "transport.kubestellar.io/reapply"
+
+// desiredStatesAnnotation is the annotation, on a wrapped object, that says (with value "true")
+// that the wrapped object has a desired-state ConfigMap (see v1alpha1.DesiredStateConfigMapLabelKey).
+const desiredStatesAnnotation = "transport.kubestellar.io/desired-states"
+
+var configMapGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+// desiredState returns the JSON encoding of the given object as desired in its destination:
+// without status and without metadata other than labels and the annotations
+// that are not about drift. It also returns the hash of that encoding.
+func desiredState(obj *unstructured.Unstructured) (string, string, error) {
+	desired := map[string]any{}
+	for key, val := range obj.Object {
+		if key != "metadata" && key != "status" {
+			desired[key] = val
+		}
+	}
+	metadata := map[string]any{}
+	if objLabels := obj.GetLabels(); len(objLabels) > 0 {
+		metadata["labels"] = objLabels
+	}
+	objAnnotations := obj.GetAnnotations()
+	delete(objAnnotations, v1alpha1.DesiredStateAnnotationKey)
+	delete(objAnnotations, v1alpha1.ReapplyAnnotationKey)
+	if len(objAnnotations) > 0 {
+		metadata["annotations"] = objAnnotations
+	}
+	if len(metadata) > 0 {
+		desired["metadata"] = metadata
+	}
+	desiredJSON, err := json.Marshal(desired)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode desired state of %s: %w", obj.GetName(), err)
+	}
+	hash := sha256.Sum256(desiredJSON)
+	return string(desiredJSON), hex.EncodeToString(hash[:]), nil
+}
+
+// withDesiredState returns a copy of the given object that has the DesiredStateAnnotationKey annotation,
+// and the desired state and hash that the annotation refers to.
+func withDesiredState(obj *unstructured.Unstructured) (*unstructured.Unstructured, string, string, error) {
+	desired, hash, err := desiredState(obj)
+	if err != nil {
+		return nil, "", "", err
+	}
+	ans := obj.DeepCopy()
+	setAnnotation(ans, v1alpha1.DesiredStateAnnotationKey, hash)
+	return ans, desired, hash, nil
+}
+
+// syncDesiredStates makes the given wrapped object, just written to the given namespace,
+// have a desired-state ConfigMap holding the given desired states, or have none if there
+// are none of them. `hadStates` tells whether the previous version of the wrapped object had one.
+// The ConfigMap has the name of the wrapped object, which owns it.
+func (c *genericTransportController) syncDesiredStates(ctx context.Context, namespace string, wrapped *unstructured.Unstructured, desiredStates map[string]string, hadStates bool) error {
+	logger := klog.FromContext(ctx)
+	cmClient := c.transportClient.Resource(configMapGVR).Namespace(namespace)
+	if len(desiredStates) == 0 {
+		if !hadStates {
+			return nil
+		}
+		err := cmClient.Delete(ctx, wrapped.GetName(), metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete desired-state ConfigMap %s/%s - %w", namespace, wrapped.GetName(), err)
+		}
+		logger.V(4).Info("Deleted desired-state ConfigMap", "namespace", namespace, "name", wrapped.GetName())
+		return nil
+	}
+	desired := &unstructured.Unstructured{Object: map[string]any{"apiVersion": "v1", "kind": "ConfigMap"}}
+	desired.SetNamespace(namespace)
+	desired.SetName(wrapped.GetName())
+	cmLabels := map[string]string{v1alpha1.DesiredStateConfigMapLabelKey: "true"}
+	for _, key := range []string{originOwnerReferenceLabel, originWdsLabel} {
+		cmLabels[key] = wrapped.GetLabels()[key]
+	}
+	desired.SetLabels(cmLabels)
+	desired.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: wrapped.GetAPIVersion(), Kind: wrapped.GetKind(),
+		Name: wrapped.GetName(), UID: wrapped.GetUID()}})
+	data := make(map[string]any, len(desiredStates))
+	for hash, state := range desiredStates {
+		data[hash] = state
+	}
+	desired.Object["data"] = data
+	existing, err := cmClient.Get(ctx, wrapped.GetName(), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if _, err := cmClient.Create(ctx, desired, metav1.CreateOptions{FieldManager: ControllerName}); err != nil {
+			return fmt.Errorf("failed to create desired-state ConfigMap %s/%s - %w", namespace, wrapped.GetName(), err)
+		}
+		logger.V(4).Info("Created desired-state ConfigMap", "namespace", namespace, "name", wrapped.GetName())
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get desired-state ConfigMap %s/%s - %w", namespace, wrapped.GetName(), err)
+	}
+	if apiequality.Semantic.DeepEqual(existing.Object["data"], desired.Object["data"]) &&
+		apiequality.Semantic.DeepEqual(existing.GetLabels(), desired.GetLabels()) &&
+		apiequality.Semantic.DeepEqual(existing.GetOwnerReferences(), desired.GetOwnerReferences()) {
+		return nil
+	}
+	desired.SetResourceVersion(existing.GetResourceVersion())
+	if _, err := cmClient.Update(ctx, desired, metav1.UpdateOptions{FieldManager: ControllerName}); err != nil {
+		return fmt.Errorf("failed to update desired-state ConfigMap %s/%s - %w", namespace, wrapped.GetName(), err)
+	}
+	logger.V(4).Info("Updated desired-state ConfigMap", "namespace", namespace, "name", wrapped.GetName())
+	return nil
+}
+
+// reapplyMarker returns the value for the ReapplyAnnotationKey annotation of the given
+// workload object, according to the drift reported in the given Binding's status.
+// The value lists the drifted destinations and the hashes of the desired states that they
+// drifted from; it is empty if the object has not drifted. The value does not change when
+// reapplying does not cure the drift, so the object is reapplied once per desired state.
+func reapplyMarker(binding *v1alpha1.Binding, gvr metav1.GroupVersionResource, namespace, name string) string {
+	for _, objDrift := range binding.Status.Drift {
+		if objDrift.Group != gvr.Group || objDrift.Resource != gvr.Resource || objDrift.Namespace != namespace || objDrift.Name != name {
+			continue
+		}
+		markers := make([]string, 0, len(objDrift.Destinations))
+		for _, destDrift := range objDrift.Destinations {
+			markers = append(markers, destDrift.ClusterId+"="+destDrift.DesiredStateHash)
+		}
+		slices.Sort(markers)
+		return strings.Join(markers, ",")
+	}
+	return ""
+}
+
+// reapplyMarkers returns the value for the reapplyAnnotation of a wrapped object
+// holding the given wrapees. It is empty if none of them is to be reapplied.
+func reapplyMarkers(wrapees []transport.Wrapee) string {
+	var markers []string
+	for _, wrapee := range wrapees {
+		if marker := wrapee.Object.GetAnnotations()[v1alpha1.ReapplyAnnotationKey]; marker != "" {
+			markers = append(markers, wrapee.GetID().String()+":"+marker)
+		}
+	}
+	return strings.Join(markers, ";")
+}
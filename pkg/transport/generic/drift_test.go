@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestDesiredState(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]any{
+			"name":      "web",
+			"namespace": "demo",
+			"labels":    map[string]any{"app": "web"},
+			"annotations": map[string]any{
+				v1alpha1.ReapplyAnnotationKey: "wec1=5",
+				"note":                        "hi",
+			},
+		},
+		"spec":   map[string]any{"replicas": int64(2)},
+		"status": map[string]any{"readyReplicas": int64(1)},
+	}}
+	withState, state, hash, err := withDesiredState(obj)
+	if err != nil {
+		t.Fatalf("Failed to add desired state: %s", err)
+	}
+	expected := `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"annotations":{"note":"hi"},"labels":{"app":"web"}},"spec":{"replicas":2}}`
+	if state != expected {
+		t.Errorf("Expected desired state %s, got %s", expected, state)
+	}
+	expectedHash := sha256.Sum256([]byte(expected))
+	if hash != hex.EncodeToString(expectedHash[:]) {
+		t.Errorf("Expected hash of desired state, got %s", hash)
+	}
+	if actual := withState.GetAnnotations()[v1alpha1.DesiredStateAnnotationKey]; actual != hash {
+		t.Errorf("Expected annotation %s, got %s", hash, actual)
+	}
+	if _, has := obj.GetAnnotations()[v1alpha1.DesiredStateAnnotationKey]; has {
+		t.Errorf("Input object was modified")
+	}
+}
+
+func TestReapplyMarker(t *testing.T) {
+	gvr := metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	binding := &v1alpha1.Binding{Status: v1alpha1.BindingStatus{Drift: []v1alpha1.ObjectDrift{{
+		GroupVersionResource: gvr, Namespace: "demo", Name: "web",
+		Destinations: []v1alpha1.DestinationDrift{
+			{ClusterId: "wec2", Paths: []string{".spec.replicas"}, ObservedResourceVersion: "7", DesiredStateHash: "abc"},
+			{ClusterId: "wec1", Paths: []string{".spec.replicas"}, ObservedResourceVersion: "12", DesiredStateHash: "def"},
+		},
+	}}}}
+	if actual := reapplyMarker(binding, gvr, "demo", "web"); actual != "wec1=def,wec2=abc" {
+		t.Errorf("Unexpected marker %q", actual)
+	}
+	// Reapplying gives the object a new resourceVersion, which must not change the marker
+	binding.Status.Drift[0].Destinations[0].ObservedResourceVersion = "8"
+	if actual := reapplyMarker(binding, gvr, "demo", "web"); actual != "wec1=def,wec2=abc" {
+		t.Errorf("Marker changed with resourceVersion: %q", actual)
+	}
+	if actual := reapplyMarker(binding, gvr, "demo", "other"); actual != "" {
+		t.Errorf("Expected no marker for an object without drift, got %q", actual)
+	}
+}
+
+func TestDesiredStateConfigMap(t *testing.T) {
+	binding := newTestBinding("b1", "wec1")
+	binding.Spec.Workload.NamespaceScope[0].DriftPolicy = v1alpha1.DriftPolicyReport
+	h := newDeliveryTestHarness(t, time.Now(), binding, nil, []runtime.Object{newTestConfigMap("ns1", "cm1")}, nil)
+	h.update("b1")
+	cmClient := h.its.Resource(configMapGVR).Namespace("wec1")
+	// Nothing is conveyed for drift detection unless it is enabled
+	if _, err := cmClient.Get(h.ctx, "b1-wds1-configuration-0", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Fatalf("Expected no desired-state ConfigMap while drift detection is disabled, got error %v", err)
+	}
+	h.ctlr.EnableDriftDetection()
+	h.update("b1")
+	cm, err := cmClient.Get(h.ctx, "b1-wds1-configuration-0", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get desired-state ConfigMap: %s", err)
+	}
+	if cm.GetLabels()[v1alpha1.DesiredStateConfigMapLabelKey] != "true" || cm.GetLabels()[originWdsLabel] != "wds1" {
+		t.Errorf("Wrong labels on desired-state ConfigMap: %v", cm.GetLabels())
+	}
+	if owners := cm.GetOwnerReferences(); len(owners) != 1 || owners[0].Name != "b1-wds1-configuration-0" || owners[0].Kind != "Wrapper" {
+		t.Errorf("Expected desired-state ConfigMap to be owned by its wrapped object, got %v", owners)
+	}
+	data, _, _ := unstructured.NestedStringMap(cm.Object, "data")
+	if len(data) != 1 {
+		t.Fatalf("Expected one desired state, got %v", data)
+	}
+	for hash, state := range data {
+		stateHash := sha256.Sum256([]byte(state))
+		if hash != hex.EncodeToString(stateHash[:]) {
+			t.Errorf("Desired state %s is not under its hash, rather %s", state, hash)
+		}
+		if !strings.Contains(state, `"data":{"k":"v"}`) {
+			t.Errorf("Unexpected desired state %s", state)
+		}
+	}
+
+	// No longer checking drift removes the ConfigMap
+	binding, err = h.ks.ControlV1alpha1().Bindings().Get(h.ctx, "b1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get Binding: %s", err)
+	}
+	binding.Spec.Workload.NamespaceScope[0].DriftPolicy = ""
+	binding.Generation++
+	if _, err := h.ks.ControlV1alpha1().Bindings().Update(h.ctx, binding, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update Binding: %s", err)
+	}
+	h.update("b1")
+	if _, err := cmClient.Get(h.ctx, "b1-wds1-configuration-0", metav1.GetOptions{}); !errors.IsNotFound(err) {
+		t.Errorf("Expected desired-state ConfigMap to be deleted, got err=%v", err)
+	}
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filtering
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kubestellar/kubestellar/pkg/jsonpath"
+)
+
+// CleanupRule says what to remove from the workload objects of one kind
+// on their way to a WEC, because those parts are specific to the WDS
+// (e.g., assigned by a controller there) and would break applying the object in the WEC.
+type CleanupRule struct {
+	// Group is the API group of the objects; empty for the core group.
+	// The rule applies to all versions.
+	Group string `json:"group,omitempty"`
+
+	Kind string `json:"kind"`
+
+	// Remove is a list of JSONPath expressions (RFC 9535, without function extensions)
+	// that identify the parts of the object to remove if present.
+	Remove []string `json:"remove"`
+}
+
+// DefaultCleanupRules returns the cleanup rules that are built into the transport controller.
+func DefaultCleanupRules() []CleanupRule {
+	return []CleanupRule{
+		{Kind: "Service", Remove: []string{
+			"$.spec.ipFamilies",
+			"$.spec.externalTrafficPolicy",
+			"$.spec.internalTrafficPolicy",
+			"$.spec.ipFamilyPolicy",
+			"$.spec.sessionAffinity",
+		}},
+		{Group: "batch", Kind: "Job", Remove: []string{
+			`$.metadata.annotations["batch.kubernetes.io/job-tracking"]`,
+			`$.metadata.labels["controller-uid"]`,
+			`$.metadata.labels["batch.kubernetes.io/controller-uid"]`,
+			"$.spec.selector",
+			"$.spec.suspend",
+			`$.spec.template.metadata.labels["controller-uid"]`,
+			`$.spec.template.metadata.labels["batch.kubernetes.io/controller-uid"]`,
+		}},
+		{Kind: "PersistentVolumeClaim", Remove: []string{
+			`$.metadata.annotations["pv.kubernetes.io/bind-completed"]`,
+			`$.metadata.annotations["pv.kubernetes.io/bound-by-controller"]`,
+			`$.metadata.annotations["volume.beta.kubernetes.io/storage-provisioner"]`,
+			`$.metadata.annotations["volume.kubernetes.io/storage-provisioner"]`,
+			`$.metadata.annotations["volume.kubernetes.io/selected-node"]`,
+			"$.spec.volumeName",
+		}},
+		{Kind: "Pod", Remove: []string{"$.spec.nodeName"}},
+		{Kind: "ServiceAccount", Remove: []string{"$.secrets"}},
+		{Group: "apps", Kind: "Deployment", Remove: []string{
+			`$.metadata.annotations["deployment.kubernetes.io/revision"]`,
+		}},
+		// Only a host that the router generated is removed; the filter selects
+		// the top-level members (thus `spec`) when the annotation says so.
+		{Group: "route.openshift.io", Kind: "Route", Remove: []string{
+			`$[?$.metadata.annotations["openshift.io/host.generated"] == "true"].host`,
+			`$.metadata.annotations["openshift.io/host.generated"]`,
+		}},
+	}
+}
+
+// LoadCleanupRules reads a list of cleanup rules from the YAML (or JSON) file at the given path.
+func LoadCleanupRules(path string) ([]CleanupRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []CleanupRule
+	if err := yaml.UnmarshalStrict(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse cleanup rules in %q: %w", path, err)
+	}
+	return rules, nil
+}
+
+// cleanObjectSpecificsFunction is a function for cleaning fields from a specific object.
+// The function cleans the specific fields in place (object is modified).
+// If the object was retrieved using a lister, it's the caller responsibility
+// to do a DeepCopy before calling this function.
+type cleanObjectSpecificsFunction func(object *unstructured.Unstructured)
+
+// CleanupRules is the digested form of a list of CleanupRule,
+// plus the cleaning that is too intricate to express in rules.
+// It is immutable.
+type CleanupRules struct {
+	gkToQueries map[schema.GroupKind][]jsonpath.Query
+	gkToFunc    map[schema.GroupKind]cleanObjectSpecificsFunction
+}
+
+// NewCleanupRules digests the given rules.
+// Multiple rules for the same GroupKind are combined.
+// The returned error reports all the invalid JSONPath expressions.
+func NewCleanupRules(rules []CleanupRule) (*CleanupRules, error) {
+	ans := &CleanupRules{
+		gkToQueries: map[schema.GroupKind][]jsonpath.Query{},
+		gkToFunc: map[schema.GroupKind]cleanObjectSpecificsFunction{
+			{Kind: "Service"}: cleanService,
+		},
+	}
+	var errs []error
+	for ruleIdx, rule := range rules {
+		gk := schema.GroupKind{Group: rule.Group, Kind: rule.Kind}
+		if rule.Kind == "" {
+			errs = append(errs, fmt.Errorf("rule %d has no kind", ruleIdx))
+			continue
+		}
+		for removeIdx, queryS := range rule.Remove {
+			query, err := jsonpath.ParseQuery(queryS)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("error in remove[%d] of rule %d (for %s): %w", removeIdx, ruleIdx, gk, err))
+				continue
+			} else if len(query) == 0 {
+				errs = append(errs, fmt.Errorf("remove[%d] of rule %d (for %s) identifies the whole object", removeIdx, ruleIdx, gk))
+				continue
+			}
+			ans.gkToQueries[gk] = append(ans.gkToQueries[gk], query)
+		}
+	}
+	return ans, errors.Join(errs...)
+}
+
+// CleanObjectSpecifics removes from the given object the parts
+// that the rules for its GroupKind identify.
+func (rules *CleanupRules) CleanObjectSpecifics(object *unstructured.Unstructured) {
+	gk := object.GroupVersionKind().GroupKind()
+	if queries := rules.gkToQueries[gk]; len(queries) > 0 {
+		var objectDataAny any = object.UnstructuredContent()
+		rootNode := jsonpath.RootNode{Value: &objectDataAny}
+		for _, query := range queries {
+			jsonpath.QueryValue(query, &rootNode, jsonpath.Node.Remove)
+		}
+		object.SetUnstructuredContent(objectDataAny.(map[string]any))
+	}
+	if cleanFunc, found := rules.gkToFunc[gk]; found {
+		cleanFunc(object)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filtering
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestDefaultCleanupRules(t *testing.T) {
+	rules, err := NewCleanupRules(DefaultCleanupRules())
+	if err != nil {
+		t.Fatalf("Invalid default rules: %s", err)
+	}
+	for _, testCase := range []struct {
+		name     string
+		object   map[string]any
+		expected map[string]any
+	}{
+		{name: "job",
+			object: map[string]any{"apiVersion": "batch/v1", "kind": "Job",
+				"metadata": map[string]any{"labels": map[string]any{"controller-uid": "x", "app": "a"}},
+				"spec": map[string]any{"selector": map[string]any{}, "suspend": false, "template": map[string]any{
+					"metadata": map[string]any{"labels": map[string]any{"batch.kubernetes.io/controller-uid": "x"}}}}},
+			expected: map[string]any{"apiVersion": "batch/v1", "kind": "Job",
+				"metadata": map[string]any{"labels": map[string]any{"app": "a"}},
+				"spec": map[string]any{"template": map[string]any{
+					"metadata": map[string]any{"labels": map[string]any{}}}}},
+		},
+		{name: "pvc-any-version",
+			object: map[string]any{"apiVersion": "v2", "kind": "PersistentVolumeClaim",
+				"metadata": map[string]any{"annotations": map[string]any{"pv.kubernetes.io/bind-completed": "yes"}},
+				"spec":     map[string]any{"volumeName": "pv-1", "storageClassName": "fast"}},
+			expected: map[string]any{"apiVersion": "v2", "kind": "PersistentVolumeClaim",
+				"metadata": map[string]any{"annotations": map[string]any{}},
+				"spec":     map[string]any{"storageClassName": "fast"}},
+		},
+		{name: "route-generated-host",
+			object: map[string]any{"apiVersion": "route.openshift.io/v1", "kind": "Route",
+				"metadata": map[string]any{"annotations": map[string]any{"openshift.io/host.generated": "true"}},
+				"spec":     map[string]any{"host": "r.apps.example.com", "to": map[string]any{"name": "s"}}},
+			expected: map[string]any{"apiVersion": "route.openshift.io/v1", "kind": "Route",
+				"metadata": map[string]any{"annotations": map[string]any{}},
+				"spec":     map[string]any{"to": map[string]any{"name": "s"}}},
+		},
+		{name: "route-given-host",
+			object: map[string]any{"apiVersion": "route.openshift.io/v1", "kind": "Route",
+				"spec": map[string]any{"host": "r.example.com"}},
+			expected: map[string]any{"apiVersion": "route.openshift.io/v1", "kind": "Route",
+				"spec": map[string]any{"host": "r.example.com"}},
+		},
+		{name: "service",
+			object: map[string]any{"apiVersion": "v1", "kind": "Service",
+				"spec": map[string]any{"clusterIP": "None", "ipFamilies": []any{"IPv4"}}},
+			expected: map[string]any{"apiVersion": "v1", "kind": "Service",
+				"spec": map[string]any{"clusterIP": "None"}},
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			object := &unstructured.Unstructured{Object: testCase.object}
+			rules.CleanObjectSpecifics(object)
+			if !apiequality.Semantic.DeepEqual(testCase.expected, object.Object) {
+				t.Errorf("Expected %#v, got %#v", testCase.expected, object.Object)
+			}
+		})
+	}
+}
+
+func TestLoadCleanupRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	content := `
+- group: example.com
+  kind: Widget
+  remove:
+  - $.spec.assignedNode
+- kind: ConfigMap
+  remove:
+  - $.data[
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	moreRules, err := LoadCleanupRules(path)
+	if err != nil {
+		t.Fatalf("Failed to load rules: %s", err)
+	}
+	if len(moreRules) != 2 {
+		t.Fatalf("Expected 2 rules, got %#v", moreRules)
+	}
+	if _, err := NewCleanupRules(moreRules); err == nil {
+		t.Errorf("Expected an error for the invalid JSONPath")
+	}
+	rules, err := NewCleanupRules(moreRules[:1])
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	object := &unstructured.Unstructured{Object: map[string]any{"apiVersion": "example.com/v1beta1", "kind": "Widget",
+		"spec": map[string]any{"assignedNode": "n1", "size": int64(3)}}}
+	rules.CleanObjectSpecifics(object)
+	if expected := map[string]any{"size": int64(3)}; !apiequality.Semantic.DeepEqual(expected, object.Object["spec"]) {
+		t.Errorf("Expected spec %#v, got %#v", expected, object.Object["spec"])
+	}
+}
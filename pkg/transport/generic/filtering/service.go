@@ -27,15 +27,9 @@ const (
 	preserveNodePortValue   = "nodeport"
 )
 
+// cleanService does the cleaning of Service objects that is not expressed
+// in the default cleanup rules.
 func cleanService(object *unstructured.Unstructured) {
-	// Fields to remove
-	fieldsToDelete := []string{"ipFamilies",
-		"externalTrafficPolicy", "internalTrafficPolicy", "ipFamilyPolicy", "sessionAffinity"}
-
-	for _, field := range fieldsToDelete {
-		unstructured.RemoveNestedField(object.Object, "spec", field)
-	}
-
 	// Keep headless Services headless, remove cluster IPs from others.
 	if val, have, _ := unstructured.NestedString(object.Object, "spec", "clusterIP"); have && val != "None" {
 		unstructured.RemoveNestedField(object.Object, "spec", "clusterIP")
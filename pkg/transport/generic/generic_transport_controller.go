@@ -18,8 +18,10 @@ package transport
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"go/token"
+	"maps"
 	"slices"
 	"sync"
 	"time"
@@ -32,6 +34,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -49,15 +52,16 @@ import (
 	"k8s.io/client-go/util/workqueue"
 	k8smetrics "k8s.io/component-base/metrics"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
 
 	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
 	"github.com/kubestellar/kubestellar/pkg/abstract"
+	"github.com/kubestellar/kubestellar/pkg/celeval"
 	"github.com/kubestellar/kubestellar/pkg/customize"
 	ksclientset "github.com/kubestellar/kubestellar/pkg/generated/clientset/versioned"
 	controlclient "github.com/kubestellar/kubestellar/pkg/generated/clientset/versioned/typed/control/v1alpha1"
 	controlv1alpha1informers "github.com/kubestellar/kubestellar/pkg/generated/informers/externalversions/control/v1alpha1"
 	controlv1alpha1listers "github.com/kubestellar/kubestellar/pkg/generated/listers/control/v1alpha1"
-	"github.com/kubestellar/kubestellar/pkg/jsonpath"
 	ksmetrics "github.com/kubestellar/kubestellar/pkg/metrics"
 	"github.com/kubestellar/kubestellar/pkg/transport"
 	"github.com/kubestellar/kubestellar/pkg/transport/generic/filtering"
@@ -74,19 +78,20 @@ const (
 	customTransformDomainIndexName = "custom-transform-domain"
 )
 
-// objectsFilter map from gvk to a filter function to clean specific fields from objects before adding them to a wrapped object.
-var objectsFilter = filtering.NewObjectFilteringMap()
+var namespaceGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
 
 // NewTransportController returns a new transport controller.
 // This func is like NewTransportControllerForWrappedObjectGVR but first uses
 // the given transport and transportClientset to discover the GVR of wrapped objects.
 // The given transportDynamicClient is used to access the ITS.
+// The given cleanupRules say what to remove from workload objects of particular kinds.
 func NewTransportController(ctx context.Context,
 	wdsClientMetrics, itsClientMetrics ksmetrics.ClientMetrics,
 	inventoryPreInformer clusterinformers.ManagedClusterInformer,
 	bindingClient controlclient.BindingInterface,
 	bindingInformer controlv1alpha1informers.BindingInformer,
 	customTransformInformer controlv1alpha1informers.CustomTransformInformer,
+	combinedStatusInformer controlv1alpha1informers.CombinedStatusInformer,
 	transportInstance transport.Transport,
 	wdsClientset ksclientset.Interface,
 	wdsDynamicClient dynamic.Interface,
@@ -94,37 +99,51 @@ func NewTransportController(ctx context.Context,
 	propCfgMapPreInformer corev1informers.ConfigMapInformer,
 	transportClientset kubernetes.Interface,
 	transportDynamicClient dynamic.Interface,
+	cleanupRules *filtering.CleanupRules,
 	maxSizeWrapped int, maxNumWrapped int, wdsName string) (*genericTransportController, error) {
 	emptyWrappedObject := transportInstance.WrapObjects(make([]transport.Wrapee, 0), nil) // empty wrapped object to get GVR from it.
 	wrappedObjectGVR, err := getGvrFromWrappedObject(transportClientset, emptyWrappedObject)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get wrapped object GVR - %w", err)
 	}
-	return NewTransportControllerForWrappedObjectGVR(ctx, wdsClientMetrics, itsClientMetrics, inventoryPreInformer, bindingClient, bindingInformer, customTransformInformer, transportInstance, wdsClientset, wdsDynamicClient, itsNSClient, propCfgMapPreInformer, transportDynamicClient, maxSizeWrapped, maxNumWrapped, wdsName, wrappedObjectGVR), nil
+	return NewTransportControllerForWrappedObjectGVR(ctx, wdsClientMetrics, itsClientMetrics, inventoryPreInformer, bindingClient, bindingInformer, customTransformInformer, combinedStatusInformer, transportInstance, wdsClientset, wdsDynamicClient, itsNSClient, propCfgMapPreInformer, transportDynamicClient, cleanupRules, maxSizeWrapped, maxNumWrapped, wdsName, wrappedObjectGVR)
 }
 
 // NewTransportControllerForWrappedObjectGVR returns a new transport controller.
 // The given transportDynamicClient is used to access the ITS.
+// The given cleanupRules say what to remove from workload objects of particular kinds.
 func NewTransportControllerForWrappedObjectGVR(ctx context.Context,
 	wdsClientMetrics, itsClientMetrics ksmetrics.ClientMetrics,
 	inventoryPreInformer clusterinformers.ManagedClusterInformer,
 	bindingClient controlclient.BindingInterface,
 	bindingInformer controlv1alpha1informers.BindingInformer,
 	customTransformInformer controlv1alpha1informers.CustomTransformInformer,
+	combinedStatusInformer controlv1alpha1informers.CombinedStatusInformer,
 	transportInstance transport.Transport,
 	wdsClientset ksclientset.Interface,
 	wdsDynamicClient dynamic.Interface,
 	itsNSClient corev1client.NamespaceInterface,
 	propCfgMapPreInformer corev1informers.ConfigMapInformer,
 	transportDynamicClient dynamic.Interface,
+	cleanupRules *filtering.CleanupRules,
 	maxSizeWrapped int,
 	maxNumWrapped int,
-	wdsName string, wrappedObjectGVR schema.GroupVersionResource) *genericTransportController {
+	wdsName string, wrappedObjectGVR schema.GroupVersionResource) (*genericTransportController, error) {
+	rolloutGateEvaluator, err := NewRolloutGateEvaluator()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL evaluator for rollout gates: %w", err)
+	}
+	computeEvaluator, err := newComputeEvaluator()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL evaluator for CustomTransform computations: %w", err)
+	}
 	measuredBindingClient := ksmetrics.NewWrappedClusterScopedClient[*v1alpha1.Binding, *v1alpha1.BindingList](wdsClientMetrics, util.GetBindingGVR(), bindingClient)
 	measuredWDSDynamicClient := ksmetrics.NewWrappedDynamicClient(wdsClientMetrics, wdsDynamicClient)
 	measuredITSDynamicClient := ksmetrics.NewWrappedDynamicClient(itsClientMetrics, transportDynamicClient)
 	dynamicInformerFactory := dynamicinformer.NewDynamicSharedInformerFactory(measuredITSDynamicClient, 0)
 	wrappedObjectGenericInformer := dynamicInformerFactory.ForResource(wrappedObjectGVR)
+	wdsDynamicInformerFactory := dynamicinformer.NewDynamicSharedInformerFactory(measuredWDSDynamicClient, 0)
+	namespaceGenericInformer := wdsDynamicInformerFactory.ForResource(namespaceGVR)
 	customTransformInformer.Informer().AddIndexers(map[string]cache.IndexFunc{customTransformDomainIndexName: customTransformToDomain})
 	customTransformsClient := wdsClientset.ControlV1alpha1().CustomTransforms()
 	measuredCustomTransformClient := ksmetrics.NewWrappedClusterScopedClient[*v1alpha1.CustomTransform, *v1alpha1.CustomTransformList](wdsClientMetrics, v1alpha1.GroupVersion.WithResource("customtransforms"), customTransformsClient)
@@ -142,8 +161,15 @@ func NewTransportControllerForWrappedObjectGVR(ctx context.Context,
 		propCfgMapLister:              propCfgMapPreInformer.Lister().ConfigMaps(v1alpha1.PropertyConfigMapNamespace),
 		propCfgMapInformerSynced:      propCfgMapPreInformer.Informer().HasSynced,
 		wrappedObjectInformerSynced:   wrappedObjectGenericInformer.Informer().HasSynced,
+		wrappedObjectLister:           wrappedObjectGenericInformer.Lister(),
+		namespaceInformerSynced:       namespaceGenericInformer.Informer().HasSynced,
+		namespaceLister:               namespaceGenericInformer.Lister(),
 		customTransformLister:         customTransformInformer.Lister(),
 		customTransformInformerSynced: customTransformInformer.Informer().HasSynced,
+		combinedStatusLister:          combinedStatusInformer.Lister(),
+		combinedStatusInformerSynced:  combinedStatusInformer.Informer().HasSynced,
+		rolloutGateEvaluator:          rolloutGateEvaluator,
+		clock:                         clock.RealClock{},
 		wecSampler: ksmetrics.NewListLenSampler(inventoryPreInformer.Informer().GetStore().List,
 			&k8smetrics.KubeOpts{Namespace: "kubestellar", Subsystem: "transport_controller",
 				Name: "wecs", Help: "number of inventory objects", StabilityLevel: k8smetrics.ALPHA}),
@@ -182,9 +208,13 @@ func NewTransportControllerForWrappedObjectGVR(ctx context.Context,
 		MaxSizeWrapped:               maxSizeWrapped,
 		MaxNumWrapped:                maxNumWrapped,
 		wdsName:                      wdsName,
+		cleanupRules:                 cleanupRules,
 		bindingSensitiveDestinations: make(map[string]sets.Set[v1alpha1.Destination]),
 		destinationProperties:        make(map[v1alpha1.Destination]clusterProperties),
-		customTransformCollection: newCustomTransformCollection(measuredCustomTransformClient,
+		destinationLabels:            make(map[v1alpha1.Destination]labels.Set),
+		destinationWindows:           make(map[v1alpha1.Destination]string),
+		bindingNamespaces:            make(map[string]sets.Set[string]),
+		customTransformCollection: newCustomTransformCollection(measuredCustomTransformClient, computeEvaluator,
 			customTransformInformer.Informer().GetIndexer().ByIndex,
 			workqueue.Add),
 	}
@@ -221,6 +251,18 @@ func NewTransportControllerForWrappedObjectGVR(ctx context.Context,
 		},
 	})
 
+	// CombinedStatus objects matter only to the gates of rollouts.
+	combinedStatusInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { transportController.handleCombinedStatus(obj, "add") },
+		UpdateFunc: func(_, obj any) { transportController.handleCombinedStatus(obj, "update") },
+		DeleteFunc: func(obj any) {
+			if deletedStateUnknown, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = deletedStateUnknown.Obj
+			}
+			transportController.handleCombinedStatus(obj, "delete")
+		},
+	})
+
 	// Set up event handlers for when WrappedObject resources change. The handlers will lookup the origin Binding
 	// of the given WrappedObject and enqueue that Binding object for processing.
 	// This way, we don't need to implement custom logic for handling WrappedObject resources. More info on this pattern:
@@ -273,9 +315,25 @@ func NewTransportControllerForWrappedObjectGVR(ctx context.Context,
 			transportController.propMapSampler.Prod()
 		},
 	})
+	// The labels of namespaces matter only to the namespaceSelectors of CustomTransforms.
+	namespaceGenericInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj any) { transportController.handleNamespace(obj, "add") },
+		UpdateFunc: func(old, new any) {
+			if !maps.Equal(old.(metav1.Object).GetLabels(), new.(metav1.Object).GetLabels()) {
+				transportController.handleNamespace(new, "update")
+			}
+		},
+		DeleteFunc: func(obj any) {
+			if dfsu, is := obj.(cache.DeletedFinalStateUnknown); is {
+				obj = dfsu.Obj
+			}
+			transportController.handleNamespace(obj, "delete")
+		},
+	})
 	dynamicInformerFactory.Start(ctx.Done())
+	wdsDynamicInformerFactory.Start(ctx.Done())
 
-	return transportController
+	return transportController, nil
 }
 
 func (c *genericTransportController) RegisterMetrics(reg ksmetrics.RegisterFn) {
@@ -287,6 +345,21 @@ func (c *genericTransportController) RegisterMetrics(reg ksmetrics.RegisterFn) {
 	)
 }
 
+// EnableUpsync makes the controller convey the upsync clauses of each Binding to the
+// status agents of its destinations (see v1alpha1.UpsyncClausesAnnotationKey).
+// Without this, upsync clauses have no effect. It must be called before Run.
+func (c *genericTransportController) EnableUpsync() {
+	c.upsyncEnabled = true
+}
+
+// EnableDriftDetection makes the controller convey the desired states of the workload objects
+// whose drift is checked (see v1alpha1.DesiredStateAnnotationKey) and reapply the drifted ones
+// whose drift policy says so. Without this, drift policies have no effect.
+// It must be called before Run.
+func (c *genericTransportController) EnableDriftDetection() {
+	c.driftDetectionEnabled = true
+}
+
 func convertObjectToUnstructured(object runtime.Object) (*unstructured.Unstructured, error) {
 	unstructuredObject, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
 	if err != nil {
@@ -328,9 +401,14 @@ type genericTransportController struct {
 	propCfgMapLister            corev1listers.ConfigMapNamespaceLister
 	propCfgMapInformerSynced    cache.InformerSynced
 	wrappedObjectInformerSynced cache.InformerSynced
+	wrappedObjectLister         cache.GenericLister
+	namespaceInformerSynced     cache.InformerSynced
+	namespaceLister             cache.GenericLister // for Namespaces in the WDS
 
 	customTransformLister                                                        controlv1alpha1listers.CustomTransformLister
 	customTransformInformerSynced                                                cache.InformerSynced
+	combinedStatusLister                                                         controlv1alpha1listers.CombinedStatusLister
+	combinedStatusInformerSynced                                                 cache.InformerSynced
 	wecSampler, bindingSampler, transformSampler, propMapSampler, wrappedSampler ksmetrics.Sampler
 	bindingWhatsHist, bindingWheresHist, bindingAreaHist                         *k8smetrics.Histogram
 
@@ -353,6 +431,20 @@ type genericTransportController struct {
 
 	customTransformCollection customTransformCollection
 
+	// cleanupRules say what to remove from workload objects of particular kinds.
+	cleanupRules *filtering.CleanupRules
+
+	// upsyncEnabled is set by EnableUpsync.
+	upsyncEnabled bool
+	// driftDetectionEnabled is set by EnableDriftDetection.
+	driftDetectionEnabled bool
+
+	// rolloutGateEvaluator evaluates the gates of rollouts.
+	rolloutGateEvaluator *celeval.Evaluator
+
+	// clock tells the time for propagation windows.
+	clock clock.PassiveClock
+
 	propsMutex sync.Mutex
 
 	// bindingSensitiveDestinations maps Binding name to the set of destinations whose properties the Binding is senstive to.
@@ -366,6 +458,24 @@ type genericTransportController struct {
 	// deletion of the destination's property ConfigMap.
 	// Every `clusterProperties` that appears here is immutable from the time that it arrived.
 	destinationProperties map[v1alpha1.Destination]clusterProperties
+
+	// destinationLabels maps a destination to the labels of its inventory object,
+	// for use in selecting overrides.
+	// Access and maintenance are like for destinationProperties.
+	// Every `labels.Set` that appears here is immutable from the time that it arrived.
+	destinationLabels map[v1alpha1.Destination]labels.Set
+
+	// destinationWindows maps a destination to the value of the PropagationWindowsAnnotationKey
+	// annotation of its inventory object (empty string if none).
+	// Access and maintenance are like for destinationProperties.
+	destinationWindows map[v1alpha1.Destination]string
+
+	namespacesMutex sync.Mutex
+
+	// bindingNamespaces maps Binding name to the set of WDS namespaces whose labels
+	// were consulted for the namespaceSelectors of CustomTransforms in the last processing of the Binding.
+	// Access only while holding namespacesMutex. No set here is empty.
+	bindingNamespaces map[string]sets.Set[string]
 }
 
 // enqueueBinding takes an Binding resource and
@@ -404,6 +514,31 @@ func (c *genericTransportController) handleWrappedObject(obj interface{}, event
 	c.workqueue.Add(ownerBindingKey)
 }
 
+// handleNamespace enqueues the Bindings whose last processing consulted the labels of the given WDS namespace.
+func (c *genericTransportController) handleNamespace(obj any, event string) {
+	namespace := obj.(metav1.Object).GetName()
+	c.namespacesMutex.Lock()
+	defer c.namespacesMutex.Unlock()
+	for bindingName, namespaces := range c.bindingNamespaces {
+		if namespaces.Has(namespace) {
+			c.logger.V(5).Info("Enqueuing reference to Binding due to informer event about namespace", "bindingName", bindingName, "namespace", namespace, "event", event)
+			c.workqueue.Add(bindingName)
+		}
+	}
+}
+
+// setBindingNamespaces records the set of WDS namespaces whose labels were consulted
+// in processing the named Binding.
+func (c *genericTransportController) setBindingNamespaces(bindingName string, namespaces sets.Set[string]) {
+	c.namespacesMutex.Lock()
+	defer c.namespacesMutex.Unlock()
+	if namespaces.Len() == 0 {
+		delete(c.bindingNamespaces, bindingName)
+	} else {
+		c.bindingNamespaces[bindingName] = namespaces
+	}
+}
+
 // Run will set up the event handlers for types we are interested in, as well
 // as syncing informer caches and starting workers. It will block until context
 // is cancelled, at which point it will shutdown the workqueue and wait for
@@ -418,7 +553,7 @@ func (c *genericTransportController) Run(ctx context.Context, workersCount int)
 	// Wait for the caches to be synced before starting workers
 	c.logger.Info("waiting for informer caches to sync")
 
-	if ok := cache.WaitForCacheSync(ctx.Done(), c.inventoryInformerSynced, c.bindingInformerSynced, c.wrappedObjectInformerSynced, c.propCfgMapInformerSynced, c.customTransformInformerSynced); !ok {
+	if ok := cache.WaitForCacheSync(ctx.Done(), c.inventoryInformerSynced, c.bindingInformerSynced, c.wrappedObjectInformerSynced, c.namespaceInformerSynced, c.propCfgMapInformerSynced, c.customTransformInformerSynced, c.combinedStatusInformerSynced); !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
@@ -558,18 +693,31 @@ func (c *genericTransportController) process(ctx context.Context, obj interface{
 func (c *genericTransportController) syncProperties(ctx context.Context, invName string) {
 	logger := klog.FromContext(ctx)
 	newProps := c.collectPropertiesForDestination(logger, invName)
+	newLabels := c.collectLabelsForDestination(logger, invName)
+	newWindows := c.collectWindowsAnnotationForDestination(invName)
 	c.propsMutex.Lock()
 	defer c.propsMutex.Unlock()
 	dest := v1alpha1.Destination{ClusterId: invName}
-	oldProps, have := c.destinationProperties[dest]
-	if !have { // not cached, nobody cares
-		return
-	}
-	if abstract.PrimitiveMapEqual(oldProps, newProps) {
+	changed := false
+	// An entry that is not cached is one that nobody cares about
+	if oldProps, have := c.destinationProperties[dest]; have && !abstract.PrimitiveMapEqual(oldProps, newProps) {
+		c.logger.V(5).Info("syncProperties", "dest", dest, "props", newProps)
+		c.destinationProperties[dest] = newProps
+		changed = true
+	}
+	if oldLabels, have := c.destinationLabels[dest]; have && !abstract.PrimitiveMapEqual(oldLabels, newLabels) {
+		c.logger.V(5).Info("syncProperties", "dest", dest, "labels", newLabels)
+		c.destinationLabels[dest] = newLabels
+		changed = true
+	}
+	if oldWindows, have := c.destinationWindows[dest]; have && oldWindows != newWindows {
+		c.logger.V(5).Info("syncProperties", "dest", dest, "windows", newWindows)
+		c.destinationWindows[dest] = newWindows
+		changed = true
+	}
+	if !changed {
 		return
 	}
-	c.logger.V(5).Info("syncProperties", "dest", dest, "props", newProps)
-	c.destinationProperties[dest] = newProps
 	for bindingName, dests := range c.bindingSensitiveDestinations {
 		if dests.Has(dest) {
 			c.logger.V(5).Info("Enqueuing reference to Binding that depends on changed destination properties", "binding", bindingName, "destination", dest)
@@ -632,6 +780,8 @@ func (c *genericTransportController) deleteWrappedObjectsAndFinalizer(ctx contex
 		return fmt.Errorf("failed to get current wrapped objects that are owned by Binding '%s' - %w", binding.GetName(), err)
 	}
 	c.customTransformCollection.setBindingGroupResources(binding.Name, sets.New[metav1.GroupResource]())
+	c.customTransformCollection.setComputeErrors(ctx, binding.Name, nil)
+	c.setBindingNamespaces(binding.Name, nil)
 	for _, destination := range binding.Spec.Destinations {
 		for {
 			currentWrappedObject := c.popWrappedObjectByNamespace(currentWrappedObjectList, destination.ClusterId)
@@ -689,43 +839,78 @@ func (c *genericTransportController) updateWrappedObjectsAndFinalizer(ctx contex
 	if err != nil {
 		return fmt.Errorf("failed to get current wrapped objects that are owned by Binding '%s' - %w", binding.GetName(), err)
 	}
+	// what the ITS has before any changes here, for reporting on delivery
+	observedWrappedObjects := slices.Clone(currentWrappedObjectList.Items)
 	// calculate desired state
-	destToDesiredWrappedObjects, kindToResource, bindingErrors, groupResources, err := c.computeDestToWrappedObjects(ctx, binding)
+	freeze := &deliveryFreeze{}
+	destToDesiredWrappedObjects, kindToResource, bindingErrors, groupResources, err := c.computeDestToWrappedObjects(ctx, binding, freeze)
 	if err != nil {
 		return fmt.Errorf("failed to build wrapped object(s) from Binding '%s' - %w", binding.GetName(), err)
 	}
-	if binding.Status.ObservedGeneration != binding.Generation || !slices.Equal(binding.Status.Errors, bindingErrors) {
+	// Errors in the propagation windows or the rollout freeze all the destinations, so that nothing is
+	// changed at a time or in an order that the user did not want.
+	hold, windowErrors := c.newPropagationHold(binding)
+	freeze.freezeAll(windowErrors...)
+	waves, rolloutErrors := c.computeRolloutWaves(binding)
+	freeze.freezeAll(rolloutErrors...)
+	statusErrors := append(slices.Clone(bindingErrors), freeze.errors...)
+	if binding.Status.ObservedGeneration != binding.Generation || !slices.Equal(binding.Status.Errors, statusErrors) {
 		bindingCopy := binding.DeepCopy()
-		bindingCopy.Status = v1alpha1.BindingStatus{
-			ObservedGeneration: binding.Generation,
-			Errors:             bindingErrors,
-		}
+		bindingCopy.Status.ObservedGeneration = binding.Generation
+		bindingCopy.Status.Errors = statusErrors
 		binding2, err := c.bindingClient.UpdateStatus(ctx, bindingCopy, metav1.UpdateOptions{FieldManager: ControllerName})
 		if err != nil {
 			return fmt.Errorf("failed to update status of Binding '%s' - %w", binding.Name, err)
 		} else {
 			klog.FromContext(ctx).V(2).Info("Updated Binding.Status", "bindingName", binding.Name, "resourceVersion", binding2.ResourceVersion)
+			binding = binding2
 		}
 	}
 	c.customTransformCollection.setBindingGroupResources(binding.Name, groupResources)
+	holds := func(dest v1alpha1.Destination) bool { return hold != nil && hold.holds(dest) }
 	// converge actual state to the desired state
-	if len(bindingErrors) == 0 {
-		if err := c.propagateWrappedObjectToClusters(ctx, destToDesiredWrappedObjects, kindToResource, currentWrappedObjectList, binding.Spec.Destinations); err != nil {
+	var held []v1alpha1.Destination
+	// whether any wrapped object gets created, updated, or deleted here
+	wrote := false
+	if freeze.all {
+		klog.FromContext(ctx).Info("Leaving all wrapped objects in ITS as they are because of errors in Binding", "binding", binding.Name)
+		currentWrappedObjectList.Items = nil
+	} else if len(bindingErrors) == 0 {
+		gatePasses := func(wave []v1alpha1.Destination) bool { return c.rolloutGatePasses(ctx, binding, wave) }
+		held, wrote, err = c.propagateWrappedObjectToClusters(ctx, destToDesiredWrappedObjects, kindToResource, currentWrappedObjectList, waves, gatePasses, freeze.frozen, holds)
+		if err != nil {
 			return fmt.Errorf("failed to propagate wrapped object(s) for binding '%s' to all required WECs - %w", binding.GetName(), err)
 		}
 	} else {
 		klog.FromContext(ctx).Info("Deleting all wrapped objects in ITS because of errors in Binding", "binding", binding.Name)
 	}
-	// all objects that appear in the desired state were handled. need to remove wrapped objects that are not part of the desired state
+	// all objects that appear in the desired state were handled. need to remove wrapped objects that are not part of the desired state,
+	// except in frozen destinations and in destinations outside their propagation windows.
 	if len(currentWrappedObjectList.Items) > 0 {
 		klog.FromContext(ctx).V(4).Info("Removing unmatched wrapped objects", "binding", binding.Name, "count", len(currentWrappedObjectList.Items))
 		for _, wrappedObject := range currentWrappedObjectList.Items { // objects left in currentWrappedObjectList.Items have to be deleted
+			dest := v1alpha1.Destination{ClusterId: wrappedObject.GetNamespace()}
+			if freeze.frozen(dest) {
+				continue
+			}
+			if holds(dest) {
+				if !slices.Contains(held, dest) {
+					held = append(held, dest)
+				}
+				continue
+			}
 			if err := c.deleteWrappedObject(ctx, wrappedObject.GetNamespace(), wrappedObject.GetName()); err != nil {
 				return fmt.Errorf("failed to delete wrapped object from destinations that were removed from desired state - %w", err)
 			}
+			wrote = true
 		}
 	}
-	return nil
+	if len(held) > 0 && !hold.nextOpen.IsZero() {
+		klog.FromContext(ctx).V(4).Info("Holding changes until a propagation window opens", "binding", binding.Name, "held", held, "nextOpen", hold.nextOpen)
+		c.workqueue.AddAfter(binding.Name, hold.nextOpen.Sub(c.clock.Now()))
+	}
+	// report on delivery; changes to the wrapped objects will bring us back here
+	return c.updateDeliveryStatus(ctx, binding, held, freeze, observedWrappedObjects, wrote)
 }
 
 // getWrapeesFromWDS returns a slice of Wrapee holding the objects that have been subject to destination-independent transformations
@@ -734,13 +919,43 @@ func (c *genericTransportController) getWrapeesFromWDS(ctx context.Context, bind
 	groupResources := sets.New[metav1.GroupResource]()
 	wrapees := make([]WrapeeWithUID, 0)
 	kindToResource := map[schema.GroupKind]string{}
-	appendObj := func(gvr metav1.GroupVersionResource, object *unstructured.Unstructured, createOnly bool) {
+	namespaces := sets.New[string]()
+	defer c.setBindingNamespaces(binding.Name, namespaces)
+	getNamespaceLabels := func(namespace string) (labels.Set, error) {
+		namespaces.Insert(namespace)
+		nsObj, err := c.namespaceLister.Get(namespace)
+		if errors.IsNotFound(err) {
+			// A namespace not seen yet has no labels here; its arrival brings the Binding back.
+			return labels.Set{}, nil
+		} else if err != nil { // listers do not fail
+			return nil, fmt.Errorf("failed to get namespace %q from WDS informer cache - %w", namespace, err)
+		}
+		return labels.Set(nsObj.(metav1.Object).GetLabels()), nil
+	}
+	appendObj := func(gvr metav1.GroupVersionResource, object *unstructured.Unstructured, modulation v1alpha1.DownsyncModulation) error {
 		gr := metav1.GroupResource{Group: gvr.Group, Resource: gvr.Resource}
 		groupResources.Insert(gr)
 		kindToResource[object.GroupVersionKind().GroupKind()] = gvr.Resource
+		transformed, destChanges, err := transformObject(ctx, c.customTransformCollection, c.cleanupRules, gr, object, getNamespaceLabels, binding.Name)
+		if err != nil {
+			return err
+		}
+		if !c.driftDetectionEnabled {
+			modulation.DriftPolicy = ""
+		}
+		if modulation.DriftPolicy == v1alpha1.DriftPolicyReapply {
+			if marker := reapplyMarker(binding, gvr, object.GetNamespace(), object.GetName()); marker != "" {
+				setAnnotation(transformed, v1alpha1.ReapplyAnnotationKey, marker)
+			}
+		}
 		wrapees = append(wrapees, WrapeeWithUID{
-			transport.NewWrapee(TransformObject(ctx, c.customTransformCollection, gr, object, binding.Name), createOnly),
-			string(object.GetUID())})
+			Wrapee:       transport.NewWrapee(transformed, modulation.CreateOnly, modulation.DeletionPolicy),
+			UID:          string(object.GetUID()),
+			Resource:     gvr.Resource,
+			ReplicaSplit: modulation.ReplicaSplit,
+			DriftPolicy:  modulation.DriftPolicy,
+			destChanges:  destChanges})
+		return nil
 	}
 	// add cluster-scoped objects to the 'objectsToPropagate' slice
 	for _, clause := range binding.Spec.Workload.ClusterScope {
@@ -749,7 +964,9 @@ func (c *genericTransportController) getWrapeesFromWDS(ctx context.Context, bind
 		if err != nil {
 			return nil, nil, groupResources, fmt.Errorf("failed to get required cluster-scoped object '%s' with gvr %s from WDS - %w", clause.Name, gvr, err)
 		}
-		appendObj(clause.GroupVersionResource, object, clause.CreateOnly)
+		if err := appendObj(clause.GroupVersionResource, object, clause.DownsyncModulation); err != nil {
+			return nil, nil, groupResources, err
+		}
 	}
 	// add namespace-scoped objects to the 'objectsToPropagate' slice
 	for _, clause := range binding.Spec.Workload.NamespaceScope {
@@ -759,7 +976,9 @@ func (c *genericTransportController) getWrapeesFromWDS(ctx context.Context, bind
 			return nil, nil, groupResources, fmt.Errorf("failed to get required namespace-scoped object '%s' in namespace '%s' with gvr '%s' from WDS - %w", clause.Name,
 				clause.Namespace, gvr, err)
 		}
-		appendObj(clause.GroupVersionResource, object, clause.CreateOnly)
+		if err := appendObj(clause.GroupVersionResource, object, clause.DownsyncModulation); err != nil {
+			return nil, nil, groupResources, err
+		}
 	}
 
 	return wrapees, abstract.PrimitiveMapGet(kindToResource), groupResources, nil
@@ -770,10 +989,12 @@ func (c *genericTransportController) getWrapeesFromWDS(ctx context.Context, bind
 //     for that destination. This func also returns a `bool` that is false when
 //     the function has no answer for the given destination.
 //   - the function that maps every GroupKind appearing in the workload objects to the corresponding "resource".
-//   - the slice of strings describing user errors in the Binding.
+//   - the slice of strings describing user errors in the Binding that call for deleting all the wrapped objects.
 //   - the set of GroupResource that appear among the workload objects.
 //   - an error if something transient went wrong.
-func (c *genericTransportController) computeDestToWrappedObjects(ctx context.Context, binding *v1alpha1.Binding) (
+//
+// User errors that call for leaving wrapped objects as they are go in the given freeze.
+func (c *genericTransportController) computeDestToWrappedObjects(ctx context.Context, binding *v1alpha1.Binding, freeze *deliveryFreeze) (
 	func(v1alpha1.Destination) ([]transportTask, bool), func(schema.GroupKind) (string, bool), []string, sets.Set[metav1.GroupResource], error) {
 	wrapeesToPropagate, kindToResource, grs, err := c.getWrapeesFromWDS(ctx, binding)
 	if err != nil {
@@ -781,10 +1002,11 @@ func (c *genericTransportController) computeDestToWrappedObjects(ctx context.Con
 	}
 
 	if len(wrapeesToPropagate) == 0 {
+		c.customTransformCollection.setComputeErrors(ctx, binding.Name, nil)
 		return nil, nil, nil, grs, nil // if no objects were found in the workload section, return nil so that we don't distribute an empty wrapped object.
 	}
 
-	destToCustomizedObjects, bindingErrors := c.computeDestToCustomizedObjects(wrapeesToPropagate, binding)
+	destToCustomizedObjects, bindingErrors := c.computeDestToCustomizedObjects(ctx, wrapeesToPropagate, binding, freeze)
 	// This will be constant if no object needed customization, otherwise a map's get func
 	var destToTasks func(v1alpha1.Destination) ([]transportTask, bool)
 
@@ -812,15 +1034,26 @@ func (c *genericTransportController) computeDestToWrappedObjects(ctx context.Con
 // computeDestToCustomizedObjects returns the following two things.
 //   - a map from destination to slice of customized workload objects.
 //     This map will be nil if customization is not needed for the given slice of objects.
-//   - the slice of strings containing the user errors found in the given Binding.
+//   - the slice of strings containing the user errors found in the given Binding
+//     that call for deleting all the wrapped objects.
 //
+// Customization consists of application of CustomTransforms that depend on the destination,
+// template expansion, splitting of replicas, and application of overrides.
+// User errors in splitting replicas freeze all the destinations, in the given freeze;
+// user errors in applying overrides and failures of CustomTransform computations
+// freeze the destinations where they arise. The latter are also reported in the
+// status of the CustomTransform.
 // This func also updates c.bindingSensitiveDestinations for the given Binding.
 // The input Wrapees have been subject to destination-independent transformation.
-func (c *genericTransportController) computeDestToCustomizedObjects(uncustomizedWrapees []WrapeeWithUID, binding *v1alpha1.Binding) (map[v1alpha1.Destination][]WrapeeWithUID, []string) {
+func (c *genericTransportController) computeDestToCustomizedObjects(ctx context.Context, uncustomizedWrapees []WrapeeWithUID, binding *v1alpha1.Binding, freeze *deliveryFreeze) (map[v1alpha1.Destination][]WrapeeWithUID, []string) {
 	// This will become non-nil if any object to propagate needs customization
 	var destToCustomizedWrapees map[v1alpha1.Destination][]WrapeeWithUID
 
 	bindingErrors := []string{}
+	// whether the outcome depends on destination properties even though no object is customized
+	consultedProperties := false
+	// maps CustomTransform name to the first failures of its computations, for its status
+	ctNameToComputeErrors := map[string][]string{}
 
 	// Look through the objects to propagate to see if any needs customization.
 	// If any needs customization then catch up destToCustomizedObjects and proceed from there.
@@ -831,23 +1064,79 @@ func (c *genericTransportController) computeDestToCustomizedObjects(uncustomized
 		customizeThisObject := false
 		reportedSomeErrors := false
 		objRefStr := util.RefToRuntimeObj(objToPropagate).String()
+		replicaShares, splitErrors := c.computeReplicaShares(binding.Name, objToPropagate, wrapee.ReplicaSplit, binding.Spec.Destinations)
+		// the shares for every destination depend on the weights of all of them
+		freeze.freezeAll(splitErrors...)
+		splitThisObject := replicaShares != nil
+		overrideThisObject := overridesApplyToObject(binding.Spec.Overrides, objToPropagate, wrapee.Resource)
+		reportedOverrideErrors := false
+		transformThisObject := len(wrapee.destChanges) > 0
+		computeThisObject := slices.ContainsFunc(wrapee.destChanges, func(changes selectiveChanges) bool { return len(changes.computes) > 0 })
+		reportedTransformErrors := false
+		consultedProperties = consultedProperties || wrapee.ReplicaSplit != nil && wrapee.ReplicaSplit.WeightProperty != ""
 		for destIdx, dest := range binding.Spec.Destinations {
-			objC := objToPropagate
+			// objD is objToPropagate as transformed for this destination
+			objD := objToPropagate
+			if transformThisObject {
+				var props clusterProperties
+				if computeThisObject {
+					props = c.getPropertiesForDestination(binding.Name, dest)
+				}
+				var transformErrors map[string][]string
+				objD, transformErrors = applyDestinationChanges(objToPropagate, c.getLabelsForDestination(binding.Name, dest), props, wrapee.destChanges)
+				if len(transformErrors) != 0 {
+					// Only this destination is frozen, and only the first troubled destination is reported.
+					var toReport []string
+					for _, ctName := range slices.Sorted(maps.Keys(transformErrors)) {
+						described := abstract.SliceMap(transformErrors[ctName], func(msg string) string {
+							return fmt.Sprintf("%s, for %s going to destination %q", msg, objRefStr, dest.ClusterId)
+						})
+						if _, reported := ctNameToComputeErrors[ctName]; !reported {
+							ctNameToComputeErrors[ctName] = described
+						}
+						if !reportedTransformErrors {
+							toReport = append(toReport, described...)
+						}
+					}
+					reportedTransformErrors = true
+					freeze.freezeDestination(dest, toReport...)
+				}
+			}
+			objC := objD
 			var customizationErrors []string
 			if objRequestsExpansion && (destIdx == 0 || customizeThisObject) {
 				defs := c.getPropertiesForDestination(binding.Name, dest)
 				// customizeThisObject does not vary with destination, for a given objToPropagate
-				objC, customizationErrors, customizeThisObject = c.customizeForDestination(objToPropagate, dest.ClusterId+"/"+objRefStr, defs)
+				objC, customizationErrors, customizeThisObject = c.customizeForDestination(objD, dest.ClusterId+"/"+objRefStr, defs)
 				if len(customizationErrors) != 0 && !reportedSomeErrors {
 					// Let's not overwhelm the user, only report errors from the first troubled destination
 					reportedSomeErrors = true
 					bindingErrors = append(bindingErrors, customizationErrors...)
 				}
 				if !customizeThisObject {
-					objC = objToPropagate
+					objC = objD
 				}
 			}
-			if customizeThisObject && destToCustomizedWrapees == nil {
+			if splitThisObject {
+				if objC == objToPropagate {
+					objC = objToPropagate.DeepCopy()
+				}
+				// cannot fail, computeReplicaShares found an int64 here
+				_ = unstructured.SetNestedField(objC.Object, replicaShares[destIdx], "spec", "replicas")
+			}
+			if overrideThisObject {
+				destLabels := c.getLabelsForDestination(binding.Name, dest)
+				var overrideErrors []string
+				objC, overrideErrors = applyOverrides(objC, wrapee.Resource, dest.ClusterId, destLabels, binding.Spec.Overrides)
+				if len(overrideErrors) != 0 {
+					if reportedOverrideErrors {
+						overrideErrors = nil
+					}
+					reportedOverrideErrors = true
+					freeze.freezeDestination(dest, overrideErrors...)
+				}
+			}
+			if (transformThisObject || customizeThisObject || splitThisObject || overrideThisObject) && destToCustomizedWrapees == nil {
 				destToCustomizedWrapees = map[v1alpha1.Destination][]WrapeeWithUID{}
 				for _, dest := range binding.Spec.Destinations {
 					destToCustomizedWrapees[dest] = slices.Clone(uncustomizedWrapees[:objIdx])
@@ -855,26 +1144,30 @@ func (c *genericTransportController) computeDestToCustomizedObjects(uncustomized
 			}
 			if destToCustomizedWrapees != nil {
 				customizedObjectsSoFar := destToCustomizedWrapees[dest]
-				customizedObjectsSoFar = append(customizedObjectsSoFar, WrapeeWithUID{transport.NewWrapee(objC, wrapee.CreateOnly), wrapee.UID})
+				customizedWrapee := wrapee
+				customizedWrapee.Wrapee = transport.NewWrapee(objC, wrapee.CreateOnly, wrapee.DeletionPolicy)
+				customizedObjectsSoFar = append(customizedObjectsSoFar, customizedWrapee)
 				destToCustomizedWrapees[dest] = customizedObjectsSoFar
 			}
 		}
 	}
 	// update the index in c.bindingSensitiveDestinations
 	var cares sets.Set[v1alpha1.Destination]
-	if destToCustomizedWrapees != nil {
+	if destToCustomizedWrapees != nil || consultedProperties {
 		cares = sets.New(binding.Spec.Destinations...)
 	} else {
 		cares = sets.New[v1alpha1.Destination]()
 	}
 	c.setBindingSensitivities(binding.Name, cares) // forget about now-irrelevant destinations
+	c.customTransformCollection.setComputeErrors(ctx, binding.Name, ctNameToComputeErrors)
 
 	return destToCustomizedWrapees, bindingErrors
 }
 
 // wrapBatch invokes the transport's WrapObjects.
 // uidToPropagate is the UID (in the WDS) of one of the objects in batchToPropagate.
-func (c *genericTransportController) wrapBatch(batchToPropagate []transport.Wrapee, uidToPropagate string, kindToResource func(schema.GroupKind) (string, bool), binding *v1alpha1.Binding, numShard int) (*unstructured.Unstructured, error) {
+// numShard is the index of the batch among those of the given phase.
+func (c *genericTransportController) wrapBatch(batchToPropagate []transport.Wrapee, uidToPropagate string, kindToResource func(schema.GroupKind) (string, bool), binding *v1alpha1.Binding, phase deliveryPhase, numShard int) (*unstructured.Unstructured, error) {
 	wrapped := c.transport.WrapObjects(batchToPropagate, abstract.DropOK11(kindToResource))
 	wrappedObject, err := convertObjectToUnstructured(wrapped)
 	if err != nil {
@@ -889,12 +1182,29 @@ func (c *genericTransportController) wrapBatch(batchToPropagate []transport.Wrap
 		// pay attention - we cannot use the Binding object name, cause we might have duplicate names coming from different WDS spaces.
 		// we add WdsName to the object name to assure name uniqueness,
 		// in order to easily get the origin Binding object name and wds, we add it as an annotations.
-		wrapperName = fmt.Sprintf("%s-%s-%d", binding.GetName(), c.wdsName, numShard)
+		// The shards are numbered within their delivery phase, so that adding or removing objects
+		// of one phase does not rename (and thus delete and recreate at the destinations)
+		// the wrapped objects of the other phases.
+		if phasePart := phase.namePart(); phasePart != "" {
+			wrapperName = fmt.Sprintf("%s-%s-%s-%d", binding.GetName(), c.wdsName, phasePart, numShard)
+		} else {
+			wrapperName = fmt.Sprintf("%s-%s-%d", binding.GetName(), c.wdsName, numShard)
+		}
 	}
 	wrappedObject.SetName(wrapperName)
 	setLabel(wrappedObject, originOwnerReferenceLabel, binding.GetName())
 	setLabel(wrappedObject, originWdsLabel, c.wdsName)
 	setAnnotation(wrappedObject, originOwnerGenerationAnnotation, binding.GetGeneration())
+	if markers := reapplyMarkers(batchToPropagate); markers != "" {
+		setAnnotation(wrappedObject, reapplyAnnotation, markers)
+	}
+	if c.upsyncEnabled && len(binding.Spec.Upsync) > 0 {
+		upsyncJSON, err := json.Marshal(binding.Spec.Upsync)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode upsync clauses - %w", err)
+		}
+		setAnnotation(wrappedObject, v1alpha1.UpsyncClausesAnnotationKey, string(upsyncJSON))
+	}
 	return wrappedObject, err
 }
 
@@ -902,15 +1212,40 @@ type WrapeeWithUID struct {
 	transport.Wrapee
 	// UID of the object in the WDS
 	UID string
+	// Resource is the lowercase plural name of the object's resource
+	Resource string
+	// ReplicaSplit, if not nil, says how to divide the object's replicas among the destinations.
+	// It is immutable.
+	ReplicaSplit *v1alpha1.ReplicaSplit
+	// DriftPolicy, if not empty, requests drift detection for the object.
+	DriftPolicy v1alpha1.DriftPolicy
+	// destChanges are the CustomTransforms that apply to the object
+	// and depend on the destination. They are immutable.
+	destChanges []selectiveChanges
 }
 
-// transportTask is one wrapped object and a gloss of its contents
+// transportTask is one wrapped object, a gloss of its contents, and their delivery phase
 type transportTask struct {
 	ObjU  *unstructured.Unstructured
 	Gloss transport.Gloss
+	Phase deliveryPhase
+	// DesiredStates maps the hash of the desired state of each workload object in ObjU
+	// whose drift is checked to that desired state. It is immutable.
+	DesiredStates map[string]string
 }
 
+// wrap packs the given wrapees into wrapped objects, limited in size and count.
+// The wrapees are ordered by delivery phase and no wrapped object holds more than one phase;
+// within a phase, they keep their given order.
+// The wrapped objects are numbered within their phase (see wrapBatch).
+// For a Binding whose objects span several phases, this moves objects to other
+// wrapped objects than when wrapping ignored phases, so upgrading rewrites those
+// wrapped objects once.
 func (c *genericTransportController) wrap(wrapeesToPropagate []WrapeeWithUID, kindToResource func(schema.GroupKind) (string, bool), binding *v1alpha1.Binding) ([]transportTask, error) {
+	wrapeesToPropagate = slices.Clone(wrapeesToPropagate)
+	slices.SortStableFunc(wrapeesToPropagate, func(a, b WrapeeWithUID) int {
+		return int(deliveryPhaseOf(a.Object.GroupVersionKind().GroupKind())) - int(deliveryPhaseOf(b.Object.GroupVersionKind().GroupKind()))
+	})
 	var transportTasks []transportTask
 	var batchToPropagate []transport.Wrapee = nil
 	var uidToPropagate string
@@ -920,7 +1255,29 @@ func (c *genericTransportController) wrap(wrapeesToPropagate []WrapeeWithUID, ki
 	numShard := 0
 	var batchSize int = 0
 	var batchCount int = 0
+	var batchPhase deliveryPhase
+	var batchDesiredStates map[string]string
+	finishBatch := func() error {
+		wrappedObject, err := c.wrapBatch(batchToPropagate, uidToPropagate, kindToResource, binding, batchPhase, numShard)
+		if err != nil {
+			return err
+		}
+		if len(batchDesiredStates) > 0 {
+			setAnnotation(wrappedObject, desiredStatesAnnotation, "true")
+		}
+		transportTasks = append(transportTasks, transportTask{ObjU: wrappedObject, Gloss: gloss, Phase: batchPhase, DesiredStates: batchDesiredStates})
+		return nil
+	}
 	for _, wrapee := range wrapeesToPropagate {
+		var desiredState, desiredStateHash string
+		if wrapee.DriftPolicy != "" {
+			objWithDesiredState, state, hash, err := withDesiredState(wrapee.Object)
+			if err != nil {
+				return nil, err
+			}
+			wrapee.Object = objWithDesiredState
+			desiredState, desiredStateHash = state, hash
+		}
 		bytes, err := wrapee.Object.MarshalJSON()
 		if err != nil {
 			return nil, err
@@ -929,30 +1286,39 @@ func (c *genericTransportController) wrap(wrapeesToPropagate []WrapeeWithUID, ki
 		if objSize > maxSize {
 			return nil, fmt.Errorf("failed to wrap object that is larger than max size")
 		}
-		if (objSize+batchSize >= maxSize) || (batchCount+1 > maxCount) {
-			wrappedObject, err := c.wrapBatch(batchToPropagate, uidToPropagate, kindToResource, binding, numShard)
-			if err != nil {
+		phase := deliveryPhaseOf(wrapee.Object.GroupVersionKind().GroupKind())
+		if batchToPropagate != nil && phase != batchPhase || (objSize+batchSize >= maxSize) || (batchCount+1 > maxCount) {
+			if err := finishBatch(); err != nil {
 				return nil, err
 			}
-			numShard += 1
-			transportTasks = append(transportTasks, transportTask{wrappedObject, gloss})
+			if phase != batchPhase {
+				numShard = 0
+			} else {
+				numShard += 1
+			}
 			batchToPropagate = nil
 			gloss = transport.Gloss{}
 			batchSize = 0
 			batchCount = 0
+			batchDesiredStates = nil
+		}
+		if desiredStateHash != "" {
+			if batchDesiredStates == nil {
+				batchDesiredStates = map[string]string{}
+			}
+			batchDesiredStates[desiredStateHash] = desiredState
 		}
 		batchToPropagate = append(batchToPropagate, wrapee.Wrapee)
+		batchPhase = phase
 		uidToPropagate = wrapee.UID
 		gloss.Insert(wrapee.GetID())
 		batchSize += objSize
 		batchCount += 1
 	}
 	if batchToPropagate != nil {
-		wrappedObject, err := c.wrapBatch(batchToPropagate, uidToPropagate, kindToResource, binding, numShard)
-		if err != nil {
+		if err := finishBatch(); err != nil {
 			return nil, err
 		}
-		transportTasks = append(transportTasks, transportTask{wrappedObject, gloss})
 	}
 	return transportTasks, nil
 }
@@ -979,6 +1345,40 @@ func (c *genericTransportController) getPropertiesForDestination(bindingName str
 	return props
 }
 
+// getLabelsForDestination returns the labels of the given destination's inventory object and notes
+// that the given binding is sensitive to the fact that the destination has those labels.
+func (c *genericTransportController) getLabelsForDestination(bindingName string, dest v1alpha1.Destination) labels.Set {
+	c.propsMutex.Lock()
+	defer c.propsMutex.Unlock()
+	dests := c.bindingSensitiveDestinations[bindingName]
+	if dests == nil {
+		dests = sets.New[v1alpha1.Destination](dest)
+		c.bindingSensitiveDestinations[bindingName] = dests
+	} else {
+		dests.Insert(dest)
+	}
+	destLabels, have := c.destinationLabels[dest]
+	if have {
+		return destLabels
+	}
+	destLabels = c.collectLabelsForDestination(c.logger.WithValues("forBinding", bindingName), dest.ClusterId)
+	c.destinationLabels[dest] = destLabels
+	c.logger.V(4).Info("getLabelsForDestination", "bindingName", bindingName, "dest", dest, "labels", destLabels)
+	return destLabels
+}
+
+// collectLabelsForDestination fetches the labels of the given destination's inventory object.
+// The returned Set is never nil.
+func (c *genericTransportController) collectLabelsForDestination(logger logr.Logger, invName string) labels.Set {
+	invObj, err := c.inventoryLister.Get(invName)
+	if err == nil && invObj != nil {
+		return labels.Set(maps.Clone(invObj.Labels))
+	} else if err != nil && !errors.IsNotFound(err) { // listers do not fail
+		logger.Error(err, "Inconceivable failure to fetch inventory object", "dest", invName)
+	}
+	return labels.Set{}
+}
+
 // collectPropertiesForDestination computes the properties for the given destination
 func (c *genericTransportController) collectPropertiesForDestination(logger logr.Logger, invName string) clusterProperties {
 	props := clusterProperties{"clusterName": invName}
@@ -1062,53 +1462,160 @@ func (c *genericTransportController) customizeForDestination(object *unstructure
 func (c *genericTransportController) propagateWrappedObjectToClusters(ctx context.Context,
 	destToDesiredWrappedObjects func(v1alpha1.Destination) ([]transportTask, bool),
 	kindToResource func(schema.GroupKind) (string, bool),
-	currentWrappedObjectList *unstructured.UnstructuredList, destinations []v1alpha1.Destination) error {
+	currentWrappedObjectList *unstructured.UnstructuredList, waves [][]v1alpha1.Destination,
+	gatePasses func([]v1alpha1.Destination) bool, frozen, holds func(v1alpha1.Destination) bool) ([]v1alpha1.Destination, bool, error) {
 	// if the desired wrapped object is nil, that means we should not propagate this object.
 	// this may happen when the workload section is empty.
 	// this is not an error state but a valid scenario.
 	// return without propagating, the delete section will remove existing instances of the wrapped object from all current destinations.
 	if destToDesiredWrappedObjects == nil {
-		return nil // this is not considered an error.
+		return nil, false, nil // this is not considered an error.
 	}
 	logger := klog.FromContext(ctx)
-	logger.V(5).Info("In propagateWrappedObjectToClusters", "destinations", destinations)
-
-	for _, destination := range destinations {
-		tasks, _ := destToDesiredWrappedObjects(destination)
-		for _, task := range tasks {
-			wrappedID := klog.ObjectRef{Namespace: destination.ClusterId, Name: task.ObjU.GetName()}
-			currentWrappedObject := popUnstructuredByID(currentWrappedObjectList, wrappedID)
-			if currentWrappedObject == nil {
-				logger.V(5).Info("No current wrapped object has sought ID", "id", wrappedID, "currentWrappedObjectList", currentWrappedObjectList)
-			} else {
-				gloss, err := c.transport.UnwrapObjects(currentWrappedObject, kindToResource)
-				if err != nil {
-					logger.Error(err, fmt.Sprintf("Failed to unwrap %#v", currentWrappedObject))
-				}
-				desiredGeneration := task.ObjU.GetAnnotations()[originOwnerGenerationAnnotation]
-				actualGeneration := currentWrappedObject.GetAnnotations()[originOwnerGenerationAnnotation]
-				// This test covers workload object ResourceVersion and the create-only bit.
-				// This test is also an imperfect test for consistency in customization.
-				// It does not take into account the effects of absence of, or changes in, CustomTransform objects.
-				generationMatch := actualGeneration == desiredGeneration
-				glossEqual := abstract.PrimitiveMapEqual(task.Gloss, gloss)
-				if generationMatch && glossEqual {
-					logger.V(5).Info("No need to change wrapped object", "id", wrappedID)
-					continue
+	logger.V(5).Info("In propagateWrappedObjectToClusters", "waves", waves)
+
+	// A wave after the first proceeds only if the previous wave needed no change
+	// and the rollout gate passes for the previous wave.
+	// held accumulates the destinations that need changes but are outside their propagation windows.
+	// Whether a frozen destination needs changes is not known, so it counts as changed.
+	// wrote tells whether any wrapped object was created or updated.
+	var held []v1alpha1.Destination
+	wrote := false
+	proceed := true
+	for waveIdx, wave := range waves {
+		if !proceed {
+			logger.V(4).Info("Holding rollout wave", "wave", waveIdx, "destinations", wave)
+			// keep what the held destinations already have
+			for _, destination := range wave {
+				for c.popWrappedObjectByNamespace(currentWrappedObjectList, destination.ClusterId) != nil {
 				}
-				if glossEqual {
-					logger.V(5).Info("Need to change wrapped object because of Binding generation mismatch", "id", wrappedID, "desiredGeneration", desiredGeneration, "actualGeneration", actualGeneration)
-				} else {
-					logger.V(5).Info("Need to change wrapped object because of (at least) gloss mismatch", "id", wrappedID, "desiredGeneration", desiredGeneration, "actualGeneration", actualGeneration, "desiredGloss", util.K8sSet4Log(task.Gloss), "actualGloss", util.K8sSet4Log(gloss))
+			}
+			continue
+		}
+		changed := false
+		for _, destination := range wave {
+			if frozen(destination) {
+				logger.V(4).Info("Leaving wrapped objects of frozen destination as they are", "destination", destination)
+				for c.popWrappedObjectByNamespace(currentWrappedObjectList, destination.ClusterId) != nil {
 				}
+				changed = true
+				continue
+			}
+			hold := holds(destination)
+			destChanged, destWrote, err := c.propagateWrappedObjectToCluster(ctx, destToDesiredWrappedObjects, kindToResource, currentWrappedObjectList, destination, hold)
+			wrote = wrote || destWrote
+			if err != nil {
+				return held, wrote, err
 			}
-			if err := c.createOrUpdateWrappedObject(ctx, destination.ClusterId, task.ObjU); err != nil {
-				return fmt.Errorf("failed to propagate wrapped object to cluster mailbox namespace '%s' - %w", destination.ClusterId, err)
+			if hold && destChanged {
+				held = append(held, destination)
 			}
+			changed = changed || destChanged
+		}
+		if waveIdx+1 < len(waves) {
+			proceed = !changed && gatePasses(wave)
 		}
 	}
 
-	return nil
+	return held, wrote, nil
+}
+
+// propagateWrappedObjectToCluster creates or updates the wrapped objects for the given destination
+// as needed, removing the existing ones from currentWrappedObjectList.
+// The wrapped objects of a delivery phase are created or updated only once those of
+// all earlier phases report that they have been applied; until then, the existing
+// ones stay as they are. Status changes on the wrapped objects bring the Binding back here.
+// When `hold` is true, nothing is created or updated and all the destination's existing
+// wrapped objects are removed from currentWrappedObjectList, so that they stay as they are.
+// When the transport is a transport.OrphanCarrier, each update keeps orphaning the
+// workload objects that leave the wrapped object.
+// The first returned bool tells whether any wrapped object needed to be created or updated,
+// the second whether any was.
+func (c *genericTransportController) propagateWrappedObjectToCluster(ctx context.Context,
+	destToDesiredWrappedObjects func(v1alpha1.Destination) ([]transportTask, bool),
+	kindToResource func(schema.GroupKind) (string, bool),
+	currentWrappedObjectList *unstructured.UnstructuredList, destination v1alpha1.Destination, hold bool) (bool, bool, error) {
+	logger := klog.FromContext(ctx)
+	changed, wrote := false, false
+	reporter, _ := c.transport.(transport.DeliveryReporter)
+	// whether all the wrapped objects of the earlier phases, and of the current phase so far, are applied
+	earlierApplied, phaseApplied := true, true
+	var phase deliveryPhase
+	tasks, _ := destToDesiredWrappedObjects(destination)
+	for _, task := range tasks {
+		if task.Phase != phase {
+			phase = task.Phase
+			earlierApplied = earlierApplied && phaseApplied
+			phaseApplied = true
+		}
+		wrappedID := klog.ObjectRef{Namespace: destination.ClusterId, Name: task.ObjU.GetName()}
+		currentWrappedObject := popUnstructuredByID(currentWrappedObjectList, wrappedID)
+		if currentWrappedObject == nil {
+			logger.V(5).Info("No current wrapped object has sought ID", "id", wrappedID, "currentWrappedObjectList", currentWrappedObjectList)
+		} else {
+			gloss, err := c.transport.UnwrapObjects(currentWrappedObject, kindToResource)
+			if err != nil {
+				logger.Error(err, fmt.Sprintf("Failed to unwrap %#v", currentWrappedObject))
+			}
+			desiredGeneration := task.ObjU.GetAnnotations()[originOwnerGenerationAnnotation]
+			actualGeneration := currentWrappedObject.GetAnnotations()[originOwnerGenerationAnnotation]
+			// This test covers workload object ResourceVersion and the create-only bit.
+			// This test is also an imperfect test for consistency in customization.
+			// It does not take into account the effects of absence of, or changes in, CustomTransform objects.
+			// Reapplying drifted objects changes the reapply markers rather than the generation,
+			// and enabling or disabling upsync or drift detection changes other annotations.
+			generationMatch := actualGeneration == desiredGeneration &&
+				task.ObjU.GetAnnotations()[reapplyAnnotation] == currentWrappedObject.GetAnnotations()[reapplyAnnotation] &&
+				task.ObjU.GetAnnotations()[desiredStatesAnnotation] == currentWrappedObject.GetAnnotations()[desiredStatesAnnotation] &&
+				task.ObjU.GetAnnotations()[v1alpha1.UpsyncClausesAnnotationKey] == currentWrappedObject.GetAnnotations()[v1alpha1.UpsyncClausesAnnotationKey]
+			glossEqual := abstract.PrimitiveMapEqual(task.Gloss, gloss)
+			if generationMatch && glossEqual {
+				logger.V(5).Info("No need to change wrapped object", "id", wrappedID)
+				applied, err := deliveryApplied(reporter, currentWrappedObject)
+				if err != nil {
+					logger.Error(err, "Failed to extract delivery conditions", "id", wrappedID)
+				}
+				phaseApplied = phaseApplied && applied
+				continue
+			}
+			if glossEqual {
+				logger.V(5).Info("Need to change wrapped object because of Binding generation mismatch", "id", wrappedID, "desiredGeneration", desiredGeneration, "actualGeneration", actualGeneration)
+			} else {
+				logger.V(5).Info("Need to change wrapped object because of (at least) gloss mismatch", "id", wrappedID, "desiredGeneration", desiredGeneration, "actualGeneration", actualGeneration, "desiredGloss", util.K8sSet4Log(task.Gloss), "actualGloss", util.K8sSet4Log(gloss))
+			}
+		}
+		changed = true
+		phaseApplied = false
+		if hold {
+			continue
+		}
+		if !earlierApplied {
+			logger.V(4).Info("Holding wrapped object until earlier delivery phases are applied", "id", wrappedID, "phase", task.Phase)
+			continue
+		}
+		toWrite := task.ObjU
+		if carrier, ok := c.transport.(transport.OrphanCarrier); ok && currentWrappedObject != nil {
+			var err error
+			toWrite, err = carrier.CarryOrphaning(currentWrappedObject, task.ObjU, kindToResource)
+			if err != nil {
+				return changed, wrote, fmt.Errorf("failed to carry orphaning forward into wrapped object %v - %w", wrappedID, err)
+			}
+		}
+		written, err := c.createOrUpdateWrappedObject(ctx, destination.ClusterId, toWrite)
+		if err != nil {
+			return changed, wrote, fmt.Errorf("failed to propagate wrapped object to cluster mailbox namespace '%s' - %w", destination.ClusterId, err)
+		}
+		wrote = true
+		hadStates := currentWrappedObject != nil && currentWrappedObject.GetAnnotations()[desiredStatesAnnotation] == "true"
+		if err := c.syncDesiredStates(ctx, destination.ClusterId, written, task.DesiredStates, hadStates); err != nil {
+			return changed, wrote, err
+		}
+	}
+	if hold {
+		for c.popWrappedObjectByNamespace(currentWrappedObjectList, destination.ClusterId) != nil {
+		}
+	}
+	return changed, wrote, nil
 }
 
 // pops wrapped object by namespace from the list and returns the requested wrapped object.
@@ -1144,12 +1651,12 @@ func popUnstructuredByID(list *unstructured.UnstructuredList, id klog.ObjectRef)
 	return nil
 }
 
-func (c *genericTransportController) createOrUpdateWrappedObject(ctx context.Context, namespace string, wrappedObject *unstructured.Unstructured) error {
+func (c *genericTransportController) createOrUpdateWrappedObject(ctx context.Context, namespace string, wrappedObject *unstructured.Unstructured) (*unstructured.Unstructured, error) {
 	logger := klog.FromContext(ctx)
 	existingWrappedObject, err := c.transportClient.Resource(c.wrappedObjectGVR).Namespace(namespace).Get(ctx, wrappedObject.GetName(), metav1.GetOptions{})
 	if err != nil {
 		if !errors.IsNotFound(err) { // if object is not there, we need to create it. otherwise report an error.
-			return fmt.Errorf("failed to create wrapped object '%s' in destination WEC with namespace '%s' - %w", wrappedObject.GetName(), namespace, err)
+			return nil, fmt.Errorf("failed to create wrapped object '%s' in destination WEC with namespace '%s' - %w", wrappedObject.GetName(), namespace, err)
 		}
 		// object not found when using get, create it
 		wrappedObject.SetResourceVersion("") // must be unset for this destination
@@ -1157,14 +1664,14 @@ func (c *genericTransportController) createOrUpdateWrappedObject(ctx context.Con
 			FieldManager: ControllerName,
 		})
 		if err != nil {
-			return fmt.Errorf("failed to create wrapped object '%s' in destination WEC mailbox namespace '%s' - %w", wrappedObject.GetName(), namespace, err)
+			return nil, fmt.Errorf("failed to create wrapped object '%s' in destination WEC mailbox namespace '%s' - %w", wrappedObject.GetName(), namespace, err)
 		}
 		if hi := logger.V(3); hi.Enabled() {
 			hi.Info("Created wrapped object in ITS", "namespace", namespace, "objectName", wrappedObject.GetName(), "wrappedObject", wrappedObject2)
 		} else {
 			logger.V(2).Info("Created wrapped object in ITS", "namespace", namespace, "objectName", wrappedObject.GetName(), "resourceVersion", wrappedObject2.GetResourceVersion())
 		}
-		return nil
+		return wrappedObject2, nil
 	}
 	// if we reached here object already exists, try update object
 	wrappedObject.SetResourceVersion(existingWrappedObject.GetResourceVersion())
@@ -1173,7 +1680,7 @@ func (c *genericTransportController) createOrUpdateWrappedObject(ctx context.Con
 		FieldManager: ControllerName,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to update wrapped object '%s' in destination WEC mailbox namespace '%s' - %w", wrappedObject.GetName(), namespace, err)
+		return nil, fmt.Errorf("failed to update wrapped object '%s' in destination WEC mailbox namespace '%s' - %w", wrappedObject.GetName(), namespace, err)
 	}
 	if hi := logger.V(3); hi.Enabled() {
 		hi.Info("Updated wrapped object in ITS", "namespace", namespace, "objectName", wrappedObject.GetName(), "wrappedObject", wrappedObject2)
@@ -1181,7 +1688,7 @@ func (c *genericTransportController) createOrUpdateWrappedObject(ctx context.Con
 		logger.V(2).Info("Updated wrapped object in ITS", "namespace", namespace, "objectName", wrappedObject.GetName(), "wrappedObject", wrappedObject, "resourceVersion", wrappedObject2.GetResourceVersion())
 	}
 
-	return nil
+	return wrappedObject2, nil
 }
 
 // updateObjectFunc is a function that updates the given object.
@@ -1272,12 +1779,24 @@ func setLabel(object metav1.Object, key string, value any) {
 
 // TransformObject does the WEC-independent transformation of a workload object.
 // This is done before customization and wrapping.
-// Currently the only transformations are removing content.
-// There are three sorts of content removal done here:
+// There are three sorts of transformation done here:
 // 1. Removal that is common for all API objects;
-// 2. Removal that is specific to a Kind of object and fixed in KubeStellar code;
-// 3. Removal that is specific to a Kind of object and configured by API object(s).
-func TransformObject(ctx context.Context, ctc customTransformCollection, groupResource metav1.GroupResource, object *unstructured.Unstructured, bindingName string) *unstructured.Unstructured {
+// 2. Removal that is specific to a Kind of object and configured by the given cleanupRules;
+// 3. Removal, renaming, and setting that is specific to a Kind of object and
+// configured by API object(s).
+// The given labels are those of the object's namespace, if it has one.
+// CustomTransforms that have a clusterSelector or computations are not applied here,
+// they are applied during customization.
+func TransformObject(ctx context.Context, ctc customTransformCollection, cleanupRules *filtering.CleanupRules, groupResource metav1.GroupResource, object *unstructured.Unstructured, namespaceLabels labels.Set, bindingName string) *unstructured.Unstructured {
+	transformed, _, _ := transformObject(ctx, ctc, cleanupRules, groupResource, object, func(string) (labels.Set, error) { return namespaceLabels, nil }, bindingName)
+	return transformed
+}
+
+// transformObject is TransformObject that also returns the relevant CustomTransforms that
+// depend on the destination, and those that follow them, for application during customization;
+// this keeps the CustomTransforms applied in order.
+// getNamespaceLabels is only called if needed; an error from it is returned.
+func transformObject(ctx context.Context, ctc customTransformCollection, cleanupRules *filtering.CleanupRules, groupResource metav1.GroupResource, object *unstructured.Unstructured, getNamespaceLabels func(namespace string) (labels.Set, error), bindingName string) (*unstructured.Unstructured, []selectiveChanges, error) {
 	objectCopy := object.DeepCopy() // don't modify object directly. create a copy before zeroing fields
 	objectCopy.SetManagedFields(nil)
 	objectCopy.SetFinalizers(nil)
@@ -1296,20 +1815,52 @@ func TransformObject(ctx context.Context, ctc customTransformCollection, groupRe
 	unstructured.RemoveNestedField(objectCopy.Object, "status")
 
 	// clean fields specific to the concrete object.
-	objectsFilter.CleanObjectSpecifics(objectCopy)
+	cleanupRules.CleanObjectSpecifics(objectCopy)
 
-	customChanges := ctc.getCustomTransformChanges(ctx, groupResource, bindingName)
+	var destChanges []selectiveChanges
+	objectData := objectCopy.UnstructuredContent()
+	for _, customChanges := range ctc.getCustomTransformChanges(ctx, groupResource, bindingName) {
+		applies, err := customChanges.appliesToObject(object, getNamespaceLabels)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !applies {
+			continue
+		}
+		if len(destChanges) > 0 || customChanges.destinationDependent() {
+			destChanges = append(destChanges, customChanges)
+			continue
+		}
+		customChanges.apply(objectData)
+	}
+	objectCopy.SetUnstructuredContent(objectData)
+	return objectCopy, destChanges, nil
+}
 
-	if len(customChanges.removes) > 0 {
-		objectData := objectCopy.UnstructuredContent()
-		var objectDataAny any = objectData
-		rootNode := jsonpath.RootNode{Value: &objectDataAny}
-		for _, query := range customChanges.removes {
-			jsonpath.QueryValue(query, &rootNode, jsonpath.Node.Remove)
+// applyDestinationChanges applies, in order, the given changes whose clusterSelector
+// (if any) matches the given destination labels, using the given destination properties.
+// The given object is not mutated.
+// The returned object is the given one if no changes applied.
+// The returned map describes the computations that failed; it maps CustomTransform name
+// to descriptions of that CustomTransform's failed computations, and is nil if none failed.
+func applyDestinationChanges(object *unstructured.Unstructured, destLabels labels.Set, props clusterProperties, destChanges []selectiveChanges) (*unstructured.Unstructured, map[string][]string) {
+	ans := object
+	var ctNameToErrors map[string][]string
+	for _, changes := range destChanges {
+		if changes.clusterSelector != nil && !changes.clusterSelector.Matches(destLabels) {
+			continue
+		}
+		if ans == object {
+			ans = object.DeepCopy()
+		}
+		if errs := changes.applyForDestination(ans.Object, props); len(errs) > 0 {
+			if ctNameToErrors == nil {
+				ctNameToErrors = map[string][]string{}
+			}
+			ctNameToErrors[changes.ctName] = append(ctNameToErrors[changes.ctName], errs...)
 		}
-		objectCopy.SetUnstructuredContent(objectData)
 	}
-	return objectCopy
+	return ans, ctNameToErrors
 }
 
 func customTransformToDomain(obj any) ([]string, error) {
@@ -19,6 +19,7 @@ package transport
 import (
 	"context"
 	"fmt"
+	"maps"
 	"math/rand"
 	"os"
 	"sync"
@@ -40,6 +41,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	dynamicfake "k8s.io/client-go/dynamic/fake"
 	k8sinformers "k8s.io/client-go/informers"
@@ -54,6 +56,7 @@ import (
 	ksinformers "github.com/kubestellar/kubestellar/pkg/generated/informers/externalversions"
 	ksmetrics "github.com/kubestellar/kubestellar/pkg/metrics"
 	"github.com/kubestellar/kubestellar/pkg/transport"
+	"github.com/kubestellar/kubestellar/pkg/transport/generic/filtering"
 	"github.com/kubestellar/kubestellar/pkg/util"
 )
 
@@ -257,31 +260,66 @@ type testTransport struct {
 	ctx            context.Context
 	bindingName    string
 	ctc            customTransformCollection
+	cleanupRules   *filtering.CleanupRules
 	kindToResource map[metav1.GroupKind]string
 
 	expect map[util.GKObjRef]jsonMapToWrap
 	sync.Mutex
 	wrapped bool
-	missed  map[string]any
-	wrong   map[string]any
-	extra   []any
+	// phaseToLast holds, for each delivery phase, the outcome of the last call to WrapObjects for that phase.
+	phaseToLast map[deliveryPhase]*wrapping
+}
+
+// wrapping is the outcome of one call to WrapObjects, which gets the objects of one delivery phase.
+type wrapping struct {
+	missed map[string]any
+	wrong  map[string]any
+	extra  []any
+}
+
+// lastWrapping combines the outcomes of the last calls to WrapObjects for each delivery phase.
+// An expected object of a phase that has not been wrapped yet counts as missed.
+// Caller asserts that tt is locked.
+func (tt *testTransport) lastWrapping() wrapping {
+	ans := wrapping{missed: map[string]any{}, wrong: map[string]any{}, extra: []any{}}
+	for key, val := range tt.expect {
+		if _, found := tt.phaseToLast[deliveryPhaseOf(key.GK)]; !found {
+			ans.missed[key.String()] = fmt.Sprintf("%#v", val)
+		}
+	}
+	for _, last := range tt.phaseToLast {
+		maps.Copy(ans.missed, last.missed)
+		maps.Copy(ans.wrong, last.wrong)
+		ans.extra = append(ans.extra, last.extra...)
+	}
+	return ans
 }
 
 func (tt *testTransport) WrapObjects(wrapees []transport.Wrapee, kindToResource func(k8sschema.GroupKind) string) runtime.Object {
 	tt.Lock()
 	defer tt.Unlock()
 	tt.wrapped = true
-	tt.missed = map[string]any{}
+	// The objects are spread over one wrapped object per delivery phase,
+	// so each call is judged against the expected objects of its phase.
+	phase := deliveryPhaseOf(wrapees[0].Object.GroupVersionKind().GroupKind())
+	last := &wrapping{missed: map[string]any{}, wrong: map[string]any{}, extra: []any{}}
 	for key, val := range tt.expect {
-		tt.missed[key.String()] = fmt.Sprintf("%#v", val)
+		if deliveryPhaseOf(key.GK) == phase {
+			last.missed[key.String()] = fmt.Sprintf("%#v", val)
+		}
+	}
+	if tt.phaseToLast == nil {
+		tt.phaseToLast = map[deliveryPhase]*wrapping{}
 	}
-	tt.wrong = map[string]any{}
-	tt.extra = []any{}
+	tt.phaseToLast[phase] = last
 	for _, wrapee := range wrapees {
 		obj := wrapee.Object
+		if objPhase := deliveryPhaseOf(obj.GroupVersionKind().GroupKind()); objPhase != phase {
+			tt.t.Errorf("Wrapped objects of phases %d and %d together, obj=%v", phase, objPhase, util.RefToRuntimeObj(obj))
+		}
 		// TODO: test wrapee.CreateOnly
 		key := util.RefToRuntimeObj(obj)
-		delete(tt.missed, key.String())
+		delete(last.missed, key.String())
 		if expectedJMTW, found := tt.expect[key]; found {
 			if wrapee.CreateOnly != expectedJMTW.createOnly {
 				tt.t.Errorf("Expected createOnly=%v, got %v obj=%v", expectedJMTW.createOnly, wrapee.CreateOnly, key)
@@ -300,7 +338,7 @@ func (tt *testTransport) WrapObjects(wrapees []transport.Wrapee, kindToResource
 			groupResource := metav1.GroupResource{Group: groupKind.Group, Resource: resource}
 			// clean expected object since transport objects are cleaned
 			uncleanedExpectedObj := &unstructured.Unstructured{Object: expectedJMTW.jm}
-			cleanedExpectedObjU := TransformObject(tt.ctx, tt.ctc, groupResource, uncleanedExpectedObj, tt.bindingName)
+			cleanedExpectedObjU := TransformObject(tt.ctx, tt.ctc, tt.cleanupRules, groupResource, uncleanedExpectedObj, nil, tt.bindingName)
 			cleanedExpectedObj := cleanedExpectedObjU.Object
 			cleanable := obj.GetKind() == "ClusterRole"
 			hadLabel := uncleanedExpectedObj.GetLabels()["test.kubestellar.io/delete-me"] != ""
@@ -314,10 +352,10 @@ func (tt *testTransport) WrapObjects(wrapees []transport.Wrapee, kindToResource
 			}
 			equal := apiequality.Semantic.DeepEqual(objM, cleanedExpectedObj)
 			if !equal {
-				tt.wrong[key.String()] = obj
+				last.wrong[key.String()] = obj
 			}
 		} else {
-			tt.extra = append(tt.extra, obj)
+			last.extra = append(last.extra, obj)
 		}
 	}
 	return &workapi.ManifestWork{
@@ -380,11 +418,20 @@ func TestGenericController(t *testing.T) {
 	itsDynamicClient := dynamicfake.NewSimpleDynamicClient(scheme)
 	wdsControlInformers := wdsKsInformerFactory.Control().V1alpha1()
 	ctIndexer := wdsControlInformers.CustomTransforms().Informer().GetIndexer()
+	computeEvaluator, err := newComputeEvaluator()
+	if err != nil {
+		t.Fatalf("Failed to create CEL evaluator: %s", err)
+	}
+	cleanupRules, err := filtering.NewCleanupRules(filtering.DefaultCleanupRules())
+	if err != nil {
+		t.Fatalf("Failed to digest default cleanup rules: %s", err)
+	}
 	transport := &testTransport{
-		t:           t,
-		bindingName: bindingCase.Binding.Name,
-		ctx:         ctx,
-		ctc: newCustomTransformCollection(wdsKsClientFake.ControlV1alpha1().CustomTransforms(),
+		t:            t,
+		bindingName:  bindingCase.Binding.Name,
+		ctx:          ctx,
+		cleanupRules: cleanupRules,
+		ctc: newCustomTransformCollection(wdsKsClientFake.ControlV1alpha1().CustomTransforms(), computeEvaluator,
 			ctIndexer.ByIndex,
 			func(any) {}),
 		kindToResource: map[metav1.GroupKind]string{
@@ -405,30 +452,35 @@ func TestGenericController(t *testing.T) {
 	ksmetrics.MustRegister(legacyregistry.Register, spacesClientMetrics)
 	wdsClientMetrics := spacesClientMetrics.MetricsForSpace("wds")
 	itsClientMetrics := spacesClientMetrics.MetricsForSpace("its")
-	ctlr := NewTransportControllerForWrappedObjectGVR(ctx, wdsClientMetrics, itsClientMetrics,
+	ctlr, err := NewTransportControllerForWrappedObjectGVR(ctx, wdsClientMetrics, itsClientMetrics,
 		inventoryPreInformer, wdsKsClientFake.ControlV1alpha1().Bindings(),
 		wdsControlInformers.Bindings(), wdsControlInformers.CustomTransforms(),
+		wdsControlInformers.CombinedStatuses(),
 		transport,
 		wdsKsClientFake,
 		wdsDynamicClient,
 		itsK8sClientFake.CoreV1().Namespaces(), parmCfgMapPreInformer,
-		itsDynamicClient, 500*1024, 500*1024, "test-wds", wrapperGVR)
+		itsDynamicClient, cleanupRules, 500*1024, 500*1024, "test-wds", wrapperGVR)
+	if err != nil {
+		t.Fatalf("Failed to create transport controller: %s", err)
+	}
 	ctlr.RegisterMetrics(legacyregistry.Register)
 	inventoryInformerFactory.Start(ctx.Done())
 	wdsKsInformerFactory.Start(ctx.Done())
 	itsK8sInformerFactory.Start(ctx.Done())
 
 	go ctlr.Run(ctx, 4)
-	err := wait.PollUntilContextTimeout(ctx, 5*time.Second, time.Minute, false, func(ctx context.Context) (done bool, err error) {
+	err = wait.PollUntilContextTimeout(ctx, 5*time.Second, time.Minute, false, func(ctx context.Context) (done bool, err error) {
 		transport.Lock()
 		defer transport.Unlock()
-		if transport.wrapped && len(transport.missed) == 0 && len(transport.wrong) == 0 && len(transport.extra) == 0 {
+		last := transport.lastWrapping()
+		if transport.wrapped && len(last.missed) == 0 && len(last.wrong) == 0 && len(last.extra) == 0 {
 			return true, nil
 		}
 		if !transport.wrapped {
 			logger.Info("No wrapping done yet")
 		} else {
-			logger.Info("Last wrapping was bad", "missed", transport.missed, "wrong", transport.wrong, "extra", transport.extra)
+			logger.Info("Last wrapping was bad", "missed", last.missed, "wrong", last.wrong, "extra", last.extra)
 		}
 		return false, nil
 	})
@@ -438,3 +490,40 @@ func TestGenericController(t *testing.T) {
 		logger.Info("Success", "objects", len(objs), "numExpected", len(transport.expect))
 	}
 }
+
+func TestNamespaceSelectorUsesLister(t *testing.T) {
+	binding := newTestBinding("b1", "wec1")
+	binding.Spec.Workload.NamespaceScope = append(binding.Spec.Workload.NamespaceScope, ksapi.NamespaceScopeDownsyncClause{
+		NamespaceScopeDownsyncObject: ksapi.NamespaceScopeDownsyncObject{
+			GroupVersionResource: metav1.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+			Namespace:            "ns2", Name: "cm2"}})
+	namespace := &k8score.Namespace{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"},
+		ObjectMeta: metav1.ObjectMeta{Name: "ns1", Labels: map[string]string{"team": "a"}},
+	}
+	// Namespace ns2 is not known yet
+	h := newDeliveryTestHarness(t, time.Now(), binding, nil,
+		[]runtime.Object{namespace, newTestConfigMap("ns1", "cm1"), newTestConfigMap("ns2", "cm2")}, nil)
+	ct := &ksapi.CustomTransform{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+		Spec: ksapi.CustomTransformSpec{Resource: "configmaps", Remove: []string{"$.data.k"},
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}}},
+	}
+	h.ctlr.customTransformCollection = newCustomTransformCollection(&customTransformStatusRecorder{}, h.ctlr.customTransformCollection.(*customTransformCollectionImpl).computeEvaluator,
+		func(string, string) ([]any, error) { return []any{ct}, nil }, func(any) {})
+	binding = h.update("b1")
+	if len(binding.Status.Errors) != 0 {
+		t.Errorf("Expected no errors in Binding status, got %v", binding.Status.Errors)
+	}
+	if actual, expected := h.ctlr.bindingNamespaces["b1"], sets.New("ns1", "ns2"); !actual.Equal(expected) {
+		t.Errorf("Expected Binding to be sensitive to namespaces %v, got %v", sets.List(expected), sets.List(actual))
+	}
+	h.ctlr.handleNamespace(&k8score.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns3"}}, "add")
+	if h.ctlr.workqueue.Len() != 0 {
+		t.Errorf("Expected no Binding to be enqueued for an irrelevant namespace")
+	}
+	h.ctlr.handleNamespace(&k8score.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns2"}}, "add")
+	if h.ctlr.workqueue.Len() != 1 {
+		t.Errorf("Expected the Binding to be enqueued for a relevant namespace, queue length is %d", h.ctlr.workqueue.Len())
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"fmt"
+	"slices"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+// overrideMatchesObject tests whether the given override applies to the given object,
+// whose resource is identified by `resource` (lowercase plural).
+func overrideMatchesObject(override v1alpha1.Override, object *unstructured.Unstructured, resource string) bool {
+	if override.APIGroup != nil && *override.APIGroup != object.GroupVersionKind().Group {
+		return false
+	}
+	if len(override.Resources) > 0 && !(slices.Contains(override.Resources, "*") ||
+		slices.Contains(override.Resources, resource)) {
+		return false
+	}
+	if len(override.Namespaces) > 0 && !(slices.Contains(override.Namespaces, "*") ||
+		slices.Contains(override.Namespaces, object.GetNamespace())) {
+		return false
+	}
+	if len(override.ObjectNames) > 0 && !(slices.Contains(override.ObjectNames, "*") ||
+		slices.Contains(override.ObjectNames, object.GetName())) {
+		return false
+	}
+	return true
+}
+
+// overridesApplyToObject tests whether any of the given overrides might apply to the given object,
+// for some destination.
+func overridesApplyToObject(overrides []v1alpha1.Override, object *unstructured.Unstructured, resource string) bool {
+	return slices.ContainsFunc(overrides, func(override v1alpha1.Override) bool {
+		return overrideMatchesObject(override, object, resource)
+	})
+}
+
+// applyOverrides applies, in order, the given overrides that match the given object
+// and the destination whose inventory object has the given labels.
+// The given object is not mutated.
+// The returned object is the given one if no override applied.
+// The returned strings describe user errors; when there are any, the returned object is the given one,
+// so that no partially overridden object escapes.
+func applyOverrides(object *unstructured.Unstructured, resource string, destName string, destLabels labels.Set, overrides []v1alpha1.Override) (*unstructured.Unstructured, []string) {
+	ans := object
+	objRefStr := util.RefToRuntimeObj(object).String()
+	for idx, override := range overrides {
+		if !overrideMatchesObject(override, object, resource) {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(&override.ClusterSelector)
+		if err != nil {
+			return object, []string{fmt.Sprintf("Invalid clusterSelector in overrides[%d]: %s", idx, err)}
+		}
+		if !selector.Matches(destLabels) {
+			continue
+		}
+		objJSON, err := ans.MarshalJSON()
+		if err != nil {
+			return object, []string{fmt.Sprintf("Failed to serialize %s for applying overrides[%d]: %s", objRefStr, idx, err)}
+		}
+		var patchedJSON []byte
+		switch {
+		case override.MergePatch != nil && override.JSONPatch == nil:
+			patchedJSON, err = jsonpatch.MergePatch(objJSON, override.MergePatch.Raw)
+		case override.JSONPatch != nil && override.MergePatch == nil:
+			var patch jsonpatch.Patch
+			patch, err = jsonpatch.DecodePatch(override.JSONPatch.Raw)
+			if err == nil {
+				patchedJSON, err = patch.Apply(objJSON)
+			}
+		default:
+			return object, []string{fmt.Sprintf("overrides[%d] must have exactly one of mergePatch and jsonPatch", idx)}
+		}
+		if err != nil {
+			return object, []string{fmt.Sprintf("Failed to apply overrides[%d] to %s for destination %q: %s", idx, objRefStr, destName, err)}
+		}
+		patched := &unstructured.Unstructured{}
+		if err := patched.UnmarshalJSON(patchedJSON); err != nil {
+			return object, []string{fmt.Sprintf("Applying overrides[%d] to %s for destination %q produced an invalid object: %s", idx, objRefStr, destName, err)}
+		}
+		ans = patched
+	}
+	return ans, nil
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"testing"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	newDeployment := func(replicas int64, image string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]any{"name": "web", "namespace": "demo"},
+			"spec": map[string]any{
+				"replicas": replicas,
+				"template": map[string]any{"spec": map[string]any{
+					"containers": []any{map[string]any{"name": "app", "image": image}},
+				}},
+			},
+		}}
+	}
+	prodOnly := metav1.LabelSelector{MatchLabels: map[string]string{"env": "prod"}}
+	mergeReplicas := v1alpha1.Override{
+		ClusterSelector: prodOnly,
+		MergePatch:      &apiextensionsv1.JSON{Raw: []byte(`{"spec":{"replicas":5}}`)},
+	}
+	patchImage := v1alpha1.Override{
+		Resources: []string{"deployments"},
+		JSONPatch: &apiextensionsv1.JSON{Raw: []byte(`[{"op":"replace","path":"/spec/template/spec/containers/0/image","value":"app:v2"}]`)},
+	}
+	otherResource := v1alpha1.Override{
+		Resources:  []string{"services"},
+		MergePatch: &apiextensionsv1.JSON{Raw: []byte(`{"spec":{"replicas":9}}`)},
+	}
+	badPatch := v1alpha1.Override{
+		JSONPatch: &apiextensionsv1.JSON{Raw: []byte(`[{"op":"remove","path":"/spec/nosuch"}]`)},
+	}
+	for _, tc := range []struct {
+		name       string
+		destLabels labels.Set
+		overrides  []v1alpha1.Override
+		expected   *unstructured.Unstructured
+		expectErr  bool
+	}{
+		{name: "selector matches", destLabels: labels.Set{"env": "prod"}, overrides: []v1alpha1.Override{mergeReplicas}, expected: newDeployment(5, "app:v1")},
+		{name: "selector does not match", destLabels: labels.Set{"env": "dev"}, overrides: []v1alpha1.Override{mergeReplicas}, expected: newDeployment(1, "app:v1")},
+		{name: "both in order", destLabels: labels.Set{"env": "prod"}, overrides: []v1alpha1.Override{mergeReplicas, patchImage}, expected: newDeployment(5, "app:v2")},
+		{name: "other resource", destLabels: labels.Set{}, overrides: []v1alpha1.Override{otherResource}, expected: newDeployment(1, "app:v1")},
+		{name: "failed patch", destLabels: labels.Set{}, overrides: []v1alpha1.Override{patchImage, badPatch}, expected: newDeployment(1, "app:v1"), expectErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			original := newDeployment(1, "app:v1")
+			actual, errs := applyOverrides(original, "deployments", "wec1", tc.destLabels, tc.overrides)
+			if tc.expectErr != (len(errs) > 0) {
+				t.Errorf("Expected error=%v, got %v", tc.expectErr, errs)
+			}
+			if !apiequality.Semantic.DeepEqual(actual.Object, tc.expected.Object) {
+				t.Errorf("Expected %v, got %v", tc.expected.Object, actual.Object)
+			}
+			if !apiequality.Semantic.DeepEqual(original.Object, newDeployment(1, "app:v1").Object) {
+				t.Errorf("Input object was mutated: %v", original.Object)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+// maxHeldInCondition limits the number of destinations named in a PendingWindow condition.
+const maxHeldInCondition = 10
+
+// windowSchedule is a parsed PropagationWindow.
+type windowSchedule struct {
+	schedule cron.Schedule
+	location *time.Location
+	duration time.Duration
+}
+
+// parsePropagationWindows parses the given windows.
+// The returned slice is empty (possibly nil) if and only if the given one is.
+func parsePropagationWindows(windows []v1alpha1.PropagationWindow) ([]windowSchedule, error) {
+	ans := make([]windowSchedule, 0, len(windows))
+	for idx, window := range windows {
+		schedule, err := cron.ParseStandard(window.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule in window %d (%q): %w", idx, window.Schedule, err)
+		}
+		location := time.UTC
+		if window.TimeZone != "" {
+			location, err = time.LoadLocation(window.TimeZone)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timeZone in window %d (%q): %w", idx, window.TimeZone, err)
+			}
+		}
+		if window.Duration.Duration <= 0 {
+			return nil, fmt.Errorf("invalid duration in window %d (%s): must be positive", idx, window.Duration.Duration)
+		}
+		ans = append(ans, windowSchedule{schedule: schedule, location: location, duration: window.Duration.Duration})
+	}
+	return ans, nil
+}
+
+// CheckPropagationWindows returns the errors in the given propagation windows, if any.
+// This is for validating BindingPolicy objects elsewhere.
+func CheckPropagationWindows(windows []v1alpha1.PropagationWindow) []error {
+	if _, err := parsePropagationWindows(windows); err != nil {
+		return []error{fmt.Errorf("invalid propagationWindows: %w", err)}
+	}
+	return nil
+}
+
+// windowsOpen tells whether any of the given windows is open at the given time,
+// and returns the earliest time after `now` at which one of them opens
+// (the zero time if none of them ever opens again).
+// An empty slice of windows is always open.
+func windowsOpen(windows []windowSchedule, now time.Time) (bool, time.Time) {
+	if len(windows) == 0 {
+		return true, time.Time{}
+	}
+	open := false
+	var nextOpen time.Time
+	for _, window := range windows {
+		localNow := now.In(window.location)
+		// The latest opening that could still be in effect is after now - duration.
+		if start := window.schedule.Next(localNow.Add(-window.duration)); !start.IsZero() && !start.After(localNow) {
+			open = true
+		}
+		if next := window.schedule.Next(localNow); !next.IsZero() && (nextOpen.IsZero() || next.Before(nextOpen)) {
+			nextOpen = next
+		}
+	}
+	return open, nextOpen
+}
+
+// propagationHold decides which destinations of a Binding are outside their propagation windows,
+// and remembers when to look again.
+type propagationHold struct {
+	controller  *genericTransportController
+	bindingName string
+	now         time.Time
+	// policyOpen and policyNext are from the windows in the Binding.
+	policyOpen bool
+	policyNext time.Time
+	// nextOpen is the earliest time at which a window of a held destination opens.
+	nextOpen time.Time
+}
+
+// newPropagationHold parses the windows of the given Binding.
+// The returned strings describe user errors; when there are any, the returned hold is nil.
+func (c *genericTransportController) newPropagationHold(binding *v1alpha1.Binding) (*propagationHold, []string) {
+	policyWindows, err := parsePropagationWindows(binding.Spec.PropagationWindows)
+	if err != nil {
+		return nil, []string{fmt.Sprintf("Invalid propagationWindows: %s", err)}
+	}
+	now := c.clock.Now()
+	policyOpen, policyNext := windowsOpen(policyWindows, now)
+	return &propagationHold{controller: c, bindingName: binding.Name, now: now, policyOpen: policyOpen, policyNext: policyNext}, nil
+}
+
+// holds tells whether changes for the given destination are to be held back.
+func (ph *propagationHold) holds(dest v1alpha1.Destination) bool {
+	destOpen, destNext := true, time.Time{}
+	if annotation := ph.controller.getWindowsAnnotationForDestination(ph.bindingName, dest); annotation != "" {
+		destWindows, err := parseWindowsAnnotation(annotation)
+		if err != nil {
+			ph.controller.logger.Error(err, "Invalid propagation windows annotation on inventory object, holding changes", "dest", dest.ClusterId, "annotation", annotation)
+			return true
+		}
+		destOpen, destNext = windowsOpen(destWindows, ph.now)
+	}
+	if ph.policyOpen && destOpen {
+		return false
+	}
+	for _, next := range []time.Time{ph.policyNext, destNext} {
+		if !next.IsZero() && (ph.nextOpen.IsZero() || next.Before(ph.nextOpen)) {
+			ph.nextOpen = next
+		}
+	}
+	return true
+}
+
+// parseWindowsAnnotation parses the value of a PropagationWindowsAnnotationKey annotation.
+func parseWindowsAnnotation(annotation string) ([]windowSchedule, error) {
+	var windows []v1alpha1.PropagationWindow
+	if err := json.Unmarshal([]byte(annotation), &windows); err != nil {
+		return nil, err
+	}
+	return parsePropagationWindows(windows)
+}
+
+// getWindowsAnnotationForDestination returns the propagation windows annotation of the given
+// destination's inventory object and notes that the given binding is sensitive to it.
+func (c *genericTransportController) getWindowsAnnotationForDestination(bindingName string, dest v1alpha1.Destination) string {
+	c.propsMutex.Lock()
+	defer c.propsMutex.Unlock()
+	dests := c.bindingSensitiveDestinations[bindingName]
+	if dests == nil {
+		dests = sets.New[v1alpha1.Destination](dest)
+		c.bindingSensitiveDestinations[bindingName] = dests
+	} else {
+		dests.Insert(dest)
+	}
+	annotation, have := c.destinationWindows[dest]
+	if have {
+		return annotation
+	}
+	annotation = c.collectWindowsAnnotationForDestination(dest.ClusterId)
+	c.destinationWindows[dest] = annotation
+	return annotation
+}
+
+// collectWindowsAnnotationForDestination fetches the propagation windows annotation
+// of the given destination's inventory object.
+func (c *genericTransportController) collectWindowsAnnotationForDestination(invName string) string {
+	invObj, err := c.inventoryLister.Get(invName)
+	if err != nil || invObj == nil {
+		return ""
+	}
+	return invObj.Annotations[v1alpha1.PropagationWindowsAnnotationKey]
+}
+
+// setPendingWindowCondition maintains the PendingWindow condition in the given conditions,
+// given the destinations being held back.
+// The returned bool tells whether the conditions changed.
+func setPendingWindowCondition(conditions []v1alpha1.BindingPolicyCondition, held []v1alpha1.Destination) ([]v1alpha1.BindingPolicyCondition, bool) {
+	return maintainCondition(conditions, v1alpha1.TypePendingWindow, func(current *v1alpha1.BindingPolicyCondition) (v1alpha1.BindingPolicyCondition, bool) {
+		return pendingWindowCondition(current, held)
+	})
+}
+
+// pendingWindowCondition returns the PendingWindow condition to put in the status of a Binding,
+// given the condition currently there (nil if none) and the destinations being held back.
+// The returned bool is false when no condition is called for.
+func pendingWindowCondition(current *v1alpha1.BindingPolicyCondition, held []v1alpha1.Destination) (v1alpha1.BindingPolicyCondition, bool) {
+	if len(held) == 0 {
+		if current == nil {
+			return v1alpha1.BindingPolicyCondition{}, false
+		}
+		return v1alpha1.BindingPolicyCondition{
+			Type:    v1alpha1.TypePendingWindow,
+			Status:  corev1.ConditionFalse,
+			Reason:  v1alpha1.ReasonNothingHeld,
+			Message: "No destination has changes held back",
+		}, true
+	}
+	names := make([]string, len(held))
+	for idx, dest := range held {
+		names[idx] = dest.ClusterId
+	}
+	slices.Sort(names)
+	message := "Changes held back for destinations outside their propagation windows: "
+	if len(names) > maxHeldInCondition {
+		message += fmt.Sprintf("%s, and %d more", strings.Join(names[:maxHeldInCondition], ", "), len(names)-maxHeldInCondition)
+	} else {
+		message += strings.Join(names, ", ")
+	}
+	return v1alpha1.BindingPolicyCondition{
+		Type:    v1alpha1.TypePendingWindow,
+		Status:  corev1.ConditionTrue,
+		Reason:  v1alpha1.ReasonOutsideWindow,
+		Message: message,
+	}, true
+}
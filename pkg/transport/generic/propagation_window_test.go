@@ -0,0 +1,84 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestWindowsOpen(t *testing.T) {
+	windows, err := parsePropagationWindows([]v1alpha1.PropagationWindow{
+		{Schedule: "0 22 * * 1-5", Duration: metav1.Duration{Duration: 2 * time.Hour}},
+		{Schedule: "0 9 * * 6", Duration: metav1.Duration{Duration: time.Hour}, TimeZone: "America/New_York"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to parse windows: %s", err)
+	}
+	at := func(value string) time.Time {
+		ans, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatalf("Failed to parse time %q: %s", value, err)
+		}
+		return ans
+	}
+	for _, tc := range []struct {
+		now          string
+		expectedOpen bool
+		expectedNext string
+	}{
+		// 2024-06-03 is a Monday
+		{now: "2024-06-03T21:59:00Z", expectedOpen: false, expectedNext: "2024-06-03T22:00:00Z"},
+		{now: "2024-06-03T22:00:00Z", expectedOpen: true, expectedNext: "2024-06-04T22:00:00Z"},
+		{now: "2024-06-03T23:59:00Z", expectedOpen: true, expectedNext: "2024-06-04T22:00:00Z"},
+		{now: "2024-06-04T00:00:00Z", expectedOpen: false, expectedNext: "2024-06-04T22:00:00Z"},
+		// Friday night, then Saturday morning in New York (UTC-4 in June)
+		{now: "2024-06-07T23:30:00Z", expectedOpen: true, expectedNext: "2024-06-08T13:00:00Z"},
+		{now: "2024-06-08T13:30:00Z", expectedOpen: true, expectedNext: "2024-06-10T22:00:00Z"},
+		{now: "2024-06-08T14:00:00Z", expectedOpen: false, expectedNext: "2024-06-10T22:00:00Z"},
+	} {
+		open, next := windowsOpen(windows, at(tc.now))
+		if open != tc.expectedOpen || !next.Equal(at(tc.expectedNext)) {
+			t.Errorf("At %s expected open=%v next=%s, got open=%v next=%s", tc.now, tc.expectedOpen, tc.expectedNext, open, next.UTC().Format(time.RFC3339))
+		}
+	}
+	if open, _ := windowsOpen(nil, at("2024-06-03T12:00:00Z")); !open {
+		t.Errorf("Expected no windows to be always open")
+	}
+	if _, err := parseWindowsAnnotation(`[{"schedule": "not cron", "duration": "1h"}]`); err == nil {
+		t.Errorf("Expected error from invalid schedule")
+	}
+}
+
+func TestPendingWindowCondition(t *testing.T) {
+	if _, wanted := pendingWindowCondition(nil, nil); wanted {
+		t.Errorf("Expected no condition when nothing is or was held")
+	}
+	held, wanted := pendingWindowCondition(nil, []v1alpha1.Destination{{ClusterId: "wec2"}, {ClusterId: "wec1"}})
+	if !wanted || held.Status != corev1.ConditionTrue || held.Message != "Changes held back for destinations outside their propagation windows: wec1, wec2" {
+		t.Errorf("Unexpected condition %#v", held)
+	}
+	released, wanted := pendingWindowCondition(&held, nil)
+	if !wanted || released.Status != corev1.ConditionFalse || released.Reason != v1alpha1.ReasonNothingHeld {
+		t.Errorf("Unexpected condition %#v", released)
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"fmt"
+
+	clusterlisters "open-cluster-management.io/api/client/cluster/listers/cluster/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/transport"
+	"github.com/kubestellar/kubestellar/pkg/transport/generic/filtering"
+)
+
+// renderBindingName is the name of the Binding that RenderForDestination pretends to process.
+const renderBindingName = "render"
+
+// RenderInput is what RenderForDestination works on.
+// These are given directly rather than read from the WDS and ITS.
+type RenderInput struct {
+	// Objects are the workload objects, as in the WDS.
+	Objects []*unstructured.Unstructured
+
+	CustomTransforms []*v1alpha1.CustomTransform
+
+	// Namespaces are the namespaces in the WDS.
+	// They are consulted only for CustomTransforms that have a namespaceSelector.
+	Namespaces []*corev1.Namespace
+
+	// Inventory holds the inventory objects (in the ITS).
+	Inventory []*clusterv1.ManagedCluster
+
+	// PropertyConfigMaps holds the ConfigMaps in the ITS that supply properties of destinations.
+	// Only those in the namespace named v1alpha1.PropertyConfigMapNamespace matter.
+	PropertyConfigMaps []*corev1.ConfigMap
+
+	CleanupRules *filtering.CleanupRules
+
+	// Resources maps the kinds of workload objects to their resources.
+	// The resource of a kind that is not here is guessed from the kind
+	// (see meta.UnsafeGuessKindToResource), which is wrong for irregular plurals.
+	Resources map[schema.GroupKind]string
+
+	// Destination is the name of the destination's inventory object.
+	Destination string
+}
+
+// RenderOutput is what RenderForDestination produces.
+type RenderOutput struct {
+	// Objects are the workload objects as they would be delivered, in the order given.
+	Objects []*unstructured.Unstructured
+
+	// CustomTransformProblems are the errors and warnings that the transport controller
+	// would report in the status of the relevant CustomTransforms.
+	CustomTransformProblems []string
+
+	// BindingErrors are the errors that the transport controller would report
+	// in the status of the Binding. When there are any, nothing would be delivered.
+	BindingErrors []string
+
+	// FrozenErrors are the errors that the transport controller would report
+	// in the status of the Binding and that make it leave what the destination
+	// has been sent as it is.
+	FrozenErrors []string
+}
+
+// RenderForDestination computes the workload objects as the transport controller would
+// deliver them to the given destination, using the same transformation and customization code.
+// There are no overrides, replica splits, nor downsync modulations
+// because those come from a BindingPolicy, which is not part of the input.
+func RenderForDestination(ctx context.Context, input RenderInput) (*RenderOutput, error) {
+	computeEvaluator, err := newComputeEvaluator()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL evaluator for CustomTransform computations: %w", err)
+	}
+	ctIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{customTransformDomainIndexName: customTransformToDomain})
+	for _, ct := range input.CustomTransforms {
+		if err := ctIndexer.Add(ct); err != nil {
+			return nil, fmt.Errorf("failed to index CustomTransform %q: %w", ct.Name, err)
+		}
+	}
+	ctStatuses := &customTransformStatusRecorder{}
+	inventoryIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, invObj := range input.Inventory {
+		if err := inventoryIndexer.Add(invObj); err != nil {
+			return nil, fmt.Errorf("failed to index inventory object %q: %w", invObj.Name, err)
+		}
+	}
+	cmIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, cm := range input.PropertyConfigMaps {
+		if err := cmIndexer.Add(cm); err != nil {
+			return nil, fmt.Errorf("failed to index ConfigMap %s/%s: %w", cm.Namespace, cm.Name, err)
+		}
+	}
+	c := &genericTransportController{
+		logger:                       klog.FromContext(ctx),
+		inventoryLister:              clusterlisters.NewManagedClusterLister(inventoryIndexer),
+		propCfgMapLister:             corev1listers.NewConfigMapLister(cmIndexer).ConfigMaps(v1alpha1.PropertyConfigMapNamespace),
+		customTransformCollection:    newCustomTransformCollection(ctStatuses, computeEvaluator, ctIndexer.ByIndex, func(any) {}),
+		cleanupRules:                 input.CleanupRules,
+		bindingSensitiveDestinations: make(map[string]sets.Set[v1alpha1.Destination]),
+		destinationProperties:        make(map[v1alpha1.Destination]clusterProperties),
+		destinationLabels:            make(map[v1alpha1.Destination]labels.Set),
+	}
+	nsToLabels := map[string]labels.Set{}
+	for _, ns := range input.Namespaces {
+		nsToLabels[ns.Name] = labels.Set(ns.Labels)
+	}
+	getNamespaceLabels := func(namespace string) (labels.Set, error) {
+		nsLabels, have := nsToLabels[namespace]
+		if !have {
+			return nil, fmt.Errorf("namespace %q is needed for a namespaceSelector but was not given", namespace)
+		}
+		return nsLabels, nil
+	}
+	dest := v1alpha1.Destination{ClusterId: input.Destination}
+	binding := &v1alpha1.Binding{
+		ObjectMeta: metav1.ObjectMeta{Name: renderBindingName},
+		Spec:       v1alpha1.BindingSpec{Destinations: []v1alpha1.Destination{dest}},
+	}
+	wrapees := make([]WrapeeWithUID, 0, len(input.Objects))
+	for _, object := range input.Objects {
+		gvk := object.GroupVersionKind()
+		gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+		if resource, has := input.Resources[gvk.GroupKind()]; has {
+			gvr.Resource = resource
+		}
+		gr := metav1.GroupResource{Group: gvr.Group, Resource: gvr.Resource}
+		transformed, destChanges, err := transformObject(ctx, c.customTransformCollection, c.cleanupRules, gr, object, getNamespaceLabels, binding.Name)
+		if err != nil {
+			return nil, err
+		}
+		wrapees = append(wrapees, WrapeeWithUID{
+			Wrapee:      transport.NewWrapee(transformed, false, ""),
+			UID:         string(object.GetUID()),
+			Resource:    gvr.Resource,
+			destChanges: destChanges})
+	}
+	output := &RenderOutput{}
+	var destToCustomizedWrapees map[v1alpha1.Destination][]WrapeeWithUID
+	freeze := &deliveryFreeze{}
+	destToCustomizedWrapees, output.BindingErrors = c.computeDestToCustomizedObjects(ctx, wrapees, binding, freeze)
+	if freeze.frozen(dest) {
+		output.FrozenErrors = freeze.errors
+	}
+	if destToCustomizedWrapees != nil {
+		wrapees = destToCustomizedWrapees[dest]
+	}
+	for _, wrapee := range wrapees {
+		output.Objects = append(output.Objects, wrapee.Object)
+	}
+	for _, ct := range input.CustomTransforms {
+		status := ctStatuses.ctNameToStatus[ct.Name]
+		for _, msg := range status.Errors {
+			output.CustomTransformProblems = append(output.CustomTransformProblems, fmt.Sprintf("Error in CustomTransform %q: %s", ct.Name, msg))
+		}
+		for _, msg := range status.Warnings {
+			output.CustomTransformProblems = append(output.CustomTransformProblems, fmt.Sprintf("Warning about CustomTransform %q: %s", ct.Name, msg))
+		}
+	}
+	return output, nil
+}
+
+// customTransformStatusRecorder is a customTransformStatusWriter
+// that just remembers the status written for each CustomTransform.
+type customTransformStatusRecorder struct {
+	ctNameToStatus map[string]v1alpha1.CustomTransformStatus
+}
+
+var _ customTransformStatusWriter = &customTransformStatusRecorder{}
+
+func (recorder *customTransformStatusRecorder) UpdateStatus(ctx context.Context, ct *v1alpha1.CustomTransform, opts metav1.UpdateOptions) (*v1alpha1.CustomTransform, error) {
+	if recorder.ctNameToStatus == nil {
+		recorder.ctNameToStatus = map[string]v1alpha1.CustomTransformStatus{}
+	}
+	recorder.ctNameToStatus[ct.Name] = ct.Status
+	return ct, nil
+}
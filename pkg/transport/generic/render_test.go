@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"testing"
+
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/transport/generic/filtering"
+)
+
+func TestRenderForDestination(t *testing.T) {
+	cleanupRules, err := filtering.NewCleanupRules(filtering.DefaultCleanupRules())
+	if err != nil {
+		t.Fatalf("Invalid default rules: %s", err)
+	}
+	input := RenderInput{
+		Objects: []*unstructured.Unstructured{
+			{Object: map[string]any{"apiVersion": "v1", "kind": "ConfigMap",
+				"metadata": map[string]any{"name": "cm", "namespace": "ns1", "resourceVersion": "5",
+					"annotations": map[string]any{v1alpha1.TemplateExpansionAnnotationKey: "true"}},
+				"data": map[string]any{"where": "{{.region}}", "drop": "me"}}},
+			{Object: map[string]any{"apiVersion": "v1", "kind": "Service",
+				"metadata": map[string]any{"name": "svc", "namespace": "ns1"},
+				"spec":     map[string]any{"clusterIP": "None", "ipFamilies": []any{"IPv4"}}}},
+		},
+		CustomTransforms: []*v1alpha1.CustomTransform{
+			{ObjectMeta: metav1.ObjectMeta{Name: "all-cms"},
+				Spec: v1alpha1.CustomTransformSpec{Resource: "configmaps", Remove: []string{"$.data.drop"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "labeled-cms"},
+				Spec: v1alpha1.CustomTransformSpec{Resource: "configmaps",
+					ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "edge"}},
+					Compute:         []v1alpha1.ComputeOperation{{Path: "$.data.size", Expression: `props.size + "Gi"`}}}},
+		},
+		Inventory: []*clusterv1.ManagedCluster{
+			{ObjectMeta: metav1.ObjectMeta{Name: "c1", Labels: map[string]string{"tier": "edge", "region": "north"}}},
+		},
+		PropertyConfigMaps: []*corev1.ConfigMap{
+			{ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: v1alpha1.PropertyConfigMapNamespace},
+				Data: map[string]string{"size": "3"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "c1", Namespace: "elsewhere"},
+				Data: map[string]string{"size": "99"}},
+		},
+		CleanupRules: cleanupRules,
+		Destination:  "c1",
+	}
+	output, err := RenderForDestination(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Failed to render: %s", err)
+	}
+	if len(output.BindingErrors) != 0 {
+		t.Fatalf("Unexpected Binding errors: %q", output.BindingErrors)
+	}
+	// Each CustomTransform gets the warning about sharing a GroupResource with another
+	if len(output.CustomTransformProblems) != 2 {
+		t.Errorf("Expected 2 CustomTransform problems, got %q", output.CustomTransformProblems)
+	}
+	expected := []map[string]any{
+		{"apiVersion": "v1", "kind": "ConfigMap",
+			"metadata": map[string]any{"name": "cm", "namespace": "ns1",
+				"annotations": map[string]any{v1alpha1.TemplateExpansionAnnotationKey: "true"}},
+			"data": map[string]any{"where": "north", "size": "3Gi"}},
+		{"apiVersion": "v1", "kind": "Service",
+			"metadata": map[string]any{"name": "svc", "namespace": "ns1"},
+			"spec":     map[string]any{"clusterIP": "None"}},
+	}
+	if len(output.Objects) != len(expected) {
+		t.Fatalf("Expected %d objects, got %d", len(expected), len(output.Objects))
+	}
+	for idx, obj := range output.Objects {
+		if !apiequality.Semantic.DeepEqual(expected[idx], obj.Object) {
+			t.Errorf("Object %d: expected %#v, got %#v", idx, expected[idx], obj.Object)
+		}
+	}
+
+	input.Destination = "c2"
+	output, err = RenderForDestination(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Failed to render: %s", err)
+	}
+	if len(output.BindingErrors) == 0 {
+		t.Errorf("Expected an error about the missing region property for c2")
+	}
+
+	// A resource that is not the guessed plural of its kind is matched only when given
+	input = RenderInput{
+		Objects: []*unstructured.Unstructured{{Object: map[string]any{"apiVersion": "example.com/v1", "kind": "Mouse",
+			"metadata": map[string]any{"name": "m"}, "spec": map[string]any{"keep": "me", "drop": "me"}}}},
+		CustomTransforms: []*v1alpha1.CustomTransform{{ObjectMeta: metav1.ObjectMeta{Name: "mice"},
+			Spec: v1alpha1.CustomTransformSpec{APIGroup: "example.com", Resource: "mice", Remove: []string{"$.spec.drop"}}}},
+		Inventory:    []*clusterv1.ManagedCluster{{ObjectMeta: metav1.ObjectMeta{Name: "c1"}}},
+		CleanupRules: cleanupRules,
+		Destination:  "c1",
+	}
+	for _, testCase := range []struct {
+		name      string
+		resources map[schema.GroupKind]string
+		expected  map[string]any
+	}{
+		{name: "guessed", expected: map[string]any{"keep": "me", "drop": "me"}},
+		{name: "given", resources: map[schema.GroupKind]string{{Group: "example.com", Kind: "Mouse"}: "mice"},
+			expected: map[string]any{"keep": "me"}},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			input.Resources = testCase.resources
+			output, err := RenderForDestination(context.Background(), input)
+			if err != nil {
+				t.Fatalf("Failed to render: %s", err)
+			}
+			if len(output.Objects) != 1 || !apiequality.Semantic.DeepEqual(output.Objects[0].Object["spec"], testCase.expected) {
+				t.Errorf("Expected one object with spec %v, got %v", testCase.expected, output.Objects)
+			}
+		})
+	}
+}
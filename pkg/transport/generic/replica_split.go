@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/util"
+)
+
+// computeReplicaShares returns the number of replicas of the given object that each
+// of the given destinations gets, in the same order as the destinations.
+// The returned slice is nil if the object's replicas are not to be split,
+// which is the case when `split` is nil or the object has no integer `spec.replicas`.
+// The returned strings describe user errors; when there are any, the returned slice is nil.
+func (c *genericTransportController) computeReplicaShares(bindingName string, object *unstructured.Unstructured, split *v1alpha1.ReplicaSplit, destinations []v1alpha1.Destination) ([]int64, []string) {
+	if split == nil || len(destinations) == 0 {
+		return nil, nil
+	}
+	total, found, err := unstructured.NestedInt64(object.Object, "spec", "replicas")
+	if err != nil || !found || total < 0 || total > math.MaxInt32 {
+		return nil, nil
+	}
+	objRefStr := util.RefToRuntimeObj(object).String()
+	weights := make([]int64, len(destinations))
+	var sum int64
+	for idx, dest := range destinations {
+		if split.WeightProperty == "" {
+			weights[idx] = 1
+		} else {
+			props := c.getPropertiesForDestination(bindingName, dest)
+			valStr, has := props[split.WeightProperty]
+			if !has {
+				continue
+			}
+			val, err := strconv.ParseUint(valStr, 10, 32)
+			if err != nil {
+				return nil, []string{fmt.Sprintf("Invalid replica weight %q in property %q of destination %q, for splitting replicas of %s: %s", valStr, split.WeightProperty, dest.ClusterId, objRefStr, err)}
+			}
+			weights[idx] = int64(val)
+		}
+		sum += weights[idx]
+	}
+	if sum == 0 {
+		return nil, []string{fmt.Sprintf("None of the %d destinations has a positive replica weight in property %q, for splitting replicas of %s", len(destinations), split.WeightProperty, objRefStr)}
+	}
+	return splitReplicas(total, weights), nil
+}
+
+// splitReplicas divides `total` in proportion to the given weights,
+// using the largest remainder method.
+// Ties among remainders are broken in favor of the earlier weight.
+// The sum of the weights must be positive, and `total` times any weight must fit in an int64.
+func splitReplicas(total int64, weights []int64) []int64 {
+	var sum int64
+	for _, weight := range weights {
+		sum += weight
+	}
+	shares := make([]int64, len(weights))
+	remainders := make([]int64, len(weights))
+	var assigned int64
+	for idx, weight := range weights {
+		shares[idx] = total * weight / sum
+		remainders[idx] = total * weight % sum
+		assigned += shares[idx]
+	}
+	order := make([]int, len(weights))
+	for idx := range order {
+		order[idx] = idx
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		switch {
+		case remainders[a] > remainders[b]:
+			return -1
+		case remainders[a] < remainders[b]:
+			return 1
+		}
+		return 0
+	})
+	for _, idx := range order[:total-assigned] {
+		shares[idx]++
+	}
+	return shares
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitReplicas(t *testing.T) {
+	for _, tc := range []struct {
+		total    int64
+		weights  []int64
+		expected []int64
+	}{
+		{total: 10, weights: []int64{1, 1, 1, 1, 1}, expected: []int64{2, 2, 2, 2, 2}},
+		{total: 7, weights: []int64{1, 1, 1}, expected: []int64{3, 2, 2}},
+		{total: 2, weights: []int64{1, 1, 1, 1, 1}, expected: []int64{1, 1, 0, 0, 0}},
+		{total: 10, weights: []int64{3, 1, 0}, expected: []int64{8, 2, 0}},
+		{total: 10, weights: []int64{1, 2, 3, 4}, expected: []int64{1, 2, 3, 4}},
+		{total: 5, weights: []int64{1, 2, 2}, expected: []int64{1, 2, 2}},
+		{total: 3, weights: []int64{1, 3, 3}, expected: []int64{1, 1, 1}},
+		{total: 4, weights: []int64{1, 3, 3}, expected: []int64{0, 2, 2}},
+		{total: 0, weights: []int64{1, 2}, expected: []int64{0, 0}},
+	} {
+		actual := splitReplicas(tc.total, tc.weights)
+		if !slices.Equal(actual, tc.expected) {
+			t.Errorf("splitReplicas(%d, %v): expected %v, got %v", tc.total, tc.weights, tc.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/celeval"
+)
+
+const (
+	// rolloutGateRowsKey is the key used to store the rows of the StatusCollector's result.
+	rolloutGateRowsKey = "rows"
+	// rolloutGateClustersKey is the key used to store the names of the destinations in the judged wave.
+	rolloutGateClustersKey = "clusters"
+
+	// combinedStatusBindingPolicyLabel is the label on a CombinedStatus that holds the BindingPolicy (and Binding) name.
+	combinedStatusBindingPolicyLabel = "status.kubestellar.io/binding-policy"
+)
+
+// combinedStatusSubjectLabels are the labels on a CombinedStatus that identify its workload object.
+var combinedStatusSubjectLabels = []string{"status.kubestellar.io/api-group", "status.kubestellar.io/resource",
+	"status.kubestellar.io/namespace", "status.kubestellar.io/name"}
+
+// NewRolloutGateEvaluator returns a CEL evaluator whose environment
+// is suited to the `expression` of a RolloutGate.
+// This is also for validating BindingPolicy objects elsewhere.
+func NewRolloutGateEvaluator() (*celeval.Evaluator, error) {
+	return celeval.NewEvaluator(
+		cel.Variable(rolloutGateRowsKey, cel.ListType(cel.MapType(cel.StringType, cel.DynType))),
+		cel.Variable(rolloutGateClustersKey, cel.ListType(cel.StringType)),
+		cel.CrossTypeNumericComparisons(true),
+	)
+}
+
+// rolloutWaves partitions the given destinations into the waves of the given strategy,
+// in order. `getLabels` returns the labels of a destination's inventory object.
+// When `strategy` is nil there is just one wave, holding all the destinations.
+// The returned strings describe user errors; when there are any, the returned waves are nil.
+func rolloutWaves(strategy *v1alpha1.RolloutStrategy, destinations []v1alpha1.Destination, getLabels func(v1alpha1.Destination) labels.Set) ([][]v1alpha1.Destination, []string) {
+	if strategy == nil {
+		return [][]v1alpha1.Destination{destinations}, nil
+	}
+	remaining := slices.Clone(destinations)
+	slices.SortFunc(remaining, func(a, b v1alpha1.Destination) int { return strings.Compare(a.ClusterId, b.ClusterId) })
+	var waves [][]v1alpha1.Destination
+	for idx, waveSpec := range strategy.Waves {
+		var selector labels.Selector
+		if waveSpec.ClusterSelector != nil {
+			var err error
+			selector, err = metav1.LabelSelectorAsSelector(waveSpec.ClusterSelector)
+			if err != nil {
+				return nil, []string{fmt.Sprintf("Invalid clusterSelector in rollout.waves[%d]: %s", idx, err)}
+			}
+		}
+		var wave, rest []v1alpha1.Destination
+		for _, dest := range remaining {
+			if (selector == nil || selector.Matches(getLabels(dest))) &&
+				(waveSpec.Count == nil || len(wave) < int(*waveSpec.Count)) {
+				wave = append(wave, dest)
+			} else {
+				rest = append(rest, dest)
+			}
+		}
+		if len(wave) > 0 {
+			waves = append(waves, wave)
+		}
+		remaining = rest
+	}
+	if len(remaining) > 0 {
+		waves = append(waves, remaining)
+	}
+	return waves, nil
+}
+
+// CheckRolloutStrategy returns the errors in the given rollout strategy, if any.
+// The given evaluator is one made by NewRolloutGateEvaluator.
+// This is for validating BindingPolicy objects elsewhere.
+func CheckRolloutStrategy(gateEvaluator *celeval.Evaluator, strategy *v1alpha1.RolloutStrategy) []error {
+	if strategy == nil {
+		return nil
+	}
+	var errs []error
+	for idx, waveSpec := range strategy.Waves {
+		if waveSpec.ClusterSelector == nil {
+			continue
+		}
+		if _, err := metav1.LabelSelectorAsSelector(waveSpec.ClusterSelector); err != nil {
+			errs = append(errs, fmt.Errorf("invalid clusterSelector in rollout.waves[%d]: %w", idx, err))
+		}
+	}
+	if err := gateEvaluator.CheckBoolExpression(strategy.Gate.Expression); err != nil {
+		errs = append(errs, fmt.Errorf("invalid rollout.gate.expression (%q): %w", strategy.Gate.Expression, err))
+	}
+	return errs
+}
+
+// computeRolloutWaves returns the waves of the given Binding's rollout,
+// and the strings describing user errors in the rollout.
+func (c *genericTransportController) computeRolloutWaves(binding *v1alpha1.Binding) ([][]v1alpha1.Destination, []string) {
+	strategy := binding.Spec.Rollout
+	if strategy != nil {
+		if err := c.rolloutGateEvaluator.CheckBoolExpression(strategy.Gate.Expression); err != nil {
+			return nil, []string{fmt.Sprintf("Invalid rollout.gate.expression (%q): %s", strategy.Gate.Expression, err)}
+		}
+	}
+	return rolloutWaves(strategy, binding.Spec.Destinations, func(dest v1alpha1.Destination) labels.Set {
+		return c.getLabelsForDestination(binding.Name, dest)
+	})
+}
+
+// rolloutGatePasses tests whether the gate of the given Binding's rollout passes for the given wave.
+// Failures are logged and make the gate not pass.
+func (c *genericTransportController) rolloutGatePasses(ctx context.Context, binding *v1alpha1.Binding, wave []v1alpha1.Destination) bool {
+	logger := klog.FromContext(ctx)
+	gate := binding.Spec.Rollout.Gate
+	combinedStatuses, err := c.combinedStatusLister.List(labels.SelectorFromSet(labels.Set{combinedStatusBindingPolicyLabel: binding.Name}))
+	if err != nil { // listers do not fail
+		logger.Error(err, "Failed to list CombinedStatus objects", "binding", binding.Name)
+		return false
+	}
+	subjectToStatus := map[string]*v1alpha1.CombinedStatus{}
+	for _, combinedStatus := range combinedStatuses {
+		subjectToStatus[combinedStatusSubjectKey(combinedStatus.Labels)] = combinedStatus
+	}
+	clusters := make([]string, len(wave))
+	for idx, dest := range wave {
+		clusters[idx] = dest.ClusterId
+	}
+	tested := 0
+	testObject := func(group, resource, namespace, name string, modulation v1alpha1.DownsyncModulation) bool {
+		if !slices.Contains(modulation.StatusCollectors, gate.StatusCollector) {
+			return true
+		}
+		tested++
+		objRef := strings.Join([]string{group, resource, namespace, name}, "/")
+		combinedStatus := subjectToStatus[objRef]
+		if combinedStatus == nil {
+			logger.V(4).Info("Rollout gate waits for CombinedStatus", "binding", binding.Name, "object", objRef)
+			return false
+		}
+		idx := slices.IndexFunc(combinedStatus.Results, func(result v1alpha1.NamedStatusCombination) bool {
+			return result.Name == gate.StatusCollector
+		})
+		if idx < 0 {
+			logger.V(4).Info("Rollout gate waits for StatusCollector result", "binding", binding.Name, "object", objRef, "statusCollector", gate.StatusCollector)
+			return false
+		}
+		rows, err := statusCombinationRows(combinedStatus.Results[idx])
+		if err != nil {
+			logger.Error(err, "Failed to convert CombinedStatus rows", "binding", binding.Name, "object", objRef)
+			return false
+		}
+		passed, err := c.rolloutGateEvaluator.EvaluateBool(gate.Expression, map[string]interface{}{
+			rolloutGateRowsKey:     rows,
+			rolloutGateClustersKey: clusters,
+		})
+		if err != nil {
+			logger.V(2).Info("Failed to evaluate rollout gate", "binding", binding.Name, "object", objRef, "err", err)
+			return false
+		}
+		return passed
+	}
+	for _, clause := range binding.Spec.Workload.ClusterScope {
+		if !testObject(clause.Group, clause.Resource, metav1.NamespaceNone, clause.Name, clause.DownsyncModulation) {
+			return false
+		}
+	}
+	for _, clause := range binding.Spec.Workload.NamespaceScope {
+		if !testObject(clause.Group, clause.Resource, clause.Namespace, clause.Name, clause.DownsyncModulation) {
+			return false
+		}
+	}
+	if tested == 0 {
+		logger.V(2).Info("Rollout gate does not pass because no workload object has its StatusCollector", "binding", binding.Name, "statusCollector", gate.StatusCollector)
+		return false
+	}
+	return true
+}
+
+// combinedStatusSubjectKey returns the group/resource/namespace/name of the workload object
+// of the CombinedStatus that has the given labels.
+func combinedStatusSubjectKey(csLabels map[string]string) string {
+	parts := make([]string, len(combinedStatusSubjectLabels))
+	for idx, key := range combinedStatusSubjectLabels {
+		parts[idx] = csLabels[key]
+	}
+	return strings.Join(parts, "/")
+}
+
+// statusCombinationRows converts the rows of the given result into
+// maps from column name to native value, for use in CEL.
+func statusCombinationRows(result v1alpha1.NamedStatusCombination) ([]any, error) {
+	rows := make([]any, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		asMap := make(map[string]any, len(row.Columns))
+		for idx, column := range row.Columns {
+			if idx >= len(result.ColumnNames) {
+				break
+			}
+			val, err := valueToNative(column)
+			if err != nil {
+				return nil, fmt.Errorf("column %q: %w", result.ColumnNames[idx], err)
+			}
+			asMap[result.ColumnNames[idx]] = val
+		}
+		rows = append(rows, asMap)
+	}
+	return rows, nil
+}
+
+// valueToNative converts the given Value into the corresponding Go value,
+// as would come from decoding JSON except that integral numbers become int64.
+func valueToNative(value v1alpha1.Value) (any, error) {
+	switch value.Type {
+	case v1alpha1.TypeString:
+		if value.String == nil {
+			return nil, fmt.Errorf("String value lacks string")
+		}
+		return *value.String, nil
+	case v1alpha1.TypeNumber:
+		if value.Number == nil {
+			return nil, fmt.Errorf("Number value lacks number")
+		}
+		if asInt, err := strconv.ParseInt(*value.Number, 10, 64); err == nil {
+			return asInt, nil
+		}
+		return strconv.ParseFloat(*value.Number, 64)
+	case v1alpha1.TypeBool:
+		if value.Bool == nil {
+			return nil, fmt.Errorf("Bool value lacks bool")
+		}
+		return *value.Bool, nil
+	case v1alpha1.TypeNull:
+		return nil, nil
+	case v1alpha1.TypeObject, v1alpha1.TypeArray:
+		raw := value.Object
+		if value.Type == v1alpha1.TypeArray {
+			raw = value.Array
+		}
+		if raw == nil {
+			return nil, fmt.Errorf("%s value lacks content", value.Type)
+		}
+		var ans any
+		if err := json.Unmarshal(raw.Raw, &ans); err != nil {
+			return nil, err
+		}
+		return ans, nil
+	default:
+		return nil, fmt.Errorf("unknown value type %q", value.Type)
+	}
+}
+
+// handleCombinedStatus enqueues the Binding of the given CombinedStatus
+// if that Binding has a rollout, whose gate may be affected.
+func (c *genericTransportController) handleCombinedStatus(obj any, event string) {
+	combinedStatus := obj.(metav1.Object)
+	bindingName, found := combinedStatus.GetLabels()[combinedStatusBindingPolicyLabel]
+	if !found {
+		return
+	}
+	binding, err := c.bindingLister.Get(bindingName)
+	if err != nil {
+		if !errors.IsNotFound(err) { // listers do not fail
+			c.logger.Error(err, "Inconceivable failure to fetch Binding", "name", bindingName)
+		}
+		return
+	}
+	if binding.Spec.Rollout == nil {
+		return
+	}
+	c.logger.V(5).Info("Enqueuing reference to Binding due to informer event about CombinedStatus", "bindingName", bindingName, "combinedStatusRef", klog.KObj(combinedStatus), "event", event)
+	c.workqueue.Add(bindingName)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package transport
+
+import (
+	"testing"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/utils/ptr"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestRolloutWaves(t *testing.T) {
+	destLabels := map[string]labels.Set{
+		"wec1": {"ring": "canary"},
+		"wec2": {},
+		"wec3": {"ring": "canary"},
+		"wec4": {},
+		"wec5": {},
+	}
+	getLabels := func(dest v1alpha1.Destination) labels.Set { return destLabels[dest.ClusterId] }
+	dests := func(names ...string) []v1alpha1.Destination {
+		ans := make([]v1alpha1.Destination, len(names))
+		for idx, name := range names {
+			ans[idx] = v1alpha1.Destination{ClusterId: name}
+		}
+		return ans
+	}
+	canary := &metav1.LabelSelector{MatchLabels: map[string]string{"ring": "canary"}}
+	for _, tc := range []struct {
+		name     string
+		waves    []v1alpha1.RolloutWave
+		expected [][]v1alpha1.Destination
+	}{
+		{name: "label then rest",
+			waves:    []v1alpha1.RolloutWave{{ClusterSelector: canary}},
+			expected: [][]v1alpha1.Destination{dests("wec1", "wec3"), dests("wec2", "wec4", "wec5")}},
+		{name: "counts",
+			waves:    []v1alpha1.RolloutWave{{Count: ptr.To[int32](1)}, {Count: ptr.To[int32](2)}},
+			expected: [][]v1alpha1.Destination{dests("wec1"), dests("wec2", "wec3"), dests("wec4", "wec5")}},
+		{name: "label with count",
+			waves:    []v1alpha1.RolloutWave{{ClusterSelector: canary, Count: ptr.To[int32](1)}, {ClusterSelector: canary}},
+			expected: [][]v1alpha1.Destination{dests("wec1"), dests("wec3"), dests("wec2", "wec4", "wec5")}},
+		{name: "empty wave skipped",
+			waves:    []v1alpha1.RolloutWave{{ClusterSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"ring": "none"}}}, {Count: ptr.To[int32](5)}},
+			expected: [][]v1alpha1.Destination{dests("wec1", "wec2", "wec3", "wec4", "wec5")}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			strategy := &v1alpha1.RolloutStrategy{Waves: tc.waves}
+			actual, errs := rolloutWaves(strategy, dests("wec5", "wec4", "wec3", "wec2", "wec1"), getLabels)
+			if len(errs) != 0 {
+				t.Fatalf("Unexpected errors: %v", errs)
+			}
+			if !apiequality.Semantic.DeepEqual(actual, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRolloutGateExpression(t *testing.T) {
+	evaluator, err := NewRolloutGateEvaluator()
+	if err != nil {
+		t.Fatalf("Failed to create evaluator: %s", err)
+	}
+	row := func(wec string, ready bool, replicas string) v1alpha1.StatusCombinationRow {
+		return v1alpha1.StatusCombinationRow{Columns: []v1alpha1.Value{
+			{Type: v1alpha1.TypeString, String: &wec},
+			{Type: v1alpha1.TypeBool, Bool: &ready},
+			{Type: v1alpha1.TypeNumber, Number: &replicas},
+		}}
+	}
+	result := v1alpha1.NamedStatusCombination{
+		Name:        "health",
+		ColumnNames: []string{"wec", "ready", "replicas"},
+		Rows:        []v1alpha1.StatusCombinationRow{row("wec1", true, "3"), row("wec2", false, "0")},
+	}
+	rows, err := statusCombinationRows(result)
+	if err != nil {
+		t.Fatalf("Failed to convert rows: %s", err)
+	}
+	expression := v1alpha1.Expression(`clusters.all(c, rows.exists(r, r.wec == c && r.ready && r.replicas >= 1))`)
+	if err := evaluator.CheckBoolExpression(expression); err != nil {
+		t.Fatalf("Failed to check expression: %s", err)
+	}
+	for _, tc := range []struct {
+		clusters []string
+		expected bool
+	}{
+		{clusters: []string{"wec1"}, expected: true},
+		{clusters: []string{"wec1", "wec2"}, expected: false},
+		{clusters: []string{"wec3"}, expected: false},
+	} {
+		passed, err := evaluator.EvaluateBool(expression, map[string]interface{}{
+			rolloutGateRowsKey:     rows,
+			rolloutGateClustersKey: tc.clusters,
+		})
+		if err != nil {
+			t.Errorf("Failed to evaluate for %v: %s", tc.clusters, err)
+		} else if passed != tc.expected {
+			t.Errorf("Expected %v for %v, got %v", tc.expected, tc.clusters, passed)
+		}
+	}
+}
@@ -18,15 +18,19 @@ package ocm
 
 import (
 	"fmt"
+	"slices"
 
 	workv1 "open-cluster-management.io/api/work/v1"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
 
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/abstract"
 	"github.com/kubestellar/kubestellar/pkg/transport"
 	"github.com/kubestellar/kubestellar/pkg/util"
 )
@@ -48,21 +52,28 @@ var createOnlyStrategy = workv1.UpdateStrategy{Type: workv1.UpdateStrategyTypeCr
 func (ocm *ocm) WrapObjects(wrapees []transport.Wrapee, kindToResource func(schema.GroupKind) string) runtime.Object {
 	manifests := make([]workv1.Manifest, len(wrapees))
 	var configs []workv1.ManifestConfigOption
+	var orphaningRules []workv1.OrphaningRule
 	for i, wrapee := range wrapees {
 		manifests[i].RawExtension = runtime.RawExtension{Object: wrapee.Object}
+		if !wrapee.CreateOnly && wrapee.DeletionPolicy != v1alpha1.DeletionPolicyOrphan {
+			continue
+		}
+		gvk := wrapee.Object.GroupVersionKind()
+		rscId := workv1.ResourceIdentifier{
+			Group:     gvk.Group,
+			Resource:  kindToResource(gvk.GroupKind()),
+			Namespace: wrapee.Object.GetNamespace(),
+			Name:      wrapee.Object.GetName(),
+		}
 		if wrapee.CreateOnly {
-			gvk := wrapee.Object.GroupVersionKind()
-			rsc := kindToResource(gvk.GroupKind())
 			configs = append(configs, workv1.ManifestConfigOption{
-				ResourceIdentifier: workv1.ResourceIdentifier{
-					Group:     gvk.Group,
-					Resource:  rsc,
-					Namespace: wrapee.Object.GetNamespace(),
-					Name:      wrapee.Object.GetName(),
-				},
-				UpdateStrategy: &createOnlyStrategy,
+				ResourceIdentifier: rscId,
+				UpdateStrategy:     &createOnlyStrategy,
 			})
 		}
+		if wrapee.DeletionPolicy == v1alpha1.DeletionPolicyOrphan {
+			orphaningRules = append(orphaningRules, workv1.OrphaningRule(rscId))
+		}
 	}
 	return &workv1.ManifestWork{
 		TypeMeta: metav1.TypeMeta{
@@ -74,10 +85,178 @@ func (ocm *ocm) WrapObjects(wrapees []transport.Wrapee, kindToResource func(sche
 				Manifests: manifests,
 			},
 			ManifestConfigs: configs,
+			DeleteOption:    deleteOption(len(wrapees), orphaningRules),
 		},
 	}
 }
 
+// deleteOption returns the DeleteOption for a ManifestWork of numWrapees objects
+// of which those identified by orphaningRules are to be orphaned.
+// All orphaned maps to the Orphan policy and none orphaned maps to the default.
+func deleteOption(numWrapees int, orphaningRules []workv1.OrphaningRule) *workv1.DeleteOption {
+	switch len(orphaningRules) {
+	case 0:
+		return nil
+	case numWrapees:
+		return &workv1.DeleteOption{PropagationPolicy: workv1.DeletePropagationPolicyTypeOrphan}
+	default:
+		return &workv1.DeleteOption{
+			PropagationPolicy: workv1.DeletePropagationPolicyTypeSelectivelyOrphan,
+			SelectivelyOrphan: &workv1.SelectivelyOrphan{OrphaningRules: orphaningRules},
+		}
+	}
+}
+
+var _ transport.OrphanCarrier = &ocm{}
+
+// CarryOrphaning adds, to the DeleteOption of `next`, an orphaning rule for each object
+// that `previous` orphans and `next` does not hold, because the work agent deletes an object
+// that leaves a ManifestWork unless the ManifestWork says to orphan it.
+// A rule that `previous` itself got this way, for an object that it does not hold,
+// is kept only until `previous` has been applied.
+func (ocm *ocm) CarryOrphaning(previous, next *unstructured.Unstructured, kindToResource func(schema.GroupKind) (string, bool)) (*unstructured.Unstructured, error) {
+	nextOption, err := getDeleteOption(next)
+	if err != nil {
+		return nil, err
+	}
+	if nextOption != nil && nextOption.PropagationPolicy == workv1.DeletePropagationPolicyTypeOrphan {
+		return next, nil // everything is orphaned already
+	}
+	prevOption, err := getDeleteOption(previous)
+	if err != nil {
+		return nil, err
+	}
+	if prevOption == nil || prevOption.PropagationPolicy != workv1.DeletePropagationPolicyTypeOrphan &&
+		prevOption.PropagationPolicy != workv1.DeletePropagationPolicyTypeSelectivelyOrphan {
+		return next, nil
+	}
+	// Kinds that have left the Binding altogether are found in the status of previous
+	statusKindToResource, err := resourcesInStatus(previous)
+	if err != nil {
+		return nil, err
+	}
+	resourceOf := func(gk schema.GroupKind) (string, bool) {
+		if resource, ok := kindToResource(gk); ok {
+			return resource, true
+		}
+		resource, ok := statusKindToResource[gk]
+		return resource, ok
+	}
+	prevHolds, err := manifestIdentifiers(previous, resourceOf)
+	if err != nil {
+		return nil, err
+	}
+	nextHolds, err := manifestIdentifiers(next, resourceOf)
+	if err != nil {
+		return nil, err
+	}
+	var prevRules, nextRules []workv1.OrphaningRule
+	if prevOption.PropagationPolicy == workv1.DeletePropagationPolicyTypeOrphan {
+		prevRules = abstract.SliceMap(prevHolds, func(id workv1.ResourceIdentifier) workv1.OrphaningRule { return workv1.OrphaningRule(id) })
+	} else if prevOption.SelectivelyOrphan != nil {
+		prevRules = prevOption.SelectivelyOrphan.OrphaningRules
+	}
+	if nextOption != nil && nextOption.SelectivelyOrphan != nil {
+		nextRules = slices.Clone(nextOption.SelectivelyOrphan.OrphaningRules)
+	}
+	prevApplied, err := ocm.applied(previous)
+	if err != nil {
+		return nil, err
+	}
+	carried := false
+	for _, rule := range prevRules {
+		id := workv1.ResourceIdentifier(rule)
+		if slices.Contains(nextHolds, id) || slices.Contains(nextRules, rule) || prevApplied && !slices.Contains(prevHolds, id) {
+			continue
+		}
+		nextRules = append(nextRules, rule)
+		carried = true
+	}
+	if !carried {
+		return next, nil
+	}
+	option, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&workv1.DeleteOption{
+		PropagationPolicy: workv1.DeletePropagationPolicyTypeSelectivelyOrphan,
+		SelectivelyOrphan: &workv1.SelectivelyOrphan{OrphaningRules: nextRules},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert DeleteOption: %w", err)
+	}
+	ans := next.DeepCopy()
+	if err := unstructured.SetNestedMap(ans.Object, option, "spec", "deleteOption"); err != nil {
+		return nil, fmt.Errorf("failed to set DeleteOption: %w", err)
+	}
+	return ans, nil
+}
+
+// getDeleteOption returns the DeleteOption of the given ManifestWork, nil if it has none.
+func getDeleteOption(work *unstructured.Unstructured) (*workv1.DeleteOption, error) {
+	optionM, found, err := unstructured.NestedMap(work.Object, "spec", "deleteOption")
+	if err != nil || !found {
+		return nil, err
+	}
+	option := &workv1.DeleteOption{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(optionM, option); err != nil {
+		return nil, fmt.Errorf("failed to convert spec.deleteOption of ManifestWork %s/%s: %w", work.GetNamespace(), work.GetName(), err)
+	}
+	return option, nil
+}
+
+// resourcesInStatus returns the resource of each kind that the status of the given ManifestWork reports on.
+func resourcesInStatus(work *unstructured.Unstructured) (map[schema.GroupKind]string, error) {
+	ans := map[schema.GroupKind]string{}
+	manifests, _, err := unstructured.NestedSlice(work.Object, "status", "resourceStatus", "manifests")
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract status.resourceStatus.manifests of ManifestWork %s/%s: %w", work.GetNamespace(), work.GetName(), err)
+	}
+	for _, manifest := range manifests {
+		manifestM, ok := manifest.(map[string]any)
+		if !ok {
+			continue
+		}
+		group, _, _ := unstructured.NestedString(manifestM, "resourceMeta", "group")
+		kind, _, _ := unstructured.NestedString(manifestM, "resourceMeta", "kind")
+		resource, _, _ := unstructured.NestedString(manifestM, "resourceMeta", "resource")
+		if kind != "" && resource != "" {
+			ans[schema.GroupKind{Group: group, Kind: kind}] = resource
+		}
+	}
+	return ans, nil
+}
+
+// manifestIdentifiers returns the identifiers of the objects in the given ManifestWork, in order.
+func manifestIdentifiers(work *unstructured.Unstructured, kindToResource func(schema.GroupKind) (string, bool)) ([]workv1.ResourceIdentifier, error) {
+	manifests, _, err := unstructured.NestedSlice(work.Object, "spec", "workload", "manifests")
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract manifests from ManifestWork %s/%s: %w", work.GetNamespace(), work.GetName(), err)
+	}
+	ans := make([]workv1.ResourceIdentifier, len(manifests))
+	for idx, manifest := range manifests {
+		manifestM, ok := manifest.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("manifests[%d] is a %T but expected a map[string]any", idx, manifest)
+		}
+		obj := &unstructured.Unstructured{Object: manifestM}
+		gk := obj.GroupVersionKind().GroupKind()
+		resource, ok := kindToResource(gk)
+		if !ok {
+			return nil, fmt.Errorf("resource of manifests[%d] (kind %v) in ManifestWork %s/%s is not known", idx, gk, work.GetNamespace(), work.GetName())
+		}
+		ans[idx] = workv1.ResourceIdentifier{Group: gk.Group, Resource: resource, Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	}
+	return ans, nil
+}
+
+// applied tells whether the given ManifestWork reports that its current generation has been applied.
+func (ocm *ocm) applied(work *unstructured.Unstructured) (bool, error) {
+	conditions, err := ocm.DeliveryConditions(work)
+	if err != nil {
+		return false, err
+	}
+	condition := meta.FindStatusCondition(conditions, transport.DeliveryConditionApplied)
+	return condition != nil && condition.Status == metav1.ConditionTrue && condition.ObservedGeneration == work.GetGeneration(), nil
+}
+
 func (ocm *ocm) UnwrapObjects(wrapped runtime.Object, kindToResource func(schema.GroupKind) (string, bool)) (transport.Gloss, error) {
 	gloss := transport.Gloss{}
 	switch typed := wrapped.(type) {
@@ -109,6 +288,35 @@ func (ocm *ocm) UnwrapObjects(wrapped runtime.Object, kindToResource func(schema
 	return gloss, nil
 }
 
+var _ transport.DeliveryReporter = &ocm{}
+
+// DeliveryConditions returns the conditions in the status of the given ManifestWork,
+// such as `Applied` and `Available`.
+func (ocm *ocm) DeliveryConditions(wrapped runtime.Object) ([]metav1.Condition, error) {
+	switch typed := wrapped.(type) {
+	case *workv1.ManifestWork:
+		return typed.Status.Conditions, nil
+	case *unstructured.Unstructured:
+		conditions, found, err := unstructured.NestedSlice(typed.UnstructuredContent(), "status", "conditions")
+		if err != nil || !found {
+			return nil, err
+		}
+		ans := make([]metav1.Condition, len(conditions))
+		for idx, condition := range conditions {
+			conditionM, ok := condition.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("status.conditions[%d] is a %T but expected a map[string]any", idx, condition)
+			}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(conditionM, &ans[idx]); err != nil {
+				return nil, fmt.Errorf("failed to convert status.conditions[%d]: %w", idx, err)
+			}
+		}
+		return ans, nil
+	default:
+		return nil, fmt.Errorf("expected a ManifestWork but got a %T", wrapped)
+	}
+}
+
 func ManifestConfigOptionResourceIdentifier(mc workv1.ManifestConfigOption) workv1.ResourceIdentifier {
 	return mc.ResourceIdentifier
 }
@@ -0,0 +1,172 @@
+/*
+Copyright 2025 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ocm
+
+import (
+	"testing"
+
+	workv1 "open-cluster-management.io/api/work/v1"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/transport"
+)
+
+func newTestObject(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func kindToResource(gk schema.GroupKind) (string, bool) {
+	switch gk {
+	case schema.GroupKind{Kind: "ConfigMap"}:
+		return "configmaps", true
+	case schema.GroupKind{Group: "apps", Kind: "Deployment"}:
+		return "deployments", true
+	}
+	return "", false
+}
+
+func wrapForTest(t *testing.T, wrapees ...transport.Wrapee) *unstructured.Unstructured {
+	t.Helper()
+	wrapped := NewOCMTransport().WrapObjects(wrapees, func(gk schema.GroupKind) string {
+		resource, _ := kindToResource(gk)
+		return resource
+	})
+	objM, err := runtime.DefaultUnstructuredConverter.ToUnstructured(wrapped)
+	if err != nil {
+		t.Fatalf("Failed to convert ManifestWork: %s", err)
+	}
+	ans := &unstructured.Unstructured{Object: objM}
+	ans.SetGeneration(1)
+	return ans
+}
+
+func setApplied(t *testing.T, work *unstructured.Unstructured) {
+	t.Helper()
+	conditions := []any{map[string]any{
+		"type": workv1.WorkApplied, "status": "True", "observedGeneration": work.GetGeneration(),
+		"reason": "AppliedManifestWorkComplete", "message": "", "lastTransitionTime": "2025-01-01T00:00:00Z",
+	}}
+	if err := unstructured.SetNestedSlice(work.Object, conditions, "status", "conditions"); err != nil {
+		t.Fatalf("Failed to set conditions: %s", err)
+	}
+}
+
+func expectDeleteOption(t *testing.T, work *unstructured.Unstructured, expected *workv1.DeleteOption) {
+	t.Helper()
+	actual, err := getDeleteOption(work)
+	if err != nil {
+		t.Fatalf("Failed to get DeleteOption: %s", err)
+	}
+	if !apiequality.Semantic.DeepEqual(actual, expected) {
+		t.Errorf("Expected DeleteOption %+v, got %+v", expected, actual)
+	}
+}
+
+func TestCarryOrphaning(t *testing.T) {
+	carrier := NewOCMTransport().(transport.OrphanCarrier)
+	kept := newTestObject("v1", "ConfigMap", "demo", "kept")
+	leaving := newTestObject("apps/v1", "Deployment", "demo", "leaving")
+	leavingRule := workv1.OrphaningRule{Group: "apps", Resource: "deployments", Namespace: "demo", Name: "leaving"}
+	selective := func(rules ...workv1.OrphaningRule) *workv1.DeleteOption {
+		return &workv1.DeleteOption{
+			PropagationPolicy: workv1.DeletePropagationPolicyTypeSelectivelyOrphan,
+			SelectivelyOrphan: &workv1.SelectivelyOrphan{OrphaningRules: rules},
+		}
+	}
+
+	t.Run("object stops matching", func(t *testing.T) {
+		previous := wrapForTest(t, transport.NewWrapee(kept, false, ""), transport.NewWrapee(leaving, false, v1alpha1.DeletionPolicyOrphan))
+		next := wrapForTest(t, transport.NewWrapee(kept, false, ""))
+		carried, err := carrier.CarryOrphaning(previous, next, kindToResource)
+		if err != nil {
+			t.Fatalf("Failed to carry orphaning: %s", err)
+		}
+		expectDeleteOption(t, carried, selective(leavingRule))
+		expectDeleteOption(t, next, nil)
+
+		// The carried rule stays until that update is applied
+		carried.SetGeneration(2)
+		again, err := carrier.CarryOrphaning(carried, next, kindToResource)
+		if err != nil {
+			t.Fatalf("Failed to carry orphaning: %s", err)
+		}
+		expectDeleteOption(t, again, selective(leavingRule))
+		setApplied(t, carried)
+		again, err = carrier.CarryOrphaning(carried, next, kindToResource)
+		if err != nil {
+			t.Fatalf("Failed to carry orphaning: %s", err)
+		}
+		expectDeleteOption(t, again, nil)
+	})
+
+	t.Run("all orphaned before", func(t *testing.T) {
+		previous := wrapForTest(t, transport.NewWrapee(kept, false, v1alpha1.DeletionPolicyOrphan), transport.NewWrapee(leaving, false, v1alpha1.DeletionPolicyOrphan))
+		next := wrapForTest(t, transport.NewWrapee(kept, false, ""))
+		carried, err := carrier.CarryOrphaning(previous, next, kindToResource)
+		if err != nil {
+			t.Fatalf("Failed to carry orphaning: %s", err)
+		}
+		expectDeleteOption(t, carried, selective(leavingRule))
+	})
+
+	t.Run("kind leaves", func(t *testing.T) {
+		previous := wrapForTest(t, transport.NewWrapee(kept, false, ""), transport.NewWrapee(leaving, false, v1alpha1.DeletionPolicyOrphan))
+		next := wrapForTest(t, transport.NewWrapee(kept, false, ""))
+		onlyConfigMaps := func(gk schema.GroupKind) (string, bool) {
+			if gk.Kind == "ConfigMap" {
+				return "configmaps", true
+			}
+			return "", false
+		}
+		if _, err := carrier.CarryOrphaning(previous, next, onlyConfigMaps); err == nil {
+			t.Error("Expected an error for a kind whose resource is not known")
+		}
+		resourceStatus := []any{map[string]any{"resourceMeta": map[string]any{
+			"group": "apps", "version": "v1", "kind": "Deployment", "resource": "deployments", "namespace": "demo", "name": "leaving",
+		}}}
+		if err := unstructured.SetNestedSlice(previous.Object, resourceStatus, "status", "resourceStatus", "manifests"); err != nil {
+			t.Fatalf("Failed to set resource status: %s", err)
+		}
+		carried, err := carrier.CarryOrphaning(previous, next, onlyConfigMaps)
+		if err != nil {
+			t.Fatalf("Failed to carry orphaning: %s", err)
+		}
+		expectDeleteOption(t, carried, selective(leavingRule))
+	})
+
+	t.Run("nothing to carry", func(t *testing.T) {
+		previous := wrapForTest(t, transport.NewWrapee(kept, false, ""), transport.NewWrapee(leaving, false, v1alpha1.DeletionPolicyOrphan))
+		next := wrapForTest(t, transport.NewWrapee(kept, false, ""), transport.NewWrapee(leaving, false, ""))
+		carried, err := carrier.CarryOrphaning(previous, next, kindToResource)
+		if err != nil {
+			t.Fatalf("Failed to carry orphaning: %s", err)
+		}
+		if carried != next {
+			t.Errorf("Expected next to be returned as it is, got %#v", carried.Object)
+		}
+	})
+}
@@ -17,12 +17,14 @@ limitations under the License.
 package transport
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
 	"github.com/kubestellar/kubestellar/pkg/util"
 )
 
@@ -39,10 +41,38 @@ type Transport interface {
 	UnwrapObjects(wrapped runtime.Object, kindToResource func(schema.GroupKind) (string, bool)) (Gloss, error)
 }
 
-// Wrapee is a workload object to wrap and its associated create-only bit
+// DeliveryConditionApplied is the type of the delivery condition that, when True,
+// says that the contents of a wrapped object have been applied at its destination.
+const DeliveryConditionApplied = "Applied"
+
+// DeliveryReporter is an optional interface that a Transport can also implement,
+// to report on the delivery of wrapped objects to their destinations.
+type DeliveryReporter interface {
+	// DeliveryConditions extracts, from the status of the given wrapped object,
+	// the conditions that describe the delivery of its contents to its destination
+	// (for example, whether they have been applied there).
+	// The returned slice is empty if the wrapped object reports nothing (yet).
+	DeliveryConditions(wrapped runtime.Object) ([]metav1.Condition, error)
+}
+
+// OrphanCarrier is an optional interface that a Transport can also implement
+// when its destinations delete a workload object that leaves a wrapped object
+// unless the replacing wrapped object says to orphan it.
+type OrphanCarrier interface {
+	// CarryOrphaning returns `next`, which is to replace `previous` at the same destination,
+	// changed if necessary so that it orphans the workload objects that `previous`
+	// orphans and `next` does not hold. `next` itself is not modified.
+	// `kindToResource` is a typical Map.Get function.
+	CarryOrphaning(previous, next *unstructured.Unstructured, kindToResource func(schema.GroupKind) (string, bool)) (*unstructured.Unstructured, error)
+}
+
+// Wrapee is a workload object to wrap and its associated create-only bit and deletion policy
 type Wrapee struct {
 	Object     *unstructured.Unstructured
 	CreateOnly bool
+	// DeletionPolicy says what the destination should do with the object when it is
+	// no longer wrapped for that destination. The empty value means Delete.
+	DeletionPolicy v1alpha1.DeletionPolicy
 }
 
 // Gloss is a set of identities of workload objects
@@ -57,6 +87,6 @@ func (wr Wrapee) GetID() util.GKObjRef {
 	}
 }
 
-func NewWrapee(object *unstructured.Unstructured, createOnly bool) Wrapee {
-	return Wrapee{object, createOnly}
+func NewWrapee(object *unstructured.Unstructured, createOnly bool, deletionPolicy v1alpha1.DeletionPolicy) Wrapee {
+	return Wrapee{object, createOnly, deletionPolicy}
 }
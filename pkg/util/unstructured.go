@@ -47,6 +47,10 @@ const (
 	BindingKind           = "Binding"
 	BindingResource       = "bindings"
 
+	NamespacedBindingPolicyKind     = "NamespacedBindingPolicy"
+	NamespacedBindingPolicyResource = "namespacedbindingpolicies"
+	ClusterGrantResource            = "clustergrants"
+
 	WorkStatusGroup    = "control.kubestellar.io"
 	WorkStatusVersion  = "v1alpha1"
 	WorkStatusResource = "workstatuses"
@@ -0,0 +1,104 @@
+/*
+Copyright 2025 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+// UpsyncAllowedResources holds the resources that upsync clauses may name without more ado.
+// Upsync copies objects from WECs into the WDS, so it is limited to resources
+// that hold reports rather than workload or credentials.
+var UpsyncAllowedResources = sets.New(
+	metav1.GroupResource{Group: "", Resource: "configmaps"},
+	metav1.GroupResource{Group: "wgpolicyk8s.io", Resource: "policyreports"},
+	metav1.GroupResource{Group: "wgpolicyk8s.io", Resource: "clusterpolicyreports"},
+)
+
+// UpsyncSecretsResource is the resource that an upsync clause may name only
+// when it sets AcknowledgeSecretExposure.
+var UpsyncSecretsResource = metav1.GroupResource{Group: "", Resource: "secrets"}
+
+// upsyncResourceAllowed tells whether the given clause may upsync objects of the given resource.
+func upsyncResourceAllowed(clause v1alpha1.UpsyncPolicyClause, resource metav1.GroupResource) bool {
+	return UpsyncAllowedResources.Has(resource) || clause.AcknowledgeSecretExposure && resource == UpsyncSecretsResource
+}
+
+// CheckUpsyncClauses returns one error for each resource, in the given upsync clauses,
+// that is "*" or not allowed for its clause (see UpsyncAllowedResources and UpsyncSecretsResource).
+func CheckUpsyncClauses(clauses []v1alpha1.UpsyncPolicyClause) []error {
+	var errs []error
+	for idx, clause := range clauses {
+		for _, resource := range clause.Resources {
+			groupResource := metav1.GroupResource{Group: clause.APIGroup, Resource: resource}
+			if resource == "*" {
+				errs = append(errs, fmt.Errorf("upsync[%d] must name its resources explicitly rather than use \"*\"", idx))
+			} else if groupResource == UpsyncSecretsResource && !clause.AcknowledgeSecretExposure {
+				errs = append(errs, fmt.Errorf("upsync[%d] names secrets, which requires acknowledgeSecretExposure because their data becomes readable in the ITS and the WDS", idx))
+			} else if !upsyncResourceAllowed(clause, groupResource) {
+				errs = append(errs, fmt.Errorf("upsync[%d] names resource %q of API group %q, which is not allowed for upsync", idx, resource, clause.APIGroup))
+			}
+		}
+	}
+	return errs
+}
+
+// UpsyncClausesOf returns the upsync clauses that the transport controller
+// put on the given wrapped object (see v1alpha1.UpsyncClausesAnnotationKey).
+// This is how the WEC's status agent learns what to report for upsync.
+func UpsyncClausesOf(wrappedObject metav1.Object) ([]v1alpha1.UpsyncPolicyClause, error) {
+	clausesJSON, has := wrappedObject.GetAnnotations()[v1alpha1.UpsyncClausesAnnotationKey]
+	if !has {
+		return nil, nil
+	}
+	var clauses []v1alpha1.UpsyncPolicyClause
+	if err := json.Unmarshal([]byte(clausesJSON), &clauses); err != nil {
+		return nil, fmt.Errorf("failed to parse value of annotation %s: %w", v1alpha1.UpsyncClausesAnnotationKey, err)
+	}
+	return clauses, nil
+}
+
+// UpsyncClauseMatches tells whether the given upsync clause matches the given object.
+// A resource that the clause may not upsync (see CheckUpsyncClauses) is never matched,
+// and a selector that does not parse matches nothing.
+func UpsyncClauseMatches(clause v1alpha1.UpsyncPolicyClause, objId ObjectIdentifier, objLabels labels.Set) bool {
+	if clause.APIGroup != objId.GVK.Group || !slices.Contains(clause.Resources, objId.Resource) {
+		return false
+	}
+	if !upsyncResourceAllowed(clause, metav1.GroupResource{Group: objId.GVK.Group, Resource: objId.Resource}) {
+		return false
+	}
+	if len(clause.Namespaces) > 0 && !slices.Contains(clause.Namespaces, objId.ObjectName.Namespace) {
+		return false
+	}
+	if len(clause.ObjectNames) > 0 && !slices.Contains(clause.ObjectNames, objId.ObjectName.Name) {
+		return false
+	}
+	if len(clause.ObjectSelectors) > 0 {
+		matches, err := SelectorsMatchLabels(clause.ObjectSelectors, objLabels)
+		return err == nil && matches
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2025 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestUpsyncClauseMatches(t *testing.T) {
+	cmId := ObjectIdentifier{
+		GVK:        schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+		Resource:   "configmaps",
+		ObjectName: cache.NewObjectName("demo", "report"),
+	}
+	secretId := ObjectIdentifier{
+		GVK:        schema.GroupVersionKind{Version: "v1", Kind: "Secret"},
+		Resource:   "secrets",
+		ObjectName: cache.NewObjectName("demo", "report"),
+	}
+	for _, tc := range []struct {
+		name     string
+		clause   v1alpha1.UpsyncPolicyClause
+		objId    ObjectIdentifier
+		expected bool
+	}{
+		{name: "resource", clause: v1alpha1.UpsyncPolicyClause{Resources: []string{"configmaps"}}, objId: cmId, expected: true},
+		{name: "other group", clause: v1alpha1.UpsyncPolicyClause{APIGroup: "apps", Resources: []string{"configmaps"}}, objId: cmId},
+		{name: "wildcard", clause: v1alpha1.UpsyncPolicyClause{Resources: []string{"*"}}, objId: cmId},
+		{name: "not allowed", clause: v1alpha1.UpsyncPolicyClause{Resources: []string{"secrets"}}, objId: secretId},
+		{name: "acknowledged", clause: v1alpha1.UpsyncPolicyClause{Resources: []string{"secrets"}, AcknowledgeSecretExposure: true}, objId: secretId, expected: true},
+		{name: "namespace", clause: v1alpha1.UpsyncPolicyClause{Resources: []string{"configmaps"}, Namespaces: []string{"demo"}}, objId: cmId, expected: true},
+		{name: "other namespace", clause: v1alpha1.UpsyncPolicyClause{Resources: []string{"configmaps"}, Namespaces: []string{"prod"}}, objId: cmId},
+		{name: "other name", clause: v1alpha1.UpsyncPolicyClause{Resources: []string{"configmaps"}, ObjectNames: []string{"other"}}, objId: cmId},
+		{name: "selector", expected: true, objId: cmId, clause: v1alpha1.UpsyncPolicyClause{Resources: []string{"configmaps"},
+			ObjectSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"kind": "report"}}}}},
+		{name: "other selector", objId: cmId, clause: v1alpha1.UpsyncPolicyClause{Resources: []string{"configmaps"},
+			ObjectSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"kind": "other"}}}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := UpsyncClauseMatches(tc.clause, tc.objId, labels.Set{"kind": "report"})
+			if actual != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCheckUpsyncClauses(t *testing.T) {
+	errs := CheckUpsyncClauses([]v1alpha1.UpsyncPolicyClause{
+		{Resources: []string{"configmaps"}},
+		{APIGroup: "wgpolicyk8s.io", Resources: []string{"policyreports", "clusterpolicyreports"}},
+		{Resources: []string{"*", "secrets"}},
+		{APIGroup: "wgpolicyk8s.io", Resources: []string{"configmaps"}},
+		{Resources: []string{"secrets"}, AcknowledgeSecretExposure: true},
+		{APIGroup: "apps", Resources: []string{"deployments"}, AcknowledgeSecretExposure: true},
+	})
+	if len(errs) != 4 {
+		t.Errorf("Expected 4 errors, got %v", errs)
+	}
+}
+
+func TestUpsyncClausesOf(t *testing.T) {
+	clauses := []v1alpha1.UpsyncPolicyClause{{Resources: []string{"configmaps"}, Namespaces: []string{"demo"},
+		Placement: v1alpha1.UpsyncPlacementClusterNamePrefix}}
+	clausesJSON, err := json.Marshal(clauses)
+	if err != nil {
+		t.Fatalf("Failed to marshal clauses: %s", err)
+	}
+	wrapped := &metav1.ObjectMeta{Annotations: map[string]string{v1alpha1.UpsyncClausesAnnotationKey: string(clausesJSON)}}
+	actual, err := UpsyncClausesOf(wrapped)
+	if err != nil {
+		t.Fatalf("Failed to get clauses: %s", err)
+	}
+	if !apiequality.Semantic.DeepEqual(actual, clauses) {
+		t.Errorf("Expected %+v, got %+v", clauses, actual)
+	}
+	actual, err = UpsyncClausesOf(&metav1.ObjectMeta{})
+	if err != nil || actual != nil {
+		t.Errorf("Expected no clauses and no error for an object without the annotation, got %+v and %v", actual, err)
+	}
+	wrapped.Annotations[v1alpha1.UpsyncClausesAnnotationKey] = "[{"
+	if _, err = UpsyncClausesOf(wrapped); err == nil {
+		t.Error("Expected an error for an annotation that does not parse")
+	}
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2025 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	certutil "k8s.io/client-go/util/cert"
+
+	"github.com/kubestellar/kubestellar/pkg/crd"
+)
+
+// manifests holds the webhook configurations generated from the kubebuilder markers
+// in this package; `make manifests` copies them here from config/webhook.
+//
+//go:embed files/manifests.yaml
+var manifests []byte
+
+// ConfigurationNamePrefix is prepended to the names of the webhook configurations
+// that InstallConfigurations maintains.
+const ConfigurationNamePrefix = "kubestellar-"
+
+// GenerateServingCert makes a self-signed certificate and key for serving the webhooks
+// at the given DNS name, and writes them as tls.crt and tls.key in the given directory.
+// It returns the PEM encoding of the certificates that clients are to trust.
+func GenerateServingCert(certDir, host string) ([]byte, error) {
+	certPEM, keyPEM, err := certutil.GenerateSelfSignedCertKey(host, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serving certificate for %q: %w", host, err)
+	}
+	if err := os.WriteFile(filepath.Join(certDir, "tls.crt"), certPEM, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write serving certificate: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(certDir, "tls.key"), keyPEM, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write serving key: %w", err)
+	}
+	return certPEM, nil
+}
+
+// InstallConfigurations creates or updates, in the cluster of the given client,
+// the mutating and validating webhook configurations for the webhooks that Register adds.
+// Each webhook is called at baseURL followed by its path, and its server is
+// authenticated by the given CA bundle.
+func InstallConfigurations(ctx context.Context, client kubernetes.Interface, baseURL string, caBundle []byte, logger logr.Logger) error {
+	objs, err := crd.DecodeYAML(manifests)
+	if err != nil {
+		return fmt.Errorf("failed to decode embedded webhook configurations: %w", err)
+	}
+	clientConfig := func(cc admissionregistrationv1.WebhookClientConfig) admissionregistrationv1.WebhookClientConfig {
+		url := baseURL + *cc.Service.Path
+		return admissionregistrationv1.WebhookClientConfig{URL: &url, CABundle: caBundle}
+	}
+	for _, obj := range objs {
+		switch obj.GetKind() {
+		case "MutatingWebhookConfiguration":
+			desired := &admissionregistrationv1.MutatingWebhookConfiguration{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, desired); err != nil {
+				return fmt.Errorf("failed to convert embedded %s %q: %w", obj.GetKind(), obj.GetName(), err)
+			}
+			desired.Name = ConfigurationNamePrefix + desired.Name
+			for idx := range desired.Webhooks {
+				desired.Webhooks[idx].ClientConfig = clientConfig(desired.Webhooks[idx].ClientConfig)
+			}
+			err = applyConfiguration(ctx, client.AdmissionregistrationV1().MutatingWebhookConfigurations(), desired,
+				func(existing, desired *admissionregistrationv1.MutatingWebhookConfiguration) bool {
+					return apiequality.Semantic.DeepEqual(existing.Webhooks, desired.Webhooks)
+				}, logger)
+		case "ValidatingWebhookConfiguration":
+			desired := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, desired); err != nil {
+				return fmt.Errorf("failed to convert embedded %s %q: %w", obj.GetKind(), obj.GetName(), err)
+			}
+			desired.Name = ConfigurationNamePrefix + desired.Name
+			for idx := range desired.Webhooks {
+				desired.Webhooks[idx].ClientConfig = clientConfig(desired.Webhooks[idx].ClientConfig)
+			}
+			err = applyConfiguration(ctx, client.AdmissionregistrationV1().ValidatingWebhookConfigurations(), desired,
+				func(existing, desired *admissionregistrationv1.ValidatingWebhookConfiguration) bool {
+					return apiequality.Semantic.DeepEqual(existing.Webhooks, desired.Webhooks)
+				}, logger)
+		default:
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// configurationClient is the part of the clients for webhook configurations that applyConfiguration uses.
+type configurationClient[Obj any] interface {
+	Create(ctx context.Context, obj Obj, opts metav1.CreateOptions) (Obj, error)
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (Obj, error)
+	Update(ctx context.Context, obj Obj, opts metav1.UpdateOptions) (Obj, error)
+}
+
+// applyConfiguration creates the given webhook configuration, or updates the existing one
+// if `same` says that it differs.
+func applyConfiguration[Obj interface {
+	metav1.Object
+	runtime.Object
+}](ctx context.Context, client configurationClient[Obj], desired Obj, same func(existing, desired Obj) bool, logger logr.Logger) error {
+	_, err := client.Create(ctx, desired, metav1.CreateOptions{FieldManager: crd.FieldManager})
+	if err == nil {
+		logger.Info("Created webhook configuration", "name", desired.GetName())
+		return nil
+	} else if !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("unable to create webhook configuration %q: %w", desired.GetName(), err)
+	}
+	existing, err := client.Get(ctx, desired.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to fetch existing webhook configuration %q: %w", desired.GetName(), err)
+	}
+	if same(existing, desired) {
+		logger.V(1).Info("Existing webhook configuration is acceptable", "name", desired.GetName())
+		return nil
+	}
+	desired.SetResourceVersion(existing.GetResourceVersion())
+	if _, err := client.Update(ctx, desired, metav1.UpdateOptions{FieldManager: crd.FieldManager}); err != nil {
+		return fmt.Errorf("unable to update existing webhook configuration %q: %w", desired.GetName(), err)
+	}
+	logger.Info("Updated webhook configuration", "name", desired.GetName())
+	return nil
+}
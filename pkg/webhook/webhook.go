@@ -0,0 +1,228 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhook implements admission webhooks for the KubeStellar control objects.
+// The validating ones apply, at admission time, the same checks that the
+// controllers otherwise report after the fact in the objects' status.
+// The mutating ones record the creator and last modifier of each BindingPolicy and
+// NamespacedBindingPolicy (see v1alpha1.CreatorAnnotationKey).
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	"github.com/kubestellar/kubestellar/pkg/binding"
+	"github.com/kubestellar/kubestellar/pkg/status"
+	transport "github.com/kubestellar/kubestellar/pkg/transport/generic"
+)
+
+// The paths at which the mutating webhooks are served.
+const (
+	BindingPolicyCreatorPath           = "/mutate-control-kubestellar-io-v1alpha1-bindingpolicy"
+	NamespacedBindingPolicyCreatorPath = "/mutate-control-kubestellar-io-v1alpha1-namespacedbindingpolicy"
+)
+
+// The paths at which the validating webhooks are served.
+const (
+	BindingPolicyPath           = "/validate-control-kubestellar-io-v1alpha1-bindingpolicy"
+	NamespacedBindingPolicyPath = "/validate-control-kubestellar-io-v1alpha1-namespacedbindingpolicy"
+	StatusCollectorPath         = "/validate-control-kubestellar-io-v1alpha1-statuscollector"
+	CustomTransformPath         = "/validate-control-kubestellar-io-v1alpha1-customtransform"
+)
+
+// +kubebuilder:webhook:path=/mutate-control-kubestellar-io-v1alpha1-bindingpolicy,mutating=true,failurePolicy=fail,sideEffects=None,groups=control.kubestellar.io,resources=bindingpolicies,verbs=create;update,versions=v1alpha1,name=mbindingpolicy.control.kubestellar.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/mutate-control-kubestellar-io-v1alpha1-namespacedbindingpolicy,mutating=true,failurePolicy=fail,sideEffects=None,groups=control.kubestellar.io,resources=namespacedbindingpolicies,verbs=create;update,versions=v1alpha1,name=mnamespacedbindingpolicy.control.kubestellar.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-control-kubestellar-io-v1alpha1-bindingpolicy,mutating=false,failurePolicy=fail,sideEffects=None,groups=control.kubestellar.io,resources=bindingpolicies,verbs=create;update,versions=v1alpha1,name=vbindingpolicy.control.kubestellar.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-control-kubestellar-io-v1alpha1-namespacedbindingpolicy,mutating=false,failurePolicy=fail,sideEffects=None,groups=control.kubestellar.io,resources=namespacedbindingpolicies,verbs=create;update,versions=v1alpha1,name=vnamespacedbindingpolicy.control.kubestellar.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-control-kubestellar-io-v1alpha1-statuscollector,mutating=false,failurePolicy=fail,sideEffects=None,groups=control.kubestellar.io,resources=statuscollectors,verbs=create;update,versions=v1alpha1,name=vstatuscollector.control.kubestellar.io,admissionReviewVersions=v1
+// +kubebuilder:webhook:path=/validate-control-kubestellar-io-v1alpha1-customtransform,mutating=false,failurePolicy=fail,sideEffects=None,groups=control.kubestellar.io,resources=customtransforms,verbs=create;update,versions=v1alpha1,name=vcustomtransform.control.kubestellar.io,admissionReviewVersions=v1
+
+// Register adds the validating webhooks for BindingPolicy, NamespacedBindingPolicy,
+// StatusCollector and CustomTransform objects, and the mutating webhooks that record the
+// creators and last modifiers of BindingPolicy and NamespacedBindingPolicy objects, to the given server.
+// The given scheme must include the KubeStellar control API.
+func Register(server ctrlwebhook.Server, scheme *runtime.Scheme) error {
+	bindingPolicyValidator, err := binding.NewBindingPolicyValidator()
+	if err != nil {
+		return fmt.Errorf("failed to create BindingPolicy validator: %w", err)
+	}
+	statusCollectorValidator, err := status.NewStatusCollectorValidator()
+	if err != nil {
+		return fmt.Errorf("failed to create StatusCollector validator: %w", err)
+	}
+	customTransformValidator, err := transport.NewCustomTransformValidator()
+	if err != nil {
+		return fmt.Errorf("failed to create CustomTransform validator: %w", err)
+	}
+	server.Register(BindingPolicyCreatorPath, admission.WithCustomDefaulter(scheme, &v1alpha1.BindingPolicy{}, creatorRecorder{}))
+	server.Register(NamespacedBindingPolicyCreatorPath, admission.WithCustomDefaulter(scheme, &v1alpha1.NamespacedBindingPolicy{}, creatorRecorder{}))
+	server.Register(BindingPolicyPath, admission.WithCustomValidator(scheme, &v1alpha1.BindingPolicy{},
+		validator[*v1alpha1.BindingPolicy]{kind: "BindingPolicy", validate: bindingPolicyValidator.Validate}))
+	server.Register(NamespacedBindingPolicyPath, admission.WithCustomValidator(scheme, &v1alpha1.NamespacedBindingPolicy{},
+		validator[*v1alpha1.NamespacedBindingPolicy]{kind: "NamespacedBindingPolicy", validate: bindingPolicyValidator.ValidateNamespaced}))
+	server.Register(StatusCollectorPath, admission.WithCustomValidator(scheme, &v1alpha1.StatusCollector{},
+		validator[*v1alpha1.StatusCollector]{kind: "StatusCollector", validate: statusCollectorValidator.Validate}))
+	server.Register(CustomTransformPath, admission.WithCustomValidator(scheme, &v1alpha1.CustomTransform{},
+		validator[*v1alpha1.CustomTransform]{kind: "CustomTransform", validate: customTransformValidator.Validate}))
+	return nil
+}
+
+// validator is an admission.CustomValidator that rejects creations and updates
+// of objects of one kind for which the given function returns errors.
+// Deletions are always allowed.
+type validator[ObjPtr interface {
+	runtime.Object
+	GetName() string
+}] struct {
+	kind     string
+	validate func(ObjPtr) []error
+}
+
+var _ admission.CustomValidator = validator[*v1alpha1.BindingPolicy]{}
+
+func (v validator[ObjPtr]) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, v.check(obj)
+}
+
+// ValidateUpdate checks only updates that change the spec of an object that is not being deleted,
+// so that an object that became invalid under newer checks can still have its metadata
+// and status updated and its finalizers removed.
+func (v validator[ObjPtr]) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	newMeta, err := meta.Accessor(newObj)
+	if err != nil {
+		return nil, err
+	}
+	if newMeta.GetDeletionTimestamp() != nil {
+		return nil, nil
+	}
+	sameSpec, err := specsEqual(oldObj, newObj)
+	if err != nil {
+		return nil, err
+	}
+	if sameSpec {
+		return nil, nil
+	}
+	return nil, v.check(newObj)
+}
+
+func (v validator[ObjPtr]) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// specsEqual tells whether the given objects have semantically equal `spec` fields.
+func specsEqual(oldObj, newObj runtime.Object) (bool, error) {
+	oldM, err := runtime.DefaultUnstructuredConverter.ToUnstructured(oldObj)
+	if err != nil {
+		return false, fmt.Errorf("failed to convert the old object: %w", err)
+	}
+	newM, err := runtime.DefaultUnstructuredConverter.ToUnstructured(newObj)
+	if err != nil {
+		return false, fmt.Errorf("failed to convert the new object: %w", err)
+	}
+	return apiequality.Semantic.DeepEqual(oldM["spec"], newM["spec"]), nil
+}
+
+func (v validator[ObjPtr]) check(obj runtime.Object) error {
+	typed, ok := obj.(ObjPtr)
+	if !ok {
+		return fmt.Errorf("expected a %s but got a %T", v.kind, obj)
+	}
+	errs := v.validate(typed)
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s %q is invalid: %w", v.kind, typed.GetName(), utilerrors.NewAggregate(errs))
+}
+
+// creatorRecorder is an admission.CustomDefaulter that maintains the
+// v1alpha1.CreatorAnnotationKey and v1alpha1.ModifierAnnotationKey annotations.
+// On creation it records the requesting user as both. On update it restores the
+// creator that the old object had, or leaves it absent if there was none;
+// and it records the requesting user as the modifier if the spec changes,
+// restoring the old modifier (or else the creator, if any) otherwise.
+// Thus neither record can be forged or removed.
+type creatorRecorder struct{}
+
+var _ admission.CustomDefaulter = creatorRecorder{}
+
+func (creatorRecorder) Default(ctx context.Context, obj runtime.Object) error {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	annotations := objMeta.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	userJSON, err := json.Marshal(req.UserInfo)
+	if err != nil {
+		return fmt.Errorf("failed to encode the requesting user: %w", err)
+	}
+	switch req.Operation {
+	case admissionv1.Create:
+		annotations[v1alpha1.CreatorAnnotationKey] = string(userJSON)
+		annotations[v1alpha1.ModifierAnnotationKey] = string(userJSON)
+	case admissionv1.Update:
+		oldObj := &unstructured.Unstructured{}
+		if err := utiljson.Unmarshal(req.OldObject.Raw, &oldObj.Object); err != nil {
+			return fmt.Errorf("failed to decode the old object: %w", err)
+		}
+		oldAnnotations := oldObj.GetAnnotations()
+		// A policy that was created without a record of its creator (a legacy policy)
+		// keeps having none, so that an editor can not pass for its creator
+		if creatorJSON, has := oldAnnotations[v1alpha1.CreatorAnnotationKey]; has {
+			annotations[v1alpha1.CreatorAnnotationKey] = creatorJSON
+		} else {
+			delete(annotations, v1alpha1.CreatorAnnotationKey)
+		}
+		// Compare the specs as they were sent, because a round trip through the Go type
+		// can add or drop fields and thus make equal specs differ
+		newObj := map[string]any{}
+		if err := utiljson.Unmarshal(req.Object.Raw, &newObj); err != nil {
+			return fmt.Errorf("failed to decode the new object: %w", err)
+		}
+		if !apiequality.Semantic.DeepEqual(oldObj.Object["spec"], newObj["spec"]) {
+			annotations[v1alpha1.ModifierAnnotationKey] = string(userJSON)
+		} else if modifierJSON, has := oldAnnotations[v1alpha1.ModifierAnnotationKey]; has {
+			annotations[v1alpha1.ModifierAnnotationKey] = modifierJSON
+		} else if creatorJSON, has := oldAnnotations[v1alpha1.CreatorAnnotationKey]; has {
+			annotations[v1alpha1.ModifierAnnotationKey] = creatorJSON
+		} else {
+			delete(annotations, v1alpha1.ModifierAnnotationKey)
+		}
+	default:
+		return nil
+	}
+	objMeta.SetAnnotations(annotations)
+	return nil
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2025 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/kubestellar/kubestellar/api/control/v1alpha1"
+)
+
+func TestValidateUpdate(t *testing.T) {
+	v := validator[*v1alpha1.CustomTransform]{kind: "CustomTransform", validate: func(ct *v1alpha1.CustomTransform) []error {
+		if len(ct.Spec.Remove) == 0 {
+			return []error{errors.New("nothing to remove")}
+		}
+		return nil
+	}}
+	invalid := &v1alpha1.CustomTransform{ObjectMeta: metav1.ObjectMeta{Name: "ct"}, Spec: v1alpha1.CustomTransformSpec{Resource: "deployments"}}
+	valid := invalid.DeepCopy()
+	valid.Spec.Remove = []string{"$.spec.replicas"}
+	relabeled := invalid.DeepCopy()
+	relabeled.Labels = map[string]string{"a": "b"}
+	deleting := invalid.DeepCopy()
+	deleting.Spec.APIGroup = "apps"
+	deleting.DeletionTimestamp = &metav1.Time{}
+	for _, tc := range []struct {
+		name     string
+		old, new *v1alpha1.CustomTransform
+		expectOK bool
+	}{
+		{name: "spec becomes invalid", old: valid, new: invalid},
+		{name: "spec becomes valid", old: invalid, new: valid, expectOK: true},
+		{name: "spec unchanged", old: invalid, new: relabeled, expectOK: true},
+		{name: "being deleted", old: invalid, new: deleting, expectOK: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := v.ValidateUpdate(context.Background(), tc.old, tc.new)
+			if tc.expectOK && err != nil {
+				t.Errorf("Expected update to be allowed, got %s", err)
+			} else if !tc.expectOK && err == nil {
+				t.Error("Expected update to be rejected")
+			}
+		})
+	}
+}
+
+func TestCreatorRecorder(t *testing.T) {
+	userJSON := func(name string) string {
+		ans, err := json.Marshal(authenticationv1.UserInfo{Username: name})
+		if err != nil {
+			t.Fatalf("Failed to encode user: %s", err)
+		}
+		return string(ans)
+	}
+	// admitJSON runs the recorder on the given new policy, as requested by the given user,
+	// and returns the resulting creator and modifier annotations.
+	// The policies are given as they are sent to the webhook.
+	admitJSON := func(userName string, operation admissionv1.Operation, oldJSON, newJSON []byte) (string, string) {
+		t.Helper()
+		req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: operation,
+			UserInfo:  authenticationv1.UserInfo{Username: userName},
+			Object:    runtime.RawExtension{Raw: newJSON},
+			OldObject: runtime.RawExtension{Raw: oldJSON},
+		}}
+		policy := &v1alpha1.BindingPolicy{}
+		if err := json.Unmarshal(newJSON, policy); err != nil {
+			t.Fatalf("Failed to decode new policy: %s", err)
+		}
+		if err := (creatorRecorder{}).Default(admission.NewContextWithRequest(context.Background(), req), policy); err != nil {
+			t.Fatalf("Failed to record users: %s", err)
+		}
+		return policy.Annotations[v1alpha1.CreatorAnnotationKey], policy.Annotations[v1alpha1.ModifierAnnotationKey]
+	}
+	admit := func(userName string, operation admissionv1.Operation, old, new *v1alpha1.BindingPolicy) (string, string) {
+		t.Helper()
+		var oldJSON []byte
+		if old != nil {
+			var err error
+			if oldJSON, err = json.Marshal(old); err != nil {
+				t.Fatalf("Failed to encode old policy: %s", err)
+			}
+		}
+		newJSON, err := json.Marshal(new)
+		if err != nil {
+			t.Fatalf("Failed to encode new policy: %s", err)
+		}
+		return admitJSON(userName, operation, oldJSON, newJSON)
+	}
+	expect := func(creator, modifier, expectedCreator, expectedModifier string) {
+		t.Helper()
+		if creator != userJSON(expectedCreator) || modifier != userJSON(expectedModifier) {
+			t.Errorf("Expected creator %s and modifier %s, got %s and %s", expectedCreator, expectedModifier, creator, modifier)
+		}
+	}
+
+	policy := &v1alpha1.BindingPolicy{ObjectMeta: metav1.ObjectMeta{Name: "p",
+		Annotations: map[string]string{v1alpha1.CreatorAnnotationKey: userJSON("mallory")}}}
+	creator, modifier := admit("alice", admissionv1.Create, nil, policy)
+	expect(creator, modifier, "alice", "alice")
+	policy.Annotations = map[string]string{v1alpha1.CreatorAnnotationKey: creator, v1alpha1.ModifierAnnotationKey: modifier}
+
+	forged := policy.DeepCopy()
+	forged.Annotations = map[string]string{v1alpha1.CreatorAnnotationKey: userJSON("bob"), v1alpha1.ModifierAnnotationKey: userJSON("bob")}
+	creator, modifier = admit("bob", admissionv1.Update, policy, forged)
+	expect(creator, modifier, "alice", "alice")
+
+	changed := policy.DeepCopy()
+	changed.Spec.ClusterSelectors = []metav1.LabelSelector{{MatchLabels: map[string]string{"env": "prod"}}}
+	creator, modifier = admit("bob", admissionv1.Update, policy, changed)
+	expect(creator, modifier, "alice", "bob")
+
+	// The stored form omits fields that the Go type always has (here, `apiGroup`),
+	// so an update of only the annotations must not count as a change of the spec
+	storedJSON := func(annotations string) []byte {
+		return []byte(`{"apiVersion":"control.kubestellar.io/v1alpha1","kind":"BindingPolicy",` +
+			`"metadata":{"name":"p","annotations":{` + annotations + `}},` +
+			`"spec":{"downsync":[{"resources":["configmaps"]}]}}`)
+	}
+	recorded := fmt.Sprintf("%q:%q,%q:%q", v1alpha1.CreatorAnnotationKey, userJSON("alice"), v1alpha1.ModifierAnnotationKey, userJSON("carol"))
+	creator, modifier = admitJSON("bob", admissionv1.Update, storedJSON(recorded), storedJSON(recorded+`,"note":"hi"`))
+	expect(creator, modifier, "alice", "carol")
+
+	// A legacy policy, created without a record of its creator, does not get one
+	unrecorded := &v1alpha1.BindingPolicy{ObjectMeta: metav1.ObjectMeta{Name: "p"}}
+	forgedLegacy := unrecorded.DeepCopy()
+	forgedLegacy.Annotations = map[string]string{v1alpha1.CreatorAnnotationKey: userJSON("alice"), v1alpha1.ModifierAnnotationKey: userJSON("alice")}
+	if creator, modifier = admit("bob", admissionv1.Update, unrecorded, forgedLegacy); creator != "" || modifier != "" {
+		t.Errorf("Expected no creator and no modifier for an unchanged legacy policy, got %s and %s", creator, modifier)
+	}
+	changedLegacy := unrecorded.DeepCopy()
+	changedLegacy.Spec.ClusterSelectors = []metav1.LabelSelector{{MatchLabels: map[string]string{"env": "prod"}}}
+	if creator, modifier = admit("bob", admissionv1.Update, unrecorded, changedLegacy); creator != "" || modifier != userJSON("bob") {
+		t.Errorf("Expected no creator and modifier bob for a changed legacy policy, got %s and %s", creator, modifier)
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2024 The KubeStellar Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooktest
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/klog/v2/ktesting"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	ctrlwebhook "sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	ksapi "github.com/kubestellar/kubestellar/api/control/v1alpha1"
+	kswebhook "github.com/kubestellar/kubestellar/pkg/webhook"
+)
+
+// An integration test for the validating admission webhooks.
+// This test uses envtest, which needs the kube-apiserver and etcd binaries
+// in the directory named by the KUBEBUILDER_ASSETS environment variable
+// (e.g., as set by `make test-webhook-integration`).
+// The test is skipped, saying so, if that variable is not set.
+func TestValidatingWebhooks(t *testing.T) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		t.Skip("Skipping the webhook integration test because KUBEBUILDER_ASSETS is not set; run `make test-webhook-integration` to run it with envtest's kube-apiserver and etcd")
+	}
+	_, ctx := ktesting.NewTestContext(t)
+	ctx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(ksapi.AddToScheme(scheme))
+	testEnv := &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "..", "..", "config", "crd", "bases")},
+		ErrorIfCRDPathMissing: true,
+		WebhookInstallOptions: envtest.WebhookInstallOptions{
+			Paths: []string{filepath.Join("..", "..", "..", "config", "webhook")},
+		},
+	}
+	config, err := testEnv.Start()
+	if err != nil {
+		t.Fatalf("Failed to start test environment: %s", err)
+	}
+	t.Cleanup(func() {
+		if err := testEnv.Stop(); err != nil {
+			t.Logf("Failed to stop test environment: %s", err)
+		}
+	})
+	webhookOpts := testEnv.WebhookInstallOptions
+	webhookServer := ctrlwebhook.NewServer(ctrlwebhook.Options{
+		Host:    webhookOpts.LocalServingHost,
+		Port:    webhookOpts.LocalServingPort,
+		CertDir: webhookOpts.LocalServingCertDir,
+	})
+	if err := kswebhook.Register(webhookServer, scheme); err != nil {
+		t.Fatalf("Failed to register webhooks: %s", err)
+	}
+	go func() {
+		if err := webhookServer.Start(ctx); err != nil && ctx.Err() == nil {
+			t.Errorf("Webhook server failed: %s", err)
+		}
+	}()
+	addr := net.JoinHostPort(webhookOpts.LocalServingHost, fmt.Sprint(webhookOpts.LocalServingPort))
+	err = wait.PollUntilContextTimeout(ctx, 100*time.Millisecond, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+		conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+		if err != nil {
+			return false, nil
+		}
+		return true, conn.Close()
+	})
+	if err != nil {
+		t.Fatalf("Webhook server did not start serving: %s", err)
+	}
+	kubeClient, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		t.Fatalf("Failed to create client: %s", err)
+	}
+	for _, testCase := range []struct {
+		name     string
+		obj      client.Object
+		expectOK bool
+	}{
+		{name: "valid-bindingpolicy", expectOK: true, obj: &ksapi.BindingPolicy{
+			Spec: ksapi.BindingPolicySpec{
+				ClusterSelectors: []metav1.LabelSelector{{MatchLabels: map[string]string{"location-group": "edge"}}},
+			}}},
+		{name: "bindingpolicy-bad-label-selector", obj: &ksapi.BindingPolicy{
+			Spec: ksapi.BindingPolicySpec{
+				ClusterSelectors: []metav1.LabelSelector{{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "location-group", Operator: metav1.LabelSelectorOpIn}}}},
+			}}},
+		{name: "valid-statuscollector", expectOK: true, obj: &ksapi.StatusCollector{
+			Spec: ksapi.StatusCollectorSpec{
+				Select: []ksapi.NamedExpression{{Name: "replicas", Def: "returned.status.replicas"}}, Limit: 10,
+			}}},
+		{name: "statuscollector-bad-cel", obj: &ksapi.StatusCollector{
+			Spec: ksapi.StatusCollectorSpec{
+				Select: []ksapi.NamedExpression{{Name: "replicas", Def: "returned.status.replicas +"}}, Limit: 10,
+			}}},
+		{name: "valid-customtransform", expectOK: true, obj: &ksapi.CustomTransform{
+			Spec: ksapi.CustomTransformSpec{
+				APIGroup: "apps", Resource: "deployments", Remove: []string{"$.spec.replicas"},
+			}}},
+		{name: "customtransform-bad-jsonpath", obj: &ksapi.CustomTransform{
+			Spec: ksapi.CustomTransformSpec{
+				APIGroup: "apps", Resource: "deployments", Remove: []string{"$.spec[.replicas"},
+			}}},
+		{name: "customtransform-whole-object", obj: &ksapi.CustomTransform{
+			Spec: ksapi.CustomTransformSpec{
+				APIGroup: "apps", Resource: "deployments", Remove: []string{"$"},
+			}}},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.obj.SetName(testCase.name)
+			err := kubeClient.Create(ctx, testCase.obj)
+			if testCase.expectOK && err != nil {
+				t.Errorf("Expected creation to succeed, got error: %s", err)
+			} else if !testCase.expectOK && err == nil {
+				t.Errorf("Expected creation to be rejected")
+			} else {
+				t.Logf("Got expected outcome; err=%v", err)
+			}
+		})
+	}
+	t.Run("creator-recorded", func(t *testing.T) {
+		policy := &ksapi.BindingPolicy{}
+		if err := kubeClient.Get(ctx, client.ObjectKey{Name: "valid-bindingpolicy"}, policy); err != nil {
+			t.Fatalf("Failed to get BindingPolicy: %s", err)
+		}
+		creatorJSON := policy.Annotations[ksapi.CreatorAnnotationKey]
+		if creatorJSON == "" {
+			t.Fatalf("Expected the %s annotation to be set", ksapi.CreatorAnnotationKey)
+		}
+		if actual := policy.Annotations[ksapi.ModifierAnnotationKey]; actual != creatorJSON {
+			t.Errorf("Expected the %s annotation to be %s, got %s", ksapi.ModifierAnnotationKey, creatorJSON, actual)
+		}
+		policy.Annotations[ksapi.CreatorAnnotationKey] = `{"username":"someone-else"}`
+		policy.Annotations[ksapi.ModifierAnnotationKey] = `{"username":"someone-else"}`
+		if err := kubeClient.Update(ctx, policy); err != nil {
+			t.Fatalf("Failed to update BindingPolicy: %s", err)
+		}
+		for _, key := range []string{ksapi.CreatorAnnotationKey, ksapi.ModifierAnnotationKey} {
+			if actual := policy.Annotations[key]; actual != creatorJSON {
+				t.Errorf("Expected the %s annotation to stay %s, got %s", key, creatorJSON, actual)
+			}
+		}
+	})
+}